@@ -14,6 +14,7 @@ import (
 var (
 	Version   = "dev"
 	BuildTime = "unknown"
+	GitCommit = "unknown"
 
 	cfgFile string
 	rootCmd = &cobra.Command{
@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
-	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
 	"github.com/VennLe/charlotte/internal/config"
 	"github.com/VennLe/charlotte/internal/initialize"
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/lifecycle"
 	"github.com/VennLe/charlotte/pkg/logger"
 )
 
@@ -97,13 +103,14 @@ var versionCmd = &cobra.Command{
 }
 
 func runServer() {
-	// 1. 初始化日志
+	// 1. 初始化日志，日志在所有组件关闭后最后刷新
 	initialize.InitLogger()
 	defer logger.Sync()
 
 	logger.Info("启动 Charlotte API",
 		zap.String("version", Version),
-		zap.String("build_time", BuildTime))
+		zap.String("build_time", BuildTime),
+		zap.String("git_commit", GitCommit))
 
 	// 2. 验证配置
 	if err := config.Validate(); err != nil {
@@ -111,53 +118,173 @@ func runServer() {
 	}
 	logger.Info(config.GetConfigSummary())
 
-	// 3. 初始化组件
-	// Redis 和 Kafka 是可选的，数据库是必需的
-	logger.Debug("开始初始化Redis")
-	if err := initialize.InitRedis(); err != nil {
-		logger.Error("Redis 初始化失败", zap.Error(err))
+	if err := initialize.InitPassword(); err != nil {
+		logger.Fatal("密码哈希配置无效", zap.Error(err))
 	}
-	logger.Debug("Redis初始化完成")
 
-	logger.Debug("开始初始化Kafka")
-	if err := initialize.InitKafka(); err != nil {
-		logger.Error("Kafka 初始化失败", zap.Error(err))
+	initialize.InitNotifier()
+
+	// 3. 按依赖顺序启动组件，关闭时顺序相反
+	cfg := config.Global.Shutdown
+	lc := lifecycle.New(time.Duration(cfg.ComponentTimeout) * time.Second)
+	registerComponents(lc)
+
+	if err := lc.Start(context.Background()); err != nil {
+		logger.Fatal("服务启动失败", zap.Error(err))
 	}
-	logger.Debug("Kafka初始化完成")
 
-	// 数据库必须成功连接，否则无法运行
-	logger.Debug("开始初始化数据库")
-	if err := initialize.InitGorm(); err != nil {
-		logger.Fatal("数据库初始化失败", zap.Error(err))
+	// 4. 收到退出信号或 HTTP 服务异常退出时关闭
+	failErr := lc.Wait(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	if failErr != nil {
+		logger.Error("服务异常退出", zap.Error(failErr))
 	}
-	logger.Debug("数据库初始化完成")
+	logger.Info("正在关闭服务...")
 
-	// 4. 初始化路由
-	logger.Debug("开始初始化路由")
-	router := initialize.InitRouter()
-	logger.Debug("路由初始化完成")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+	if err := lc.Stop(ctx); err != nil {
+		logger.Error("部分组件未能正常关闭", zap.Error(err))
+	}
 
-	// 5. 优雅关闭
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	logger.Info("服务已停止")
+	if failErr != nil {
+		cancel()
+		logger.Sync()
+		os.Exit(1)
+	}
+}
 
-	go func() {
-		port := config.Global.Server.Port
-		if port == "" {
-			port = "8080"
-		}
+// registerComponents 注册服务的全部组件，被依赖的组件先注册
+// 关闭顺序：HTTP 服务 -> 定时任务 -> Kafka 消费者 -> Kafka 生产者 -> Redis -> 数据库 -> 链路追踪
+func registerComponents(lc *lifecycle.Manager) {
+	// 链路追踪最后关闭，以便发送其他组件关闭过程中产生的 span
+	lc.Register(lifecycle.Component{
+		Name: "tracing",
+		Start: func(ctx context.Context) error {
+			if err := initialize.InitTracing(Version); err != nil {
+				logger.Error("链路追踪初始化失败", zap.Error(err))
+			}
+			return nil
+		},
+		Stop:        initialize.CloseTracing,
+		StopTimeout: componentStopTimeout("tracing"),
+	})
 
-		logger.Info("HTTP 服务启动", zap.String("port", port))
-		if err := router.Run(":" + port); err != nil {
-			logger.Fatal("服务启动失败", zap.Error(err))
-		}
-	}()
+	// 数据库必须成功连接，否则无法运行
+	lc.Register(lifecycle.Component{
+		Name: "database",
+		Start: func(ctx context.Context) error {
+			return initialize.InitGorm()
+		},
+		Stop: func(ctx context.Context) error {
+			return initialize.CloseGorm()
+		},
+		StopTimeout: componentStopTimeout("database"),
+	})
 
-	<-quit
-	logger.Info("正在关闭服务...")
+	// Redis 和 Kafka 是可选的，初始化失败只记录日志
+	lc.Register(lifecycle.Component{
+		Name: "redis",
+		Start: func(ctx context.Context) error {
+			if err := initialize.InitRedis(); err != nil {
+				logger.Error("Redis 初始化失败", zap.Error(err))
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return initialize.CloseRedis()
+		},
+		StopTimeout: componentStopTimeout("redis"),
+	})
 
-	// 关闭 Kafka 连接
-	initialize.CloseKafka()
+	// 生产者和消费者一起初始化，消费者单独注册以便先于生产者关闭
+	lc.Register(lifecycle.Component{
+		Name: "kafka_producer",
+		Start: func(ctx context.Context) error {
+			if err := initialize.InitKafka(); err != nil {
+				logger.Error("Kafka 初始化失败", zap.Error(err))
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return initialize.CloseKafkaProducer()
+		},
+		StopTimeout: componentStopTimeout("kafka_producer"),
+	})
+	lc.Register(lifecycle.Component{
+		Name: "kafka_consumer",
+		Stop: func(ctx context.Context) error {
+			return initialize.CloseKafkaConsumers()
+		},
+		StopTimeout: componentStopTimeout("kafka_consumer"),
+	})
 
-	logger.Info("服务已停止")
+	// 定时任务由各服务在初始化路由时注册，路由初始化完成后才能启动
+	var router http.Handler
+	lc.Register(lifecycle.Component{
+		Name: "router",
+		Start: func(ctx context.Context) error {
+			initialize.InitScheduler()
+			versionInfo := GetVersionInfo()
+			router = initialize.InitRouter(service.BuildInfo{
+				Version:   versionInfo.Version,
+				BuildTime: versionInfo.BuildTime,
+				GitCommit: versionInfo.GitCommit,
+				GoVersion: versionInfo.GoVersion,
+			})
+			return nil
+		},
+	})
+	lc.Register(lifecycle.Component{
+		Name: "scheduler",
+		Start: func(ctx context.Context) error {
+			initialize.StartScheduler()
+			return nil
+		},
+		Stop:        initialize.CloseScheduler,
+		StopTimeout: componentStopTimeout("scheduler"),
+	})
+
+	// 请求头读取超时与响应写超时分别由 request_timeout、response_timeout 控制
+	// 请求体的读取超时由 RequestTimeout 中间件按路由设置，上传、导入等路由可单独延长；流式下载路由会在中间件中取消写超时
+	server := &http.Server{
+		ReadHeaderTimeout: time.Duration(config.Global.Performance.RequestTimeout) * time.Second,
+		WriteTimeout:      time.Duration(config.Global.Performance.ResponseTimeout) * time.Second,
+	}
+	lc.Register(lifecycle.Component{
+		Name: "http",
+		Start: func(ctx context.Context) error {
+			port := config.Global.Server.Port
+			if port == "" {
+				port = "8080"
+			}
+			server.Addr = ":" + port
+			server.Handler = router
+
+			// 先监听端口，端口被占用等错误在启动阶段返回
+			ln, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			logger.Info("HTTP 服务启动",
+				zap.String("port", port),
+				zap.Duration("read_header_timeout", server.ReadHeaderTimeout),
+				zap.Duration("write_timeout", server.WriteTimeout))
+
+			go func() {
+				if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					lc.Fail(fmt.Errorf("HTTP 服务异常退出: %w", err))
+				}
+			}()
+			return nil
+		},
+		// 停止接收新请求并等待处理中的请求完成
+		Stop:        server.Shutdown,
+		StopTimeout: componentStopTimeout("http"),
+	})
+}
+
+// componentStopTimeout 组件在 shutdown.components 中配置的关闭超时，未配置时为 0，使用默认值
+func componentStopTimeout(name string) time.Duration {
+	return time.Duration(config.Global.Shutdown.Components[name]) * time.Second
 }
@@ -10,6 +10,7 @@ import (
 type VersionInfo struct {
 	Version   string
 	BuildTime string
+	GitCommit string
 	GoVersion string
 	Platform  string
 }
@@ -19,6 +20,7 @@ func GetVersionInfo() VersionInfo {
 	return VersionInfo{
 		Version:   Version,
 		BuildTime: BuildTime,
+		GitCommit: GitCommit,
 		GoVersion: runtime.Version(),
 		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
 	}
@@ -32,6 +34,7 @@ func PrintVersion() {
 	fmt.Println("=========================================")
 	fmt.Printf("版本:     %s\n", info.Version)
 	fmt.Printf("构建时间: %s\n", info.BuildTime)
+	fmt.Printf("Git提交:  %s\n", info.GitCommit)
 	fmt.Printf("Go 版本:  %s\n", info.GoVersion)
 	fmt.Printf("平台:     %s\n", info.Platform)
 	fmt.Println("=========================================")
@@ -54,6 +57,7 @@ func PrintDetailedVersion() {
 		}
 	}
 
+	fmt.Printf("Git 提交:     %s\n", info.GitCommit)
 	fmt.Printf("Go 版本:      %s\n", info.GoVersion)
 	fmt.Printf("操作系统:     %s\n", runtime.GOOS)
 	fmt.Printf("架构:         %s\n", runtime.GOARCH)
@@ -69,7 +73,8 @@ func GetVersionJSON() string {
   "name": "Charlotte API",
   "version": "%s",
   "build_time": "%s", 
+  "git_commit": "%s",
   "go_version": "%s",
   "platform": "%s"
-}`, info.Version, info.BuildTime, info.GoVersion, info.Platform)
+}`, info.Version, info.BuildTime, info.GitCommit, info.GoVersion, info.Platform)
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strings"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
@@ -22,6 +21,8 @@ type Config struct {
 	Kafka        KafkaConfig        `mapstructure:"kafka" json:"kafka"`
 	Log          logger.Config      `mapstructure:"log" json:"log"`
 	JWT          JWTConfig          `mapstructure:"jwt" json:"jwt"`
+	Session      SessionConfig      `mapstructure:"session" json:"session"`
+	ReadOnly     ReadOnlyConfig     `mapstructure:"read_only" json:"read_only"`
 	Migrate      MigrateConfig      `mapstructure:"migrate" json:"migrate"`
 	Performance  PerformanceConfig  `mapstructure:"performance" json:"performance"`
 	Health       HealthConfig       `mapstructure:"health" json:"health"`
@@ -30,6 +31,52 @@ type Config struct {
 	DevTools     DevToolsConfig     `mapstructure:"devtools" json:"devtools"`
 	File         FileConfig         `mapstructure:"file" json:"file"`
 	ImportExport ImportExportConfig `mapstructure:"import_export" json:"import_export"`
+	Permission   PermissionConfig   `mapstructure:"permission" json:"permission"`
+	Notification NotificationConfig `mapstructure:"notification" json:"notification"`
+	Webhook      WebhookConfig      `mapstructure:"webhook" json:"webhook"`
+	API          APIConfig          `mapstructure:"api" json:"api"`
+	Scheduler    SchedulerConfig    `mapstructure:"scheduler" json:"scheduler"`
+	Shutdown     ShutdownConfig     `mapstructure:"shutdown" json:"shutdown"`
+}
+
+// ReadOnlyConfig 只读模式配置，启用时拒绝 POST/PUT/PATCH/DELETE 请求，读请求照常处理
+// 也可由超级管理员在运行时开启（保存在 Redis 中，多实例共享），配置启用时运行时无法关闭
+type ReadOnlyConfig struct {
+	Enabled bool   `mapstructure:"enabled" json:"enabled"`
+	Message string `mapstructure:"message" json:"message"` // 拒绝写请求时返回的提示
+}
+
+// SchedulerConfig 定时任务配置
+// 调度规则支持 Go 时长（如 10m）、@every <时长>、@hourly、@daily，为空时不注册该任务
+type SchedulerConfig struct {
+	Enabled          bool   `mapstructure:"enabled" json:"enabled"`
+	ExportJobCleanup string `mapstructure:"export_job_cleanup" json:"export_job_cleanup"`     // 清理过期的异步导出、导入任务
+	WebhookRetry     string `mapstructure:"webhook_retry" json:"webhook_retry"`               // 重试到期的 Webhook 投递
+	ChunkUploadClean string `mapstructure:"chunk_upload_cleanup" json:"chunk_upload_cleanup"` // 清理过期的分片上传会话
+}
+
+// ShutdownConfig 优雅关闭配置，单位秒
+// 组件按启动的相反顺序关闭：HTTP 服务、定时任务、Kafka 消费者、Kafka 生产者、Redis、数据库
+type ShutdownConfig struct {
+	Timeout          int `mapstructure:"timeout" json:"timeout"`                     // 关闭全部组件的总时长上限
+	ComponentTimeout int `mapstructure:"component_timeout" json:"component_timeout"` // 单个组件的默认关闭超时
+	// Components 按组件名覆盖关闭超时，如 http、scheduler、kafka_consumer
+	Components map[string]int `mapstructure:"components" json:"components"`
+}
+
+// APIConfig API 版本配置
+type APIConfig struct {
+	// Versions 各 API 版本的弃用配置，键为版本号（如 v1）
+	Versions map[string]APIVersionConfig `mapstructure:"versions" json:"versions"`
+}
+
+// APIVersionConfig API 版本弃用配置，已弃用版本的响应会带上 Deprecation/Sunset/Link 头
+type APIVersionConfig struct {
+	Deprecated   bool   `mapstructure:"deprecated" json:"deprecated"`
+	DeprecatedAt string `mapstructure:"deprecated_at" json:"deprecated_at"` // RFC3339
+	Sunset       string `mapstructure:"sunset" json:"sunset"`               // 计划下线时间，RFC3339
+	DocLink      string `mapstructure:"doc_link" json:"doc_link"`           // 迁移说明文档
+	Successor    string `mapstructure:"successor" json:"successor"`         // 后继版本，如 v2
 }
 
 type PerformanceConfig struct {
@@ -37,6 +84,9 @@ type PerformanceConfig struct {
 	ResponseTimeout int `mapstructure:"response_timeout" json:"response_timeout"`
 	MaxRequestSize  int `mapstructure:"max_request_size" json:"max_request_size"`
 	RateLimit       int `mapstructure:"rate_limit" json:"rate_limit"`
+	// RouteTimeouts 按路由覆盖请求超时（秒），键为路由后缀，如 /import-export/import；0 表示不设超时
+	// 配置文件中的键会被转为小写
+	RouteTimeouts map[string]int `mapstructure:"route_timeouts" json:"route_timeouts"`
 }
 
 type HealthConfig struct {
@@ -51,6 +101,57 @@ type SecurityConfig struct {
 	CORSOrigins        []string `mapstructure:"cors_origins" json:"cors_origins"`
 	RateLimitEnabled   bool     `mapstructure:"rate_limit_enabled" json:"rate_limit_enabled"`
 	RateLimitPerMinute int      `mapstructure:"rate_limit_per_minute" json:"rate_limit_per_minute"`
+	RateLimitAlgorithm string   `mapstructure:"rate_limit_algorithm" json:"rate_limit_algorithm"` // sliding_window 或 token_bucket
+	// RateLimitKey 全局限流的键：ip（默认）或 api_key（携带 API Key 的请求按 Key 单独计数，未携带时按IP）
+	RateLimitKey string `mapstructure:"rate_limit_key" json:"rate_limit_key"`
+	// APIKeyHeader 携带 API Key 的请求头，默认 X-API-Key
+	APIKeyHeader string `mapstructure:"api_key_header" json:"api_key_header"`
+
+	// CORSPolicies 命名CORS策略，由路由按组绑定（如 admin），未绑定的路由使用 cors_origins
+	CORSPolicies map[string]CORSPolicyConfig `mapstructure:"cors_policies" json:"cors_policies"`
+
+	// Registration 注册防护配置（单IP注册频率、邮箱域名黑白名单、人机验证）
+	Registration RegistrationGuardConfig `mapstructure:"registration" json:"registration"`
+
+	// Password 密码哈希配置
+	Password PasswordConfig `mapstructure:"password" json:"password"`
+}
+
+// PasswordConfig 密码哈希配置
+// 新密码和修改的密码使用 algorithm 哈希；其他算法或参数的已有哈希在用户下次登录成功时自动升级
+type PasswordConfig struct {
+	Algorithm         string `mapstructure:"algorithm" json:"algorithm"` // bcrypt 或 argon2id
+	BcryptCost        int    `mapstructure:"bcrypt_cost" json:"bcrypt_cost"`
+	Argon2Memory      uint32 `mapstructure:"argon2_memory" json:"argon2_memory"` // KiB
+	Argon2Iterations  uint32 `mapstructure:"argon2_iterations" json:"argon2_iterations"`
+	Argon2Parallelism uint8  `mapstructure:"argon2_parallelism" json:"argon2_parallelism"`
+	// Argon2MaxConcurrent 同时计算的 argon2id 哈希数上限，每个占用 argon2_memory 内存；0 表示 CPU 核数
+	Argon2MaxConcurrent int `mapstructure:"argon2_max_concurrent" json:"argon2_max_concurrent"`
+}
+
+// RegistrationGuardConfig 注册防护配置
+type RegistrationGuardConfig struct {
+	MaxPerIPPerHour     int           `mapstructure:"max_per_ip_per_hour" json:"max_per_ip_per_hour"`     // 单IP每小时最多注册数，0 表示不限制
+	AllowedEmailDomains []string      `mapstructure:"allowed_email_domains" json:"allowed_email_domains"` // 为空表示不限制
+	DeniedEmailDomains  []string      `mapstructure:"denied_email_domains" json:"denied_email_domains"`
+	Captcha             CaptchaConfig `mapstructure:"captcha" json:"captcha"`
+}
+
+// CaptchaConfig 人机验证配置，verify_url 需兼容 reCAPTCHA/hCaptcha 的 siteverify 接口
+type CaptchaConfig struct {
+	Enabled   bool   `mapstructure:"enabled" json:"enabled"`
+	VerifyURL string `mapstructure:"verify_url" json:"verify_url"`
+	Secret    string `mapstructure:"secret" json:"-"`
+	Timeout   int    `mapstructure:"timeout" json:"timeout"` // 秒
+}
+
+// CORSPolicyConfig CORS策略配置
+type CORSPolicyConfig struct {
+	AllowOrigins     []string `mapstructure:"allow_origins" json:"allow_origins"`
+	AllowMethods     []string `mapstructure:"allow_methods" json:"allow_methods"`
+	AllowHeaders     []string `mapstructure:"allow_headers" json:"allow_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials" json:"allow_credentials"`
+	MaxAge           int      `mapstructure:"max_age" json:"max_age"` // 秒
 }
 
 type MonitoringConfig struct {
@@ -58,6 +159,12 @@ type MonitoringConfig struct {
 	MetricsPath        string `mapstructure:"metrics_path" json:"metrics_path"`
 	TracingEnabled     bool   `mapstructure:"tracing_enabled" json:"tracing_enabled"`
 	HealthCheckEnabled bool   `mapstructure:"health_check_enabled" json:"health_check_enabled"`
+
+	// 链路追踪（OpenTelemetry），通过 OTLP/HTTP 导出到 TracingEndpoint
+	TracingEndpoint    string  `mapstructure:"tracing_endpoint" json:"tracing_endpoint"`         // 如 otel-collector:4318
+	TracingInsecure    bool    `mapstructure:"tracing_insecure" json:"tracing_insecure"`         // 使用 HTTP 而非 HTTPS
+	TracingSampleRatio float64 `mapstructure:"tracing_sample_ratio" json:"tracing_sample_ratio"` // 采样率 0~1
+	TracingServiceName string  `mapstructure:"tracing_service_name" json:"tracing_service_name"`
 }
 
 type DevToolsConfig struct {
@@ -73,15 +180,135 @@ type FileConfig struct {
 	MaxUploadSize    int64    `mapstructure:"max_upload_size" json:"max_upload_size"`
 	AllowedTypes     []string `mapstructure:"allowed_types" json:"allowed_types"`
 	AllowedExtensions []string `mapstructure:"allowed_extensions" json:"allowed_extensions"`
+	// StrictTypeCheck 扩展名和按内容识别的类型都须在允许列表中且相互一致；关闭时任一满足即可
+	StrictTypeCheck bool `mapstructure:"strict_type_check" json:"strict_type_check"`
+	// InlineTypes 允许在浏览器内预览（Content-Disposition: inline）的 MIME 类型，其余类型一律作为附件下载
+	InlineTypes []string `mapstructure:"inline_types" json:"inline_types"`
+
+	// 批量上传配置
+	MaxBatchFiles     int `mapstructure:"max_batch_files" json:"max_batch_files"`       // 单次请求最多上传的文件数
+	UploadConcurrency int `mapstructure:"upload_concurrency" json:"upload_concurrency"` // 批量上传并发处理数
+
+	// StorageType 上传文件的存储后端：local 保存在 upload_path 目录（默认），s3 保存在 S3 兼容的对象存储中，多实例部署时使用
+	StorageType string            `mapstructure:"storage_type" json:"storage_type"`
+	Storage     FileStorageConfig `mapstructure:"storage" json:"storage"`
+
+	// DedupEnabled 上传内容相同（MD5 和大小一致）的文件时不再重复保存，新文件记录引用已存储的文件
+	DedupEnabled bool `mapstructure:"dedup_enabled" json:"dedup_enabled"`
+
+	// 分片上传配置
+	ChunkMaxSize   int64 `mapstructure:"chunk_max_size" json:"chunk_max_size"`     // 单个分片的最大字节数
+	ChunkUploadTTL int   `mapstructure:"chunk_upload_ttl" json:"chunk_upload_ttl"` // 分片上传会话无新分片多久后清理（小时）
+
+	// ThumbnailSize 上传 JPEG、PNG 图片时生成的缩略图最长边像素数，0 表示不生成
+	ThumbnailSize int `mapstructure:"thumbnail_size" json:"thumbnail_size"`
+
+	// PresignedURL 文件下载签名链接
+	PresignedURL PresignedURLConfig `mapstructure:"presigned_url" json:"presigned_url"`
+}
+
+// PresignedURLConfig 文件下载签名链接配置
+// 签名链接在查询参数中携带过期时间和 HMAC 签名，过期或被篡改的链接返回 403
+type PresignedURLConfig struct {
+	// Required 为 true（默认）时非公开文件只能通过签名链接下载；为 false 时不带签名的请求不校验（兼容已有链接）
+	Required   bool   `mapstructure:"required" json:"required"`
+	SigningKey string `mapstructure:"signing_key" json:"-"`           // 签名密钥，为空时使用 jwt.secret
+	DefaultTTL int    `mapstructure:"default_ttl" json:"default_ttl"` // 未指定有效期时的默认值（秒）
+	MaxTTL     int    `mapstructure:"max_ttl" json:"max_ttl"`         // 有效期上限（秒）
+}
+
+// FileStorageConfig 文件存储后端配置
+type FileStorageConfig struct {
+	S3 S3StorageConfig `mapstructure:"s3" json:"s3"`
+}
+
+// S3StorageConfig S3 兼容对象存储配置
+type S3StorageConfig struct {
+	// Endpoint 服务地址，为空时使用 AWS 区域地址 https://s3.{region}.amazonaws.com；MinIO 等需填写完整地址
+	Endpoint        string `mapstructure:"endpoint" json:"endpoint"`
+	Region          string `mapstructure:"region" json:"region"`
+	Bucket          string `mapstructure:"bucket" json:"bucket"`
+	AccessKeyID     string `mapstructure:"access_key_id" json:"-"`
+	SecretAccessKey string `mapstructure:"secret_access_key" json:"-"`
+	SessionToken    string `mapstructure:"session_token" json:"-"`
+	// Prefix 对象键前缀，多个应用共用存储桶时区分目录
+	Prefix string `mapstructure:"prefix" json:"prefix"`
+	// UsePathStyle 使用 {endpoint}/{bucket}/{key} 形式的地址，MinIO 等不支持虚拟主机形式的服务需开启
+	UsePathStyle bool `mapstructure:"use_path_style" json:"use_path_style"`
 }
 
 // ImportExportConfig 导入导出配置
 type ImportExportConfig struct {
-	DefaultDateFormat string   `mapstructure:"default_date_format" json:"default_date_format"`
-	DefaultTimeFormat string   `mapstructure:"default_time_format" json:"default_time_format"`
-	MaxImportRows     int      `mapstructure:"max_import_rows" json:"max_import_rows"`
+	DefaultDateFormat string `mapstructure:"default_date_format" json:"default_date_format"`
+	DefaultTimeFormat string `mapstructure:"default_time_format" json:"default_time_format"`
+	// DefaultLocale 导出默认区域格式（如 de-DE、en-GB），为空时使用 yyyy-MM-dd 日期和点号小数
+	DefaultLocale string `mapstructure:"default_locale" json:"default_locale"`
+	// FileNameTemplate 导出文件名模板，支持 {data_type}、{date}、{datetime}、{user}、{count}，为空时为 {data_type}_{datetime}
+	FileNameTemplate string `mapstructure:"file_name_template" json:"file_name_template"`
+	MaxImportRows    int    `mapstructure:"max_import_rows" json:"max_import_rows"`   // 单次导入最多数据行数，0 表示不限制
+	ExportPageSize   int    `mapstructure:"export_page_size" json:"export_page_size"` // 从处理器获取导出数据时每页条数
+	// ExportJobRetention 异步导出任务结束后保留的小时数，超过后由定时任务清理任务记录和结果文件，
+	// 异步导入任务的状态记录及保留的待重新导入文件按同样的时长清理
+	ExportJobRetention int      `mapstructure:"export_job_retention" json:"export_job_retention"`
 	SupportedDataTypes []string `mapstructure:"supported_data_types" json:"supported_data_types"`
 	SupportedFileTypes []string `mapstructure:"supported_file_types" json:"supported_file_types"`
+
+	// 全局并发限制（每个实例）：同时进行的导入导出数，超出后排队，排队已满或等待超时返回 429；MaxConcurrent 为 0 时不限制
+	MaxConcurrent int `mapstructure:"max_concurrent" json:"max_concurrent"`
+	MaxQueue      int `mapstructure:"max_queue" json:"max_queue"`
+	QueueTimeout  int `mapstructure:"queue_timeout" json:"queue_timeout"` // 秒
+}
+
+// PermissionConfig 权限配置
+type PermissionConfig struct {
+	MaxGroupsPerUser int `mapstructure:"max_groups_per_user" json:"max_groups_per_user"` // 单个用户最多加入的用户组数量，0表示不限制
+	// ExportFields 按角色限制可导出的字段：角色 -> 数据类型 -> 允许导出的结构体字段名
+	// 未配置的角色或数据类型不限制，其余字段导出时去掉并在响应中列出
+	ExportFields map[string]map[string][]string `mapstructure:"export_fields" json:"export_fields"`
+	// Audit 权限决策日志
+	Audit PermissionAuditConfig `mapstructure:"audit" json:"audit"`
+}
+
+// PermissionAuditConfig 权限决策日志配置，权限拒绝全部记录，授权只记录敏感操作
+type PermissionAuditConfig struct {
+	Enabled             bool     `mapstructure:"enabled" json:"enabled"`
+	SensitiveOperations []string `mapstructure:"sensitive_operations" json:"sensitive_operations"` // 授权时也记录的操作：read/write/delete/all
+}
+
+// NotificationConfig 通知配置
+type NotificationConfig struct {
+	Email   EmailNotificationConfig   `mapstructure:"email" json:"email"`
+	Webhook WebhookNotificationConfig `mapstructure:"webhook" json:"webhook"`
+}
+
+// EmailNotificationConfig 邮件通知配置
+type EmailNotificationConfig struct {
+	Enabled  bool   `mapstructure:"enabled" json:"enabled"`
+	Host     string `mapstructure:"host" json:"host"`
+	Port     int    `mapstructure:"port" json:"port"`
+	Username string `mapstructure:"username" json:"username"`
+	Password string `mapstructure:"password" json:"-"`
+	From     string `mapstructure:"from" json:"from"`
+	Timeout  int    `mapstructure:"timeout" json:"timeout"` // 秒
+}
+
+// WebhookConfig 领域事件 Webhook 配置，订阅由管理接口维护
+// 首次投递失败后按 RetryBackoff 指数退避重试（最长间隔 1 小时），共尝试 MaxAttempts 次
+type WebhookConfig struct {
+	Enabled      bool `mapstructure:"enabled" json:"enabled"`
+	Timeout      int  `mapstructure:"timeout" json:"timeout"`             // 单次投递超时，秒
+	MaxAttempts  int  `mapstructure:"max_attempts" json:"max_attempts"`   // 最多投递次数（含首次）
+	RetryBackoff int  `mapstructure:"retry_backoff" json:"retry_backoff"` // 首次重试间隔，秒，之后每次翻倍
+	Concurrency  int  `mapstructure:"concurrency" json:"concurrency"`     // 本实例同时进行的投递数
+	// AllowPrivateNetworks 允许投递到回环、内网、链路本地等地址，仅用于本地开发和测试
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks" json:"allow_private_networks"`
+}
+
+// WebhookNotificationConfig Webhook通知配置
+type WebhookNotificationConfig struct {
+	Enabled bool   `mapstructure:"enabled" json:"enabled"`
+	URL     string `mapstructure:"url" json:"url"`
+	Timeout int    `mapstructure:"timeout" json:"timeout"` // 秒
 }
 
 type MigrateConfig struct {
@@ -98,6 +325,13 @@ type ServerConfig struct {
 	Port    string `mapstructure:"port" json:"port"`
 	Mode    string `mapstructure:"mode" json:"mode"` // debug/release
 	BaseURL string `mapstructure:"base_url" json:"base_url"`
+
+	// RequestIDHeader 请求ID所在的请求/响应头，用于链路追踪
+	RequestIDHeader string `mapstructure:"request_id_header" json:"request_id_header"`
+
+	// TrustedProxies 受信任的代理IP或CIDR，仅来自这些地址的 X-Forwarded-For/X-Real-IP 会被采信
+	// 为空时不信任任何代理，客户端IP取连接的远端地址
+	TrustedProxies []string `mapstructure:"trusted_proxies" json:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -118,11 +352,25 @@ type DatabaseConfig struct {
 	Charset      string `mapstructure:"charset" json:"charset"`
 	ParseTime    bool   `mapstructure:"parse_time" json:"parse_time"`
 	Loc          string `mapstructure:"loc" json:"loc"`
+
+	// TLS配置（PostgreSQL、MySQL）
+	// SSLMode: disable/require/verify-ca/verify-full，为空时 release 模式默认 require，其他模式默认 disable
+	SSLMode     string `mapstructure:"ssl_mode" json:"ssl_mode"`
+	SSLRootCert string `mapstructure:"ssl_root_cert" json:"ssl_root_cert"` // CA证书路径
+	SSLCert     string `mapstructure:"ssl_cert" json:"ssl_cert"`           // 客户端证书路径（双向认证）
+	SSLKey      string `mapstructure:"ssl_key" json:"ssl_key"`             // 客户端私钥路径（双向认证）
 	
 	// 连接池配置
 	ConnMaxLifetime int `mapstructure:"conn_max_lifetime" json:"conn_max_lifetime"`
 	ConnMaxIdleTime int `mapstructure:"conn_max_idle_time" json:"conn_max_idle_time"`
+
+	// StatementTimeout 单条语句在数据库端的最长执行时间（秒），超时由数据库终止，0 表示不限制
+	// PostgreSQL 设置 statement_timeout；MySQL 设置 max_execution_time，仅对 SELECT 生效；SQLite 不支持
+	StatementTimeout int `mapstructure:"statement_timeout" json:"statement_timeout"`
 	
+	// SearchUnaccent 关键词搜索忽略重音（jose 可匹配 José），仅 PostgreSQL 生效，需要 unaccent 扩展
+	SearchUnaccent bool `mapstructure:"search_unaccent" json:"search_unaccent"`
+
 	// 缓存配置
 	CacheEnabled    bool `mapstructure:"cache_enabled" json:"cache_enabled"`
 	CacheTTL        int  `mapstructure:"cache_ttl" json:"cache_ttl"`
@@ -135,12 +383,24 @@ type RedisConfig struct {
 	Password string `mapstructure:"password" json:"password"`
 	DB       int    `mapstructure:"db" json:"db"`
 	PoolSize int    `mapstructure:"pool_size" json:"pool_size"`
+
+	// 连接池配置（时间单位均为秒，0 表示使用默认值或不限制）
+	MinIdleConns int  `mapstructure:"min_idle_conns" json:"min_idle_conns"` // 最小空闲连接数
+	MaxConnAge   int  `mapstructure:"max_conn_age" json:"max_conn_age"`     // 连接最大存活时间
+	IdleTimeout  int  `mapstructure:"idle_timeout" json:"idle_timeout"`     // 空闲连接回收时间
+	PoolTimeout  int  `mapstructure:"pool_timeout" json:"pool_timeout"`     // 从连接池获取连接的超时时间
+	Warmup       bool `mapstructure:"warmup" json:"warmup"`                 // 启动时预先建立 min_idle_conns 个连接
 }
 
 type KafkaConfig struct {
 	Brokers []string `mapstructure:"brokers" json:"brokers"`
 	Topic   string   `mapstructure:"topic" json:"topic"`
 	GroupID string   `mapstructure:"group_id" json:"group_id"`
+
+	// CacheInvalidation 是否通过用户事件清除各实例的用户缓存
+	CacheInvalidation bool `mapstructure:"cache_invalidation" json:"cache_invalidation"`
+	// CacheGroupID 缓存失效消费者组，每个实例须不同且重启后保持不变；为空时使用 <group_id>-cache-<主机名>
+	CacheGroupID string `mapstructure:"cache_group_id" json:"cache_group_id"`
 }
 
 type JWTConfig struct {
@@ -148,6 +408,18 @@ type JWTConfig struct {
 	Expire int    `mapstructure:"expire" json:"expire"` // 小时
 }
 
+// SessionConfig 服务端会话配置
+// 启用后登录在 Redis 中创建会话并通过 HttpOnly Cookie 下发会话ID，需认证的接口改为校验会话而非 JWT，
+// 登出或轮换令牌时会话立即失效；Redis 不可用时回退为 JWT 认证
+type SessionConfig struct {
+	Enabled    bool   `mapstructure:"enabled" json:"enabled"`
+	CookieName string `mapstructure:"cookie_name" json:"cookie_name"`
+	Expire     int    `mapstructure:"expire" json:"expire"` // 小时
+	Domain     string `mapstructure:"domain" json:"domain"`
+	Secure     bool   `mapstructure:"secure" json:"secure"`       // 仅通过 HTTPS 发送 Cookie
+	SameSite   string `mapstructure:"same_site" json:"same_site"` // lax、strict 或 none（none 要求 secure）
+}
+
 // Load 加载配置（兼容旧版本，推荐使用LoadSecureConfig）
 func Load(cfgFile string) {
 	LoadSecureConfig(cfgFile)
@@ -186,12 +458,20 @@ func LoadSecureConfig(cfgFile string) error {
 	// 加载配置
 	if err := scm.LoadSecureConfig(configPath); err != nil {
 		// 回退到旧配置系统
-		logger.Warn("安全配置加载失败，使用旧配置系统", zap.Error(err))
+		if logger.GetLogger() != nil {
+			logger.Warn("安全配置加载失败，使用旧配置系统", zap.Error(err))
+		} else {
+			log.Printf("安全配置加载失败，使用旧配置系统: %v", err)
+		}
 		return loadLegacyConfig(cfgFile)
 	}
 	
 	// 成功加载安全配置
-	logger.Info("安全配置加载成功", zap.String("file", configPath))
+	if logger.GetLogger() != nil {
+		logger.Info("安全配置加载成功", zap.String("file", configPath))
+	} else {
+		log.Printf("安全配置加载成功: %s", configPath)
+	}
 	return nil
 }
 
@@ -201,6 +481,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.name", "charlotte-api")
 	v.SetDefault("server.port", "8080")
 	v.SetDefault("server.mode", "debug")
+	v.SetDefault("server.request_id_header", "X-Request-ID")
+	v.SetDefault("server.trusted_proxies", []string{})
 
 	// 数据库默认配置
 	v.SetDefault("database.host", "localhost")
@@ -211,6 +493,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.max_idle_conns", 10)
 	v.SetDefault("database.conn_max_lifetime", 3600)
 	v.SetDefault("database.conn_max_idle_time", 1800)
+	v.SetDefault("database.statement_timeout", 0)
+	v.SetDefault("database.search_unaccent", false)
 
 	// Redis默认配置
 	v.SetDefault("redis.host", "localhost")
@@ -220,11 +504,14 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("redis.min_idle_conns", 5)
 	v.SetDefault("redis.max_conn_age", 0)
 	v.SetDefault("redis.pool_timeout", 4)
+	v.SetDefault("redis.idle_timeout", 300)
+	v.SetDefault("redis.warmup", true)
 
 	// Kafka默认配置
 	v.SetDefault("kafka.brokers", []string{"localhost:9092"})
 	v.SetDefault("kafka.topic", "user-events")
 	v.SetDefault("kafka.group_id", "charlotte-group")
+	v.SetDefault("kafka.cache_invalidation", true)
 	v.SetDefault("kafka.auto_offset_reset", "latest")
 	v.SetDefault("kafka.session_timeout", 10000)
 	v.SetDefault("kafka.heartbeat_interval", 3000)
@@ -236,12 +523,27 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("jwt.issuer", "charlotte-api")
 	v.SetDefault("jwt.audience", "charlotte-users")
 
+	// 会话默认配置
+	v.SetDefault("session.enabled", false)
+	v.SetDefault("session.cookie_name", "charlotte_session")
+	v.SetDefault("session.expire", 24)
+	v.SetDefault("session.secure", true)
+	v.SetDefault("session.same_site", "lax")
+
+	// 只读模式默认配置
+	v.SetDefault("read_only.enabled", false)
+	v.SetDefault("read_only.message", "系统维护中，暂时只能查询，请稍后再试")
+
 	// 日志默认配置
 	v.SetDefault("log.level", "debug")
 	v.SetDefault("log.encoding", "json")
 	v.SetDefault("log.output_paths", []string{"stdout"})
 	v.SetDefault("log.error_output_paths", []string{"stderr"})
 	v.SetDefault("log.development", false)
+	v.SetDefault("log.sampling.enabled", false)
+	v.SetDefault("log.sampling.initial", 100)
+	v.SetDefault("log.sampling.thereafter", 100)
+	v.SetDefault("log.sampling.tick", 1)
 
 	// 迁移默认配置
 	v.SetDefault("migrate.enabled", true)
@@ -267,11 +569,29 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("security.cors_origins", []string{"*"})
 	v.SetDefault("security.rate_limit_enabled", true)
 	v.SetDefault("security.rate_limit_per_minute", 100)
+	v.SetDefault("security.rate_limit_algorithm", "sliding_window")
+	v.SetDefault("security.rate_limit_key", "ip")
+	v.SetDefault("security.api_key_header", "X-API-Key")
+	v.SetDefault("security.registration.max_per_ip_per_hour", 5)
+	v.SetDefault("security.registration.captcha.enabled", false)
+	v.SetDefault("security.registration.captcha.verify_url", "")
+	v.SetDefault("security.registration.captcha.secret", "")
+	v.SetDefault("security.registration.captcha.timeout", 5)
+	v.SetDefault("security.password.algorithm", "argon2id")
+	v.SetDefault("security.password.bcrypt_cost", 10)
+	v.SetDefault("security.password.argon2_memory", 64*1024)
+	v.SetDefault("security.password.argon2_iterations", 3)
+	v.SetDefault("security.password.argon2_parallelism", 2)
+	v.SetDefault("security.password.argon2_max_concurrent", 0)
 
 	// 监控配置默认值
 	v.SetDefault("monitoring.metrics_enabled", true)
 	v.SetDefault("monitoring.metrics_path", "/metrics")
 	v.SetDefault("monitoring.tracing_enabled", false)
+	v.SetDefault("monitoring.tracing_endpoint", "localhost:4318")
+	v.SetDefault("monitoring.tracing_insecure", false)
+	v.SetDefault("monitoring.tracing_sample_ratio", 1.0)
+	v.SetDefault("monitoring.tracing_service_name", "charlotte")
 	v.SetDefault("monitoring.health_check_enabled", true)
 
 	// 开发工具默认值
@@ -312,13 +632,70 @@ func setDefaults(v *viper.Viper) {
 		".csv",
 		".json",
 	})
+	v.SetDefault("file.strict_type_check", true)
+	v.SetDefault("file.inline_types", []string{
+		"image/jpeg",
+		"image/png",
+		"image/gif",
+		"image/webp",
+		"application/pdf",
+	})
+	v.SetDefault("file.max_batch_files", 20)
+	v.SetDefault("file.upload_concurrency", 4)
+	v.SetDefault("file.storage_type", "local")
+	v.SetDefault("file.storage.s3.region", "us-east-1")
+	v.SetDefault("file.dedup_enabled", false)
+	v.SetDefault("file.thumbnail_size", 256)
+	v.SetDefault("file.presigned_url.required", true)
+	v.SetDefault("file.presigned_url.default_ttl", 3600)
+	v.SetDefault("file.presigned_url.max_ttl", 604800)
+	v.SetDefault("file.chunk_max_size", 8388608)
+	v.SetDefault("file.chunk_upload_ttl", 24)
 
 	// 导入导出默认值
 	v.SetDefault("import_export.default_date_format", "2006-01-02")
 	v.SetDefault("import_export.default_time_format", "15:04:05")
+	v.SetDefault("import_export.default_locale", "")
+	v.SetDefault("import_export.file_name_template", "{data_type}_{datetime}")
 	v.SetDefault("import_export.max_import_rows", 10000)
+	v.SetDefault("import_export.export_job_retention", 24)
+	v.SetDefault("import_export.export_page_size", 1000)
+	v.SetDefault("import_export.max_concurrent", 4)
+	v.SetDefault("import_export.max_queue", 20)
+	v.SetDefault("import_export.queue_timeout", 30)
 	v.SetDefault("import_export.supported_data_types", []string{"user", "product", "order", "customer"})
-	v.SetDefault("import_export.supported_file_types", []string{"csv", "excel", "json"})
+	v.SetDefault("import_export.supported_file_types", []string{"csv", "excel", "json", "xml"})
+
+	// 权限默认值
+	v.SetDefault("permission.max_groups_per_user", 20)
+	v.SetDefault("permission.audit.enabled", true)
+	v.SetDefault("permission.audit.sensitive_operations", []string{"write", "delete", "all"})
+
+	// 通知默认值
+	v.SetDefault("notification.email.enabled", false)
+	v.SetDefault("notification.email.port", 587)
+	v.SetDefault("notification.email.timeout", 10)
+	v.SetDefault("notification.webhook.enabled", false)
+	v.SetDefault("notification.webhook.timeout", 10)
+
+	// 定时任务默认值
+	v.SetDefault("scheduler.enabled", true)
+	v.SetDefault("scheduler.export_job_cleanup", "@every 10m")
+	v.SetDefault("scheduler.webhook_retry", "@every 30s")
+	v.SetDefault("scheduler.chunk_upload_cleanup", "@every 30m")
+
+	// 优雅关闭默认值
+	v.SetDefault("shutdown.timeout", 30)
+	v.SetDefault("shutdown.component_timeout", 10)
+	v.SetDefault("shutdown.components", map[string]int{"http": 15})
+
+	// Webhook默认配置
+	v.SetDefault("webhook.enabled", true)
+	v.SetDefault("webhook.timeout", 10)
+	v.SetDefault("webhook.max_attempts", 6)
+	v.SetDefault("webhook.retry_backoff", 30)
+	v.SetDefault("webhook.concurrency", 8)
+	v.SetDefault("webhook.allow_private_networks", false)
 }
 
 func Show() {
@@ -367,6 +744,19 @@ func Validate() error {
 		}
 	}
 
+	// 验证注册防护配置，启用人机验证却未配置校验地址时会跳过人机验证
+	if captcha := Global.Security.Registration.Captcha; captcha.Enabled && captcha.VerifyURL == "" {
+		return fmt.Errorf("启用人机验证时 security.registration.captcha.verify_url 不能为空")
+	}
+
+	// 验证超时配置
+	// http.Server 的写超时从读取完请求头开始计算，小于处理超时会导致响应被截断
+	if Global.Performance.ResponseTimeout > 0 && Global.Performance.ResponseTimeout < Global.Performance.RequestTimeout {
+		logger.Warn("响应超时小于请求超时，超时前完成处理的请求也可能无法写回响应",
+			zap.Int("request_timeout", Global.Performance.RequestTimeout),
+			zap.Int("response_timeout", Global.Performance.ResponseTimeout))
+	}
+
 	// 验证Kafka配置
 	if len(Global.Kafka.Brokers) == 0 {
 		logger.Warn("Kafka代理未配置，Kafka功能将不可用")
@@ -406,9 +796,7 @@ func loadLegacyConfig(cfgFile string) error {
 	setDefaults(v)
 
 	// 环境变量支持
-	v.AutomaticEnv()
-	v.SetEnvPrefix("CHARLOTTE")
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	setupEnv(v)
 
 	// 配置文件加载
 	if cfgFile != "" {
@@ -425,7 +813,11 @@ func loadLegacyConfig(cfgFile string) error {
 	// 读取配置文件
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			logger.Warn("配置文件未找到，使用默认配置和环境变量")
+			if logger.GetLogger() != nil {
+				logger.Warn("配置文件未找到，使用默认配置和环境变量")
+			} else {
+				log.Printf("配置文件未找到，使用默认配置和环境变量")
+			}
 		} else {
 			log.Printf("配置文件读取失败: %v", err)
 			return err
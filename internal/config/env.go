@@ -0,0 +1,88 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量前缀，配置键 database.host 对应环境变量 CHARLOTTE_DATABASE_HOST
+const EnvPrefix = "CHARLOTTE"
+
+// envKeyReplacer 配置键到环境变量名的替换规则
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// setupEnv 启用环境变量覆盖，并为全部配置键显式绑定环境变量
+// 仅依赖 AutomaticEnv 时，Unmarshal 只能看到配置文件或默认值中出现过的键，
+// 无配置文件部署时嵌套键的环境变量会被忽略，因此需要显式 BindEnv
+func setupEnv(v *viper.Viper) {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(envKeyReplacer)
+	v.AutomaticEnv()
+
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		_ = v.BindEnv(key)
+	}
+}
+
+// EnvVarNames 返回全部配置项对应的环境变量名（按字母排序）
+// map 类型的配置项（如 security.cors_policies）无法逐键绑定，只能通过配置文件设置
+func EnvVarNames() []string {
+	keys := configKeys(reflect.TypeOf(Config{}), "")
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, EnvPrefix+"_"+strings.ToUpper(envKeyReplacer.Replace(key)))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configKeys 根据 mapstructure 标签递归收集结构体的叶子配置键
+func configKeys(t reflect.Type, prefix string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && strings.Contains(opts, "squash") {
+			keys = append(keys, configKeys(fieldType, prefix)...)
+			continue
+		}
+
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		switch fieldType.Kind() {
+		case reflect.Struct:
+			keys = append(keys, configKeys(fieldType, key)...)
+		case reflect.Map:
+			// map 的键在运行时才确定，无法绑定环境变量
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/fsnotify/fsnotify"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -55,7 +56,11 @@ func (scm *SecureConfigManager) LoadSecureConfig(configPath string) error {
 
 	// 3. 解密敏感配置
 	if err := scm.decryptSensitiveConfig(); err != nil {
-		logger.Warn("配置解密失败，使用明文配置", zap.Error(err))
+		if logger.GetLogger() != nil {
+			logger.Warn("配置解密失败，使用明文配置", zap.Error(err))
+		} else {
+			log.Printf("配置解密失败，使用明文配置: %v", err)
+		}
 	}
 
 	// 4. 验证配置完整性
@@ -63,7 +68,15 @@ func (scm *SecureConfigManager) LoadSecureConfig(configPath string) error {
 		return fmt.Errorf("配置验证失败: %v", err)
 	}
 
-	// 5. 设置配置热更新
+	// 5. 补齐默认值并解析到全局配置（默认值在验证之后设置，必需配置须由配置文件或环境变量提供）
+	setDefaults(scm.viper)
+	cfg := &Config{}
+	if err := scm.viper.Unmarshal(cfg); err != nil {
+		return fmt.Errorf("配置解析失败: %v", err)
+	}
+	Global = cfg
+
+	// 6. 设置配置热更新
 	scm.setupConfigWatch()
 
 	if logger.GetLogger() != nil {
@@ -72,14 +85,11 @@ func (scm *SecureConfigManager) LoadSecureConfig(configPath string) error {
 	return nil
 }
 
-// setupEnvironment 设置环境变量
+// setupEnvironment 设置环境变量，环境变量优先级高于配置文件
+// 不启用 SetTypeByDefaultValue：它会按空白切分字符串切片，
+// 保留原始字符串由 Unmarshal 按逗号切分（如 CHARLOTTE_KAFKA_BROKERS=a:9092,b:9092）
 func (scm *SecureConfigManager) setupEnvironment() {
-	scm.viper.AutomaticEnv()
-	scm.viper.SetEnvPrefix("CHARLOTTE")
-	scm.viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
-
-	// 设置环境变量优先级高于配置文件
-	scm.viper.SetTypeByDefaultValue(true)
+	setupEnv(scm.viper)
 }
 
 // loadConfigFile 加载配置文件
@@ -98,7 +108,11 @@ func (scm *SecureConfigManager) loadConfigFile(configPath string) error {
 
 	if err := scm.viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			logger.Warn("配置文件未找到，使用环境变量和默认配置")
+			if logger.GetLogger() != nil {
+				logger.Warn("配置文件未找到，使用环境变量和默认配置")
+			} else {
+				log.Printf("配置文件未找到，使用环境变量和默认配置")
+			}
 			return nil
 		}
 		return fmt.Errorf("配置文件读取失败: %v", err)
@@ -126,6 +140,7 @@ func (scm *SecureConfigManager) decryptSensitiveConfig() error {
 		"database.password",
 		"redis.password",
 		"jwt.secret",
+		"file.presigned_url.signing_key",
 		"security.api_keys",
 		"security.certificates",
 	}
@@ -225,7 +240,11 @@ func (scm *SecureConfigManager) validateConfig() error {
 	// 验证JWT密钥长度
 	jwtSecret := scm.viper.GetString("jwt.secret")
 	if len(jwtSecret) < 32 {
-		logger.Warn("JWT密钥长度建议至少32位，当前密钥安全性较低")
+		if logger.GetLogger() != nil {
+			logger.Warn("JWT密钥长度建议至少32位，当前密钥安全性较低")
+		} else {
+			log.Printf("JWT密钥长度建议至少32位，当前密钥安全性较低")
+		}
 	}
 
 	return nil
@@ -0,0 +1,84 @@
+// Package ctxutil gin 上下文中请求级数据的类型化键与读写函数
+// 认证、权限中间件通过 Set* 写入，处理器通过同名读取函数获取，避免字符串键拼写错误和不安全的类型断言
+package ctxutil
+
+import "github.com/gin-gonic/gin"
+
+// Key gin 上下文的键类型，与其他包使用的字符串键不会冲突
+type Key string
+
+const (
+	// KeyUserID 当前登录用户ID（uint）
+	KeyUserID Key = "user_id"
+	// KeyUsername 当前登录用户名（string）
+	KeyUsername Key = "username"
+	// KeyUserRole 当前登录用户角色（string），权限中间件按数据库中的最新角色覆盖
+	KeyUserRole Key = "user_role"
+	// KeySessionID 服务端会话ID（string），仅会话认证时设置
+	KeySessionID Key = "session_id"
+	// KeyRequestID 请求ID（string）
+	KeyRequestID Key = "request_id"
+)
+
+// SetUser 写入认证通过的用户信息
+func SetUser(c *gin.Context, userID uint, username, role string) {
+	c.Set(KeyUserID, userID)
+	c.Set(KeyUsername, username)
+	c.Set(KeyUserRole, role)
+}
+
+// UserID 当前登录用户ID，未登录时 ok 为 false
+func UserID(c *gin.Context) (id uint, ok bool) {
+	id, ok = value[uint](c, KeyUserID)
+	return id, ok && id != 0
+}
+
+// Username 当前登录用户名，未登录时为空
+func Username(c *gin.Context) string {
+	username, _ := value[string](c, KeyUsername)
+	return username
+}
+
+// SetUserRole 写入用户角色
+func SetUserRole(c *gin.Context, role string) {
+	c.Set(KeyUserRole, role)
+}
+
+// UserRole 当前登录用户角色，未登录时为空
+func UserRole(c *gin.Context) string {
+	role, _ := value[string](c, KeyUserRole)
+	return role
+}
+
+// SetSessionID 写入服务端会话ID
+func SetSessionID(c *gin.Context, sessionID string) {
+	c.Set(KeySessionID, sessionID)
+}
+
+// SessionID 服务端会话ID，未使用会话认证时为空
+func SessionID(c *gin.Context) string {
+	sessionID, _ := value[string](c, KeySessionID)
+	return sessionID
+}
+
+// SetRequestID 写入请求ID
+func SetRequestID(c *gin.Context, requestID string) {
+	c.Set(KeyRequestID, requestID)
+}
+
+// RequestID 请求ID，未经过 RequestID 中间件时为空
+func RequestID(c *gin.Context) string {
+	requestID, _ := value[string](c, KeyRequestID)
+	return requestID
+}
+
+// value 读取键对应的值，不存在或类型不符时返回零值和 false
+func value[T any](c *gin.Context, key Key) (T, bool) {
+	v, exists := c.Get(key)
+	if !exists {
+		var zero T
+		return zero, false
+	}
+	typed, ok := v.(T)
+	return typed, ok
+}
@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+
+	"gorm.io/gorm"
+
+	"github.com/VennLe/charlotte/internal/model"
+)
+
+// AuditDAO 审计日志数据访问对象
+type AuditDAO struct {
+	*BaseDAOImpl[model.AuditLog, uint]
+}
+
+// NewAuditDAO 创建审计日志DAO
+func NewAuditDAO(db *gorm.DB) *AuditDAO {
+	return &AuditDAO{
+		BaseDAOImpl: NewBaseDAO[model.AuditLog, uint](db),
+	}
+}
+
+// Record 写入审计日志，detail 序列化为 JSON；ctx 中有事务时随事务提交
+func (d *AuditDAO) Record(ctx context.Context, action string, operatorID uint, targetType string, targetID uint, detail interface{}) error {
+	data, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	return d.Create(ctx, &model.AuditLog{
+		Action:     action,
+		OperatorID: operatorID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Detail:     string(data),
+	})
+}
+
+// PermissionAuditDAO 权限决策日志数据访问对象
+type PermissionAuditDAO struct {
+	*BaseDAOImpl[model.PermissionAuditLog, uint]
+}
+
+// NewPermissionAuditDAO 创建权限决策日志DAO
+func NewPermissionAuditDAO(db *gorm.DB) *PermissionAuditDAO {
+	return &PermissionAuditDAO{
+		BaseDAOImpl: NewBaseDAO[model.PermissionAuditLog, uint](db),
+	}
+}
@@ -3,27 +3,61 @@ package dao
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/VennLe/charlotte/internal/model"
 )
 
 // Common errors
 var (
 	ErrRecordNotFound = errors.New("记录不存在")
 	ErrRecordExists   = errors.New("记录已存在")
+	// ErrFieldNotUpdatable 更新了不在允许列表中的字段
+	ErrFieldNotUpdatable = errors.New("字段不允许更新")
+	// ErrFieldNotGroupable 分组统计的字段不在允许列表中
+	ErrFieldNotGroupable = errors.New("字段不允许分组统计")
+	// ErrStatusNotSupported 模型没有状态列（未实现 model.StatusModel）
+	ErrStatusNotSupported = errors.New("模型不支持状态修改")
+)
+
+// DeletePolicy 删除策略
+type DeletePolicy int
+
+const (
+	// DeleteSoft 软删除（默认），模型包含 gorm.DeletedAt 字段时只标记删除时间
+	DeleteSoft DeletePolicy = iota
+	// DeleteHard 物理删除，适用于令牌、临时角色等无需保留历史的模型
+	DeleteHard
 )
 
 // QueryOptions 查询选项
 type QueryOptions struct {
-	Page     int                    // 页码
-	Size     int                    // 每页大小
-	Keyword  string                 // 关键词搜索
-	Filters  map[string]interface{} // 精确过滤条件
-	OrderBy  string                 // 排序字段
-	OrderDir string                 // 排序方向 (asc/desc)
-	Preloads []string              // 预加载关联
+	Page     int                       // 页码
+	Size     int                       // 每页大小
+	Keyword  string                    // 关键词搜索
+	Filters  map[string]interface{}    // 精确过滤条件
+	OrderBy  string                    // 排序字段
+	OrderDir string                    // 排序方向 (asc/desc)
+	Preloads []string                  // 预加载关联
+	Scopes   []func(*gorm.DB) *gorm.DB // 额外查询条件（如关键词搜索），由子类组装
+
+	IncludeDeleted bool // 是否包含软删除记录（审计视图）
+	OnlyDeleted    bool // 仅查询软删除记录（恢复视图），优先于 IncludeDeleted
+
+	// SkipCount 不统计总数，List 返回的 total 为 -1，用于无限滚动等不需要总数的场景
+	SkipCount bool
+	// ApproximateCount 使用表统计信息估算总数，避免大表上的 COUNT(*)
+	// 仅 PostgreSQL 且没有过滤条件、关键词和额外查询条件时生效（估算值包含软删除记录），其他情况仍精确统计
+	ApproximateCount bool
 }
 
+// MaxPageSize 分页查询每页最多返回的记录数，超出时按该值查询
+const MaxPageSize = 1000
+
 // BaseDAO 基础数据访问接口
 // T 为具体的模型类型，K为模型主键类型
 //go:generate mockgen -source=base.go -destination=mocks/base_mock.go -package=mocks
@@ -43,7 +77,7 @@ type BaseDAO[T any, K comparable] interface {
 	// GetMany 获取多条记录（带条件）
 	GetMany(ctx context.Context, conditions map[string]interface{}) ([]*T, error)
 
-	// List 分页列表查询
+	// List 分页列表查询，options.SkipCount 时返回的总数为 -1
 	List(ctx context.Context, options *QueryOptions) ([]*T, int64, error)
 
 	// Update 更新记录
@@ -52,7 +86,7 @@ type BaseDAO[T any, K comparable] interface {
 	// UpdateWhere 条件更新
 	UpdateWhere(ctx context.Context, conditions map[string]interface{}, updates map[string]interface{}) error
 
-	// Delete 删除记录（软删除）
+	// Delete 删除记录（按删除策略，默认软删除）
 	Delete(ctx context.Context, id K) error
 
 	// DeleteWhere 条件删除
@@ -67,6 +101,9 @@ type BaseDAO[T any, K comparable] interface {
 	// Count 统计记录数量
 	Count(ctx context.Context, conditions map[string]interface{}) (int64, error)
 
+	// CountBy 按字段分组统计记录数量
+	CountBy(ctx context.Context, groupColumn string, conditions map[string]interface{}) (map[string]int64, error)
+
 	// Exists 检查记录是否存在
 	Exists(ctx context.Context, conditions map[string]interface{}) (bool, error)
 
@@ -79,6 +116,13 @@ type BaseDAO[T any, K comparable] interface {
 //go:generate mockgen -source=base.go -destination=mocks/base_mock.go -package=mocks
 type BaseDAOImpl[T any, K comparable] struct {
 	DB *gorm.DB
+
+	// updatableFields Update 允许更新的字段（列名），为空时不限制
+	updatableFields map[string]bool
+	// groupableFields CountBy 允许分组的字段（列名），为空时不允许分组统计
+	groupableFields map[string]bool
+	// deletePolicy Delete/DeleteWhere 的删除策略
+	deletePolicy DeletePolicy
 }
 
 // NewBaseDAO 创建基础DAO实例
@@ -86,20 +130,95 @@ func NewBaseDAO[T any, K comparable](db *gorm.DB) *BaseDAOImpl[T, K] {
 	return &BaseDAOImpl[T, K]{DB: db}
 }
 
+// SetUpdatableFields 设置 Update 允许更新的字段（列名）
+// 设置后 Update 遇到列表外的字段会返回 ErrFieldNotUpdatable，防止通过通用更新接口修改角色等敏感字段
+func (d *BaseDAOImpl[T, K]) SetUpdatableFields(fields ...string) {
+	d.updatableFields = make(map[string]bool, len(fields))
+	for _, field := range fields {
+		d.updatableFields[strings.ToLower(field)] = true
+	}
+}
+
+// SetDeletePolicy 设置 Delete/DeleteWhere 的删除策略，HardDelete/HardDeleteWhere 不受影响
+func (d *BaseDAOImpl[T, K]) SetDeletePolicy(policy DeletePolicy) {
+	d.deletePolicy = policy
+}
+
+// deleteConn 按删除策略返回数据库连接
+func (d *BaseDAOImpl[T, K]) deleteConn(ctx context.Context) *gorm.DB {
+	if d.deletePolicy == DeleteHard {
+		return d.conn(ctx).Unscoped()
+	}
+	return d.conn(ctx)
+}
+
+// supportsPartialIndex 数据库是否支持部分索引（CREATE INDEX ... WHERE）
+// 软删除模型的唯一列应使用 where:deleted_at IS NULL 的部分唯一索引，MySQL 不支持时需在写入前自行处理冲突
+func supportsPartialIndex(db *gorm.DB) bool {
+	switch db.Dialector.Name() {
+	case "postgres", "sqlite":
+		return true
+	default:
+		return false
+	}
+}
+
+// SetGroupableFields 设置 CountBy 允许分组的字段（列名）
+// 分组列会直接拼入 SQL，因此必须显式列出
+func (d *BaseDAOImpl[T, K]) SetGroupableFields(fields ...string) {
+	d.groupableFields = make(map[string]bool, len(fields))
+	for _, field := range fields {
+		d.groupableFields[strings.ToLower(field)] = true
+	}
+}
+
+// checkUpdatableFields 检查更新字段是否都在允许列表中
+func (d *BaseDAOImpl[T, K]) checkUpdatableFields(updates map[string]interface{}) error {
+	if len(d.updatableFields) == 0 {
+		return nil
+	}
+	for field := range updates {
+		if !d.updatableFields[strings.ToLower(field)] {
+			return fmt.Errorf("%w: %s", ErrFieldNotUpdatable, field)
+		}
+	}
+	return nil
+}
+
+// conn 获取当前请求使用的数据库连接（优先使用 context 中的事务）
+func (d *BaseDAOImpl[T, K]) conn(ctx context.Context) *gorm.DB {
+	return dbFromContext(ctx, d.DB)
+}
+
 // Create 创建记录
 func (d *BaseDAOImpl[T, K]) Create(ctx context.Context, entity *T) error {
-	return d.DB.WithContext(ctx).Create(entity).Error
+	return d.conn(ctx).Create(entity).Error
 }
 
 // CreateBatch 批量创建记录
 func (d *BaseDAOImpl[T, K]) CreateBatch(ctx context.Context, entities []*T) error {
-	return d.DB.WithContext(ctx).CreateInBatches(entities, 100).Error
+	return d.conn(ctx).CreateInBatches(entities, 100).Error
+}
+
+// byPrimaryKey 按主键匹配的查询条件，主键列取自模型定义
+// 适用于数值、字符串和 UUID 等任意主键类型，字符串主键不会被 GORM 当作 SQL 片段
+func byPrimaryKey[K comparable](id K) clause.Expression {
+	return clause.Eq{Column: clause.PrimaryColumn, Value: id}
+}
+
+// byPrimaryKeys 主键 IN 查询条件
+func byPrimaryKeys[K comparable](ids []K) clause.Expression {
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	return clause.IN{Column: clause.PrimaryColumn, Values: values}
 }
 
 // GetByID 根据主键获取记录
 func (d *BaseDAOImpl[T, K]) GetByID(ctx context.Context, id K) (*T, error) {
 	var entity T
-	err := d.DB.WithContext(ctx).First(&entity, id).Error
+	err := d.conn(ctx).Where(byPrimaryKey(id)).First(&entity).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, ErrRecordNotFound
 	}
@@ -109,7 +228,7 @@ func (d *BaseDAOImpl[T, K]) GetByID(ctx context.Context, id K) (*T, error) {
 // GetOne 获取单条记录（带条件）
 func (d *BaseDAOImpl[T, K]) GetOne(ctx context.Context, conditions map[string]interface{}) (*T, error) {
 	var entity T
-	query := d.DB.WithContext(ctx).Model(&entity)
+	query := d.conn(ctx).Model(&entity)
 	
 	for field, value := range conditions {
 		query = query.Where(field, value)
@@ -125,7 +244,7 @@ func (d *BaseDAOImpl[T, K]) GetOne(ctx context.Context, conditions map[string]in
 // GetMany 获取多条记录（带条件）
 func (d *BaseDAOImpl[T, K]) GetMany(ctx context.Context, conditions map[string]interface{}) ([]*T, error) {
 	var entities []*T
-	query := d.DB.WithContext(ctx)
+	query := d.conn(ctx)
 	
 	for field, value := range conditions {
 		query = query.Where(field, value)
@@ -140,10 +259,13 @@ func (d *BaseDAOImpl[T, K]) List(ctx context.Context, options *QueryOptions) ([]
 	var entities []*T
 	var total int64
 
-	query := d.DB.WithContext(ctx).Model(new(T))
+	query := d.conn(ctx).Model(new(T))
 
 	// 应用过滤条件
 	if options != nil {
+		// 软删除记录范围
+		query = applyDeletedScope(query, options)
+
 		// 关键词搜索
 		if options.Keyword != "" {
 			// 具体实现由子类重写，这里提供框架
@@ -155,6 +277,7 @@ func (d *BaseDAOImpl[T, K]) List(ctx context.Context, options *QueryOptions) ([]
 				query = query.Where(field, value)
 			}
 		}
+		query = query.Scopes(options.Scopes...)
 
 		// 预加载关联
 		for _, preload := range options.Preloads {
@@ -162,13 +285,15 @@ func (d *BaseDAOImpl[T, K]) List(ctx context.Context, options *QueryOptions) ([]
 		}
 
 		// 统计总数
-		if err := query.Count(&total).Error; err != nil {
+		var err error
+		if total, err = d.countTotal(ctx, query, options); err != nil {
 			return nil, 0, err
 		}
 
 		// 分页
 		if options.Page > 0 && options.Size > 0 {
-			query = query.Offset((options.Page - 1) * options.Size).Limit(options.Size)
+			size := min(options.Size, MaxPageSize)
+			query = query.Offset((options.Page - 1) * size).Limit(size)
 		}
 
 		// 排序
@@ -185,9 +310,71 @@ func (d *BaseDAOImpl[T, K]) List(ctx context.Context, options *QueryOptions) ([]
 	return entities, total, err
 }
 
+// countTotal 按查询选项统计总数，SkipCount 时返回 -1
+func (d *BaseDAOImpl[T, K]) countTotal(ctx context.Context, query *gorm.DB, options *QueryOptions) (int64, error) {
+	if options.SkipCount {
+		return -1, nil
+	}
+	if options.ApproximateCount && len(options.Filters) == 0 && len(options.Scopes) == 0 && options.Keyword == "" && !options.OnlyDeleted {
+		if estimate, ok := d.estimateCount(ctx); ok {
+			return estimate, nil
+		}
+	}
+
+	var total int64
+	err := query.Count(&total).Error
+	return total, err
+}
+
+// estimateCount 从 PostgreSQL 表统计信息（pg_class.reltuples）读取估算行数
+// 非 PostgreSQL、查询失败或表尚未 ANALYZE（估算值不大于 0）时返回 false，由调用方精确统计
+func (d *BaseDAOImpl[T, K]) estimateCount(ctx context.Context) (int64, bool) {
+	db := d.conn(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return 0, false
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return 0, false
+	}
+
+	var estimate float64
+	err := db.Raw("SELECT reltuples FROM pg_class WHERE oid = to_regclass(?)", stmt.Schema.Table).Scan(&estimate).Error
+	if err != nil || estimate <= 0 {
+		return 0, false
+	}
+	return int64(estimate), true
+}
+
+// applyDeletedScope 根据查询选项处理软删除记录
+// 未启用软删除（无 deleted_at 字段）的模型不受 OnlyDeleted 影响
+func applyDeletedScope(query *gorm.DB, options *QueryOptions) *gorm.DB {
+	switch {
+	case options.OnlyDeleted:
+		query = query.Unscoped()
+		if err := query.Statement.Parse(query.Statement.Model); err == nil {
+			if field := query.Statement.Schema.LookUpField("DeletedAt"); field != nil {
+				query = query.Where(field.DBName + " IS NOT NULL")
+			}
+		}
+	case options.IncludeDeleted:
+		query = query.Unscoped()
+	}
+	return query
+}
+
 // Update 更新记录
+// 设置了允许更新字段时，只能更新列表中的字段
 func (d *BaseDAOImpl[T, K]) Update(ctx context.Context, id K, updates map[string]interface{}) error {
-	result := d.DB.WithContext(ctx).Model(new(T)).Where("id = ?", id).Updates(updates)
+	if err := d.checkUpdatableFields(updates); err != nil {
+		return err
+	}
+	return d.updateColumns(ctx, id, updates)
+}
+
+// updateColumns 更新记录，不检查允许更新字段（供DAO内部的专用更新方法使用）
+func (d *BaseDAOImpl[T, K]) updateColumns(ctx context.Context, id K, updates map[string]interface{}) error {
+	result := d.conn(ctx).Model(new(T)).Where(byPrimaryKey(id)).Updates(updates)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -199,7 +386,7 @@ func (d *BaseDAOImpl[T, K]) Update(ctx context.Context, id K, updates map[string
 
 // UpdateWhere 条件更新
 func (d *BaseDAOImpl[T, K]) UpdateWhere(ctx context.Context, conditions map[string]interface{}, updates map[string]interface{}) error {
-	query := d.DB.WithContext(ctx).Model(new(T))
+	query := d.conn(ctx).Model(new(T))
 	
 	for field, value := range conditions {
 		query = query.Where(field, value)
@@ -208,9 +395,41 @@ func (d *BaseDAOImpl[T, K]) UpdateWhere(ctx context.Context, conditions map[stri
 	return query.Updates(updates).Error
 }
 
-// Delete 删除记录（软删除）
+// statusBatchSize SetStatusBatch 单条 UPDATE 的最大主键数，避免超出数据库的参数个数限制
+const statusBatchSize = 500
+
+// SetStatusBatch 批量修改状态，每 statusBatchSize 个主键一条 UPDATE ... WHERE id IN (...)，返回受影响的行数
+// 模型需实现 model.StatusModel，否则返回 ErrStatusNotSupported；分多批执行时在同一事务中完成
+func (d *BaseDAOImpl[T, K]) SetStatusBatch(ctx context.Context, ids []K, status model.Status) (int64, error) {
+	sm, ok := any(new(T)).(model.StatusModel)
+	if !ok {
+		return 0, ErrStatusNotSupported
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var affected int64
+	err := RunInTransaction(ctx, d.DB, func(ctx context.Context) error {
+		for start := 0; start < len(ids); start += statusBatchSize {
+			end := min(start+statusBatchSize, len(ids))
+			result := d.conn(ctx).Model(new(T)).Where(byPrimaryKeys(ids[start:end])).Update(sm.StatusColumn(), status)
+			if result.Error != nil {
+				return result.Error
+			}
+			affected += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// Delete 删除记录，默认软删除，DeleteHard 策略下物理删除
 func (d *BaseDAOImpl[T, K]) Delete(ctx context.Context, id K) error {
-	result := d.DB.WithContext(ctx).Delete(new(T), id)
+	result := d.deleteConn(ctx).Where(byPrimaryKey(id)).Delete(new(T))
 	if result.Error != nil {
 		return result.Error
 	}
@@ -222,7 +441,7 @@ func (d *BaseDAOImpl[T, K]) Delete(ctx context.Context, id K) error {
 
 // DeleteWhere 条件删除
 func (d *BaseDAOImpl[T, K]) DeleteWhere(ctx context.Context, conditions map[string]interface{}) error {
-	query := d.DB.WithContext(ctx).Model(new(T))
+	query := d.deleteConn(ctx).Model(new(T))
 	
 	for field, value := range conditions {
 		query = query.Where(field, value)
@@ -233,7 +452,7 @@ func (d *BaseDAOImpl[T, K]) DeleteWhere(ctx context.Context, conditions map[stri
 
 // HardDelete 硬删除
 func (d *BaseDAOImpl[T, K]) HardDelete(ctx context.Context, id K) error {
-	result := d.DB.WithContext(ctx).Unscoped().Delete(new(T), id)
+	result := d.conn(ctx).Unscoped().Where(byPrimaryKey(id)).Delete(new(T))
 	if result.Error != nil {
 		return result.Error
 	}
@@ -245,7 +464,7 @@ func (d *BaseDAOImpl[T, K]) HardDelete(ctx context.Context, id K) error {
 
 // HardDeleteWhere 条件硬删除
 func (d *BaseDAOImpl[T, K]) HardDeleteWhere(ctx context.Context, conditions map[string]interface{}) error {
-	query := d.DB.WithContext(ctx).Unscoped().Model(new(T))
+	query := d.conn(ctx).Unscoped().Model(new(T))
 	
 	for field, value := range conditions {
 		query = query.Where(field, value)
@@ -257,7 +476,7 @@ func (d *BaseDAOImpl[T, K]) HardDeleteWhere(ctx context.Context, conditions map[
 // Count 统计记录数量
 func (d *BaseDAOImpl[T, K]) Count(ctx context.Context, conditions map[string]interface{}) (int64, error) {
 	var count int64
-	query := d.DB.WithContext(ctx).Model(new(T))
+	query := d.conn(ctx).Model(new(T))
 	
 	for field, value := range conditions {
 		query = query.Where(field, value)
@@ -267,6 +486,41 @@ func (d *BaseDAOImpl[T, K]) Count(ctx context.Context, conditions map[string]int
 	return count, err
 }
 
+// CountBy 按字段分组统计记录数量，返回 字段值 -> 数量，单条 GROUP BY 查询完成
+// groupColumn 须通过 SetGroupableFields 登记；字段值为 NULL 时键为空字符串
+func (d *BaseDAOImpl[T, K]) CountBy(ctx context.Context, groupColumn string, conditions map[string]interface{}) (map[string]int64, error) {
+	column := strings.ToLower(groupColumn)
+	if !d.groupableFields[column] {
+		return nil, fmt.Errorf("%w: %s", ErrFieldNotGroupable, groupColumn)
+	}
+
+	query := d.conn(ctx).Model(new(T))
+	for field, value := range conditions {
+		query = query.Where(field, value)
+	}
+
+	var rows []struct {
+		Value *string
+		Count int64
+	}
+	err := query.Select(column + " AS value, COUNT(*) AS count").
+		Group(column).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		key := ""
+		if row.Value != nil {
+			key = *row.Value
+		}
+		counts[key] += row.Count
+	}
+	return counts, nil
+}
+
 // Exists 检查记录是否存在
 func (d *BaseDAOImpl[T, K]) Exists(ctx context.Context, conditions map[string]interface{}) (bool, error) {
 	count, err := d.Count(ctx, conditions)
@@ -278,8 +532,15 @@ func (d *BaseDAOImpl[T, K]) Exists(ctx context.Context, conditions map[string]in
 
 // Transaction 执行事务操作
 func (d *BaseDAOImpl[T, K]) Transaction(ctx context.Context, fn func(txDAO BaseDAO[T, K]) error) error {
-	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		txDAO := NewBaseDAO[T, K](tx)
+	// 复用 context 中已有的事务，保证与其他DAO的操作处于同一事务
+	return RunInTransaction(ctx, d.DB, func(txCtx context.Context) error {
+		tx, _ := TxFromContext(txCtx)
+		txDAO := &BaseDAOImpl[T, K]{
+			DB:              tx,
+			updatableFields: d.updatableFields,
+			groupableFields: d.groupableFields,
+			deletePolicy:    d.deletePolicy,
+		}
 		return fn(txDAO)
 	})
 }
\ No newline at end of file
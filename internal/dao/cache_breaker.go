@@ -0,0 +1,208 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VennLe/charlotte/pkg/metrics"
+)
+
+// 熔断器指标，按模型区分，同一模型的DAO实例共享
+var (
+	breakerTrips = metrics.NewCounterVec("charlotte_dao_cache_breaker_trips_total",
+		"缓存熔断器进入熔断状态的次数", "model")
+	breakerState = metrics.NewGaugeVec("charlotte_dao_cache_breaker_state",
+		"缓存熔断器当前状态：0 正常，1 熔断，2 半开", "model")
+)
+
+// 熔断器默认参数
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// BreakerState 熔断器状态
+type BreakerState int32
+
+const (
+	BreakerClosed   BreakerState = iota // 正常访问缓存
+	BreakerOpen                         // 缓存不可用，直接访问数据库
+	BreakerHalfOpen                     // 冷却结束，放行一个探测请求
+)
+
+// String 状态名称
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker 缓存熔断器
+// 连续失败达到阈值后熔断，冷却期内所有缓存操作直接跳过；冷却结束后放行一个探测请求，
+// 成功则恢复，失败则重新计时。go-redis 自身会在后台重连，探测成功即视为恢复
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	// 统计指标
+	trips    atomic.Int64 // 熔断次数
+	bypassed atomic.Int64 // 熔断期间跳过的缓存操作次数
+	errors   atomic.Int64 // 缓存操作失败次数
+
+	// 导出到 metrics 的指标，未调用 Instrument 时为 nil
+	tripsMetric *metrics.Counter
+	stateMetric *metrics.Gauge
+
+	// OnStateChange 状态变化回调（可选），在持有锁之外调用
+	OnStateChange func(from, to BreakerState, err error)
+}
+
+// NewCircuitBreaker 创建熔断器，threshold/cooldown 为 0 时使用默认值（5次、30秒）
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Instrument 将熔断次数和当前状态导出为 metrics 指标，name 为指标的 model 标签，应在使用前调用
+func (b *CircuitBreaker) Instrument(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tripsMetric = breakerTrips.WithLabelValues(name)
+	b.stateMetric = breakerState.WithLabelValues(name)
+	b.stateMetric.Set(int64(b.state))
+}
+
+// setState 切换状态并同步状态指标，须持有锁
+func (b *CircuitBreaker) setState(state BreakerState) {
+	b.state = state
+	if b.stateMetric != nil {
+		b.stateMetric.Set(int64(state))
+	}
+}
+
+// Allow 判断当前是否允许访问缓存
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	var changed bool
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			b.bypassed.Add(1)
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		changed = true
+	case BreakerHalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			b.bypassed.Add(1)
+			return false
+		}
+		b.probing = true
+	}
+	b.mu.Unlock()
+
+	if changed {
+		b.notify(BreakerOpen, BreakerHalfOpen, nil)
+	}
+	return true
+}
+
+// Record 记录一次缓存操作结果，redis.Nil 和调用方取消不计为失败
+func (b *CircuitBreaker) Record(err error) {
+	if !isCacheFailure(err) {
+		b.onSuccess()
+		return
+	}
+	b.errors.Add(1)
+	b.onFailure(err)
+}
+
+func (b *CircuitBreaker) onSuccess() {
+	b.mu.Lock()
+	from := b.state
+	b.setState(BreakerClosed)
+	b.failures = 0
+	b.probing = false
+	b.mu.Unlock()
+
+	if from != BreakerClosed {
+		b.notify(from, BreakerClosed, nil)
+	}
+}
+
+func (b *CircuitBreaker) onFailure(err error) {
+	b.mu.Lock()
+	from := b.state
+	b.failures++
+	b.probing = false
+	if from == BreakerHalfOpen || (from == BreakerClosed && b.failures >= b.threshold) {
+		b.setState(BreakerOpen)
+		b.openedAt = time.Now()
+	}
+	to := b.state
+	tripsMetric := b.tripsMetric
+	b.mu.Unlock()
+
+	if from != to {
+		b.trips.Add(1)
+		if tripsMetric != nil {
+			tripsMetric.Inc()
+		}
+		b.notify(from, to, err)
+	}
+}
+
+func (b *CircuitBreaker) notify(from, to BreakerState, err error) {
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, to, err)
+	}
+}
+
+// State 当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Stats 熔断器统计信息
+func (b *CircuitBreaker) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"state":    b.State().String(),
+		"trips":    b.trips.Load(),
+		"bypassed": b.bypassed.Load(),
+		"errors":   b.errors.Load(),
+	}
+}
+
+// isCacheFailure 判断错误是否表示缓存不可用
+func isCacheFailure(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package dao
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// CacheCodec 缓存序列化编解码器
+type CacheCodec interface {
+	// Name 编解码器名称
+	Name() string
+	// Marshal 序列化
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal 反序列化
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// 编解码器名称
+const (
+	CacheCodecJSON    = "json"
+	CacheCodecMsgpack = "msgpack"
+	CacheCodecGob     = "gob"
+)
+
+var (
+	// JSONCacheCodec JSON编解码（默认），可读性好，体积较大
+	JSONCacheCodec CacheCodec = jsonCacheCodec{}
+	// MsgpackCacheCodec MessagePack编解码，体积小、速度快，沿用 json 标签（json:"-" 的字段同样不缓存）
+	MsgpackCacheCodec CacheCodec = newMsgpackCacheCodec()
+	// GobCacheCodec Gob编解码，保留Go类型信息，但会忽略 json 标签（如 json:"-" 的密码字段也会被缓存）
+	GobCacheCodec CacheCodec = gobCacheCodec{}
+)
+
+// GetCacheCodec 根据名称获取编解码器，名称为空时返回JSON编解码器
+func GetCacheCodec(name string) (CacheCodec, error) {
+	switch strings.ToLower(name) {
+	case "", CacheCodecJSON:
+		return JSONCacheCodec, nil
+	case CacheCodecMsgpack:
+		return MsgpackCacheCodec, nil
+	case CacheCodecGob:
+		return GobCacheCodec, nil
+	default:
+		return nil, fmt.Errorf("不支持的缓存编解码器: %s", name)
+	}
+}
+
+// CompareCacheCodecSizes 计算同一对象在各编解码器下序列化后的字节数，用于评估切换编解码器的收益
+// 序列化失败的编解码器结果为 -1
+func CompareCacheCodecSizes(v interface{}) map[string]int {
+	sizes := make(map[string]int, 3)
+	for _, c := range []CacheCodec{JSONCacheCodec, MsgpackCacheCodec, GobCacheCodec} {
+		data, err := c.Marshal(v)
+		if err != nil {
+			sizes[c.Name()] = -1
+			continue
+		}
+		sizes[c.Name()] = len(data)
+	}
+	return sizes
+}
+
+type jsonCacheCodec struct{}
+
+func (jsonCacheCodec) Name() string { return CacheCodecJSON }
+
+func (jsonCacheCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCacheCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCacheCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpackCacheCodec() msgpackCacheCodec {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true    // 使用扩展类型编码 time.Time，保证时间精度
+	h.RawToString = true // 字符串解码为 string 而非 []byte
+	return msgpackCacheCodec{handle: h}
+}
+
+func (msgpackCacheCodec) Name() string { return CacheCodecMsgpack }
+
+func (c msgpackCacheCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, c.handle).Encode(v); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (c msgpackCacheCodec) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
+
+type gobCacheCodec struct{}
+
+func (gobCacheCodec) Name() string { return CacheCodecGob }
+
+func (gobCacheCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCacheCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
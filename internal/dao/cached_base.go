@@ -2,18 +2,30 @@ package dao
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/VennLe/charlotte/internal/model"
 	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/metrics"
+	"github.com/VennLe/charlotte/pkg/tracing"
 )
 
+// cacheLookups 按模型统计缓存查询的命中（hit）与未命中（miss）次数
+// 空值标记命中也算命中；熔断或 Redis 读取失败时按未命中统计，因为请求实际落到了数据库
+var cacheLookups = metrics.NewCounterVec("charlotte_dao_cache_lookups_total",
+	"带缓存DAO按ID或条件查询时的缓存命中与未命中次数", "model", "result")
+
 // CacheConfig 缓存配置
 type CacheConfig struct {
 	Enabled    bool          // 是否启用缓存
@@ -21,6 +33,12 @@ type CacheConfig struct {
 	Prefix     string        // 缓存键前缀
 	NullTTL    time.Duration // 空值缓存时间（防穿透）
 	MaxSize    int           // 最大缓存条目数
+	Codec      CacheCodec    // 序列化编解码器，nil 时使用JSON
+
+	// 熔断配置：Redis 连续失败 BreakerThreshold 次后在 BreakerCooldown 内跳过缓存直接访问数据库，
+	// 为 0 时使用默认值（5次、30秒）
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
 }
 
 // CachedBaseDAO 带缓存的基础数据访问对象
@@ -30,6 +48,19 @@ type CachedBaseDAO[T any, K comparable] struct {
 	redisClient *redis.Client
 	cacheConfig *CacheConfig
 	modelName   string
+	codec       CacheCodec
+	breaker     *CircuitBreaker
+
+	// staleCache 熔断期间或删除失败时跳过了缓存失效，恢复后需先清空该模型的缓存，避免读到旧数据
+	staleCache atomic.Bool
+
+	// 序列化统计，用于评估编解码器的体积
+	encodedBytes   atomic.Int64
+	encodedEntries atomic.Int64
+
+	// 命中统计，同一模型的DAO实例共享计数
+	hits   *metrics.Counter
+	misses *metrics.Counter
 }
 
 // NewCachedBaseDAO 创建带缓存的DAO实例
@@ -44,11 +75,36 @@ func NewCachedBaseDAO[T any, K comparable](db *gorm.DB, redisClient *redis.Clien
 		}
 	}
 
-	return &CachedBaseDAO[T, K]{
+	codec := config.Codec
+	if codec == nil {
+		codec = JSONCacheCodec
+	}
+
+	d := &CachedBaseDAO[T, K]{
 		BaseDAOImpl: NewBaseDAO[T, K](db),
 		redisClient: redisClient,
 		cacheConfig: config,
 		modelName:   modelName,
+		codec:       codec,
+		breaker:     NewCircuitBreaker(config.BreakerThreshold, config.BreakerCooldown),
+		hits:        cacheLookups.WithLabelValues(modelName, "hit"),
+		misses:      cacheLookups.WithLabelValues(modelName, "miss"),
+	}
+	d.breaker.OnStateChange = d.onBreakerStateChange
+	d.breaker.Instrument(modelName)
+	return d
+}
+
+// onBreakerStateChange 记录熔断状态变化
+func (d *CachedBaseDAO[T, K]) onBreakerStateChange(from, to BreakerState, err error) {
+	switch to {
+	case BreakerOpen:
+		logger.Warn("Redis 缓存不可用，已熔断，直接访问数据库",
+			zap.String("model", d.modelName),
+			zap.Duration("cooldown", d.breaker.cooldown),
+			zap.Error(err))
+	case BreakerClosed:
+		logger.Info("Redis 缓存已恢复", zap.String("model", d.modelName), zap.String("from", from.String()))
 	}
 }
 
@@ -59,39 +115,41 @@ func (d *CachedBaseDAO[T, K]) GetByIDWithCache(ctx context.Context, id K) (*T, e
 	}
 
 	// 生成缓存键
-	cacheKey := d.generateCacheKey("id", fmt.Sprintf("%v", id))
+	cacheKey := d.idCacheKey(id)
 
 	// 尝试从缓存获取
-	cachedData, err := d.redisClient.Get(ctx, cacheKey).Result()
-	if err == nil {
+	if cachedData, ok := d.cacheGet(ctx, cacheKey); ok {
 		// 检查是否是空值标记
 		if cachedData == "__NULL__" {
+			d.hits.Inc()
 			return nil, ErrRecordNotFound
 		}
 
 		// 反序列化缓存数据
 		var entity T
-		if err := json.Unmarshal([]byte(cachedData), &entity); err == nil {
+		if err := d.decode(cachedData, &entity); err == nil {
+			d.hits.Inc()
 			logger.Debug("缓存命中", zap.String("key", cacheKey), zap.String("model", d.modelName))
 			return &entity, nil
 		}
 	}
 
 	// 缓存未命中，从数据库获取
+	d.misses.Inc()
 	entity, err := d.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, ErrRecordNotFound) {
 			// 缓存空值，防止缓存穿透
-			d.redisClient.Set(ctx, cacheKey, "__NULL__", d.cacheConfig.NullTTL)
+			d.cacheSet(ctx, cacheKey, "__NULL__", d.cacheConfig.NullTTL)
 			logger.Debug("缓存空值", zap.String("key", cacheKey), zap.String("model", d.modelName))
 		}
 		return nil, err
 	}
 
 	// 序列化并缓存数据
-	data, err := json.Marshal(entity)
+	data, err := d.encode(entity)
 	if err == nil {
-		d.redisClient.Set(ctx, cacheKey, string(data), d.cacheConfig.TTL)
+		d.cacheSet(ctx, cacheKey, data, d.cacheConfig.TTL)
 		logger.Debug("缓存写入", zap.String("key", cacheKey), zap.String("model", d.modelName))
 	}
 
@@ -108,32 +166,34 @@ func (d *CachedBaseDAO[T, K]) GetOneWithCache(ctx context.Context, conditions ma
 	cacheKey := d.generateConditionKey(conditions)
 
 	// 尝试从缓存获取
-	cachedData, err := d.redisClient.Get(ctx, cacheKey).Result()
-	if err == nil {
+	if cachedData, ok := d.cacheGet(ctx, cacheKey); ok {
 		if cachedData == "__NULL__" {
+			d.hits.Inc()
 			return nil, ErrRecordNotFound
 		}
 
 		var entity T
-		if err := json.Unmarshal([]byte(cachedData), &entity); err == nil {
+		if err := d.decode(cachedData, &entity); err == nil {
+			d.hits.Inc()
 			logger.Debug("条件缓存命中", zap.String("key", cacheKey), zap.String("model", d.modelName))
 			return &entity, nil
 		}
 	}
 
 	// 从数据库获取
+	d.misses.Inc()
 	entity, err := d.GetOne(ctx, conditions)
 	if err != nil {
 		if errors.Is(err, ErrRecordNotFound) {
-			d.redisClient.Set(ctx, cacheKey, "__NULL__", d.cacheConfig.NullTTL)
+			d.cacheSet(ctx, cacheKey, "__NULL__", d.cacheConfig.NullTTL)
 		}
 		return nil, err
 	}
 
 	// 缓存数据
-	data, err := json.Marshal(entity)
+	data, err := d.encode(entity)
 	if err == nil {
-		d.redisClient.Set(ctx, cacheKey, string(data), d.cacheConfig.TTL)
+		d.cacheSet(ctx, cacheKey, data, d.cacheConfig.TTL)
 	}
 
 	return entity, nil
@@ -154,6 +214,26 @@ func (d *CachedBaseDAO[T, K]) UpdateWithCache(ctx context.Context, id K, updates
 	return nil
 }
 
+// SetStatusBatchWithCache 带缓存的批量状态修改，成功后清除受影响记录的缓存
+func (d *CachedBaseDAO[T, K]) SetStatusBatchWithCache(ctx context.Context, ids []K, status model.Status) (int64, error) {
+	affected, err := d.SetStatusBatch(ctx, ids, status)
+	if err != nil {
+		return 0, err
+	}
+
+	if d.cacheConfig.Enabled && len(ids) > 0 {
+		keys := make([]string, 0, len(ids))
+		for _, id := range ids {
+			keys = append(keys, d.idCacheKey(id))
+		}
+		d.cacheDel(ctx, keys...)
+		d.invalidateListCache(ctx)
+		d.invalidateConditionCache(ctx)
+	}
+
+	return affected, nil
+}
+
 // DeleteWithCache 带缓存的删除操作
 func (d *CachedBaseDAO[T, K]) DeleteWithCache(ctx context.Context, id K) error {
 	err := d.Delete(ctx, id)
@@ -195,21 +275,23 @@ func (d *CachedBaseDAO[T, K]) BatchGetWithCache(ctx context.Context, ids []K) (m
 
 	// 批量从缓存获取
 	for _, id := range ids {
-		cacheKey := d.generateCacheKey("id", fmt.Sprintf("%v", id))
-		cachedData, err := d.redisClient.Get(ctx, cacheKey).Result()
-		if err == nil {
+		cacheKey := d.idCacheKey(id)
+		if cachedData, ok := d.cacheGet(ctx, cacheKey); ok {
 			if cachedData == "__NULL__" {
+				d.hits.Inc()
 				continue
 			}
 
 			var entity T
-			if err := json.Unmarshal([]byte(cachedData), &entity); err == nil {
+			if err := d.decode(cachedData, &entity); err == nil {
+				d.hits.Inc()
 				result[id] = &entity
 				continue
 			}
 		}
 		missingIDs = append(missingIDs, id)
 	}
+	d.misses.Add(uint64(len(missingIDs)))
 
 	// 从数据库获取缺失的数据
 	if len(missingIDs) > 0 {
@@ -235,6 +317,11 @@ func (d *CachedBaseDAO[T, K]) BatchGetWithCache(ctx context.Context, ids []K) (m
 	return result, nil
 }
 
+// idCacheKey 主键缓存键，主键按 %v 格式化，实现 fmt.Stringer 的类型（如 uuid.UUID）使用其字符串形式
+func (d *CachedBaseDAO[T, K]) idCacheKey(id K) string {
+	return d.generateCacheKey("id", fmt.Sprintf("%v", id))
+}
+
 // generateCacheKey 生成缓存键
 func (d *CachedBaseDAO[T, K]) generateCacheKey(keyType string, value string) string {
 	return fmt.Sprintf("%s:%s:%s:%s", d.cacheConfig.Prefix, d.modelName, keyType, value)
@@ -252,57 +339,182 @@ func (d *CachedBaseDAO[T, K]) generateConditionKey(conditions map[string]interfa
 // invalidateCache 使缓存失效
 func (d *CachedBaseDAO[T, K]) invalidateCache(ctx context.Context, id K) {
 	// 清除ID缓存
-	cacheKey := d.generateCacheKey("id", fmt.Sprintf("%v", id))
-	d.redisClient.Del(ctx, cacheKey)
+	cacheKey := d.idCacheKey(id)
+	d.cacheDel(ctx, cacheKey)
 
 	// 清除列表缓存（如果有）
 	d.invalidateListCache(ctx)
 }
 
+// InvalidateByID 使指定主键的缓存失效（供跨实例缓存同步等外部调用）
+func (d *CachedBaseDAO[T, K]) InvalidateByID(ctx context.Context, id K) {
+	d.invalidateCache(ctx, id)
+	d.invalidateConditionCache(ctx)
+}
+
+// invalidateConditionCache 使条件缓存失效
+// 条件缓存无法按主键定位，记录变更后统一清除
+func (d *CachedBaseDAO[T, K]) invalidateConditionCache(ctx context.Context) {
+	d.cacheDelPattern(ctx, fmt.Sprintf("%s:%s:condition*", d.cacheConfig.Prefix, d.modelName))
+}
+
 // invalidateListCache 使列表缓存失效
 func (d *CachedBaseDAO[T, K]) invalidateListCache(ctx context.Context) {
-	pattern := fmt.Sprintf("%s:%s:list:*", d.cacheConfig.Prefix, d.modelName)
-	keys, err := d.redisClient.Keys(ctx, pattern).Result()
-	if err == nil && len(keys) > 0 {
-		d.redisClient.Del(ctx, keys...)
-	}
+	d.cacheDelPattern(ctx, fmt.Sprintf("%s:%s:list:*", d.cacheConfig.Prefix, d.modelName))
 }
 
 // cacheEntity 缓存实体
 func (d *CachedBaseDAO[T, K]) cacheEntity(ctx context.Context, id K, entity *T) {
-	cacheKey := d.generateCacheKey("id", fmt.Sprintf("%v", id))
-	data, err := json.Marshal(entity)
+	cacheKey := d.idCacheKey(id)
+	data, err := d.encode(entity)
 	if err == nil {
-		d.redisClient.Set(ctx, cacheKey, string(data), d.cacheConfig.TTL)
+		d.cacheSet(ctx, cacheKey, data, d.cacheConfig.TTL)
 	}
 }
 
+// encode 使用配置的编解码器序列化实体，并记录序列化后的大小
+func (d *CachedBaseDAO[T, K]) encode(entity *T) ([]byte, error) {
+	data, err := d.codec.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	d.encodedBytes.Add(int64(len(data)))
+	d.encodedEntries.Add(1)
+	return data, nil
+}
+
+// decode 使用配置的编解码器反序列化实体
+// 切换编解码器后旧格式的缓存会解码失败，按缓存未命中处理并被新数据覆盖
+func (d *CachedBaseDAO[T, K]) decode(data string, entity *T) error {
+	return d.codec.Unmarshal([]byte(data), entity)
+}
+
+// CompareCodecSizes 计算实体在各编解码器下的序列化大小
+func (d *CachedBaseDAO[T, K]) CompareCodecSizes(entity *T) map[string]int {
+	return CompareCacheCodecSizes(entity)
+}
+
 // cacheNullValue 缓存空值
 func (d *CachedBaseDAO[T, K]) cacheNullValue(ctx context.Context, id K) {
-	cacheKey := d.generateCacheKey("id", fmt.Sprintf("%v", id))
-	d.redisClient.Set(ctx, cacheKey, "__NULL__", d.cacheConfig.NullTTL)
+	cacheKey := d.idCacheKey(id)
+	d.cacheSet(ctx, cacheKey, "__NULL__", d.cacheConfig.NullTTL)
 }
 
-// batchGetFromDB 从数据库批量获取
+// cacheAvailable 判断是否可以访问缓存
+// 熔断期间返回 false；恢复后若存在被跳过的缓存失效，先清空该模型的缓存再放行
+func (d *CachedBaseDAO[T, K]) cacheAvailable(ctx context.Context) bool {
+	if d.redisClient == nil || !d.breaker.Allow() {
+		return false
+	}
+	if d.staleCache.Load() {
+		deleted, err := deleteKeysByPattern(ctx, d.redisClient, fmt.Sprintf("%s:%s:*", d.cacheConfig.Prefix, d.modelName))
+		d.breaker.Record(err)
+		if err != nil {
+			return false
+		}
+		d.staleCache.Store(false)
+		logger.Info("已清空熔断期间可能过期的缓存", zap.String("model", d.modelName), zap.Int64("deleted", deleted))
+	}
+	return true
+}
+
+// cacheGet 读取缓存，熔断或读取失败时按未命中处理
+func (d *CachedBaseDAO[T, K]) cacheGet(ctx context.Context, key string) (string, bool) {
+	ctx, span := d.startCacheSpan(ctx, "cache.get", key)
+	defer span.End()
+
+	if !d.cacheAvailable(ctx) {
+		span.SetAttributes(attribute.Bool("cache.bypassed", true), attribute.Bool("cache.hit", false))
+		return "", false
+	}
+	data, err := d.redisClient.Get(ctx, key).Result()
+	d.breaker.Record(err)
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+	if isCacheFailure(err) {
+		tracing.RecordError(span, err)
+	}
+	return data, err == nil
+}
+
+// cacheSet 写入缓存，熔断时跳过
+func (d *CachedBaseDAO[T, K]) cacheSet(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	ctx, span := d.startCacheSpan(ctx, "cache.set", key)
+	defer span.End()
+
+	if !d.cacheAvailable(ctx) {
+		span.SetAttributes(attribute.Bool("cache.bypassed", true))
+		return
+	}
+	err := d.redisClient.Set(ctx, key, value, ttl).Err()
+	d.breaker.Record(err)
+	tracing.RecordError(span, err)
+}
+
+// startCacheSpan 创建缓存操作 span，记录缓存键、模型和熔断状态
+func (d *CachedBaseDAO[T, K]) startCacheSpan(ctx context.Context, name, key string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		attribute.String("db.system", "redis"),
+		attribute.String("cache.key", key),
+		attribute.String("cache.model", d.modelName),
+		attribute.String("cache.breaker", d.breaker.State().String()),
+	)
+}
+
+// cacheDel 删除缓存，熔断或删除失败时标记缓存待清空
+func (d *CachedBaseDAO[T, K]) cacheDel(ctx context.Context, keys ...string) {
+	if !d.cacheAvailable(ctx) {
+		d.staleCache.Store(true)
+		return
+	}
+	err := d.redisClient.Del(ctx, keys...).Err()
+	d.breaker.Record(err)
+	if isCacheFailure(err) {
+		d.staleCache.Store(true)
+	}
+}
+
+// cacheDelPattern 按模式删除缓存，熔断或删除失败时标记缓存待清空
+func (d *CachedBaseDAO[T, K]) cacheDelPattern(ctx context.Context, pattern string) {
+	if !d.cacheAvailable(ctx) {
+		d.staleCache.Store(true)
+		return
+	}
+	_, err := deleteKeysByPattern(ctx, d.redisClient, pattern)
+	d.breaker.Record(err)
+	if isCacheFailure(err) {
+		d.staleCache.Store(true)
+	}
+}
+
+// batchGetFromDB 从数据库批量获取，结果按主键索引
 func (d *CachedBaseDAO[T, K]) batchGetFromDB(ctx context.Context, ids []K) (map[K]*T, error) {
-	var entities []*T
-	
-	// 构建IN查询条件
-	idStrings := make([]interface{}, len(ids))
-	for i, id := range ids {
-		idStrings[i] = id
+	result := make(map[K]*T, len(ids))
+	if len(ids) == 0 {
+		return result, nil
 	}
 
-	err := d.DB.WithContext(ctx).Where("id IN (?)", idStrings).Find(&entities).Error
-	if err != nil {
+	db := d.conn(ctx)
+	var entities []*T
+	if err := db.Where(byPrimaryKeys(ids)).Find(&entities).Error; err != nil {
 		return nil, err
 	}
 
-	result := make(map[K]*T)
+	// 从模型定义中读取主键值
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return nil, fmt.Errorf("模型 %s 没有主键", stmt.Schema.Name)
+	}
 	for _, entity := range entities {
-		// 使用反射获取ID字段，这里需要具体模型实现GetID方法
-		// 简化处理，实际使用时需要根据具体模型调整
-		result[ids[0]] = entity // 简化示例
+		value, _ := pk.ValueOf(ctx, reflect.ValueOf(entity).Elem())
+		id, ok := value.(K)
+		if !ok {
+			return nil, fmt.Errorf("模型 %s 的主键类型 %T 与DAO的主键类型不一致", stmt.Schema.Name, value)
+		}
+		result[id] = entity
 	}
 
 	return result, nil
@@ -333,9 +545,14 @@ func (d *CachedBaseDAO[T, K]) GetCacheStats(ctx context.Context) map[string]inte
 		}
 	}
 
-	pattern := fmt.Sprintf("%s:%s:*", d.cacheConfig.Prefix, d.modelName)
-	keys, err := d.redisClient.Keys(ctx, pattern).Result()
-	
+	var keys []string
+	err := ErrCacheUnavailable
+	if d.cacheAvailable(ctx) {
+		pattern := fmt.Sprintf("%s:%s:*", d.cacheConfig.Prefix, d.modelName)
+		keys, err = d.redisClient.Keys(ctx, pattern).Result()
+		d.breaker.Record(err)
+	}
+
 	stats := map[string]interface{}{
 		"enabled":     true,
 		"total_keys":  len(keys),
@@ -344,6 +561,22 @@ func (d *CachedBaseDAO[T, K]) GetCacheStats(ctx context.Context) map[string]inte
 		"max_size":    d.cacheConfig.MaxSize,
 		"prefix":      d.cacheConfig.Prefix,
 		"model_name":  d.modelName,
+		"codec":       d.codec.Name(),
+		"breaker":     d.breaker.Stats(),
+	}
+
+	// 本实例进程启动以来该模型的缓存命中率
+	hits, misses := d.hits.Value(), d.misses.Value()
+	stats["hits"] = hits
+	stats["misses"] = misses
+	if total := hits + misses; total > 0 {
+		stats["hit_ratio"] = float64(hits) / float64(total)
+	}
+
+	// 本实例写入缓存的平均序列化大小
+	if entries := d.encodedEntries.Load(); entries > 0 {
+		stats["encoded_entries"] = entries
+		stats["avg_encoded_size"] = d.encodedBytes.Load() / entries
 	}
 
 	if err == nil {
@@ -351,4 +584,100 @@ func (d *CachedBaseDAO[T, K]) GetCacheStats(ctx context.Context) map[string]inte
 	}
 
 	return stats
-}
\ No newline at end of file
+}
+
+// CacheInvalidator 按模型名和主键清除缓存
+// 不依赖具体DAO实例，用于根据Kafka事件等外部信号清除其他实例写入的缓存
+type CacheInvalidator struct {
+	redisClient *redis.Client
+	prefix      string
+}
+
+// NewCacheInvalidator 创建缓存失效器，prefix 需与对应DAO的 CacheConfig.Prefix 一致
+func NewCacheInvalidator(redisClient *redis.Client, prefix string) *CacheInvalidator {
+	return &CacheInvalidator{
+		redisClient: redisClient,
+		prefix:      prefix,
+	}
+}
+
+// ErrCacheUnavailable 缓存熔断中，暂不可用
+var ErrCacheUnavailable = errors.New("缓存暂不可用")
+
+// ErrInvalidCacheModel 模型名为空或包含非法字符
+var ErrInvalidCacheModel = errors.New("无效的缓存模型名")
+
+// cacheModelPattern 模型名只允许字母、数字、下划线和连字符，避免拼入 SCAN 模式时匹配到其他键
+var cacheModelPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Invalidate 清除指定模型记录的ID缓存，以及该模型的列表和条件缓存
+func (c *CacheInvalidator) Invalidate(ctx context.Context, modelName, id string) error {
+	if !cacheModelPattern.MatchString(modelName) {
+		return ErrInvalidCacheModel
+	}
+
+	if err := c.redisClient.Del(ctx, fmt.Sprintf("%s:%s:id:%s", c.prefix, modelName, id)).Err(); err != nil {
+		return err
+	}
+
+	for _, pattern := range []string{
+		fmt.Sprintf("%s:%s:list:*", c.prefix, modelName),
+		fmt.Sprintf("%s:%s:condition*", c.prefix, modelName),
+	} {
+		if _, err := c.deleteByPattern(ctx, pattern); err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("缓存已失效", zap.String("model", modelName), zap.String("id", id))
+	return nil
+}
+
+// InvalidateModel 清除指定模型的全部缓存（ID、列表、条件缓存），返回删除的键数量
+// 只删除 "<prefix>:<model>:" 命名空间下的键，不影响限流、会话等其他数据
+func (c *CacheInvalidator) InvalidateModel(ctx context.Context, modelName string) (int64, error) {
+	if !cacheModelPattern.MatchString(modelName) {
+		return 0, ErrInvalidCacheModel
+	}
+
+	deleted, err := c.deleteByPattern(ctx, fmt.Sprintf("%s:%s:*", c.prefix, modelName))
+	if err != nil {
+		return deleted, err
+	}
+
+	logger.Info("模型缓存已清空", zap.String("model", modelName), zap.Int64("deleted", deleted))
+	return deleted, nil
+}
+
+// deleteByPattern 使用 SCAN 分批删除匹配的键
+func (c *CacheInvalidator) deleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	return deleteKeysByPattern(ctx, c.redisClient, pattern)
+}
+
+// deleteKeysByPattern 使用 SCAN 分批删除匹配的键，避免 KEYS 阻塞 Redis
+func deleteKeysByPattern(ctx context.Context, client *redis.Client, pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := client.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// InvalidateUser 清除用户缓存
+func (c *CacheInvalidator) InvalidateUser(ctx context.Context, userID uint) error {
+	return c.Invalidate(ctx, UserCacheModel, fmt.Sprintf("%d", userID))
+}
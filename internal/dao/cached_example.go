@@ -11,6 +11,12 @@ import (
 	"github.com/VennLe/charlotte/internal/model"
 )
 
+// 用户缓存键前缀和模型名，跨实例缓存失效时需保持一致
+const (
+	UserCachePrefix = "charlotte"
+	UserCacheModel  = "user"
+)
+
 // CachedUserDAO 带缓存的用户DAO示例
 type CachedUserDAO struct {
 	*CachedBaseDAO[model.User, uint]
@@ -21,13 +27,14 @@ func NewCachedUserDAO(db *gorm.DB, redisClient *redis.Client) *CachedUserDAO {
 	cacheConfig := &CacheConfig{
 		Enabled:    true,
 		TTL:        10 * time.Minute, // 缓存10分钟
-		Prefix:     "charlotte",
+		Prefix:     UserCachePrefix,
 		NullTTL:    2 * time.Minute,  // 空值缓存2分钟
 		MaxSize:    1000,
+		Codec:      MsgpackCacheCodec, // 用户缓存访问频繁，使用体积更小的二进制编码
 	}
 
 	return &CachedUserDAO{
-		CachedBaseDAO: NewCachedBaseDAO[model.User, uint](db, redisClient, cacheConfig, "user"),
+		CachedBaseDAO: NewCachedBaseDAO[model.User, uint](db, redisClient, cacheConfig, UserCacheModel),
 	}
 }
 
@@ -38,7 +45,7 @@ func (d *CachedUserDAO) GetUserByEmailWithCache(ctx context.Context, email strin
 
 // GetActiveUsersWithCache 带缓存的获取活跃用户
 func (d *CachedUserDAO) GetActiveUsersWithCache(ctx context.Context) ([]*model.User, error) {
-	return d.GetMany(ctx, map[string]interface{}{"status": 1})
+	return d.GetMany(ctx, map[string]interface{}{"status": model.StatusActive})
 }
 
 // CachedExampleUsage 带缓存的使用示例
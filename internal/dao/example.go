@@ -30,7 +30,7 @@ func (d *UserExampleDAO) GetByRole(ctx context.Context, role string) ([]*model.U
 
 // GetActiveUsers 获取活跃用户（自定义方法）
 func (d *UserExampleDAO) GetActiveUsers(ctx context.Context) ([]*model.User, error) {
-	return d.GetMany(ctx, map[string]interface{}{"status": 1})
+	return d.GetMany(ctx, map[string]interface{}{"status": model.StatusActive})
 }
 
 // UpdateRole 更新用户角色（自定义方法）
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/VennLe/charlotte/internal/model"
+)
+
+// FileDAO 文件记录数据访问对象
+type FileDAO struct {
+	*BaseDAOImpl[model.FileRecord, string]
+}
+
+// NewFileDAO 创建文件记录DAO
+func NewFileDAO(db *gorm.DB) *FileDAO {
+	return &FileDAO{
+		BaseDAOImpl: NewBaseDAO[model.FileRecord, string](db),
+	}
+}
+
+// FindByContent 查找内容相同的最早一条记录，用于上传去重，不存在时返回 ErrRecordNotFound
+func (d *FileDAO) FindByContent(ctx context.Context, md5sum string, size int64) (*model.FileRecord, error) {
+	var record model.FileRecord
+	err := d.conn(ctx).
+		Where("md5 = ? AND size = ?", md5sum, size).
+		Order("created_at").
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListByCategory 查询分类下的文件记录，category 为空时查询全部
+func (d *FileDAO) ListByCategory(ctx context.Context, category string) ([]*model.FileRecord, error) {
+	var records []*model.FileRecord
+	query := d.conn(ctx).Order("created_at")
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	err := query.Find(&records).Error
+	return records, err
+}
+
+// Release 删除文件记录，lastRef 表示已没有其他记录引用同一个存储文件，调用方应删除存储文件
+// 记录不存在时返回 ErrRecordNotFound
+func (d *FileDAO) Release(ctx context.Context, id string) (record *model.FileRecord, lastRef bool, err error) {
+	err = RunInTransaction(ctx, d.DB, func(txCtx context.Context) error {
+		var found model.FileRecord
+		err := d.conn(txCtx).
+			Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where(byPrimaryKey(id)).
+			First(&found).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := d.conn(txCtx).Where(byPrimaryKey(id)).Delete(&model.FileRecord{}).Error; err != nil {
+			return err
+		}
+
+		var refs int64
+		if err := d.conn(txCtx).Model(&model.FileRecord{}).
+			Where("storage_key = ?", found.StorageKey).
+			Count(&refs).Error; err != nil {
+			return err
+		}
+
+		record, lastRef = &found, refs == 0
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return record, lastRef, nil
+}
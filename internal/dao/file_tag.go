@@ -0,0 +1,109 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/VennLe/charlotte/internal/model"
+)
+
+// FileTagDAO 文件标签数据访问对象
+type FileTagDAO struct {
+	*BaseDAOImpl[model.FileTag, uint]
+}
+
+// NewFileTagDAO 创建文件标签DAO
+func NewFileTagDAO(db *gorm.DB) *FileTagDAO {
+	return &FileTagDAO{
+		BaseDAOImpl: NewBaseDAO[model.FileTag, uint](db),
+	}
+}
+
+// AddTags 为多个文件添加标签，文件已有的标签忽略
+func (d *FileTagDAO) AddTags(ctx context.Context, fileIDs, tags []string) error {
+	if len(fileIDs) == 0 || len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]model.FileTag, 0, len(fileIDs)*len(tags))
+	for _, fileID := range fileIDs {
+		for _, tag := range tags {
+			rows = append(rows, model.FileTag{FileID: fileID, Tag: tag})
+		}
+	}
+	return d.conn(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(rows, 500).Error
+}
+
+// RemoveTags 移除文件的指定标签
+func (d *FileTagDAO) RemoveTags(ctx context.Context, fileID string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	return d.conn(ctx).
+		Where("file_id = ? AND tag IN ?", fileID, tags).
+		Delete(&model.FileTag{}).Error
+}
+
+// SetTags 将文件的标签替换为 tags，tags 为空时清空标签
+func (d *FileTagDAO) SetTags(ctx context.Context, fileID string, tags []string) error {
+	return RunInTransaction(ctx, d.DB, func(txCtx context.Context) error {
+		query := d.conn(txCtx).Where("file_id = ?", fileID)
+		if len(tags) > 0 {
+			query = query.Where("tag NOT IN ?", tags)
+		}
+		if err := query.Delete(&model.FileTag{}).Error; err != nil {
+			return err
+		}
+		return d.AddTags(txCtx, []string{fileID}, tags)
+	})
+}
+
+// DeleteByFile 删除文件的全部标签
+func (d *FileTagDAO) DeleteByFile(ctx context.Context, fileID string) error {
+	return d.conn(ctx).Where("file_id = ?", fileID).Delete(&model.FileTag{}).Error
+}
+
+// TagsByFiles 批量查询文件的标签，按标签名排序，没有标签的文件不在结果中
+func (d *FileTagDAO) TagsByFiles(ctx context.Context, fileIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(fileIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []model.FileTag
+	err := d.conn(ctx).
+		Select("file_id", "tag").
+		Where("file_id IN ?", fileIDs).
+		Order("tag").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.FileID] = append(result[row.FileID], row.Tag)
+	}
+	return result, nil
+}
+
+// FileIDsByTags 查询带有标签的文件ID
+// matchAll 为 true 时文件需带有全部标签，否则带有任一标签即可
+func (d *FileTagDAO) FileIDsByTags(ctx context.Context, tags []string, matchAll bool) ([]string, error) {
+	var fileIDs []string
+	if len(tags) == 0 {
+		return fileIDs, nil
+	}
+
+	query := d.conn(ctx).Model(&model.FileTag{}).
+		Where("tag IN ?", tags).
+		Group("file_id")
+	if matchAll {
+		query = query.Having("COUNT(DISTINCT tag) = ?", len(tags))
+	}
+	err := query.Pluck("file_id", &fileIDs).Error
+	return fileIDs, err
+}
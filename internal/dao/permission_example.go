@@ -101,6 +101,7 @@ func (e *PermissionExample) RunPermissionExamples(ctx context.Context) error {
 }
 
 // compareRolePermissions 比较不同角色的权限
+// 仅用于演示；管理后台请使用 GET /api/v1/permissions/matrix 获取基于实际配置的权限矩阵
 func (e *PermissionExample) compareRolePermissions(ctx context.Context, permissionDAO *UnifiedPermissionDAO) {
 	roles := []string{
 		model.RoleGuest,
@@ -0,0 +1,42 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/VennLe/charlotte/internal/model"
+)
+
+// PermissionScope 请求的权限范围，由权限中间件写入 context
+type PermissionScope struct {
+	UserID uint   // 当前用户ID，游客为0
+	Scope  string // 命中权限的资源范围（all/own/public）
+}
+
+// permissionScopeContextKey 权限范围在 context 中的存储键
+type permissionScopeContextKey struct{}
+
+// WithPermissionScope 将权限范围存入 context
+func WithPermissionScope(ctx context.Context, scope PermissionScope) context.Context {
+	return context.WithValue(ctx, permissionScopeContextKey{}, scope)
+}
+
+// PermissionScopeFromContext 从 context 中获取权限范围
+func PermissionScopeFromContext(ctx context.Context) (PermissionScope, bool) {
+	scope, ok := ctx.Value(permissionScopeContextKey{}).(PermissionScope)
+	return scope, ok
+}
+
+// ScopeOwnedBy 按权限范围做行级过滤的查询条件，用法：db.Scopes(dao.ScopeOwnedBy(ctx, "owner_id"))
+// context 中没有权限范围或范围为 all 时不过滤；范围为 own 时只返回 ownerColumn 等于当前用户的记录；
+// 其他范围（如 public）同样按所有者过滤，游客的用户ID为0不会匹配任何记录，公开资源需由调用方单独查询
+func ScopeOwnedBy(ctx context.Context, ownerColumn string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		scope, ok := PermissionScopeFromContext(ctx)
+		if !ok || scope.Scope == model.ResourceScopeAll {
+			return db
+		}
+		return db.Where(db.Statement.Quote(ownerColumn)+" = ?", scope.UserID)
+	}
+}
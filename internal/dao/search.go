@@ -0,0 +1,39 @@
+package dao
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// KeywordSearch 多列关键词模糊搜索条件（任一列匹配即可），忽略大小写，用法：db.Scopes(dao.KeywordSearch(...))
+// PostgreSQL 使用 ILIKE，unaccent 为 true 时两侧都经 unaccent() 处理以忽略重音（需安装 unaccent 扩展）；
+// 其他数据库使用 LOWER() 比较，unaccent 不生效：MySQL 默认排序规则本身已忽略大小写和重音，SQLite 的 LOWER 只处理 ASCII 字符
+func KeywordSearch(keyword string, unaccent bool, columns ...string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if keyword == "" || len(columns) == 0 {
+			return db
+		}
+
+		pattern := "%" + keyword + "%"
+		postgres := db.Dialector.Name() == "postgres"
+
+		conditions := make([]string, 0, len(columns))
+		args := make([]interface{}, 0, len(columns))
+		for _, column := range columns {
+			quoted := db.Statement.Quote(column)
+			switch {
+			case postgres && unaccent:
+				conditions = append(conditions, "unaccent("+quoted+") ILIKE unaccent(?)")
+				args = append(args, pattern)
+			case postgres:
+				conditions = append(conditions, quoted+" ILIKE ?")
+				args = append(args, pattern)
+			default:
+				conditions = append(conditions, "LOWER("+quoted+") LIKE ?")
+				args = append(args, strings.ToLower(pattern))
+			}
+		}
+		return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+}
@@ -0,0 +1,41 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txContextKey 事务在 context 中的存储键
+type txContextKey struct{}
+
+// WithTx 将事务存入 context，后续使用该 context 的DAO方法会在此事务中执行
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext 从 context 中获取事务
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*gorm.DB)
+	return tx, ok && tx != nil
+}
+
+// RunInTransaction 在事务中执行 fn，fn 内通过传入的 ctx 调用的DAO方法共享同一事务
+// 若 ctx 中已存在事务则直接复用，由最外层负责提交或回滚
+func RunInTransaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(WithTx(ctx, tx))
+	})
+}
+
+// dbFromContext 优先使用 context 中的事务，否则使用默认连接
+func dbFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
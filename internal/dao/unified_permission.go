@@ -2,14 +2,20 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/VennLe/charlotte/internal/model"
 )
 
+// ErrUserGroupLimitExceeded 用户加入的用户组数量超出限制
+var ErrUserGroupLimitExceeded = errors.New("用户加入的用户组数量已达上限")
+
 // UnifiedPermissionDAO 统一权限数据访问对象
 // 整合了所有权限相关的操作，简化权限标签为角色标记
 
@@ -46,8 +52,8 @@ type RolePermission struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
-	Role       string `gorm:"size:20;not null;index" json:"role"`
-	ResourceType string `gorm:"size:50;not null" json:"resource_type"`
+	Role       string `gorm:"size:20;not null;index;index:idx_role_permissions_role_resource,priority:1" json:"role"`
+	ResourceType string `gorm:"size:50;not null;index:idx_role_permissions_role_resource,priority:2" json:"resource_type"`
 	Operations  string `gorm:"size:100;not null" json:"operations"`
 	Scope      string `gorm:"size:20;default:'own'" json:"scope"`
 }
@@ -86,7 +92,7 @@ func (d *UnifiedPermissionDAO) InitializeDefaultPermissions(ctx context.Context)
 			return err
 		}
 		if !exists {
-			if err := d.db.WithContext(ctx).Create(&perm).Error; err != nil {
+			if err := dbFromContext(ctx, d.db).Create(&perm).Error; err != nil {
 				return err
 			}
 		}
@@ -99,7 +105,7 @@ func (d *UnifiedPermissionDAO) InitializeDefaultPermissions(ctx context.Context)
 func (d *UnifiedPermissionDAO) SetUserRole(ctx context.Context, userID uint, role string) error {
 	// 先检查是否已有角色记录
 	var existingRole UserRole
-	err := d.db.WithContext(ctx).Where("user_id = ? AND is_active = ?", userID, true).First(&existingRole).Error
+	err := dbFromContext(ctx, d.db).Where("user_id = ? AND is_active = ?", userID, true).First(&existingRole).Error
 
 	if err == gorm.ErrRecordNotFound {
 		// 创建新角色记录
@@ -108,13 +114,13 @@ func (d *UnifiedPermissionDAO) SetUserRole(ctx context.Context, userID uint, rol
 			Role:    role,
 			IsActive: true,
 		}
-		return d.db.WithContext(ctx).Create(&userRole).Error
+		return dbFromContext(ctx, d.db).Create(&userRole).Error
 	} else if err != nil {
 		return err
 	}
 
 	// 更新现有角色记录
-	return d.db.WithContext(ctx).Model(&UserRole{}).
+	return dbFromContext(ctx, d.db).Model(&UserRole{}).
 		Where("user_id = ? AND is_active = ?", userID, true).
 		Update("role", role).Error
 }
@@ -122,7 +128,7 @@ func (d *UnifiedPermissionDAO) SetUserRole(ctx context.Context, userID uint, rol
 // GetUserRole 获取用户角色
 func (d *UnifiedPermissionDAO) GetUserRole(ctx context.Context, userID uint) (string, error) {
 	var userRole UserRole
-	err := d.db.WithContext(ctx).
+	err := dbFromContext(ctx, d.db).
 		Where("user_id = ? AND is_active = ?", userID, true).
 		First(&userRole).Error
 
@@ -139,33 +145,81 @@ func (d *UnifiedPermissionDAO) GetUserRole(ctx context.Context, userID uint) (st
 
 // CheckPermission 检查用户权限
 func (d *UnifiedPermissionDAO) CheckPermission(ctx context.Context, userID uint, resourceType, operation string) (bool, error) {
+	_, granted, err := d.CheckPermissionScope(ctx, userID, resourceType, operation)
+	return granted, err
+}
+
+// CheckPermissionScope 检查用户权限并返回命中权限的资源范围
+// 多条权限同时命中时取范围最大的一条（all > own > public），调用方据此对查询做行级过滤
+func (d *UnifiedPermissionDAO) CheckPermissionScope(ctx context.Context, userID uint, resourceType, operation string) (string, bool, error) {
 	// 获取用户角色
 	role, err := d.GetUserRole(ctx, userID)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 
 	// 超级管理员拥有所有权限
 	if role == model.RoleSuperAdmin {
-		return true, nil
+		return model.ResourceScopeAll, true, nil
 	}
 
 	// 检查角色权限
 	var permissions []RolePermission
-	err = d.db.WithContext(ctx).
+	err = dbFromContext(ctx, d.db).
 		Where("(role = ? AND resource_type = ?) OR (role = ? AND resource_type = '*')", role, resourceType, role).
 		Find(&permissions).Error
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 
+	scope, granted := "", false
 	for _, perm := range permissions {
-		if d.containsOperation(perm.Operations, operation) {
-			return true, nil
+		if !d.containsOperation(perm.Operations, operation) {
+			continue
 		}
+		permScope := perm.Scope
+		if permScope == "" {
+			permScope = model.ResourceScopeOwn
+		}
+		if !granted || scopeRank(permScope) > scopeRank(scope) {
+			scope = permScope
+		}
+		granted = true
+	}
+
+	return scope, granted, nil
+}
+
+// scopeRank 资源范围大小，未知范围按最小处理
+func scopeRank(scope string) int {
+	switch scope {
+	case model.ResourceScopeAll:
+		return 3
+	case model.ResourceScopeOwn:
+		return 2
+	case model.ResourceScopePublic:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// GetResourcePermissions 获取用户角色及其对指定资源类型生效的权限（含通配 *），用于批量鉴权
+func (d *UnifiedPermissionDAO) GetResourcePermissions(ctx context.Context, userID uint, resourceType string) (string, []RolePermission, error) {
+	role, err := d.GetUserRole(ctx, userID)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return false, nil
+	var permissions []RolePermission
+	err = dbFromContext(ctx, d.db).
+		Where("role = ? AND resource_type IN ?", role, []string{resourceType, "*"}).
+		Find(&permissions).Error
+	if err != nil {
+		return "", nil, err
+	}
+
+	return role, permissions, nil
 }
 
 // GetUserPermissions 获取用户的所有权限
@@ -176,7 +230,7 @@ func (d *UnifiedPermissionDAO) GetUserPermissions(ctx context.Context, userID ui
 	}
 
 	var permissions []RolePermission
-	err = d.db.WithContext(ctx).Where("role = ?", role).Find(&permissions).Error
+	err = dbFromContext(ctx, d.db).Where("role = ?", role).Find(&permissions).Error
 	if err != nil {
 		return nil, err
 	}
@@ -201,12 +255,12 @@ func (d *UnifiedPermissionDAO) GrantTemporaryRole(ctx context.Context, userID ui
 		ExpiredAt: expiredAt,
 	}
 	
-	return d.db.WithContext(ctx).Create(&userRole).Error
+	return dbFromContext(ctx, d.db).Create(&userRole).Error
 }
 
 // RevokeRole 撤销用户角色
 func (d *UnifiedPermissionDAO) RevokeRole(ctx context.Context, userID uint) error {
-	return d.db.WithContext(ctx).
+	return dbFromContext(ctx, d.db).
 		Model(&UserRole{}).
 		Where("user_id = ?", userID).
 		Update("is_active", false).Error
@@ -221,12 +275,12 @@ func (d *UnifiedPermissionDAO) AddRolePermission(ctx context.Context, role, reso
 		Scope:        scope,
 	}
 	
-	return d.db.WithContext(ctx).Create(&permission).Error
+	return dbFromContext(ctx, d.db).Create(&permission).Error
 }
 
 // RemoveRolePermission 移除角色权限
 func (d *UnifiedPermissionDAO) RemoveRolePermission(ctx context.Context, role, resourceType string) error {
-	return d.db.WithContext(ctx).
+	return dbFromContext(ctx, d.db).
 		Where("role = ? AND resource_type = ?", role, resourceType).
 		Delete(&RolePermission{}).Error
 }
@@ -234,20 +288,251 @@ func (d *UnifiedPermissionDAO) RemoveRolePermission(ctx context.Context, role, r
 // GetRolePermissions 获取角色的所有权限
 func (d *UnifiedPermissionDAO) GetRolePermissions(ctx context.Context, role string) ([]RolePermission, error) {
 	var permissions []RolePermission
-	err := d.db.WithContext(ctx).Where("role = ?", role).Find(&permissions).Error
+	err := dbFromContext(ctx, d.db).Where("role = ?", role).Find(&permissions).Error
 	return permissions, err
 }
 
+// ClonePermissions 复制角色权限，目标角色已存在的资源类型将被跳过
+// 返回实际复制的权限条数
+func (d *UnifiedPermissionDAO) ClonePermissions(ctx context.Context, fromRole, toRole string) (int, error) {
+	cloned := 0
+	err := dbFromContext(ctx, d.db).Transaction(func(tx *gorm.DB) error {
+		var sources []RolePermission
+		if err := tx.Where("role = ?", fromRole).Find(&sources).Error; err != nil {
+			return err
+		}
+
+		var existing []string
+		if err := tx.Model(&RolePermission{}).Where("role = ?", toRole).
+			Pluck("resource_type", &existing).Error; err != nil {
+			return err
+		}
+		existingSet := make(map[string]bool, len(existing))
+		for _, resourceType := range existing {
+			existingSet[resourceType] = true
+		}
+
+		for _, src := range sources {
+			if existingSet[src.ResourceType] {
+				continue
+			}
+			permission := RolePermission{
+				Role:         toRole,
+				ResourceType: src.ResourceType,
+				Operations:   src.Operations,
+				Scope:        src.Scope,
+			}
+			if err := tx.Create(&permission).Error; err != nil {
+				return err
+			}
+			existingSet[src.ResourceType] = true
+			cloned++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cloned, nil
+}
+
+// CloneGroupPermissions 复制用户组权限，目标组已存在的（权限标签+资源类型）组合将被跳过
+// 返回实际复制的权限条数
+func (d *UnifiedPermissionDAO) CloneGroupPermissions(ctx context.Context, fromGroupID, toGroupID uint) (int, error) {
+	cloned := 0
+	err := dbFromContext(ctx, d.db).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.UserGroup{}).Where("id IN ?", []uint{fromGroupID, toGroupID}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count < 2 {
+			return ErrRecordNotFound
+		}
+
+		var sources []model.UserGroupPermission
+		if err := tx.Where("user_group_id = ?", fromGroupID).Find(&sources).Error; err != nil {
+			return err
+		}
+
+		var existing []model.UserGroupPermission
+		if err := tx.Where("user_group_id = ?", toGroupID).Find(&existing).Error; err != nil {
+			return err
+		}
+		existingSet := make(map[string]bool, len(existing))
+		for _, perm := range existing {
+			existingSet[groupPermissionKey(perm)] = true
+		}
+
+		for _, src := range sources {
+			key := groupPermissionKey(src)
+			if existingSet[key] {
+				continue
+			}
+			permission := model.UserGroupPermission{
+				UserGroupID:     toGroupID,
+				PermissionTagID: src.PermissionTagID,
+				Operations:      src.Operations,
+				ResourceType:    src.ResourceType,
+				ResourceScope:   src.ResourceScope,
+				Conditions:      src.Conditions,
+			}
+			if err := tx.Omit("UserGroup", "PermissionTag").Create(&permission).Error; err != nil {
+				return err
+			}
+			existingSet[key] = true
+			cloned++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cloned, nil
+}
+
+// GetUserGroupCount 获取用户加入的用户组数量
+func (d *UnifiedPermissionDAO) GetUserGroupCount(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := dbFromContext(ctx, d.db).Model(&model.UserGroupMember{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// AddUserToGroup 将用户加入用户组
+// maxGroups 大于0时限制用户可加入的用户组数量，超出时返回 ErrUserGroupLimitExceeded
+// 先锁定用户行再检查，同一用户的并发加入按顺序执行，不会因同时计数而超出上限
+func (d *UnifiedPermissionDAO) AddUserToGroup(ctx context.Context, userID, groupID uint, maxGroups int) error {
+	return RunInTransaction(ctx, d.db, func(ctx context.Context) error {
+		tx := dbFromContext(ctx, d.db)
+
+		var user model.User
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Select("id").
+			First(&user, userID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var groupCount int64
+		if err := tx.Model(&model.UserGroup{}).Where("id = ?", groupID).Count(&groupCount).Error; err != nil {
+			return err
+		}
+		if groupCount == 0 {
+			return ErrRecordNotFound
+		}
+
+		var memberCount int64
+		if err := tx.Model(&model.UserGroupMember{}).
+			Where("user_id = ? AND user_group_id = ?", userID, groupID).
+			Count(&memberCount).Error; err != nil {
+			return err
+		}
+		if memberCount > 0 {
+			return ErrRecordExists
+		}
+
+		if maxGroups > 0 {
+			count, err := d.GetUserGroupCount(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if count >= int64(maxGroups) {
+				return ErrUserGroupLimitExceeded
+			}
+		}
+
+		member := model.UserGroupMember{
+			UserID:      userID,
+			UserGroupID: groupID,
+			JoinedAt:    time.Now(),
+			Status:      model.StatusActive,
+		}
+		return tx.Omit("User", "UserGroup").Create(&member).Error
+	})
+}
+
+// GetUserGroupNames 批量获取用户所属的有效用户组名称，返回以用户ID为键的映射
+func (d *UnifiedPermissionDAO) GetUserGroupNames(ctx context.Context, userIDs []uint) (map[uint][]string, error) {
+	result := make(map[uint][]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		UserID uint
+		Name   string
+	}
+	err := dbFromContext(ctx, d.db).
+		Table("user_group_members AS m").
+		Select("m.user_id, g.name").
+		Joins("JOIN user_groups AS g ON g.id = m.user_group_id AND g.status = ? AND g.deleted_at IS NULL", model.StatusActive).
+		Where("m.user_id IN ? AND m.status = ? AND m.deleted_at IS NULL", userIDs, model.StatusActive).
+		Order("m.user_id, g.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.UserID] = append(result[row.UserID], row.Name)
+	}
+	return result, nil
+}
+
+// ListRolePermissions 分页查询角色权限
+// role 或 resourceType 为空时不按该字段过滤
+func (d *UnifiedPermissionDAO) ListRolePermissions(ctx context.Context, role, resourceType string, page, size int) ([]RolePermission, int64, error) {
+	var permissions []RolePermission
+	var total int64
+
+	query := dbFromContext(ctx, d.db).Model(&RolePermission{})
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if resourceType != "" {
+		query = query.Where("resource_type = ?", resourceType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && size > 0 {
+		query = query.Offset((page - 1) * size).Limit(size)
+	}
+
+	err := query.Order("role, resource_type").Find(&permissions).Error
+	return permissions, total, err
+}
+
 // 辅助方法
 func (d *UnifiedPermissionDAO) checkPermissionExists(ctx context.Context, role, resourceType string) (bool, error) {
 	var count int64
-	err := d.db.WithContext(ctx).Model(&RolePermission{}).
+	err := dbFromContext(ctx, d.db).Model(&RolePermission{}).
 		Where("role = ? AND resource_type = ?", role, resourceType).
 		Count(&count).Error
 	return count > 0, err
 }
 
+func groupPermissionKey(perm model.UserGroupPermission) string {
+	return fmt.Sprintf("%d:%s", perm.PermissionTagID, perm.ResourceType)
+}
+
 func (d *UnifiedPermissionDAO) containsOperation(operations, targetOp string) bool {
+	return operationAllowed(operations, targetOp)
+}
+
+// AllowsOperation 判断该权限配置是否允许指定操作
+func (p RolePermission) AllowsOperation(targetOp string) bool {
+	return operationAllowed(p.Operations, targetOp)
+}
+
+func operationAllowed(operations, targetOp string) bool {
 	if operations == model.PermissionAll {
 		return true
 	}
@@ -3,13 +3,14 @@ package dao
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/VennLe/charlotte/internal/model"
 	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/password"
 )
 
 var (
@@ -21,12 +22,27 @@ var (
 // 继承基础DAO接口，同时保持原有的特殊方法
 type UserDAO struct {
 	*BaseDAOImpl[model.User, uint]
+
+	searchUnaccent bool
 }
 
+// UserUpdatableFields 通过通用 Update 允许修改的用户字段
+// 密码、角色、状态、权限级别等字段需通过专用方法修改
+var UserUpdatableFields = []string{"username", "email", "nickname", "avatar", "phone", "tags"}
+
+// UserSearchFields 关键词搜索匹配的用户字段
+var UserSearchFields = []string{"username", "email", "nickname"}
+
+// UserGroupableFields 允许分组统计的用户字段
+var UserGroupableFields = []string{"role", "status", "permission_level"}
+
 // NewUserDAO 创建 DAO 实例
 func NewUserDAO(db *gorm.DB) *UserDAO {
+	base := NewBaseDAO[model.User, uint](db)
+	base.SetUpdatableFields(UserUpdatableFields...)
+	base.SetGroupableFields(UserGroupableFields...)
 	return &UserDAO{
-		BaseDAOImpl: NewBaseDAO[model.User, uint](db),
+		BaseDAOImpl: base,
 	}
 }
 
@@ -50,13 +66,22 @@ func (d *UserDAO) Create(ctx context.Context, user *model.User) error {
 		return errors.New("邮箱已被注册")
 	}
 
+	// 不支持部分唯一索引的数据库（MySQL）中，软删除的记录仍占用唯一索引，重新注册前清除
+	if !supportsPartialIndex(d.DB) {
+		if err := d.conn(ctx).Unscoped().
+			Where("deleted_at IS NOT NULL AND (username = ? OR email = ?)", user.Username, user.Email).
+			Delete(&model.User{}).Error; err != nil {
+			return err
+		}
+	}
+
 	// 密码加密
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := password.Default().Hash(user.Password)
 	if err != nil {
 		logger.Error("密码加密失败", zap.Error(err))
 		return err
 	}
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 
 	// 调用基础创建方法
 	return d.BaseDAOImpl.Create(ctx, user)
@@ -72,7 +97,55 @@ func (d *UserDAO) GetByEmail(ctx context.Context, email string) (*model.User, er
 	return d.GetOne(ctx, map[string]interface{}{"email": email})
 }
 
-// List 获取用户列表（重写基础方法，支持关键词搜索）
+// getByEmailsBatchSize 批量按邮箱查询时单条SQL的最大参数数量
+const getByEmailsBatchSize = 500
+
+// GetByEmails 根据邮箱批量获取用户（不区分大小写）
+// 返回以小写邮箱为键的映射，未找到的邮箱不在结果中
+func (d *UserDAO) GetByEmails(ctx context.Context, emails []string) (map[string]*model.User, error) {
+	result := make(map[string]*model.User, len(emails))
+
+	seen := make(map[string]bool, len(emails))
+	normalized := make([]string, 0, len(emails))
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		normalized = append(normalized, email)
+	}
+
+	for start := 0; start < len(normalized); start += getByEmailsBatchSize {
+		end := start + getByEmailsBatchSize
+		if end > len(normalized) {
+			end = len(normalized)
+		}
+
+		var users []*model.User
+		if err := d.conn(ctx).Where("LOWER(email) IN ?", normalized[start:end]).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			result[strings.ToLower(user.Email)] = user
+		}
+	}
+
+	return result, nil
+}
+
+// GetByID 根据ID获取用户（重写基础方法，按请求 context 的权限范围过滤）
+// 权限范围为 own 时只能查到当前用户本人，其他用户返回 ErrRecordNotFound
+func (d *UserDAO) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	err := d.conn(ctx).Scopes(ScopeOwnedBy(ctx, "id")).Where(byPrimaryKey(id)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	return &user, err
+}
+
+// List 获取用户列表（重写基础方法，支持关键词搜索，按请求 context 的权限范围过滤）
 func (d *UserDAO) List(ctx context.Context, options *QueryOptions) ([]*model.User, int64, error) {
 	// 如果options为空，创建默认选项
 	if options == nil {
@@ -82,40 +155,61 @@ func (d *UserDAO) List(ctx context.Context, options *QueryOptions) ([]*model.Use
 		}
 	}
 
-	// 处理关键词搜索
+	opts := *options
+	opts.Scopes = append(append([]func(*gorm.DB) *gorm.DB{}, options.Scopes...), ScopeOwnedBy(ctx, "id"))
+	// 处理关键词搜索，忽略大小写（可选忽略重音）
 	if options.Keyword != "" {
-		if options.Filters == nil {
-			options.Filters = make(map[string]interface{})
-		}
-		options.Filters["username LIKE ? OR email LIKE ? OR nickname LIKE ?"] = "%" + options.Keyword + "%"
+		opts.Scopes = append(opts.Scopes, KeywordSearch(options.Keyword, d.searchUnaccent, UserSearchFields...))
 	}
 
-	return d.BaseDAOImpl.List(ctx, options)
+	return d.BaseDAOImpl.List(ctx, &opts)
 }
 
-// UpdatePassword 更新密码（特殊方法）
+// SetSearchUnaccent 设置关键词搜索是否忽略重音，仅 PostgreSQL 生效且需要 unaccent 扩展
+func (d *UserDAO) SetSearchUnaccent(enabled bool) {
+	d.searchUnaccent = enabled
+}
+
+// UpdatePassword 更新密码（特殊方法），按当前配置的算法哈希
 func (d *UserDAO) UpdatePassword(ctx context.Context, id uint, newPassword string) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := password.Default().Hash(newPassword)
 	if err != nil {
 		return err
 	}
 
-	return d.Update(ctx, id, map[string]interface{}{"password": string(hashedPassword)})
+	return d.updateColumns(ctx, id, map[string]interface{}{"password": hashedPassword})
 }
 
 // UpdateLastLogin 更新最后登录时间（特殊方法）
 func (d *UserDAO) UpdateLastLogin(ctx context.Context, id uint) error {
-	return d.Update(ctx, id, map[string]interface{}{"last_login": gorm.Expr("NOW()")})
+	return d.updateColumns(ctx, id, map[string]interface{}{"last_login": gorm.Expr("NOW()")})
+}
+
+// IncrementTokenVersion 递增用户的令牌版本并返回新版本（特殊方法）
+func (d *UserDAO) IncrementTokenVersion(ctx context.Context, id uint) (int, error) {
+	if err := d.updateColumns(ctx, id, map[string]interface{}{"token_version": gorm.Expr("token_version + 1")}); err != nil {
+		return 0, err
+	}
+	return d.GetTokenVersion(ctx, id)
+}
+
+// GetTokenVersion 获取用户当前的令牌版本
+func (d *UserDAO) GetTokenVersion(ctx context.Context, id uint) (int, error) {
+	var user model.User
+	err := d.conn(ctx).Select("token_version").Where("id = ?", id).Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrRecordNotFound
+	}
+	return user.TokenVersion, err
 }
 
-// CheckPassword 验证密码（特殊方法）
-func (d *UserDAO) CheckPassword(hashedPassword, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+// CheckPassword 验证密码（特殊方法），兼容 bcrypt 和 argon2id 哈希
+// needsRehash 表示密码正确但哈希的算法或参数已过时，应在登录成功后用 UpdatePassword 重新哈希
+func (d *UserDAO) CheckPassword(hashedPassword, plain string) (ok, needsRehash bool) {
+	return password.Default().Check(hashedPassword, plain)
 }
 
 // 以下方法现在通过基础接口提供，无需重复实现：
-// - GetByID: 通过基础接口的 GetByID 方法
-// - Update: 通过基础接口的 Update 方法（已过滤密码字段）
+// - Update: 通过基础接口的 Update 方法（仅允许更新 UserUpdatableFields 中的字段）
 // - Delete: 通过基础接口的 Delete 方法
 // - HardDelete: 通过基础接口的 HardDelete 方法
\ No newline at end of file
@@ -0,0 +1,137 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/VennLe/charlotte/internal/model"
+)
+
+// ErrMergeSameUser 合并的两个用户相同
+var ErrMergeSameUser = errors.New("不能将用户合并到自身")
+
+// UserMergeResult 用户合并结果
+type UserMergeResult struct {
+	CanonicalID      uint   `json:"canonical_id"`
+	DuplicateID      uint   `json:"duplicate_id"`
+	GroupsMoved      int    `json:"groups_moved"`      // 转移到保留用户的用户组成员关系
+	GroupsDropped    int    `json:"groups_dropped"`    // 保留用户已在组内而移除的重复成员关系
+	PermissionsMoved int64  `json:"permissions_moved"` // 转移的用户特殊权限
+	RoleMoved        bool   `json:"role_moved"`        // 保留用户无角色时转移了重复用户的角色
+	DuplicateRole    string `json:"duplicate_role,omitempty"`
+}
+
+// MergeInto 将重复用户的用户组成员关系、特殊权限和角色转移到保留用户，然后软删除重复用户
+// 在事务中执行；ctx 中已有事务时加入该事务，调用方可在同一事务中写入审计记录
+func (d *UserDAO) MergeInto(ctx context.Context, canonicalID, duplicateID uint) (*UserMergeResult, error) {
+	if canonicalID == duplicateID {
+		return nil, ErrMergeSameUser
+	}
+
+	result := &UserMergeResult{CanonicalID: canonicalID, DuplicateID: duplicateID}
+	err := RunInTransaction(ctx, d.DB, func(ctx context.Context) error {
+		tx := d.conn(ctx)
+
+		for _, id := range []uint{canonicalID, duplicateID} {
+			if _, err := d.GetByID(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		if err := mergeGroupMemberships(tx, canonicalID, duplicateID, result); err != nil {
+			return err
+		}
+
+		moved := tx.Model(&model.UserPermission{}).
+			Where("user_id = ?", duplicateID).
+			Update("user_id", canonicalID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.PermissionsMoved = moved.RowsAffected
+
+		if err := mergeUserRole(tx, canonicalID, duplicateID, result); err != nil {
+			return err
+		}
+
+		return d.BaseDAOImpl.Delete(ctx, duplicateID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeGroupMemberships 转移用户组成员关系
+// 唯一索引 (user_id, user_group_id) 包含软删除的记录，保留用户在同组的已删除成员关系需先物理删除
+func mergeGroupMemberships(tx *gorm.DB, canonicalID, duplicateID uint, result *UserMergeResult) error {
+	var existing []model.UserGroupMember
+	if err := tx.Unscoped().Where("user_id = ?", canonicalID).Find(&existing).Error; err != nil {
+		return err
+	}
+	canonicalGroups := make(map[uint]model.UserGroupMember, len(existing))
+	for _, member := range existing {
+		canonicalGroups[member.UserGroupID] = member
+	}
+
+	var memberships []model.UserGroupMember
+	if err := tx.Where("user_id = ?", duplicateID).Find(&memberships).Error; err != nil {
+		return err
+	}
+
+	for _, member := range memberships {
+		if current, ok := canonicalGroups[member.UserGroupID]; ok {
+			if !current.DeletedAt.Valid {
+				if err := tx.Delete(&model.UserGroupMember{}, member.ID).Error; err != nil {
+					return err
+				}
+				result.GroupsDropped++
+				continue
+			}
+			if err := tx.Unscoped().Delete(&model.UserGroupMember{}, current.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&model.UserGroupMember{}).Where("id = ?", member.ID).
+			Update("user_id", canonicalID).Error; err != nil {
+			return err
+		}
+		result.GroupsMoved++
+	}
+	return nil
+}
+
+// mergeUserRole 保留用户已有角色时保持不变（重复用户的角色记入结果），否则转移重复用户的角色
+func mergeUserRole(tx *gorm.DB, canonicalID, duplicateID uint, result *UserMergeResult) error {
+	var duplicateRole UserRole
+	err := tx.Where("user_id = ?", duplicateID).First(&duplicateRole).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	result.DuplicateRole = duplicateRole.Role
+
+	var canonicalCount int64
+	if err := tx.Model(&UserRole{}).Where("user_id = ?", canonicalID).Count(&canonicalCount).Error; err != nil {
+		return err
+	}
+	if canonicalCount > 0 {
+		return tx.Delete(&UserRole{}, duplicateRole.ID).Error
+	}
+
+	// user_id 唯一索引包含软删除的记录，先清除保留用户已删除的角色记录
+	if err := tx.Unscoped().Where("user_id = ?", canonicalID).Delete(&UserRole{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&UserRole{}).Where("id = ?", duplicateRole.ID).
+		Update("user_id", canonicalID).Error; err != nil {
+		return err
+	}
+	result.RoleMoved = true
+	return nil
+}
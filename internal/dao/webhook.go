@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/VennLe/charlotte/internal/model"
+)
+
+// WebhookSubscriptionDAO Webhook 订阅数据访问对象
+type WebhookSubscriptionDAO struct {
+	*BaseDAOImpl[model.WebhookSubscription, uint]
+}
+
+// NewWebhookSubscriptionDAO 创建 Webhook 订阅DAO
+func NewWebhookSubscriptionDAO(db *gorm.DB) *WebhookSubscriptionDAO {
+	return &WebhookSubscriptionDAO{
+		BaseDAOImpl: NewBaseDAO[model.WebhookSubscription, uint](db),
+	}
+}
+
+// WebhookDeliveryDAO Webhook 投递记录数据访问对象
+type WebhookDeliveryDAO struct {
+	*BaseDAOImpl[model.WebhookDelivery, uint]
+}
+
+// NewWebhookDeliveryDAO 创建 Webhook 投递记录DAO
+func NewWebhookDeliveryDAO(db *gorm.DB) *WebhookDeliveryDAO {
+	return &WebhookDeliveryDAO{
+		BaseDAOImpl: NewBaseDAO[model.WebhookDelivery, uint](db),
+	}
+}
+
+// ListDue 查询到期待投递的记录，按到期时间排序
+func (d *WebhookDeliveryDAO) ListDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := d.conn(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Claim 认领到期的投递记录，将下次投递时间推迟到 leaseUntil，避免多个实例或重试任务重复投递
+// 记录已被其他实例认领或不再待投递时返回 false
+func (d *WebhookDeliveryDAO) Claim(ctx context.Context, id uint, now, leaseUntil time.Time) (bool, error) {
+	result := d.conn(ctx).Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, model.WebhookDeliveryPending, now).
+		Update("next_attempt_at", leaseUntil)
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// CacheHandler 缓存运维处理器
+type CacheHandler struct {
+	cacheService *service.CacheService
+}
+
+// NewCacheHandler 创建缓存运维处理器
+func NewCacheHandler(cacheService *service.CacheService) *CacheHandler {
+	return &CacheHandler{cacheService: cacheService}
+}
+
+// InvalidateCacheKey 清除指定模型记录的缓存 (超级管理员)
+func (h *CacheHandler) InvalidateCacheKey(c *gin.Context) {
+	modelName := c.Param("model")
+	id := c.Param("id")
+
+	if err := h.cacheService.InvalidateKey(c.Request.Context(), modelName, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	operator := ctxutil.Username(c)
+	logger.FromContext(c.Request.Context()).Info("管理员清除缓存",
+		zap.String("model", modelName),
+		zap.String("id", id),
+		zap.String("operator", operator))
+
+	utils.Success(c, gin.H{
+		"model": modelName,
+		"id":    id,
+	})
+}
+
+// InvalidateCacheModel 清除指定模型的全部缓存 (超级管理员)
+func (h *CacheHandler) InvalidateCacheModel(c *gin.Context) {
+	modelName := c.Param("model")
+
+	deleted, err := h.cacheService.InvalidateModel(c.Request.Context(), modelName)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	operator := ctxutil.Username(c)
+	logger.FromContext(c.Request.Context()).Info("管理员清空模型缓存",
+		zap.String("model", modelName),
+		zap.Int64("deleted", deleted),
+		zap.String("operator", operator))
+
+	utils.Success(c, gin.H{
+		"model":        modelName,
+		"deleted_keys": deleted,
+	})
+}
+
+// handleError 将缓存服务错误转换为响应
+func (h *CacheHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCacheModel):
+		utils.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrCacheUnavailable):
+		utils.Error(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		logger.Error("清除缓存失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "清除缓存失败: "+err.Error())
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// InitChunkUpload 创建分片上传会话，返回 upload_id 和单个分片的大小上限
+func (h *ImportExportHandler) InitChunkUpload(c *gin.Context) {
+	var req service.InitChunkUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	status, err := h.fileService.InitChunkUpload(c.Request.Context(), &req, userID)
+	if err != nil {
+		chunkUploadError(c, err, "创建分片上传失败")
+		return
+	}
+
+	utils.Success(c, status)
+}
+
+// UploadChunk 上传一个分片，分片可乱序、并发上传，返回会话当前状态
+func (h *ImportExportHandler) UploadChunk(c *gin.Context) {
+	var req service.UploadChunkRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	status, err := h.fileService.UploadChunk(c.Request.Context(), &req, userID)
+	if err != nil {
+		chunkUploadError(c, err, "上传分片失败")
+		return
+	}
+
+	utils.Success(c, status)
+}
+
+// GetChunkUpload 查询分片上传会话状态，断点续传时据此只上传缺少的分片
+func (h *ImportExportHandler) GetChunkUpload(c *gin.Context) {
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	status, err := h.fileService.GetChunkUpload(c.Param("upload_id"), userID)
+	if err != nil {
+		chunkUploadError(c, err, "查询分片上传失败")
+		return
+	}
+
+	utils.Success(c, status)
+}
+
+// CompleteChunkUpload 合并全部分片并校验MD5，成功后与普通上传返回相同的文件信息
+func (h *ImportExportHandler) CompleteChunkUpload(c *gin.Context) {
+	var req service.CompleteChunkUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	userName := ctxutil.Username(c)
+	if userName == "" {
+		userName = "unknown"
+	}
+
+	resp, err := h.fileService.CompleteChunkUpload(c.Request.Context(), &req, userID, userName)
+	if err != nil {
+		chunkUploadError(c, err, "完成分片上传失败")
+		return
+	}
+
+	logger.Info("分片上传完成",
+		zap.String("upload_id", req.UploadID),
+		zap.String("file_id", resp.FileInfo.ID),
+		zap.String("filename", resp.FileInfo.OriginalName),
+		zap.Int64("size", resp.FileInfo.Size),
+		zap.Uint("user_id", userID),
+	)
+
+	utils.Success(c, resp)
+}
+
+// chunkUploadError 将分片上传的错误转换为响应
+func chunkUploadError(c *gin.Context, err error, failMsg string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidChunkUpload), errors.Is(err, service.ErrChunkMD5Mismatch):
+		utils.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrChunkUploadNotFound):
+		utils.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrChunkUploadIncomplete), errors.Is(err, service.ErrChunkUploadBusy):
+		utils.Error(c, http.StatusConflict, err.Error())
+	default:
+		// 与普通上传一致，文件校验失败的原因返回给客户端
+		logger.FromContext(c.Request.Context()).Error(failMsg, zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
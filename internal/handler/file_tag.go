@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// SetFileTags 将文件的标签替换为请求中的标签，标签为空时清空
+func (h *ImportExportHandler) SetFileTags(c *gin.Context) {
+	h.updateFileTags(c, "设置文件标签失败", h.fileService.SetTags)
+}
+
+// AddFileTags 为文件添加标签
+func (h *ImportExportHandler) AddFileTags(c *gin.Context) {
+	h.updateFileTags(c, "添加文件标签失败", h.fileService.AddTags)
+}
+
+// RemoveFileTags 移除文件的指定标签
+func (h *ImportExportHandler) RemoveFileTags(c *gin.Context) {
+	h.updateFileTags(c, "移除文件标签失败", h.fileService.RemoveTags)
+}
+
+// BulkAddFileTags 为多个文件添加相同的标签
+func (h *ImportExportHandler) BulkAddFileTags(c *gin.Context) {
+	var req service.BulkFileTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.fileService.BulkAddTags(c.Request.Context(), req.FileIDs, req.Tags); err != nil {
+		fileTagError(c, err, "批量添加文件标签失败")
+		return
+	}
+
+	utils.Success(c, gin.H{"message": "文件标签添加成功", "file_count": len(req.FileIDs)})
+}
+
+// updateFileTags 绑定标签请求并调用 update 修改文件标签，返回文件当前的全部标签
+func (h *ImportExportHandler) updateFileTags(c *gin.Context, failMsg string,
+	update func(ctx context.Context, fileID string, tags []string) ([]string, error)) {
+	fileID := c.Param("file_id")
+	if fileID == "" {
+		utils.Error(c, http.StatusBadRequest, "文件ID不能为空")
+		return
+	}
+
+	var req service.FileTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	tags, err := update(c.Request.Context(), fileID, req.Tags)
+	if err != nil {
+		fileTagError(c, err, failMsg)
+		return
+	}
+
+	utils.Success(c, &service.FileTagsResponse{FileID: fileID, Tags: tags})
+}
+
+// fileTagError 将文件标签操作的错误转换为响应
+func fileTagError(c *gin.Context, err error, failMsg string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidFileTag):
+		utils.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrFileNotFound):
+		utils.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrFileTagsUnavailable):
+		utils.Error(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		logger.FromContext(c.Request.Context()).Error(failMsg, zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, failMsg)
+	}
+}
@@ -22,3 +22,8 @@ func (h *HealthHandler) Check(c *gin.Context) {
 	status := h.checker.Check(c.Request.Context())
 	utils.Success(c, status)
 }
+
+// Runtime 进程运行时状态（goroutine 数、内存、GC），用于故障排查 (管理员)
+func (h *HealthHandler) Runtime(c *gin.Context) {
+	utils.Success(c, h.checker.RuntimeStats())
+}
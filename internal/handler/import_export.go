@@ -1,30 +1,38 @@
 package handler
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/VennLe/charlotte/internal/ctxutil"
 	"github.com/VennLe/charlotte/internal/service"
 	"github.com/VennLe/charlotte/pkg/logger"
 	"github.com/VennLe/charlotte/pkg/utils"
 )
 
+// exportOmittedColumnsHeader 流式导出时列出因角色无权导出而去掉的字段
+const exportOmittedColumnsHeader = "X-Export-Omitted-Columns"
+
+// exportFlushSize 流式导出时每写出多少字节刷新一次响应
+const exportFlushSize = 64 << 10
+
 // ImportExportHandler 导入导出处理器
 type ImportExportHandler struct {
 	importExportService *service.ImportExportService
 	fileService         *service.FileService
 }
 
-// NewImportExportHandler 创建导入导出处理器
-func NewImportExportHandler(
-	importExportService *service.ImportExportService,
-	fileService *service.FileService,
-) *ImportExportHandler {
+// NewImportExportHandler 创建导入导出处理器，数据处理器通过 ImportExportService.RegisterDataProcessor 注册
+func NewImportExportHandler(importExportService *service.ImportExportService, fileService *service.FileService) *ImportExportHandler {
 	return &ImportExportHandler{
 		importExportService: importExportService,
 		fileService:         fileService,
@@ -40,14 +48,14 @@ func (h *ImportExportHandler) ImportData(c *gin.Context) {
 	}
 
 	// 从JWT中获取用户信息
-	userID, exists := c.Get("user_id")
+	userID, exists := ctxutil.UserID(c)
 	if !exists {
 		utils.Error(c, http.StatusUnauthorized, "未登录")
 		return
 	}
 
 	// 根据数据类型选择处理器
-	processor, err := h.getDataProcessor(req.DataType)
+	processor, err := h.importExportService.GetDataProcessor(req.DataType)
 	if err != nil {
 		utils.Error(c, http.StatusBadRequest, err.Error())
 		return
@@ -56,6 +64,23 @@ func (h *ImportExportHandler) ImportData(c *gin.Context) {
 	// 执行导入
 	resp, err := h.importExportService.ImportData(c.Request.Context(), &req, processor)
 	if err != nil {
+		if errors.Is(err, service.ErrImportExportBusy) {
+			h.busy(c)
+			return
+		}
+		if errors.Is(err, utils.ErrTooManyRows) {
+			// 附带超出上限前已读取部分的统计和错误详情
+			c.JSON(http.StatusRequestEntityTooLarge, utils.Response{
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: err.Error(),
+				Data:    resp,
+			})
+			return
+		}
+		if errors.Is(err, utils.ErrInvalidColumnMapping) || errors.Is(err, utils.ErrInvalidDedupeKey) {
+			utils.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		logger.Error("数据导入失败",
 			zap.String("data_type", req.DataType),
 			zap.String("file_type", req.FileType),
@@ -76,12 +101,157 @@ func (h *ImportExportHandler) ImportData(c *gin.Context) {
 		zap.Int("total_rows", resp.TotalRows),
 		zap.Int("success_rows", resp.SuccessRows),
 		zap.Int("failed_rows", resp.FailedRows),
-		zap.Uint("user_id", userID.(uint)),
+		zap.Uint("user_id", userID),
 	)
 
 	utils.Success(c, resp)
 }
 
+// SubmitImportJob 提交异步导入任务，参数与 ImportData 相同，立即返回任务ID
+func (h *ImportExportHandler) SubmitImportJob(c *gin.Context) {
+	var req service.ImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 从JWT中获取用户信息
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	// 根据数据类型选择处理器
+	processor, err := h.importExportService.GetDataProcessor(req.DataType)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req.UserID = userID
+	jobID, err := h.importExportService.SubmitImportJob(c.Request.Context(), &req, processor)
+	if err != nil {
+		if errors.Is(err, service.ErrImportExportBusy) {
+			h.busy(c)
+			return
+		}
+		var fileErr *utils.ImportExportError
+		if errors.As(err, &fileErr) || errors.Is(err, utils.ErrInvalidColumnMapping) || errors.Is(err, utils.ErrInvalidDedupeKey) {
+			utils.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Error("提交异步导入任务失败",
+			zap.String("data_type", req.DataType),
+			zap.String("file_type", req.FileType),
+			zap.Error(err),
+		)
+		utils.Error(c, http.StatusInternalServerError, "提交异步导入任务失败")
+		return
+	}
+
+	logger.Info("异步导入任务已提交",
+		zap.String("job_id", jobID),
+		zap.String("data_type", req.DataType),
+		zap.String("file_type", req.FileType),
+		zap.Uint("user_id", userID),
+	)
+
+	utils.Success(c, gin.H{"job_id": jobID})
+}
+
+// GetImportJob 查询异步导入任务状态，仅任务提交者和管理员可查询
+func (h *ImportExportHandler) GetImportJob(c *gin.Context) {
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	job, err := h.importExportService.GetImportJobStatus(c.Param("job_id"), userID, ctxutil.UserRole(c))
+	if err != nil {
+		utils.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.Success(c, job)
+}
+
+// ReimportImportJob 重新导入异步导入任务中尚未导入的行
+// 可上传修正后的完整文件（file 字段，失败行在原位置修改），不上传时按原文件重试；仅任务提交者和管理员可重新导入
+func (h *ImportExportHandler) ReimportImportJob(c *gin.Context) {
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	// 请求体为空时不上传文件
+	corrected, err := c.FormFile("file")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart) {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	job, err := h.importExportService.ReimportFailed(c.Request.Context(), c.Param("job_id"), corrected, userID, ctxutil.UserRole(c))
+	if err != nil {
+		var fileErr *utils.ImportExportError
+		switch {
+		case errors.Is(err, service.ErrImportJobNotFound):
+			utils.Error(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrImportJobRunning), errors.Is(err, service.ErrNoFailedRows):
+			utils.Error(c, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrImportExportBusy):
+			h.busy(c)
+		case errors.As(err, &fileErr):
+			utils.Error(c, http.StatusBadRequest, err.Error())
+		default:
+			logger.Error("重新导入失败行失败", zap.String("job_id", c.Param("job_id")), zap.Error(err))
+			utils.Error(c, http.StatusInternalServerError, "重新导入失败行失败")
+		}
+		return
+	}
+
+	utils.Success(c, job)
+}
+
+// ProfileImport 导入前的数据概况：按列统计推断类型、空值数、去重数和无法转换的值，不导入任何数据
+// 参数与 ImportData 相同，仅支持 CSV 和 Excel
+func (h *ImportExportHandler) ProfileImport(c *gin.Context) {
+	var req service.ImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	processor, err := h.importExportService.GetDataProcessor(req.DataType)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	profile, err := h.importExportService.ProfileImport(c.Request.Context(), &req, processor)
+	if err != nil {
+		var fileErr *utils.ImportExportError
+		switch {
+		case errors.Is(err, service.ErrImportExportBusy):
+			h.busy(c)
+		case errors.As(err, &fileErr), errors.Is(err, utils.ErrInvalidColumnMapping), errors.Is(err, utils.ErrInvalidDedupeKey):
+			utils.Error(c, http.StatusBadRequest, err.Error())
+		default:
+			logger.Error("导入数据概况失败",
+				zap.String("data_type", req.DataType),
+				zap.String("file_type", req.FileType),
+				zap.Error(err),
+			)
+			utils.Error(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, profile)
+}
+
 // ExportData 导出数据
 func (h *ImportExportHandler) ExportData(c *gin.Context) {
 	var req service.ExportRequest
@@ -91,46 +261,203 @@ func (h *ImportExportHandler) ExportData(c *gin.Context) {
 	}
 
 	// 从JWT中获取用户信息
-	userID, exists := c.Get("user_id")
+	userID, exists := ctxutil.UserID(c)
 	if !exists {
 		utils.Error(c, http.StatusUnauthorized, "未登录")
 		return
 	}
+	req.User = ctxutil.Username(c)
+	req.Role = ctxutil.UserRole(c)
 
 	// 根据数据类型选择处理器
-	processor, err := h.getDataProcessor(req.DataType)
+	processor, err := h.importExportService.GetDataProcessor(req.DataType)
 	if err != nil {
 		utils.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// 执行导出
-	resp, err := h.importExportService.ExportData(c.Request.Context(), &req, processor)
+	// 流式导出：内容边生成边写入响应，不在内存中缓存完整文件
+	// 客户端断开连接时请求 context 会被取消，导出随之中止
+	started := false
+	fw := &flushWriter{w: c.Writer}
+	resp, err := h.importExportService.ExportDataTo(c.Request.Context(), fw, &req, processor, func(resp *service.ExportResponse) {
+		started = true
+		setContentDisposition(c, "attachment", resp.FileName)
+		c.Header("Content-Type", h.getContentType(resp.FileType))
+		if len(resp.OmittedColumns) > 0 {
+			// 文件内容即响应体，被去掉的列通过响应头告知
+			c.Header(exportOmittedColumnsHeader, strings.Join(resp.OmittedColumns, ","))
+		}
+		c.Status(http.StatusOK)
+	})
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			logger.Warn("客户端已断开，导出已取消",
+				zap.String("data_type", req.DataType),
+				zap.Uint("user_id", userID),
+			)
+			c.Abort()
+			return
+		}
+		if errors.Is(err, service.ErrImportExportBusy) {
+			h.busy(c)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidExportFilter) {
+			utils.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		logger.Error("数据导出失败",
 			zap.String("data_type", req.DataType),
 			zap.String("file_type", req.FileType),
+			zap.Bool("partially_sent", started),
 			zap.Error(err),
 		)
+		// 已开始写出文件内容时无法再修改状态码，只能中断，客户端收到的文件不完整
+		if started {
+			c.Abort()
+			return
+		}
 		utils.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	fw.Flush()
 
 	logger.Info("数据导出成功",
 		zap.String("data_type", req.DataType),
 		zap.String("file_type", req.FileType),
 		zap.String("file_name", resp.FileName),
 		zap.Int("file_size", resp.FileSize),
-		zap.Uint("user_id", userID.(uint)),
+		zap.Uint("user_id", userID),
 	)
+}
 
-	// 设置响应头
-	c.Header("Content-Disposition", "attachment; filename="+resp.FileName)
-	c.Header("Content-Type", h.getContentType(resp.FileType))
-	c.Header("Content-Length", strconv.Itoa(resp.FileSize))
+// flushWriter 每写出 exportFlushSize 字节刷新一次响应，下载立即开始，服务端不积压已生成的内容
+type flushWriter struct {
+	w       gin.ResponseWriter
+	pending int
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.pending += n
+	if err == nil && fw.pending >= exportFlushSize {
+		fw.Flush()
+	}
+	return n, err
+}
+
+// Flush 将已写出的内容发送给客户端
+func (fw *flushWriter) Flush() {
+	fw.w.Flush()
+	fw.pending = 0
+}
+
+// StartExportJob 提交异步导出任务
+func (h *ImportExportHandler) StartExportJob(c *gin.Context) {
+	var req service.ExportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 从JWT中获取用户信息
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+	req.User = ctxutil.Username(c)
+	req.Role = ctxutil.UserRole(c)
+	req.UserID = userID
+
+	// 根据数据类型选择处理器
+	processor, err := h.importExportService.GetDataProcessor(req.DataType)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := h.importExportService.StartExportJob(&req, processor)
+	if err != nil {
+		if errors.Is(err, service.ErrImportExportBusy) {
+			h.busy(c)
+			return
+		}
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logger.Info("异步导出任务已提交",
+		zap.String("job_id", job.ID),
+		zap.String("data_type", req.DataType),
+		zap.String("file_type", req.FileType),
+		zap.Uint("user_id", userID),
+	)
+
+	utils.Success(c, job)
+}
+
+// GetExportJob 查询异步导出任务状态，仅任务提交者和管理员可查询
+func (h *ImportExportHandler) GetExportJob(c *gin.Context) {
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	job, err := h.importExportService.GetExportJob(c.Param("job_id"), userID, ctxutil.UserRole(c))
+	if err != nil {
+		utils.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
 
-	// 发送文件数据
-	c.Data(http.StatusOK, h.getContentType(resp.FileType), resp.Data)
+	utils.Success(c, job)
+}
+
+// CancelExportJob 取消异步导出任务，仅任务提交者和管理员可取消
+func (h *ImportExportHandler) CancelExportJob(c *gin.Context) {
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	jobID := c.Param("job_id")
+	if err := h.importExportService.CancelExportJob(jobID, userID, ctxutil.UserRole(c)); err != nil {
+		if errors.Is(err, service.ErrExportJobNotFound) {
+			utils.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.Error(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"message": "导出任务已取消", "job_id": jobID})
+}
+
+// DownloadExportJob 下载异步导出任务结果，仅任务提交者和管理员可下载
+func (h *ImportExportHandler) DownloadExportJob(c *gin.Context) {
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	job, file, err := h.importExportService.OpenExportJobFile(c.Param("job_id"), userID, ctxutil.UserRole(c))
+	if err != nil {
+		if errors.Is(err, service.ErrExportJobNotFound) {
+			utils.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.Error(c, http.StatusConflict, err.Error())
+		return
+	}
+	defer file.Close()
+
+	// 设置响应头
+	setContentDisposition(c, "attachment", job.FileName)
+	c.DataFromReader(http.StatusOK, int64(job.FileSize), h.getContentType(job.FileType), file, nil)
 }
 
 // UploadFile 上传文件
@@ -142,19 +469,19 @@ func (h *ImportExportHandler) UploadFile(c *gin.Context) {
 	}
 
 	// 从JWT中获取用户信息
-	userID, exists := c.Get("user_id")
+	userID, exists := ctxutil.UserID(c)
 	if !exists {
 		utils.Error(c, http.StatusUnauthorized, "未登录")
 		return
 	}
 
-	userName, exists := c.Get("username")
-	if !exists {
+	userName := ctxutil.Username(c)
+	if userName == "" {
 		userName = "unknown"
 	}
 
 	// 执行文件上传
-	resp, err := h.fileService.UploadFile(c.Request.Context(), &req, userID.(uint), userName.(string))
+	resp, err := h.fileService.UploadFile(c.Request.Context(), &req, userID, userName)
 	if err != nil {
 		logger.Error("文件上传失败",
 			zap.String("filename", req.File.Filename),
@@ -169,13 +496,60 @@ func (h *ImportExportHandler) UploadFile(c *gin.Context) {
 		zap.String("file_id", resp.FileInfo.ID),
 		zap.String("filename", resp.FileInfo.OriginalName),
 		zap.Int64("size", resp.FileInfo.Size),
-		zap.Uint("user_id", userID.(uint)),
+		zap.Uint("user_id", userID),
 	)
 
 	utils.Success(c, resp)
 }
 
+// UploadFiles 批量上传文件（表单字段 files[]）
+// 部分文件失败时仍返回成功响应，各文件结果见 results
+func (h *ImportExportHandler) UploadFiles(c *gin.Context) {
+	var req service.UploadFilesRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 从JWT中获取用户信息
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	userName := ctxutil.Username(c)
+	if userName == "" {
+		userName = "unknown"
+	}
+
+	resp, err := h.fileService.UploadFiles(c.Request.Context(), &req, userID, userName)
+	if err != nil {
+		logger.Error("批量文件上传失败",
+			zap.Int("file_count", len(req.Files)),
+			zap.Error(err),
+		)
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if resp.Failed > 0 {
+		logger.Warn("批量文件上传部分失败",
+			zap.Int("success", resp.Success),
+			zap.Int("failed", resp.Failed),
+			zap.Uint("user_id", userID),
+		)
+	}
+
+	utils.Success(c, resp)
+}
+
+// inlineContentSecurityPolicy 文件响应的内容安全策略，禁止脚本执行，仅允许展示图片和 PDF
+const inlineContentSecurityPolicy = "default-src 'none'; img-src 'self'; style-src 'unsafe-inline'; object-src 'self'"
+
 // DownloadFile 下载文件
+// 查询参数 disposition=inline|attachment，图片、PDF 等白名单类型默认浏览器内预览，其余类型作为附件下载；
+// 签名链接带有 expires 和 signature 参数，过期或被篡改时返回 403
 func (h *ImportExportHandler) DownloadFile(c *gin.Context) {
 	fileID := c.Param("file_id")
 	if fileID == "" {
@@ -183,6 +557,14 @@ func (h *ImportExportHandler) DownloadFile(c *gin.Context) {
 		return
 	}
 
+	err := h.fileService.AuthorizeDownload(c.Request.Context(), fileID,
+		c.Query(service.PresignExpiresParam), c.Query(service.PresignSignatureParam))
+	if err != nil {
+		logger.Warn("文件下载签名校验失败", zap.String("file_id", fileID), zap.Error(err))
+		utils.Error(c, http.StatusForbidden, service.ErrInvalidDownloadSignature.Error())
+		return
+	}
+
 	// 执行文件下载
 	fileInfo, file, err := h.fileService.DownloadFile(c.Request.Context(), fileID)
 	if err != nil {
@@ -201,13 +583,64 @@ func (h *ImportExportHandler) DownloadFile(c *gin.Context) {
 		zap.Int64("size", fileInfo.Size),
 	)
 
-	// 设置响应头
-	c.Header("Content-Disposition", "attachment; filename="+fileInfo.OriginalName)
+	// 设置响应头，nosniff 阻止浏览器忽略 Content-Type 按内容猜测类型
+	disposition := h.fileService.ResolveDisposition(fileInfo, c.Query("disposition"))
+	setContentDisposition(c, disposition, fileInfo.OriginalName)
 	c.Header("Content-Type", fileInfo.MimeType)
 	c.Header("Content-Length", strconv.FormatInt(fileInfo.Size, 10))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", inlineContentSecurityPolicy)
 
-	// 发送文件
-	c.File(fileInfo.Path)
+	// 发送文件，本地存储的文件支持 Range 断点续传
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", fileInfo.UploadTime, seeker)
+		return
+	}
+	c.DataFromReader(http.StatusOK, fileInfo.Size, fileInfo.MimeType, file, nil)
+}
+
+// PresignFileURL 生成文件的限时下载链接，非公开文件仅上传者和管理员可生成
+// 查询参数 ttl 为有效期（秒），不传时使用 file.presigned_url.default_ttl
+func (h *ImportExportHandler) PresignFileURL(c *gin.Context) {
+	fileID := c.Param("file_id")
+	if fileID == "" {
+		utils.Error(c, http.StatusBadRequest, "文件ID不能为空")
+		return
+	}
+
+	var ttl time.Duration
+	if raw := c.Query("ttl"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 {
+			utils.Error(c, http.StatusBadRequest, "ttl 须为正整数（秒）")
+			return
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	userID, exists := ctxutil.UserID(c)
+	if !exists {
+		utils.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	resp, err := h.fileService.PresignDownload(c.Request.Context(), fileID, ttl, userID, ctxutil.UserRole(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPresignTTL):
+			utils.Error(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrFileNotFound):
+			utils.Error(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrFileAccessDenied):
+			utils.Error(c, http.StatusForbidden, err.Error())
+		default:
+			logger.Error("生成下载链接失败", zap.String("file_id", fileID), zap.Error(err))
+			utils.Error(c, http.StatusInternalServerError, "生成下载链接失败")
+		}
+		return
+	}
+
+	utils.Success(c, resp)
 }
 
 // ListFiles 列出文件
@@ -226,8 +659,19 @@ func (h *ImportExportHandler) ListFiles(c *gin.Context) {
 		req.Size = 20
 	}
 
+	// 稀疏字段集：?fields=id,name 只返回指定字段
+	fields, err := utils.ParseFields(c, service.FileInfo{})
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// 执行文件列表查询
 	resp, err := h.fileService.ListFiles(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidFileTag) || errors.Is(err, service.ErrFileTagsUnavailable) {
+		fileTagError(c, err, "获取文件列表失败")
+		return
+	}
 	if err != nil {
 		logger.Error("获取文件列表失败",
 			zap.String("category", req.Category),
@@ -237,6 +681,29 @@ func (h *ImportExportHandler) ListFiles(c *gin.Context) {
 		return
 	}
 
+	// 列表未变化时返回304，减少轮询流量
+	// 标签变化不会改变上传时间，将当前页的标签计入ETag
+	var lastUploaded time.Time
+	var fileTags strings.Builder
+	for _, file := range resp.Files {
+		if file.UploadTime.After(lastUploaded) {
+			lastUploaded = file.UploadTime
+		}
+		fileTags.WriteString(file.ID + ":" + strings.Join(file.Tags, ",") + ";")
+	}
+	if utils.NotModified(c, utils.ListETag(lastUploaded, len(resp.Files), resp.Total, fields.String(), fileTags.String())) {
+		return
+	}
+
+	if len(fields) > 0 {
+		utils.Success(c, gin.H{
+			"files": fields.Apply(resp.Files),
+			"total": resp.Total,
+			"page":  resp.Page,
+			"size":  resp.Size,
+		})
+		return
+	}
 	utils.Success(c, resp)
 }
 
@@ -249,7 +716,7 @@ func (h *ImportExportHandler) DeleteFile(c *gin.Context) {
 	}
 
 	// 从JWT中获取用户信息
-	userID, exists := c.Get("user_id")
+	userID, exists := ctxutil.UserID(c)
 	if !exists {
 		utils.Error(c, http.StatusUnauthorized, "未登录")
 		return
@@ -268,7 +735,7 @@ func (h *ImportExportHandler) DeleteFile(c *gin.Context) {
 
 	logger.Info("文件删除成功",
 		zap.String("file_id", req.FileID),
-		zap.Uint("user_id", userID.(uint)),
+		zap.Uint("user_id", userID),
 	)
 
 	utils.Success(c, gin.H{"message": "文件删除成功"})
@@ -285,19 +752,50 @@ func (h *ImportExportHandler) GetSupportedDataTypes(c *gin.Context) {
 	})
 }
 
-// getDataProcessor 根据数据类型获取处理器
-func (h *ImportExportHandler) getDataProcessor(dataType string) (service.DataProcessor, error) {
-	switch dataType {
-	case "user":
-		return &service.UserDataProcessor{}, nil
-	// 可以添加更多数据类型处理器
-	// case "product":
-	//     return &service.ProductDataProcessor{}, nil
-	// case "order":
-	//     return &service.OrderDataProcessor{}, nil
-	default:
-		return nil, fmt.Errorf("不支持的数据类型: %s", dataType)
+// GetDataSchema 获取数据类型的字段结构（字段名、类型、是否必填、可选值）及支持的文件类型
+func (h *ImportExportHandler) GetDataSchema(c *gin.Context) {
+	dataType := c.Query("data_type")
+	if dataType == "" {
+		utils.Error(c, http.StatusBadRequest, "数据类型不能为空")
+		return
+	}
+
+	processor, err := h.importExportService.GetDataProcessor(dataType)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	schema, err := h.importExportService.GetDataSchema(processor)
+	if err != nil {
+		logger.Error("获取数据结构失败", zap.String("data_type", dataType), zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.Success(c, schema)
+}
+
+// busy 导入导出并发已满，返回 429 并提示重试时间
+func (h *ImportExportHandler) busy(c *gin.Context) {
+	retryAfter := int(h.importExportService.RetryAfter().Seconds())
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	utils.Error(c, http.StatusTooManyRequests, service.ErrImportExportBusy.Error())
+}
+
+// GetLoad 查看本实例导入导出的并发和排队情况
+func (h *ImportExportHandler) GetLoad(c *gin.Context) {
+	utils.Success(c, h.importExportService.Load())
+}
+
+// setContentDisposition 设置 Content-Disposition，文件名含空格等字符时加引号，非 ASCII 字符按 RFC 2231 编码
+func setContentDisposition(c *gin.Context, disposition, filename string) {
+	value := mime.FormatMediaType(disposition, map[string]string{"filename": filename})
+	if value == "" {
+		// 文件名无法编码时不携带文件名，由浏览器按地址决定
+		value = disposition
 	}
+	c.Header("Content-Disposition", value)
 }
 
 // getContentType 根据文件类型获取Content-Type
@@ -309,6 +807,8 @@ func (h *ImportExportHandler) getContentType(fileType string) string {
 		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 	case "json":
 		return "application/json; charset=utf-8"
+	case "xml":
+		return "application/xml; charset=utf-8"
 	default:
 		return "application/octet-stream"
 	}
@@ -334,6 +834,12 @@ func (h *ImportExportHandler) GetFileInfo(c *gin.Context) {
 		UploadTime:   time.Now(),
 	}
 
+	tags, err := h.fileService.FileTags(c.Request.Context(), fileID)
+	if err != nil && !errors.Is(err, service.ErrFileTagsUnavailable) {
+		logger.Warn("查询文件标签失败", zap.String("file_id", fileID), zap.Error(err))
+	}
+	fileInfo.Tags = tags
+
 	utils.Success(c, fileInfo)
 }
 
@@ -348,35 +854,35 @@ func (h *ImportExportHandler) GetImportTemplate(c *gin.Context) {
 	}
 
 	// 获取处理器
-	processor, err := h.getDataProcessor(dataType)
+	processor, err := h.importExportService.GetDataProcessor(dataType)
 	if err != nil {
 		utils.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// 创建空数据用于生成模板
-	emptyData := processor.CreateEmptySlice()
-
-	// 配置导出参数
-	exportConfig := &utils.ExportConfig{
-		FileType: fileType,
-		FileName: fmt.Sprintf("%s_template.%s", dataType, fileType),
-		Headers:  processor.GetExportHeaders(),
-		FieldMap: processor.GetExportFieldMap(),
-	}
-
-	// 生成模板文件
-	fileData, err := utils.ExportData(emptyData, exportConfig)
+	// 获取模板文件（优先使用缓存）
+	template, err := h.importExportService.GetImportTemplate(c.Request.Context(), processor, fileType)
 	if err != nil {
 		utils.Error(c, http.StatusInternalServerError, "生成模板失败: "+err.Error())
 		return
 	}
 
 	// 设置响应头
-	c.Header("Content-Disposition", "attachment; filename="+exportConfig.FileName)
+	setContentDisposition(c, "attachment", template.FileName)
 	c.Header("Content-Type", h.getContentType(fileType))
-	c.Header("Content-Length", strconv.Itoa(len(fileData)))
+	c.Header("Content-Length", strconv.Itoa(len(template.Data)))
 
 	// 发送模板文件
-	c.Data(http.StatusOK, h.getContentType(fileType), fileData)
+	c.Data(http.StatusOK, h.getContentType(fileType), template.Data)
+}
+
+// FindDuplicateFiles 重复文件报告，按内容（MD5+大小）分组列出可清理的文件 (管理员)
+func (h *ImportExportHandler) FindDuplicateFiles(c *gin.Context) {
+	report, err := h.fileService.FindDuplicateFiles(c.Request.Context())
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Error("查找重复文件失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "查找重复文件失败")
+		return
+	}
+	utils.Success(c, report)
 }
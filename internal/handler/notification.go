@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/notifier"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// NotificationHandler 通知处理器
+type NotificationHandler struct {
+	dispatcher *notifier.Dispatcher
+}
+
+// NewNotificationHandler 创建通知处理器
+func NewNotificationHandler(dispatcher *notifier.Dispatcher) *NotificationHandler {
+	return &NotificationHandler{dispatcher: dispatcher}
+}
+
+// TestNotificationRequest 测试通知请求
+type TestNotificationRequest struct {
+	Channel string `json:"channel" binding:"required"` // email 或 webhook
+	To      string `json:"to"`                         // 接收者，邮件渠道必填
+}
+
+// SendTestNotification 发送测试通知 (管理员)
+// 通过已配置的通知渠道发送固定内容的消息，用于验证 SMTP/Webhook 配置
+func (h *NotificationHandler) SendTestNotification(c *gin.Context) {
+	var req TestNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	operator := ctxutil.Username(c)
+	msg := &notifier.Message{
+		To:      req.To,
+		Subject: "Charlotte 测试通知",
+		Body:    fmt.Sprintf("这是一条测试通知，由 %v 于 %s 发送。收到此消息说明通知渠道配置正确。", operator, time.Now().Format(time.RFC3339)),
+		Extra:   map[string]string{"type": "test"},
+	}
+
+	start := time.Now()
+	err := h.dispatcher.Send(c.Request.Context(), req.Channel, msg)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		logger.Warn("测试通知发送失败",
+			zap.String("channel", req.Channel),
+			zap.String("to", req.To),
+			zap.Error(err))
+
+		code := http.StatusBadGateway
+		if errors.Is(err, notifier.ErrChannelNotConfigured) {
+			code = http.StatusBadRequest
+		}
+		c.JSON(code, utils.Response{
+			Code:    code,
+			Message: "测试通知发送失败",
+			Data: gin.H{
+				"channel":            req.Channel,
+				"to":                 req.To,
+				"error":              err.Error(),
+				"elapsed_ms":         elapsed.Milliseconds(),
+				"available_channels": h.dispatcher.Channels(),
+			},
+		})
+		return
+	}
+
+	logger.Info("测试通知发送成功", zap.String("channel", req.Channel), zap.String("to", req.To))
+	utils.Success(c, gin.H{
+		"channel":    req.Channel,
+		"to":         req.To,
+		"elapsed_ms": elapsed.Milliseconds(),
+	})
+}
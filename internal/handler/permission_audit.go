@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// PermissionAuditHandler 权限决策日志处理器
+type PermissionAuditHandler struct {
+	audits *service.PermissionAuditService
+}
+
+// NewPermissionAuditHandler 创建权限决策日志处理器
+func NewPermissionAuditHandler(audits *service.PermissionAuditService) *PermissionAuditHandler {
+	return &PermissionAuditHandler{audits: audits}
+}
+
+// ListPermissionAudits 查询权限决策日志 (管理员)
+// 支持 actor_id、resource、resource_id、operation、decision、start、end 过滤及 page、size 分页参数
+func (h *PermissionAuditHandler) ListPermissionAudits(c *gin.Context) {
+	var req service.ListPermissionAuditsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Size < 1 || req.Size > 100 {
+		req.Size = 20
+	}
+
+	audits, total, err := h.audits.List(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrPermissionAuditDisabled) {
+			utils.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		logger.Error("查询权限决策日志失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"list":  audits,
+		"total": total,
+		"page":  req.Page,
+		"size":  req.Size,
+	})
+}
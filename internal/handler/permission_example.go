@@ -3,6 +3,7 @@ package handler
 import (
 	"github.com/gin-gonic/gin"
 
+	"github.com/VennLe/charlotte/internal/ctxutil"
 	"github.com/VennLe/charlotte/internal/middleware"
 	"github.com/VennLe/charlotte/pkg/utils"
 )
@@ -69,8 +70,8 @@ func (h *PermissionExampleHandler) PublicExample(c *gin.Context) {
 
 // UserOnlyExample 仅限登录用户访问的示例
 func (h *PermissionExampleHandler) UserOnlyExample(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	userRole, _ := c.Get("user_role")
+	userID, _ := ctxutil.UserID(c)
+	userRole := ctxutil.UserRole(c)
 
 	utils.Success(c, gin.H{
 		"message": "仅限登录用户访问",
@@ -82,8 +83,8 @@ func (h *PermissionExampleHandler) UserOnlyExample(c *gin.Context) {
 
 // VIPOnlyExample VIP用户专属接口示例
 func (h *PermissionExampleHandler) VIPOnlyExample(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	userRole, _ := c.Get("user_role")
+	userID, _ := ctxutil.UserID(c)
+	userRole := ctxutil.UserRole(c)
 
 	utils.Success(c, gin.H{
 		"message": "VIP用户专属接口",
@@ -95,8 +96,8 @@ func (h *PermissionExampleHandler) VIPOnlyExample(c *gin.Context) {
 
 // AdminOnlyExample 管理员专属接口示例
 func (h *PermissionExampleHandler) AdminOnlyExample(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	userRole, _ := c.Get("user_role")
+	userID, _ := ctxutil.UserID(c)
+	userRole := ctxutil.UserRole(c)
 
 	utils.Success(c, gin.H{
 		"message": "管理员专属接口",
@@ -108,8 +109,8 @@ func (h *PermissionExampleHandler) AdminOnlyExample(c *gin.Context) {
 
 // SuperAdminOnlyExample 超级管理员专属接口示例
 func (h *PermissionExampleHandler) SuperAdminOnlyExample(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	userRole, _ := c.Get("user_role")
+	userID, _ := ctxutil.UserID(c)
+	userRole := ctxutil.UserRole(c)
 
 	utils.Success(c, gin.H{
 		"message": "超级管理员专属接口",
@@ -122,12 +123,12 @@ func (h *PermissionExampleHandler) SuperAdminOnlyExample(c *gin.Context) {
 // GetUserExample 获取用户信息（需要读取权限）
 func (h *PermissionExampleHandler) GetUserExample(c *gin.Context) {
 	userID := c.Param("id")
-	permissionResult, _ := c.Get("permission_result")
+	scope := middleware.GetPermissionScope(c)
 
 	utils.Success(c, gin.H{
 		"message":    "获取用户信息成功",
 		"user_id":    userID,
-		"permission": permissionResult,
+		"permission": scope,
 		"data": gin.H{
 			"username": "示例用户",
 			"email":    "user@example.com",
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// ReadOnlyHandler 只读模式运维处理器
+type ReadOnlyHandler struct {
+	mode *service.ReadOnlyMode
+}
+
+// NewReadOnlyHandler 创建只读模式运维处理器
+func NewReadOnlyHandler(mode *service.ReadOnlyMode) *ReadOnlyHandler {
+	return &ReadOnlyHandler{mode: mode}
+}
+
+// SetReadOnlyRequest 切换只读模式请求
+type SetReadOnlyRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Message string `json:"message" binding:"max=200"` // 为空时使用配置的提示
+}
+
+// GetReadOnly 查看只读模式状态
+func (h *ReadOnlyHandler) GetReadOnly(c *gin.Context) {
+	utils.Success(c, h.mode.Status(c.Request.Context()))
+}
+
+// SetReadOnly 运行时开启或关闭只读模式 (超级管理员)
+func (h *ReadOnlyHandler) SetReadOnly(c *gin.Context) {
+	var req SetReadOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	status, err := h.mode.Set(c.Request.Context(), *req.Enabled, req.Message, ctxutil.Username(c))
+	if err != nil {
+		if errors.Is(err, service.ErrReadOnlyLocked) {
+			utils.Error(c, http.StatusConflict, err.Error())
+			return
+		}
+		logger.Error("切换只读模式失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "切换只读模式失败")
+		return
+	}
+
+	utils.Success(c, status)
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/VennLe/charlotte/pkg/scheduler"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// SchedulerHandler 定时任务运维处理器
+type SchedulerHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+// NewSchedulerHandler 创建定时任务运维处理器
+func NewSchedulerHandler(s *scheduler.Scheduler) *SchedulerHandler {
+	return &SchedulerHandler{scheduler: s}
+}
+
+// ListJobs 查看本实例定时任务的运行状态（上次执行时间、耗时、错误等）
+func (h *SchedulerHandler) ListJobs(c *gin.Context) {
+	utils.Success(c, gin.H{
+		"jobs": h.scheduler.Statuses(),
+	})
+}
@@ -1,12 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/ctxutil"
 	"github.com/VennLe/charlotte/internal/service"
 	"github.com/VennLe/charlotte/pkg/logger"
 	"github.com/VennLe/charlotte/pkg/utils"
@@ -30,10 +35,21 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
+	req.ClientIP = utils.ClientIP(c)
+
 	user, err := h.userService.Register(c.Request.Context(), &req)
 	if err != nil {
-		logger.Error("用户注册失败", zap.Error(err))
-		utils.Error(c, http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, service.ErrRegistrationRateLimited):
+			utils.Error(c, http.StatusTooManyRequests, err.Error())
+		case errors.Is(err, service.ErrEmailDomainNotAllowed):
+			utils.Error(c, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrCaptchaRequired), errors.Is(err, service.ErrCaptchaInvalid):
+			utils.Error(c, http.StatusBadRequest, err.Error())
+		default:
+			logger.Error("用户注册失败", zap.Error(err))
+			utils.Error(c, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -53,6 +69,18 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// 会话认证模式：创建服务端会话，会话ID通过 HttpOnly Cookie 下发
+	if h.userService.SessionsEnabled() {
+		resp, session, err := h.userService.LoginSession(c.Request.Context(), &req, utils.ClientIP(c), c.Request.UserAgent())
+		if err != nil {
+			utils.Error(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		setSessionCookie(c, session.ID, int(time.Until(session.ExpiresAt).Seconds()))
+		utils.Success(c, resp)
+		return
+	}
+
 	resp, err := h.userService.Login(c.Request.Context(), &req)
 	if err != nil {
 		utils.Error(c, http.StatusUnauthorized, err.Error())
@@ -62,6 +90,42 @@ func (h *UserHandler) Login(c *gin.Context) {
 	utils.Success(c, resp)
 }
 
+// Logout 用户登出，会话认证模式下删除服务端会话并清除 Cookie
+// JWT 认证模式下令牌无状态，由客户端丢弃；需要立即吊销时使用令牌轮换
+func (h *UserHandler) Logout(c *gin.Context) {
+	if !h.userService.SessionsEnabled() {
+		utils.Success(c, nil)
+		return
+	}
+
+	sessionID, _ := c.Cookie(config.Global.Session.CookieName)
+	if err := h.userService.Logout(c.Request.Context(), sessionID); err != nil {
+		logger.Error("删除会话失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "登出失败")
+		return
+	}
+	setSessionCookie(c, "", -1)
+	utils.Success(c, nil)
+}
+
+// setSessionCookie 写入会话 Cookie，maxAge 小于 0 时删除 Cookie
+func setSessionCookie(c *gin.Context, sessionID string, maxAge int) {
+	cfg := config.Global.Session
+	c.SetSameSite(sameSiteMode(cfg.SameSite))
+	c.SetCookie(cfg.CookieName, sessionID, maxAge, "/", cfg.Domain, cfg.Secure, true)
+}
+
+func sameSiteMode(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
 // GetUsers 获取用户列表
 func (h *UserHandler) GetUsers(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -75,6 +139,13 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 		size = 10
 	}
 
+	// 稀疏字段集：?fields=id,username 只返回指定字段
+	fields, err := utils.ParseFields(c, service.UserInfo{})
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	users, total, err := h.userService.GetUserList(c.Request.Context(), page, size, keyword)
 	if err != nil {
 		logger.Error("获取用户列表失败", zap.Error(err))
@@ -82,14 +153,37 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 		return
 	}
 
+	// 列表未变化时返回304，减少轮询流量
+	var lastUpdated time.Time
+	for _, user := range users {
+		if user.UpdatedAt.After(lastUpdated) {
+			lastUpdated = user.UpdatedAt
+		}
+	}
+	if utils.NotModified(c, utils.ListETag(lastUpdated, len(users), total, fields.String())) {
+		return
+	}
+
 	utils.Success(c, gin.H{
-		"list":  users,
+		"list":  fields.Apply(users),
 		"total": total,
 		"page":  page,
 		"size":  size,
 	})
 }
 
+// GetUserStats 获取用户统计（按角色、状态分组）
+func (h *UserHandler) GetUserStats(c *gin.Context) {
+	stats, err := h.userService.GetUserStats(c.Request.Context())
+	if err != nil {
+		logger.Error("获取用户统计失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "获取失败")
+		return
+	}
+
+	utils.Success(c, stats)
+}
+
 // GetUser 获取单个用户
 func (h *UserHandler) GetUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -124,7 +218,8 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	utils.Success(c, user)
 }
 
-// UpdateUser 更新用户
+// UpdateUser 部分更新用户 (PATCH)
+// 只修改请求体中出现的字段，零值同样生效：{"nickname": ""} 或 {"nickname": null} 会清空昵称
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -138,19 +233,56 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// 防止修改敏感字段
+	// 忽略只读字段，客户端可直接提交 GET 返回的对象
 	delete(updates, "id")
 	delete(updates, "password")
 	delete(updates, "created_at")
 
 	if err := h.userService.UpdateUser(c.Request.Context(), uint(id), updates); err != nil {
-		utils.Error(c, http.StatusInternalServerError, err.Error())
+		h.handleUpdateError(c, err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": "更新成功"})
+}
+
+// ReplaceUser 整体替换用户 (PUT)
+// 请求体须为完整表示：username、email 必填，未传的 nickname/avatar/phone/tags 会被清空
+func (h *UserHandler) ReplaceUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "用户ID格式错误")
+		return
+	}
+
+	var req service.ReplaceUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.userService.ReplaceUser(c.Request.Context(), uint(id), &req); err != nil {
+		h.handleUpdateError(c, err)
 		return
 	}
 
 	utils.Success(c, gin.H{"message": "更新成功"})
 }
 
+// handleUpdateError 将更新用户的错误转换为响应
+func (h *UserHandler) handleUpdateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFieldNotUpdatable),
+		errors.Is(err, service.ErrInvalidUserField),
+		errors.Is(err, service.ErrEmptyUpdate):
+		utils.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrRecordNotFound):
+		utils.Error(c, http.StatusNotFound, "用户不存在")
+	default:
+		utils.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
 // DeleteUser 删除用户
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -167,6 +299,77 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	utils.Success(c, gin.H{"message": "删除成功"})
 }
 
+// MergeUsers 合并重复用户 (超级管理员)
+func (h *UserHandler) MergeUsers(c *gin.Context) {
+	var req service.MergeUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	operator, _ := ctxutil.UserID(c)
+
+	result, err := h.userService.MergeUsers(c.Request.Context(), req.CanonicalID, req.DuplicateID, operator)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMergeSameUser):
+			utils.Error(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrRecordNotFound):
+			utils.Error(c, http.StatusNotFound, "用户不存在")
+		default:
+			logger.Error("合并用户失败", zap.Error(err))
+			utils.Error(c, http.StatusInternalServerError, "合并用户失败: "+err.Error())
+		}
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Info("管理员合并用户",
+		zap.Uint("canonical_id", req.CanonicalID),
+		zap.Uint("duplicate_id", req.DuplicateID),
+		zap.Uint("operator", operator))
+
+	utils.Success(c, result)
+}
+
+// RotateTokens 吊销用户的全部令牌，可选签发新令牌；仅本人或超级管理员可调用
+func (h *UserHandler) RotateTokens(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	var req service.RotateTokensRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+			return
+		}
+	}
+
+	operator, _ := ctxutil.UserID(c)
+	if operator != uint(id) && ctxutil.UserRole(c) != "superadmin" {
+		utils.Error(c, http.StatusForbidden, "只能轮换自己的令牌")
+		return
+	}
+
+	result, err := h.userService.RotateAllTokens(c.Request.Context(), uint(id), operator, req.IssueNew)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRecordNotFound):
+			utils.Error(c, http.StatusNotFound, "用户不存在")
+		case errors.Is(err, service.ErrAccountDisabled):
+			utils.Error(c, http.StatusForbidden, err.Error())
+		default:
+			logger.Error("轮换令牌失败", zap.Error(err))
+			utils.Error(c, http.StatusInternalServerError, "轮换令牌失败: "+err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, result)
+}
+
 // ChangePassword 修改密码
 func (h *UserHandler) ChangePassword(c *gin.Context) {
 	var req struct {
@@ -180,13 +383,13 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	// 从 JWT 中获取用户ID
-	userID, exists := c.Get("user_id")
+	userID, exists := ctxutil.UserID(c)
 	if !exists {
 		utils.Error(c, http.StatusUnauthorized, "未登录")
 		return
 	}
 
-	if err := h.userService.ChangePassword(c.Request.Context(), userID.(uint), req.OldPassword, req.NewPassword); err != nil {
+	if err := h.userService.ChangePassword(c.Request.Context(), userID, req.OldPassword, req.NewPassword); err != nil {
 		utils.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -196,13 +399,13 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 // GetProfile 获取当前用户信息
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("user_id")
+	userID, exists := ctxutil.UserID(c)
 	if !exists {
 		utils.Error(c, http.StatusUnauthorized, "未登录")
 		return
 	}
 
-	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uint))
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		utils.Error(c, http.StatusNotFound, "用户不存在")
 		return
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// ListUserSessions 查看用户的活跃会话 (管理员)
+func (h *UserHandler) ListUserSessions(c *gin.Context) {
+	id, ok := sessionUserID(c)
+	if !ok {
+		return
+	}
+	h.listSessions(c, id, "")
+}
+
+// TerminateUserSession 终止用户的指定会话 (管理员)
+func (h *UserHandler) TerminateUserSession(c *gin.Context) {
+	id, ok := sessionUserID(c)
+	if !ok {
+		return
+	}
+	h.terminateSession(c, id)
+}
+
+// TerminateUserSessions 终止用户的全部会话 (管理员)
+func (h *UserHandler) TerminateUserSessions(c *gin.Context) {
+	id, ok := sessionUserID(c)
+	if !ok {
+		return
+	}
+	h.terminateAllSessions(c, id, "")
+}
+
+// ListMySessions 查看当前用户的活跃会话，发起请求的会话标记为 current
+func (h *UserHandler) ListMySessions(c *gin.Context) {
+	userID, _ := ctxutil.UserID(c)
+	h.listSessions(c, userID, ctxutil.SessionID(c))
+}
+
+// TerminateMySession 终止当前用户的指定会话
+func (h *UserHandler) TerminateMySession(c *gin.Context) {
+	userID, _ := ctxutil.UserID(c)
+	h.terminateSession(c, userID)
+}
+
+// TerminateMyOtherSessions 终止当前用户除本会话外的全部会话（退出其他设备）
+func (h *UserHandler) TerminateMyOtherSessions(c *gin.Context) {
+	userID, _ := ctxutil.UserID(c)
+	h.terminateAllSessions(c, userID, ctxutil.SessionID(c))
+}
+
+func (h *UserHandler) listSessions(c *gin.Context, userID uint, currentSessionID string) {
+	sessions, err := h.userService.ListSessions(c.Request.Context(), userID, currentSessionID)
+	if err != nil {
+		sessionError(c, "查询会话失败", err)
+		return
+	}
+	utils.Success(c, sessions)
+}
+
+func (h *UserHandler) terminateSession(c *gin.Context, userID uint) {
+	if err := h.userService.TerminateSession(c.Request.Context(), userID, c.Param("session_id")); err != nil {
+		sessionError(c, "终止会话失败", err)
+		return
+	}
+	utils.Success(c, nil)
+}
+
+func (h *UserHandler) terminateAllSessions(c *gin.Context, userID uint, keepSessionID string) {
+	n, err := h.userService.TerminateAllSessions(c.Request.Context(), userID, keepSessionID)
+	if err != nil {
+		sessionError(c, "终止会话失败", err)
+		return
+	}
+	utils.Success(c, gin.H{"terminated": n})
+}
+
+// sessionUserID 解析路径中的用户ID，无效时写入 400 响应
+func sessionUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "无效的用户ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// sessionError 按会话服务的错误类型写入响应
+func sessionError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrSessionsDisabled):
+		utils.Error(c, http.StatusNotImplemented, err.Error())
+	case errors.Is(err, service.ErrSessionNotFound):
+		utils.Error(c, http.StatusNotFound, err.Error())
+	default:
+		logger.FromContext(c.Request.Context()).Error(message, zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// WebhookHandler 领域事件 Webhook 订阅管理处理器
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler 创建 Webhook 处理器
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// ListWebhooks 获取全部 Webhook 订阅
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subs, err := h.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		logger.Error("获取 Webhook 订阅失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "获取失败")
+		return
+	}
+	utils.Success(c, gin.H{"list": subs})
+}
+
+// CreateWebhook 创建 Webhook 订阅，响应中包含签名密钥，之后不再返回
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req service.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	operator, _ := ctxutil.UserID(c)
+	resp, err := h.webhookService.CreateSubscription(c.Request.Context(), &req, operator)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhook) {
+			utils.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Error("创建 Webhook 订阅失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "创建失败")
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Info("创建 Webhook 订阅",
+		zap.Uint("webhook_id", resp.ID),
+		zap.Strings("event_types", resp.EventTypes),
+		zap.Uint("operator", operator))
+	utils.Success(c, resp)
+}
+
+// DeleteWebhook 删除 Webhook 订阅
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "无效的订阅ID")
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, service.ErrRecordNotFound) {
+			utils.Error(c, http.StatusNotFound, "订阅不存在")
+			return
+		}
+		logger.Error("删除 Webhook 订阅失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "删除失败")
+		return
+	}
+
+	operator, _ := ctxutil.UserID(c)
+	logger.FromContext(c.Request.Context()).Info("删除 Webhook 订阅",
+		zap.Uint64("webhook_id", id),
+		zap.Uint("operator", operator))
+	utils.Success(c, gin.H{"message": "删除成功"})
+}
+
+// ListDeliveries 获取 Webhook 订阅的投递记录
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "无效的订阅ID")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), uint(id), page, size)
+	if err != nil {
+		logger.Error("获取 Webhook 投递记录失败", zap.Error(err))
+		utils.Error(c, http.StatusInternalServerError, "获取失败")
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"list":  deliveries,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
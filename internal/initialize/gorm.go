@@ -2,10 +2,15 @@ package initialize
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"github.com/VennLe/charlotte/internal/model"
+	"os"
+	"strings"
 	"time"
 
+	mysqlDriver "github.com/go-sql-driver/mysql"
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -15,6 +20,7 @@ import (
 
 	"github.com/VennLe/charlotte/internal/config"
 	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/tracing"
 )
 
 var DB *gorm.DB
@@ -68,6 +74,13 @@ func InitGorm() error {
 		return fmt.Errorf("连接数据库失败: %w", err)
 	}
 
+	// 链路追踪：每条 SQL 作为请求链路中的一个 span
+	if config.Global.Monitoring.TracingEnabled {
+		if err := db.Use(tracing.NewGormPlugin()); err != nil {
+			return fmt.Errorf("注册数据库追踪插件失败: %w", err)
+		}
+	}
+
 	// 配置连接池
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -106,14 +119,38 @@ func InitGorm() error {
 		return fmt.Errorf("数据库 ping 失败: %w", err)
 	}
 
+	// 搜索忽略重音依赖 unaccent 扩展，创建失败（如无权限）时需由 DBA 手动安装
+	if cfg.SearchUnaccent && db.Dialector.Name() == "postgres" {
+		if err := db.Exec("CREATE EXTENSION IF NOT EXISTS unaccent").Error; err != nil {
+			logger.Warn("创建 unaccent 扩展失败，用户搜索将报错，请手动安装或关闭 search_unaccent", zap.Error(err))
+		}
+	}
+
+	if cfg.StatementTimeout > 0 && cfg.Type == "sqlite" {
+		logger.Warn("SQLite 不支持语句超时，statement_timeout 配置不生效")
+	}
+
 	DB = db
 	logger.Info("数据库连接成功",
 		zap.String("type", cfg.Type),
 		zap.String("host", cfg.Host),
-		zap.String("dbname", cfg.DBName))
+		zap.String("dbname", cfg.DBName),
+		zap.Int("statement_timeout", cfg.StatementTimeout))
 	return nil
 }
 
+// CloseGorm 关闭数据库连接池，应在最后关闭，等待其他组件中进行的查询结束
+func CloseGorm() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 // generateDSN 根据数据库类型生成DSN字符串
 func generateDSN(cfg config.DatabaseConfig) (string, error) {
 	switch cfg.Type {
@@ -132,8 +169,21 @@ func generateDSN(cfg config.DatabaseConfig) (string, error) {
 			loc = "Local"
 		}
 		
-		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=%t&loc=%s",
-			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName, charset, parseTime, loc), nil
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=%t&loc=%s",
+			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName, charset, parseTime, loc)
+
+		tlsParam, err := mysqlTLSParam(cfg)
+		if err != nil {
+			return "", err
+		}
+		if tlsParam != "" {
+			dsn += "&tls=" + tlsParam
+		}
+		// 驱动不识别的参数会在建立连接时作为会话变量设置
+		if cfg.StatementTimeout > 0 {
+			dsn += fmt.Sprintf("&max_execution_time=%d", cfg.StatementTimeout*1000)
+		}
+		return dsn, nil
 		
 	case "sqlite":
 		// SQLite DSN格式: file:path?cache=shared&mode=rwc
@@ -144,15 +194,142 @@ func generateDSN(cfg config.DatabaseConfig) (string, error) {
 		return fmt.Sprintf("file:%s?cache=shared&mode=rwc", path), nil
 		
 	case "postgres", "":
-		// PostgreSQL DSN格式: host= user= password= dbname= port= sslmode=disable TimeZone=Asia/Shanghai
-		return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Shanghai",
-			cfg.Host, cfg.User, cfg.Password, cfg.DBName, cfg.Port), nil
+		// PostgreSQL DSN格式: host= user= password= dbname= port= sslmode= TimeZone=Asia/Shanghai
+		sslMode, err := resolveSSLMode(cfg)
+		if err != nil {
+			return "", err
+		}
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=Asia/Shanghai",
+			cfg.Host, cfg.User, cfg.Password, cfg.DBName, cfg.Port, sslMode)
+		if sslMode != sslModeDisable {
+			if cfg.SSLRootCert != "" {
+				dsn += " sslrootcert=" + cfg.SSLRootCert
+			}
+			if cfg.SSLCert != "" {
+				dsn += " sslcert=" + cfg.SSLCert
+			}
+			if cfg.SSLKey != "" {
+				dsn += " sslkey=" + cfg.SSLKey
+			}
+		}
+		// 非连接参数作为运行时参数在建立连接时发送，对每个连接生效
+		if cfg.StatementTimeout > 0 {
+			dsn += fmt.Sprintf(" statement_timeout=%d", cfg.StatementTimeout*1000)
+		}
+		return dsn, nil
 		
 	default:
 		return "", fmt.Errorf("不支持的数据库类型: %s", cfg.Type)
 	}
 }
 
+// 数据库TLS模式
+const (
+	sslModeDisable    = "disable"     // 不加密
+	sslModeRequire    = "require"     // 加密但不校验服务端证书
+	sslModeVerifyCA   = "verify-ca"   // 加密并校验服务端证书由可信CA签发
+	sslModeVerifyFull = "verify-full" // 加密并校验证书及主机名
+)
+
+// mysqlTLSConfigName 注册到MySQL驱动的自定义TLS配置名
+const mysqlTLSConfigName = "charlotte"
+
+// resolveSSLMode 解析数据库TLS模式，未配置时 release 模式默认 require，其他模式默认 disable
+func resolveSSLMode(cfg config.DatabaseConfig) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(cfg.SSLMode))
+	if mode == "" {
+		if config.Global.Server.Mode == "release" {
+			return sslModeRequire, nil
+		}
+		return sslModeDisable, nil
+	}
+
+	switch mode {
+	case sslModeDisable, sslModeRequire, sslModeVerifyCA, sslModeVerifyFull:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("不支持的 ssl_mode: %s", cfg.SSLMode)
+	}
+}
+
+// mysqlTLSParam 将TLS模式转换为MySQL驱动的 tls 参数，返回空字符串表示不启用TLS
+// 配置了证书时注册自定义TLS配置；verify-ca 模式校验证书链但不校验主机名
+func mysqlTLSParam(cfg config.DatabaseConfig) (string, error) {
+	mode, err := resolveSSLMode(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	switch mode {
+	case sslModeDisable:
+		return "", nil
+	case sslModeRequire:
+		return "skip-verify", nil
+	}
+
+	if cfg.SSLRootCert == "" && cfg.SSLCert == "" && mode == sslModeVerifyFull {
+		// 使用系统根证书校验
+		return "true", nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	if cfg.SSLRootCert != "" {
+		caPEM, err := os.ReadFile(cfg.SSLRootCert)
+		if err != nil {
+			return "", fmt.Errorf("读取CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return "", fmt.Errorf("解析CA证书失败: %s", cfg.SSLRootCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.SSLCert != "" || cfg.SSLKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return "", fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if mode == sslModeVerifyCA {
+		// 跳过默认校验（含主机名），仅校验证书链
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyCertChain(tlsConfig.RootCAs)
+	}
+
+	if err := mysqlDriver.RegisterTLSConfig(mysqlTLSConfigName, tlsConfig); err != nil {
+		return "", fmt.Errorf("注册MySQL TLS配置失败: %w", err)
+	}
+	return mysqlTLSConfigName, nil
+}
+
+// verifyCertChain 返回只校验证书链、不校验主机名的证书验证函数
+func verifyCertChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("服务端未提供证书")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		return err
+	}
+}
+
 // Migrate 执行数据库迁移
 func Migrate() error {
 	if DB == nil {
@@ -174,10 +351,7 @@ func Migrate() error {
 
 	// 自动迁移表结构
 	if config.Global.Migrate.AutoMigrate {
-		models := []interface{}{
-			&model.User{},
-			// 在这里添加其他模型...
-		}
+		models := migrationModels()
 
 		err := DB.AutoMigrate(models...)
 		if err != nil {
@@ -186,6 +360,11 @@ func Migrate() error {
 		}
 
 		logger.Info("数据库自动迁移完成", zap.Int("models_count", len(models)))
+
+		if err := dropLegacyUniqueIndexes(); err != nil {
+			logger.Error("删除旧唯一索引失败", zap.Error(err))
+			return err
+		}
 	}
 
 	// 如果需要创建索引
@@ -199,19 +378,19 @@ func Migrate() error {
 	return nil
 }
 
-// createIndexes 创建数据库索引
-func createIndexes() error {
-	// 用户表索引
-	if err := DB.Exec(`CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)`).Error; err != nil {
-		return err
-	}
-	if err := DB.Exec(`CREATE INDEX IF NOT EXISTS idx_users_username ON users(username)`).Error; err != nil {
-		return err
-	}
-	if err := DB.Exec(`CREATE INDEX IF NOT EXISTS idx_users_status ON users(status)`).Error; err != nil {
-		return err
+// dropLegacyUniqueIndexes 删除用户表旧的全表唯一索引
+// 用户名、邮箱已改为仅约束未删除记录的部分唯一索引（idx_users_*_active），
+// 旧索引包含软删除的记录，会导致已删除用户的邮箱无法重新注册
+func dropLegacyUniqueIndexes() error {
+	migrator := DB.Migrator()
+	for _, name := range []string{"idx_users_username", "idx_users_email"} {
+		if !migrator.HasIndex(&model.User{}, name) {
+			continue
+		}
+		if err := migrator.DropIndex(&model.User{}, name); err != nil {
+			return err
+		}
+		logger.Info("已删除旧唯一索引", zap.String("index", name))
 	}
-
-	logger.Info("数据库索引创建完成")
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,156 @@
+package initialize
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/internal/model"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// migrationModels 需要迁移的模型，普通索引、唯一索引和复合索引通过模型的 GORM 标签声明
+func migrationModels() []interface{} {
+	return []interface{}{
+		&model.User{},
+		&model.AuditLog{},
+		&model.PermissionAuditLog{},
+
+		// 权限相关表
+		&dao.UserRole{},
+		&dao.RolePermission{},
+		&model.UserGroup{},
+		&model.PermissionTag{},
+		&model.UserGroupPermission{},
+		&model.UserGroupMember{},
+		&model.UserPermission{},
+
+		// Webhook
+		&model.WebhookSubscription{},
+		&model.WebhookDelivery{},
+
+		// 文件记录与标签
+		&model.FileRecord{},
+		&model.FileTag{},
+		// 在这里添加其他模型...
+	}
+}
+
+// IndexSpec 无法用 GORM 标签表达的索引（函数索引、带条件的部分索引等）
+type IndexSpec struct {
+	Name    string // 索引名
+	Columns string // 列或表达式，如 "user_id, role" 或 "LOWER(email)"
+	Unique  bool   // 是否唯一索引
+	// Where 部分索引条件，仅 PostgreSQL、SQLite 支持；其他数据库上普通索引去掉条件创建，唯一索引跳过
+	Where string
+}
+
+// indexRegistry 各模型额外的索引
+var indexRegistry = []struct {
+	model   interface{}
+	indexes []IndexSpec
+}{
+	{
+		// GetUserRole/SetUserRole 按 user_id + is_active 查询当前有效角色
+		model: &dao.UserRole{},
+		indexes: []IndexSpec{
+			{Name: "idx_user_roles_active", Columns: "user_id, role", Where: "is_active = true AND deleted_at IS NULL"},
+		},
+	},
+	{
+		// 用户组成员列表按组和状态筛选
+		model: &model.UserGroupMember{},
+		indexes: []IndexSpec{
+			{Name: "idx_user_group_members_group_status", Columns: "user_group_id, status", Where: "deleted_at IS NULL"},
+		},
+	},
+}
+
+// createIndexes 创建数据库索引
+// 先补齐模型标签中声明但数据库中缺失的索引（未开启 auto_migrate 时同样生效），再创建登记的额外索引
+func createIndexes() error {
+	migrator := DB.Migrator()
+	created := 0
+
+	for _, m := range migrationModels() {
+		if !migrator.HasTable(m) {
+			continue
+		}
+		stmt := &gorm.Statement{DB: DB}
+		if err := stmt.Parse(m); err != nil {
+			return err
+		}
+		for _, idx := range stmt.Schema.ParseIndexes() {
+			if migrator.HasIndex(m, idx.Name) {
+				continue
+			}
+			if err := migrator.CreateIndex(m, idx.Name); err != nil {
+				return fmt.Errorf("创建索引 %s 失败: %w", idx.Name, err)
+			}
+			created++
+		}
+	}
+
+	for _, entry := range indexRegistry {
+		if !migrator.HasTable(entry.model) {
+			continue
+		}
+		for _, spec := range entry.indexes {
+			if migrator.HasIndex(entry.model, spec.Name) {
+				continue
+			}
+			ok, err := createIndex(entry.model, spec)
+			if err != nil {
+				return fmt.Errorf("创建索引 %s 失败: %w", spec.Name, err)
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+
+	logger.Info("数据库索引创建完成", zap.Int("created", created))
+	return nil
+}
+
+// createIndex 按登记的定义创建索引，数据库不支持部分索引时返回 false 表示已跳过
+func createIndex(m interface{}, spec IndexSpec) (bool, error) {
+	stmt := &gorm.Statement{DB: DB}
+	if err := stmt.Parse(m); err != nil {
+		return false, err
+	}
+
+	where := spec.Where
+	if where != "" && !supportsPartialIndex(DB) {
+		if spec.Unique {
+			logger.Warn("数据库不支持部分唯一索引，跳过", zap.String("index", spec.Name))
+			return false, nil
+		}
+		where = ""
+	}
+
+	sql := "CREATE INDEX ? ON ? (" + spec.Columns + ")"
+	if spec.Unique {
+		sql = "CREATE UNIQUE INDEX ? ON ? (" + spec.Columns + ")"
+	}
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	if err := DB.Exec(sql, clause.Column{Name: spec.Name}, clause.Table{Name: stmt.Schema.Table}).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// supportsPartialIndex 是否支持带 WHERE 条件的部分索引
+func supportsPartialIndex(db *gorm.DB) bool {
+	switch db.Dialector.Name() {
+	case "postgres", "sqlite":
+		return true
+	default:
+		return false
+	}
+}
@@ -1,20 +1,28 @@
 package initialize
 
 import (
+	"errors"
 	"fmt"
+	"os"
+
 	"github.com/IBM/sarama"
 	"go.uber.org/zap"
 
 	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/dao"
 	"github.com/VennLe/charlotte/pkg/kafka"
 	"github.com/VennLe/charlotte/pkg/logger"
 )
 
+// 生产者由 pkg/kafka 统一管理，通过 kafka.GetProducer 获取
 var (
-	KafkaProducer *sarama.SyncProducer
 	KafkaConsumer sarama.ConsumerGroup
+
+	// KafkaCacheConsumer 缓存失效消费者（每个实例独立且固定的消费者组）
+	KafkaCacheConsumer sarama.ConsumerGroup
 )
 
+// InitKafka 初始化 Kafka 生产者和消费者，重复调用时不会重复创建
 func InitKafka() error {
 	cfg := config.Global.Kafka
 
@@ -23,28 +31,19 @@ func InitKafka() error {
 		return nil
 	}
 
-	// 初始化生产者
-	saramaConfig := sarama.NewConfig()
-	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
-	saramaConfig.Producer.Retry.Max = 3
-	saramaConfig.Producer.Return.Successes = true
-	saramaConfig.Producer.Return.Errors = true
-
-	producer, err := sarama.NewSyncProducer(cfg.Brokers, saramaConfig)
-	if err != nil {
+	// 初始化生产者，未初始化时 kafka.GetProducer 返回丢弃消息的空实现
+	if _, err := kafka.InitProducer(cfg.Brokers); err != nil {
 		// 开发模式下只警告，不阻止启动
 		if config.Global.Server.Mode == "debug" {
 			logger.Warn("Kafka 连接失败，将以无消息队列模式运行", zap.Error(err))
-			KafkaProducer = nil
 			return nil
 		}
 		return fmt.Errorf("初始化 Kafka 生产者失败: %w", err)
 	}
-	KafkaProducer = &producer
 
 	// 初始化消费者 (可选)
 	consumerTopics := []string{cfg.Topic}
-	if cfg.GroupID != "" && len(consumerTopics) > 0 && consumerTopics[0] != "" {
+	if KafkaConsumer == nil && cfg.GroupID != "" && len(consumerTopics) > 0 && consumerTopics[0] != "" {
 		consumer, err := kafka.InitConsumerGroup(cfg.Brokers, cfg.GroupID, consumerTopics)
 		if err != nil {
 			return fmt.Errorf("初始化 Kafka 消费者失败: %w", err)
@@ -55,27 +54,71 @@ func InitKafka() error {
 			zap.Strings("topics", consumerTopics))
 	}
 
+	// 初始化缓存失效消费者 (可选)
+	if err := initCacheInvalidationConsumer(cfg, consumerTopics); err != nil {
+		return err
+	}
+
 	logger.Info("Kafka 初始化成功",
 		zap.Strings("brokers", cfg.Brokers),
 		zap.String("topic", cfg.Topic))
 	return nil
 }
 
-// CloseKafka 关闭 Kafka 连接
-func CloseKafka() {
-	if KafkaProducer != nil {
-		if err := (*KafkaProducer).Close(); err != nil {
-			logger.Error("关闭 Kafka 生产者失败", zap.Error(err))
-		} else {
-			logger.Info("Kafka 生产者已关闭")
+// initCacheInvalidationConsumer 初始化缓存失效消费者
+// 业务消费者组内每条消息只会投递给一个实例，因此缓存失效需使用按实例区分的消费者组；
+// 组名在实例重启后保持不变，重启后从上次提交的位置继续消费，也不会在 Kafka 中留下大量废弃的消费者组
+func initCacheInvalidationConsumer(cfg config.KafkaConfig, topics []string) error {
+	if KafkaCacheConsumer != nil || !cfg.CacheInvalidation || Redis == nil || len(topics) == 0 || topics[0] == "" {
+		return nil
+	}
+
+	groupID := cfg.CacheGroupID
+	if groupID == "" {
+		if cfg.GroupID == "" {
+			return nil
 		}
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("获取主机名失败，请配置 kafka.cache_group_id: %w", err)
+		}
+		groupID = fmt.Sprintf("%s-cache-%s", cfg.GroupID, hostname)
 	}
 
+	invalidator := dao.NewCacheInvalidator(Redis, dao.UserCachePrefix)
+	consumer, err := kafka.InitCacheInvalidationConsumer(cfg.Brokers, groupID, topics, invalidator)
+	if err != nil {
+		return fmt.Errorf("初始化 Kafka 缓存失效消费者失败: %w", err)
+	}
+	KafkaCacheConsumer = consumer
+
+	logger.Info("Kafka 缓存失效消费者初始化成功", zap.String("group_id", groupID))
+	return nil
+}
+
+// CloseKafkaConsumers 关闭 Kafka 消费者，应在生产者之前关闭，避免处理中的消息继续发送
+func CloseKafkaConsumers() error {
+	var errs []error
 	if KafkaConsumer != nil {
 		if err := KafkaConsumer.Close(); err != nil {
-			logger.Error("关闭 Kafka 消费者失败", zap.Error(err))
-		} else {
-			logger.Info("Kafka 消费者已关闭")
+			errs = append(errs, fmt.Errorf("关闭 Kafka 消费者失败: %w", err))
+		}
+		KafkaConsumer = nil
+	}
+
+	if KafkaCacheConsumer != nil {
+		if err := KafkaCacheConsumer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("关闭 Kafka 缓存失效消费者失败: %w", err))
 		}
+		KafkaCacheConsumer = nil
+	}
+	return errors.Join(errs...)
+}
+
+// CloseKafkaProducer 关闭 Kafka 生产者，未初始化时不做任何处理
+func CloseKafkaProducer() error {
+	if kafka.SyncProducer() == nil {
+		return nil
 	}
+	return kafka.CloseProducer()
 }
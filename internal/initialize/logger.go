@@ -22,6 +22,10 @@ func InitLogger() {
 	if logConfig.MaxAge == 0 {
 		logConfig.MaxAge = 7
 	}
+	// 开发模式下保留完整日志，不进行采样
+	if config.Global.Server.Mode == "debug" {
+		logConfig.Sampling.Enabled = false
+	}
 
 	logger.Init(logConfig)
 }
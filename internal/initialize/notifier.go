@@ -0,0 +1,41 @@
+package initialize
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/notifier"
+)
+
+// Notifier 通知分发器，未配置任何渠道时发送会返回 ErrChannelNotConfigured
+var Notifier *notifier.Dispatcher
+
+// InitNotifier 初始化通知渠道
+func InitNotifier() {
+	cfg := config.Global.Notification
+	var notifiers []notifier.Notifier
+
+	if cfg.Email.Enabled {
+		notifiers = append(notifiers, notifier.NewSMTPNotifier(notifier.SMTPConfig{
+			Host:     cfg.Email.Host,
+			Port:     cfg.Email.Port,
+			Username: cfg.Email.Username,
+			Password: cfg.Email.Password,
+			From:     cfg.Email.From,
+			Timeout:  time.Duration(cfg.Email.Timeout) * time.Second,
+		}))
+	}
+
+	if cfg.Webhook.Enabled && cfg.Webhook.URL != "" {
+		notifiers = append(notifiers, notifier.NewWebhookNotifier(
+			cfg.Webhook.URL,
+			time.Duration(cfg.Webhook.Timeout)*time.Second,
+		))
+	}
+
+	Notifier = notifier.NewDispatcher(notifiers...)
+	logger.Info("通知渠道初始化完成", zap.Strings("channels", Notifier.Channels()))
+}
@@ -0,0 +1,26 @@
+package initialize
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/password"
+)
+
+// InitPassword 按 security.password 配置设置全局密码哈希器
+func InitPassword() error {
+	cfg := config.Global.Security.Password
+	hasher, err := password.NewHasher(cfg.Algorithm, cfg.BcryptCost, password.Argon2Params{
+		Memory:        cfg.Argon2Memory,
+		Iterations:    cfg.Argon2Iterations,
+		Parallelism:   cfg.Argon2Parallelism,
+		MaxConcurrent: cfg.Argon2MaxConcurrent,
+	})
+	if err != nil {
+		return err
+	}
+	password.SetDefault(hasher)
+	logger.Info("密码哈希初始化完成", zap.String("algorithm", hasher.Algorithm()))
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -36,6 +37,11 @@ func InitRedis() error {
 		testConn.Close()
 	}
 
+	poolTimeout := 2 * time.Second
+	if cfg.PoolTimeout > 0 {
+		poolTimeout = time.Duration(cfg.PoolTimeout) * time.Second
+	}
+
 	// 如果连接成功或生产模式，创建正式连接
 	Redis = redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
@@ -43,7 +49,11 @@ func InitRedis() error {
 		DB:       cfg.DB,
 		PoolSize: cfg.PoolSize,
 		MaxRetries: 0, // 禁用自动重试
-		PoolTimeout: 2 * time.Second,
+		PoolTimeout: poolTimeout,
+		// 空闲连接回收与连接存活时间
+		MinIdleConns:    cfg.MinIdleConns,
+		ConnMaxIdleTime: time.Duration(cfg.IdleTimeout) * time.Second,
+		ConnMaxLifetime: time.Duration(cfg.MaxConnAge) * time.Second,
 		// 禁用连接池的自动重连
 		DialTimeout:  2 * time.Second,
 		ReadTimeout:  2 * time.Second,
@@ -61,8 +71,64 @@ func InitRedis() error {
 		return fmt.Errorf("连接 Redis 失败: %w", err)
 	}
 
+	if cfg.Warmup && cfg.MinIdleConns > 0 {
+		warmupRedisPool(Redis, cfg.MinIdleConns)
+	}
+
 	logger.Info("Redis 连接成功",
 		zap.String("addr", Redis.Options().Addr),
-		zap.Int("pool_size", Redis.Options().PoolSize))
+		zap.Int("pool_size", Redis.Options().PoolSize),
+		zap.Int("min_idle_conns", Redis.Options().MinIdleConns))
 	return nil
 }
+
+// warmupRedisPool 预热连接池：并发建立 n 个连接后归还，避免启动后首批请求承担建连延迟
+// 预热失败只记录日志，不影响启动
+func warmupRedisPool(client *redis.Client, n int) {
+	if poolSize := client.Options().PoolSize; poolSize > 0 && n > poolSize {
+		n = poolSize
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	conns := make([]*redis.Conn, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conns[i] = client.Conn()
+			errs[i] = conns[i].Ping(ctx).Err()
+		}(i)
+	}
+	wg.Wait()
+
+	warmed := 0
+	for i, conn := range conns {
+		if errs[i] == nil {
+			warmed++
+		}
+		// 归还连接到连接池
+		conn.Close()
+	}
+
+	if warmed < n {
+		logger.Warn("Redis 连接池预热未完全成功",
+			zap.Int("warmed", warmed),
+			zap.Int("expected", n),
+			zap.Duration("cost", time.Since(start)))
+		return
+	}
+	logger.Info("Redis 连接池预热完成", zap.Int("conns", warmed), zap.Duration("cost", time.Since(start)))
+}
+
+// CloseRedis 关闭 Redis 连接池，应在依赖 Redis 的组件都停止后调用
+func CloseRedis() error {
+	if Redis == nil {
+		return nil
+	}
+	return Redis.Close()
+}
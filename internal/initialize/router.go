@@ -1,52 +1,115 @@
 package initialize
 
 import (
-	"github.com/IBM/sarama"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
+	"github.com/VennLe/charlotte/internal/config"
 	"github.com/VennLe/charlotte/internal/dao"
 	"github.com/VennLe/charlotte/internal/handler"
 	"github.com/VennLe/charlotte/internal/middleware"
 	"github.com/VennLe/charlotte/internal/router"
 	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/kafka"
+	"github.com/VennLe/charlotte/pkg/logger"
 )
 
 // InitRouter 初始化路由（依赖注入模式）
-func InitRouter() *gin.Engine {
+// buildInfo 为构建时注入的版本信息，用于健康检查
+func InitRouter(buildInfo service.BuildInfo) *gin.Engine {
 	// 初始化数据访问层
 	userDAO := dao.NewUserDAO(DB)
 	permissionDAO := dao.NewUnifiedPermissionDAO(DB)
 
 	// 初始化服务层
 	userService := service.NewUserService(DB)
+	userService.SetRegistrationGuard(service.NewRegistrationGuard(Redis, config.Global.Security.Registration))
+	if Redis != nil {
+		userService.SetTokenVersionCache(Redis)
+	}
+	if config.Global.Session.Enabled {
+		if Redis != nil {
+			userService.SetSessionStore(service.NewSessionStore(Redis, time.Duration(config.Global.Session.Expire)*time.Hour))
+		} else {
+			logger.Error("Redis 不可用，无法启用会话认证，回退为 JWT 认证")
+		}
+	}
 	permissionService := service.NewSimplifiedPermissionService(userDAO, permissionDAO)
 
-	// 初始化健康检查器，处理 Kafka 可能为 nil 的情况
-	var kafkaProducer sarama.SyncProducer
-	if KafkaProducer != nil {
-		kafkaProducer = *KafkaProducer
-	}
-	healthChecker := service.NewHealthChecker(DB, Redis, kafkaProducer)
+	// 初始化健康检查器，Kafka 未初始化时生产者为 nil
+	healthChecker := service.NewHealthChecker(DB, Redis, kafka.SyncProducer(), buildInfo)
 
 	// 初始化服务层
 	fileService := service.NewFileService()
+	fileService.SetTagDAO(dao.NewFileTagDAO(DB))
+	fileService.SetFileDAO(dao.NewFileDAO(DB))
 	importExportService := service.NewImportExportService(fileService)
+	if Redis != nil {
+		// 多实例共享导入模板缓存
+		importExportService.SetTemplateStore(service.NewRedisTemplateStore(Redis))
+	}
+	// 需要依赖注入的数据处理器（如需要数据库连接的关联数据导出）
+	if err := importExportService.RegisterDataProcessor("", service.NewUserGroupsDataProcessor(DB)); err != nil {
+		logger.Error("注册数据处理器失败", zap.Error(err))
+	}
+	importExportCfg := config.Global.ImportExport
+	importExportService.SetConcurrencyLimit(importExportCfg.MaxConcurrent, importExportCfg.MaxQueue,
+		time.Duration(importExportCfg.QueueTimeout)*time.Second)
+	cacheService := service.NewCacheService(Redis)
+
+	// 只读模式，运行时状态通过 Redis 在多实例间共享
+	readOnlyMode := service.NewReadOnlyMode(Redis, config.Global.ReadOnly)
+	if Redis == nil {
+		logger.Warn("Redis 未初始化，运行时切换只读模式仅作用于本实例")
+	}
+
+	// 领域事件 Webhook，禁用时仍可管理订阅，但不推送事件
+	webhookService := service.NewWebhookService(DB, config.Global.Webhook)
+	if config.Global.Webhook.Enabled {
+		userService.SetWebhookService(webhookService)
+		fileService.SetWebhookService(webhookService)
+		importExportService.SetWebhookService(webhookService)
+	}
+
+	// 注册定时任务
+	registerJobs(importExportService, fileService, webhookService)
 
 	// 初始化处理器
 	userHandler := handler.NewUserHandler(userService)
 	healthHandler := handler.NewHealthHandler(healthChecker)
 	importExportHandler := handler.NewImportExportHandler(importExportService, fileService)
+	notificationHandler := handler.NewNotificationHandler(Notifier)
+	cacheHandler := handler.NewCacheHandler(cacheService)
+	schedulerHandler := handler.NewSchedulerHandler(Scheduler)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	readOnlyHandler := handler.NewReadOnlyHandler(readOnlyMode)
+	permissionAuditService := service.NewPermissionAuditService(DB, config.Global.Permission.Audit)
+	permissionAuditHandler := handler.NewPermissionAuditHandler(permissionAuditService)
 
 	// 初始化权限中间件
 	permissionMiddleware := middleware.NewSimplifiedPermissionMiddleware(permissionService)
+	permissionMiddleware.SetAuditService(permissionAuditService)
 
 	// 组装依赖
 	deps := &router.Dependencies{
-		UserHandler:          userHandler,
-		HealthHandler:        healthHandler,
-		ImportExportHandler:  importExportHandler,
-		RedisClient:          Redis, // 如果Redis初始化失败，这里会是nil
-		PermissionMiddleware:  permissionMiddleware,
+		UserHandler:            userHandler,
+		HealthHandler:          healthHandler,
+		ImportExportHandler:    importExportHandler,
+		NotificationHandler:    notificationHandler,
+		CacheHandler:           cacheHandler,
+		SchedulerHandler:       schedulerHandler,
+		WebhookHandler:         webhookHandler,
+		ReadOnlyHandler:        readOnlyHandler,
+		PermissionAuditHandler: permissionAuditHandler,
+		RedisClient:            Redis, // 如果Redis初始化失败，这里会是nil
+		PermissionMiddleware:   permissionMiddleware,
+		TokenVersion:           userService.TokenVersion,
+		ReadOnly:               readOnlyMode.Check,
+	}
+	if userService.SessionsEnabled() {
+		deps.Session = userService.Session
 	}
 
 	return router.NewRouter(deps)
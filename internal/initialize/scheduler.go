@@ -0,0 +1,107 @@
+package initialize
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/scheduler"
+)
+
+// Scheduler 定时任务调度器，后台任务统一在此注册，由 StartScheduler 启动、CloseScheduler 停止
+var Scheduler *scheduler.Scheduler
+
+// InitScheduler 初始化定时任务调度器，Redis 可用时互斥任务通过 Redis 锁保证多实例只执行一次
+func InitScheduler() {
+	var locker scheduler.Locker
+	if Redis != nil {
+		locker = scheduler.NewRedisLocker(Redis)
+	} else {
+		logger.Warn("Redis 未初始化，定时任务仅在本实例内互斥")
+	}
+	Scheduler = scheduler.New(locker)
+}
+
+// registerJobs 注册各服务的定时任务，调度规则为空的任务不注册
+func registerJobs(importExportService *service.ImportExportService, fileService *service.FileService, webhookService *service.WebhookService) {
+	cfg := config.Global.Scheduler
+
+	if cfg.ExportJobCleanup != "" {
+		retention := time.Duration(config.Global.ImportExport.ExportJobRetention) * time.Hour
+		registerJob(scheduler.Job{
+			Name: "export_job_cleanup",
+			Spec: cfg.ExportJobCleanup,
+			Run: func(ctx context.Context) error {
+				removed, err := importExportService.CleanupExportJobs(ctx, retention)
+				if removed > 0 {
+					logger.Info("已清理过期导出任务", zap.Int("count", removed))
+				}
+				if removed := importExportService.CleanupImportJobs(retention); removed > 0 {
+					logger.Info("已清理过期导入任务", zap.Int("count", removed))
+				}
+				return err
+			},
+		})
+	}
+
+	if cfg.ChunkUploadClean != "" {
+		ttl := time.Duration(config.Global.File.ChunkUploadTTL) * time.Hour
+		if ttl <= 0 {
+			ttl = 24 * time.Hour
+		}
+		// 分片保存在本实例的上传目录中，各实例分别清理，不需要互斥
+		registerJob(scheduler.Job{
+			Name: "chunk_upload_cleanup",
+			Spec: cfg.ChunkUploadClean,
+			Run: func(ctx context.Context) error {
+				if removed := fileService.CleanupChunkUploads(ttl); removed > 0 {
+					logger.Info("已清理过期分片上传", zap.Int("count", removed))
+				}
+				return nil
+			},
+		})
+	}
+
+	if cfg.WebhookRetry != "" && config.Global.Webhook.Enabled {
+		registerJob(scheduler.Job{
+			Name:      "webhook_retry",
+			Spec:      cfg.WebhookRetry,
+			Exclusive: true,
+			Timeout:   5 * time.Minute,
+			Run: func(ctx context.Context) error {
+				delivered, err := webhookService.RetryDue(ctx)
+				if delivered > 0 {
+					logger.Info("已重试 Webhook 投递", zap.Int("count", delivered))
+				}
+				return err
+			},
+		})
+	}
+}
+
+func registerJob(job scheduler.Job) {
+	if err := Scheduler.Register(job); err != nil {
+		logger.Error("注册定时任务失败", zap.String("job", job.Name), zap.Error(err))
+	}
+}
+
+// StartScheduler 启动定时任务，scheduler.enabled 为 false 时只注册不执行
+func StartScheduler() {
+	if !config.Global.Scheduler.Enabled {
+		logger.Info("定时任务已禁用")
+		return
+	}
+	Scheduler.Start()
+}
+
+// CloseScheduler 停止定时任务并等待正在执行的任务退出
+func CloseScheduler(ctx context.Context) error {
+	if Scheduler == nil {
+		return nil
+	}
+	return Scheduler.Stop(ctx)
+}
@@ -0,0 +1,47 @@
+package initialize
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/tracing"
+)
+
+// shutdownTracing 关闭链路追踪并刷新未发送的 span
+var shutdownTracing func(context.Context) error
+
+// InitTracing 初始化链路追踪，monitoring.tracing_enabled 为 false 时不做任何处理
+func InitTracing(serviceVersion string) error {
+	cfg := config.Global.Monitoring
+	if !cfg.TracingEnabled {
+		return nil
+	}
+
+	shutdown, err := tracing.Init(tracing.Config{
+		ServiceName:    cfg.TracingServiceName,
+		ServiceVersion: serviceVersion,
+		Endpoint:       cfg.TracingEndpoint,
+		Insecure:       cfg.TracingInsecure,
+		SampleRatio:    cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	shutdownTracing = shutdown
+
+	logger.Info("链路追踪初始化完成",
+		zap.String("endpoint", cfg.TracingEndpoint),
+		zap.Float64("sample_ratio", cfg.TracingSampleRatio))
+	return nil
+}
+
+// CloseTracing 关闭链路追踪并发送剩余的 span
+func CloseTracing(ctx context.Context) error {
+	if shutdownTracing == nil {
+		return nil
+	}
+	return shutdownTracing(ctx)
+}
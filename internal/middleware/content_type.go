@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// 常用请求体类型
+const (
+	MIMEJSON          = "application/json"
+	MIMEMultipartForm = "multipart/form-data"
+	MIMEForm          = "application/x-www-form-urlencoded"
+)
+
+// RequireContentType 请求体类型校验中间件
+// 请求携带请求体但 Content-Type 不在 allowed 中（或缺失、无法解析）时返回 415，
+// 避免错误类型的请求体在绑定阶段得到难以理解的错误；无请求体的请求（如 GET、空 body 的 POST）直接放行
+func RequireContentType(allowed ...string) gin.HandlerFunc {
+	expected := strings.Join(allowed, ", ")
+
+	return func(c *gin.Context) {
+		if c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err == nil {
+			for _, t := range allowed {
+				if strings.EqualFold(mediaType, t) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		utils.Error(c, http.StatusUnsupportedMediaType, "不支持的 Content-Type，应为: "+expected)
+		c.Abort()
+	}
+}
+
+// RequireJSON 要求请求体为 JSON
+func RequireJSON() gin.HandlerFunc {
+	return RequireContentType(MIMEJSON)
+}
+
+// RequireMultipart 要求请求体为 multipart/form-data，用于文件上传
+func RequireMultipart() gin.HandlerFunc {
+	return RequireContentType(MIMEMultipartForm)
+}
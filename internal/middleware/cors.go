@@ -2,23 +2,151 @@ package middleware
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func CORS() gin.HandlerFunc {
+// CORSPolicy CORS策略
+type CORSPolicy struct {
+	AllowOrigins     []string // 允许的来源，"*" 表示任意来源
+	AllowMethods     []string // 允许的方法
+	AllowHeaders     []string // 允许的请求头
+	ExposeHeaders    []string // 暴露给前端的响应头
+	AllowCredentials bool     // 是否允许携带凭证
+	MaxAge           int      // 预检结果缓存时间（秒）
+}
+
+// DefaultCORSPolicy 默认CORS策略（允许任意来源，不允许携带凭证）
+func DefaultCORSPolicy() *CORSPolicy {
+	return &CORSPolicy{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
+		AllowHeaders:  []string{"Content-Type", "Authorization", "X-Requested-With", DefaultRequestIDHeader},
+		ExposeHeaders: []string{"Content-Length", "Access-Control-Allow-Origin", DefaultRequestIDHeader, "Deprecation", "Sunset", "Link"},
+	}
+}
+
+// CORSPolicies 按路由组选择的CORS策略集合
+// 预检请求不会匹配到具体路由，因此由全局中间件根据请求路径所属的路由组选择策略
+type CORSPolicies struct {
+	defaultPolicy *CORSPolicy
+	named         map[string]*CORSPolicy
+	groups        []corsGroupPolicy
+}
+
+type corsGroupPolicy struct {
+	prefix string
+	policy *CORSPolicy
+}
+
+// NewCORSPolicies 创建CORS策略集合
+func NewCORSPolicies(defaultPolicy *CORSPolicy, named map[string]*CORSPolicy) *CORSPolicies {
+	if defaultPolicy == nil {
+		defaultPolicy = DefaultCORSPolicy()
+	}
+	if named == nil {
+		named = make(map[string]*CORSPolicy)
+	}
+	return &CORSPolicies{
+		defaultPolicy: defaultPolicy,
+		named:         named,
+	}
+}
+
+// Apply 将命名策略绑定到路由组，策略不存在时返回 false（路由组继续使用默认策略）
+func (p *CORSPolicies) Apply(group *gin.RouterGroup, name string) bool {
+	policy, ok := p.named[name]
+	if !ok {
+		return false
+	}
+
+	p.groups = append(p.groups, corsGroupPolicy{prefix: group.BasePath(), policy: policy})
+	// 最长前缀优先
+	sort.SliceStable(p.groups, func(i, j int) bool {
+		return len(p.groups[i].prefix) > len(p.groups[j].prefix)
+	})
+	return true
+}
+
+// Middleware 全局CORS中间件
+func (p *CORSPolicies) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
+		p.policyFor(c.Request.URL.Path).handle(c)
+	}
+}
+
+// policyFor 根据请求路径选择策略
+func (p *CORSPolicies) policyFor(path string) *CORSPolicy {
+	for _, g := range p.groups {
+		if path == g.prefix || strings.HasPrefix(path, strings.TrimSuffix(g.prefix, "/")+"/") {
+			return g.policy
+		}
+	}
+	return p.defaultPolicy
+}
+
+// handle 按策略设置CORS响应头
+func (policy *CORSPolicy) handle(c *gin.Context) {
+	origin := c.GetHeader("Origin")
+	preflight := c.Request.Method == http.MethodOptions
+
+	if origin != "" {
+		allowOrigin, ok := policy.allowOrigin(origin)
+		if !ok {
+			// 来源不被允许：预检直接拒绝，普通请求不设置CORS头由浏览器拦截
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
 			return
 		}
 
-		c.Next()
+		header := c.Writer.Header()
+		header.Set("Access-Control-Allow-Origin", allowOrigin)
+		if allowOrigin != "*" {
+			header.Add("Vary", "Origin")
+		}
+		header.Set("Access-Control-Allow-Methods", strings.Join(policy.AllowMethods, ", "))
+		header.Set("Access-Control-Allow-Headers", strings.Join(policy.AllowHeaders, ", "))
+		if len(policy.ExposeHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(policy.ExposeHeaders, ", "))
+		}
+		// 任意来源时不允许携带凭证，否则任意网站都能以用户的 Cookie 调用接口
+		if policy.AllowCredentials && allowOrigin != "*" {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if preflight && policy.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
+		}
 	}
+
+	if preflight {
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+
+	c.Next()
+}
+
+// allowOrigin 返回应写入 Access-Control-Allow-Origin 的值
+// 配置为 "*" 时始终返回 "*"，不回显请求来源；只有明确配置的来源才会回显并允许携带凭证
+func (policy *CORSPolicy) allowOrigin(origin string) (string, bool) {
+	for _, allowed := range policy.AllowOrigins {
+		if allowed == "*" {
+			return "*", true
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// CORS 使用默认策略的CORS中间件
+func CORS() gin.HandlerFunc {
+	return NewCORSPolicies(nil, nil).Middleware()
 }
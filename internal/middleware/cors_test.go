@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveCORS 用给定策略处理一个带 Origin 的请求
+func serveCORS(policy *CORSPolicy, method, origin string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewCORSPolicies(policy, nil).Middleware())
+	r.GET("/api/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(method, "/api/ping", nil)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSWildcardNeverReflectsOrigin(t *testing.T) {
+	const origin = "https://evil.example"
+
+	tests := []struct {
+		name   string
+		policy *CORSPolicy
+	}{
+		{"默认策略", DefaultCORSPolicy()},
+		{"任意来源且配置了允许凭证", &CORSPolicy{AllowOrigins: []string{"*"}, AllowMethods: []string{"GET"}, AllowCredentials: true}},
+	}
+	for _, tt := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodOptions} {
+			t.Run(tt.name+"/"+method, func(t *testing.T) {
+				w := serveCORS(tt.policy, method, origin)
+				if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+					t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "*")
+				}
+				if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+					t.Errorf("Access-Control-Allow-Credentials = %q, want empty", got)
+				}
+			})
+		}
+	}
+}
+
+func TestCORSExplicitOrigins(t *testing.T) {
+	policy := &CORSPolicy{
+		AllowOrigins:     []string{"https://app.example"},
+		AllowMethods:     []string{"GET"},
+		AllowCredentials: true,
+	}
+
+	w := serveCORS(policy, http.MethodGet, "https://app.example")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the configured origin", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, "true")
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want %q", got, "Origin")
+	}
+
+	w = serveCORS(policy, http.MethodGet, "https://evil.example")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("未配置的来源 Access-Control-Allow-Origin = %q, want empty", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("未配置的来源 Access-Control-Allow-Credentials = %q, want empty", got)
+	}
+
+	w = serveCORS(policy, http.MethodOptions, "https://evil.example")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("未配置来源的预检状态码 = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecationPolicy API 弃用策略
+type DeprecationPolicy struct {
+	// DeprecatedAt 弃用时间，为零值时 Deprecation 头为 true
+	DeprecatedAt time.Time
+	// Sunset 计划下线时间，为零值时不输出 Sunset 头
+	Sunset time.Time
+	// DocLink 弃用说明文档地址，输出为 Link rel="deprecation"
+	DocLink string
+	// FromPrefix/ToPrefix 用于计算后继版本地址，如 /api/v1 -> /api/v2，输出为 Link rel="successor-version"
+	FromPrefix string
+	ToPrefix   string
+}
+
+// Deprecation API 弃用中间件
+// 按 RFC 9745 输出 Deprecation 头、按 RFC 8594 输出 Sunset 头，并通过 Link 头指向文档和后继版本，
+// 请求仍正常处理，客户端可据此提前迁移
+func Deprecation(policy DeprecationPolicy) gin.HandlerFunc {
+	deprecation := "true"
+	if !policy.DeprecatedAt.IsZero() {
+		deprecation = "@" + strconv.FormatInt(policy.DeprecatedAt.Unix(), 10)
+	}
+
+	var sunset string
+	if !policy.Sunset.IsZero() {
+		sunset = policy.Sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("Deprecation", deprecation)
+		if sunset != "" {
+			header.Set("Sunset", sunset)
+		}
+		if policy.DocLink != "" {
+			header.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, policy.DocLink))
+		}
+		if policy.FromPrefix != "" && policy.ToPrefix != "" && strings.HasPrefix(c.Request.URL.Path, policy.FromPrefix) {
+			successor := policy.ToPrefix + strings.TrimPrefix(c.Request.URL.Path, policy.FromPrefix)
+			header.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		}
+
+		c.Next()
+	}
+}
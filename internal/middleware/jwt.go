@@ -1,17 +1,25 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 
 	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/pkg/logger"
 	"github.com/VennLe/charlotte/pkg/utils"
 )
 
+// TokenVersionFunc 查询用户当前的令牌版本
+type TokenVersionFunc func(ctx context.Context, userID uint) (int, error)
+
 // ParseToken 解析 JWT Token
 func ParseToken(tokenString string) (*jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -33,7 +41,8 @@ func ParseToken(tokenString string) (*jwt.MapClaims, error) {
 }
 
 // JWTAuth JWT 认证中间件
-func JWTAuth() gin.HandlerFunc {
+// tokenVersion 不为 nil 时拒绝令牌版本（tv）低于用户当前版本的令牌，即已被轮换吊销的令牌；为 nil 时只校验签名和有效期
+func JWTAuth(tokenVersion TokenVersionFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -57,10 +66,38 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		rawID, _ := (*claims)["user_id"].(float64)
+		userID := uint(rawID)
+		if userID == 0 {
+			utils.Error(c, http.StatusUnauthorized, "Token 无效或已过期")
+			c.Abort()
+			return
+		}
+		if tokenVersion != nil {
+			// 轮换前签发的令牌没有 tv 声明，按版本 0 处理
+			tv, _ := (*claims)["tv"].(float64)
+			current, err := tokenVersion(c.Request.Context(), userID)
+			switch {
+			case errors.Is(err, dao.ErrRecordNotFound):
+				utils.Error(c, http.StatusUnauthorized, "用户不存在")
+				c.Abort()
+				return
+			case err != nil:
+				logger.FromContext(c.Request.Context()).Error("查询令牌版本失败", zap.Uint("user_id", userID), zap.Error(err))
+				utils.Error(c, http.StatusServiceUnavailable, "认证服务暂不可用")
+				c.Abort()
+				return
+			case int(tv) < current:
+				utils.Error(c, http.StatusUnauthorized, "Token 已被吊销")
+				c.Abort()
+				return
+			}
+		}
+
 		// 将用户信息存入上下文
-		c.Set("user_id", uint((*claims)["user_id"].(float64)))
-		c.Set("username", (*claims)["username"].(string))
-		c.Set("user_role", (*claims)["role"].(string))
+		username, _ := (*claims)["username"].(string)
+		role, _ := (*claims)["role"].(string)
+		ctxutil.SetUser(c, userID, username, role)
 
 		c.Next()
 	}
@@ -69,8 +106,7 @@ func JWTAuth() gin.HandlerFunc {
 // AdminOnly 仅管理员访问
 func AdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("user_role")
-		if !exists || role != "admin" {
+		if ctxutil.UserRole(c) != "admin" {
 			utils.Error(c, http.StatusForbidden, "权限不足")
 			c.Abort()
 			return
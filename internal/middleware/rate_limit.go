@@ -1,23 +1,194 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/pkg/logger"
 	"github.com/VennLe/charlotte/pkg/utils"
 )
 
+// 限流算法
+const (
+	RateLimitSlidingWindow = "sliding_window" // 滑动窗口日志
+	RateLimitTokenBucket   = "token_bucket"   // 令牌桶
+)
+
+// 限流键的来源
+const (
+	RateLimitKeyIP     = "ip"      // 客户端IP
+	RateLimitKeyUser   = "user"    // 登录用户，须在认证中间件之后使用
+	RateLimitKeyAPIKey = "api_key" // 请求头中的 API Key
+)
+
+// DefaultAPIKeyHeader 默认携带 API Key 的请求头
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// RateLimitKeyFunc 返回请求所属的限流键，相同键的请求共享配额；返回空字符串时按客户端IP限流
+type RateLimitKeyFunc func(c *gin.Context) string
+
 // RateLimiterConfig 限流器配置
 type RateLimiterConfig struct {
 	RedisClient *redis.Client
 	MaxRequests int64
 	WindowSize  time.Duration
+	Algorithm   string // sliding_window（默认）或 token_bucket
+	// KeyFunc 限流键，为 nil 时按客户端IP限流
+	KeyFunc RateLimitKeyFunc
+	// Scope 限流范围，不同范围的限流器即使键相同也互不影响，用于按路由单独设置配额
+	Scope string
+}
+
+// RateLimitByIP 按客户端IP限流
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + utils.ClientIP(c)
+}
+
+// RateLimitByUser 按登录用户限流，未登录时返回空字符串
+func RateLimitByUser(c *gin.Context) string {
+	if userID, ok := ctxutil.UserID(c); ok {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	return ""
+}
+
+// RateLimitByAPIKey 按请求头 header 中的 API Key 限流，未携带时返回空字符串
+// 键中只保存 API Key 的哈希，避免明文写入 Redis
+func RateLimitByAPIKey(header string) RateLimitKeyFunc {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	return func(c *gin.Context) string {
+		apiKey := c.GetHeader(header)
+		if apiKey == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(apiKey))
+		return "apikey:" + hex.EncodeToString(sum[:16])
+	}
+}
+
+// RateLimitKeyChain 依次尝试各限流键，使用第一个非空的键，都为空时按客户端IP限流
+func RateLimitKeyChain(keyFuncs ...RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		for _, keyFunc := range keyFuncs {
+			if key := keyFunc(c); key != "" {
+				return key
+			}
+		}
+		return RateLimitByIP(c)
+	}
+}
+
+// RateLimitKeyByName 按名称创建限流键：api_key、user 未取到时回退为客户端IP，未知名称按客户端IP限流
+func RateLimitKeyByName(name, apiKeyHeader string) (RateLimitKeyFunc, error) {
+	switch name {
+	case "", RateLimitKeyIP:
+		return RateLimitByIP, nil
+	case RateLimitKeyUser:
+		return RateLimitKeyChain(RateLimitByUser), nil
+	case RateLimitKeyAPIKey:
+		return RateLimitKeyChain(RateLimitByAPIKey(apiKeyHeader)), nil
+	default:
+		return RateLimitByIP, fmt.Errorf("不支持的限流键: %s", name)
+	}
+}
+
+// rateLimitKey 请求的限流键
+func (config RateLimiterConfig) rateLimitKey(c *gin.Context) string {
+	key := ""
+	if config.KeyFunc != nil {
+		key = config.KeyFunc(c)
+	}
+	if key == "" {
+		key = RateLimitByIP(c)
+	}
+	if config.Scope != "" {
+		key = config.Scope + ":" + key
+	}
+	return key
 }
 
+// slidingWindowScript 滑动窗口日志限流脚本
+// KEYS[1] 限流键；ARGV: 当前时间(ms)、窗口(ms)、上限、请求唯一标识
+// 返回: {是否允许, 剩余次数, 重置时间(ms)}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = now + window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window
+end
+
+return {allowed, limit - count, reset}
+`)
+
+// tokenBucketScript 令牌桶限流脚本
+// KEYS[1] 限流键；ARGV: 当前时间(ms)、窗口(ms)、容量
+// 令牌以 容量/窗口 的速率补充；返回: {是否允许, 剩余令牌, 重置时间(ms)}
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local rate = capacity / window
+
+local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', key, window)
+
+local reset
+if allowed == 1 then
+	reset = now + math.ceil((capacity - tokens) / rate)
+else
+	reset = now + math.ceil((1 - tokens) / rate)
+end
+
+return {allowed, math.floor(tokens), reset}
+`)
+
 // NewRateLimiter 创建限流中间件
+// 按 KeyFunc 返回的键（默认为客户端IP）限流，并在响应中设置 X-RateLimit-Limit/Remaining/Reset 头（Reset 为距重置的秒数）
 func NewRateLimiter(config RateLimiterConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if config.RedisClient == nil {
@@ -25,17 +196,50 @@ func NewRateLimiter(config RateLimiterConfig) gin.HandlerFunc {
 			return
 		}
 
-		// IP 限流
-		key := "rate_limit:" + c.ClientIP()
-
 		ctx := c.Request.Context()
-		pipe := config.RedisClient.Pipeline()
-		incr := pipe.Incr(ctx, key)
-		pipe.Expire(ctx, key, config.WindowSize)
-		_, _ = pipe.Exec(ctx)
+		limitKey := config.rateLimitKey(c)
+		now := time.Now()
+		nowMs := now.UnixMilli()
+		windowMs := config.WindowSize.Milliseconds()
+
+		var (
+			result []interface{}
+			err    error
+		)
+		switch config.Algorithm {
+		case RateLimitTokenBucket:
+			key := "rate_limit:tb:" + limitKey
+			result, err = tokenBucketScript.Run(ctx, config.RedisClient, []string{key},
+				nowMs, windowMs, config.MaxRequests).Slice()
+		default:
+			key := "rate_limit:sw:" + limitKey
+			member := fmt.Sprintf("%d-%p", now.UnixNano(), c)
+			result, err = slidingWindowScript.Run(ctx, config.RedisClient, []string{key},
+				nowMs, windowMs, config.MaxRequests, member).Slice()
+		}
+		if err != nil || len(result) != 3 {
+			// Redis 不可用时放行，避免限流组件故障导致服务不可用
+			logger.Warn("限流检查失败，已放行", zap.String("key", limitKey), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		allowed, _ := result[0].(int64)
+		remaining, _ := result[1].(int64)
+		resetMs, _ := result[2].(int64)
+		if remaining < 0 {
+			remaining = 0
+		}
+		resetAt := time.UnixMilli(resetMs)
+
+		resetSeconds := strconv.FormatInt(int64(math.Ceil(resetAt.Sub(now).Seconds())), 10)
+
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(config.MaxRequests, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+		c.Header("X-RateLimit-Reset", resetSeconds)
 
-		count := incr.Val()
-		if count > config.MaxRequests {
+		if allowed != 1 {
+			c.Header("Retry-After", resetSeconds)
 			utils.Error(c, http.StatusTooManyRequests, "请求过于频繁")
 			c.Abort()
 			return
@@ -51,5 +255,6 @@ func DefaultRateLimiter(redisClient *redis.Client) gin.HandlerFunc {
 		RedisClient: redisClient,
 		MaxRequests: 100,
 		WindowSize:  time.Minute,
+		Algorithm:   RateLimitSlidingWindow,
 	})
 }
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// ReadOnlyFunc 判断当前是否处于只读模式，返回拒绝写请求时的提示
+type ReadOnlyFunc func(ctx context.Context) (enabled bool, message string)
+
+// ReadOnly 只读模式中间件，只读时对 POST/PUT/PATCH/DELETE 请求返回 503，GET/HEAD/OPTIONS 照常处理
+// exempt 为不受限制的路由后缀（按 c.FullPath() 匹配，如 /auth/login），用于登录和关闭只读模式的接口
+func ReadOnly(check ReadOnlyFunc, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		enabled, message := check(c.Request.Context())
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		fullPath := c.FullPath()
+		for _, suffix := range exempt {
+			if fullPath != "" && strings.HasSuffix(fullPath, suffix) {
+				c.Next()
+				return
+			}
+		}
+
+		utils.Error(c, http.StatusServiceUnavailable, message)
+		c.Abort()
+	}
+}
+
+// isSafeMethod 是否为不修改数据的请求方法
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/tracing"
+)
+
+// DefaultRequestIDHeader 默认请求ID请求头
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 客户端传入请求ID的最大长度，超出时重新生成
+const maxRequestIDLength = 128
+
+// RequestID 请求ID中间件
+// 优先使用客户端传入的请求ID，其次使用 W3C traceparent 中的追踪ID，否则生成新的ID（32位十六进制，可直接作为追踪ID）；
+// ID 会写入响应头、gin 上下文（request_id）和请求 context，后续的日志、Kafka 事件可通过 logger.RequestIDFromContext 获取
+func RequestID(header string) gin.HandlerFunc {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(header)
+		if requestID == "" {
+			requestID, _ = tracing.TraceIDFromTraceparent(c.GetHeader("traceparent"))
+		}
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		ctxutil.SetRequestID(c, requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header(header, requestID)
+
+		c.Next()
+	}
+}
+
+// newRequestID 生成随机请求ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/internal/service"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// SessionFunc 按会话ID查询会话，会话不存在或已过期时返回 service.ErrSessionNotFound
+type SessionFunc func(ctx context.Context, sessionID string) (*service.Session, error)
+
+// SessionAuth 服务端会话认证中间件，从 cookieName 指定的 Cookie 读取会话ID
+// 认证通过后与 JWTAuth 一样在上下文中设置 user_id、username、user_role，另设置 session_id 供登出使用
+func SessionAuth(cookieName string, lookup SessionFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID, err := c.Cookie(cookieName)
+		if err != nil || sessionID == "" {
+			utils.Error(c, http.StatusUnauthorized, "缺少认证信息")
+			c.Abort()
+			return
+		}
+
+		session, err := lookup(c.Request.Context(), sessionID)
+		if err != nil {
+			if errors.Is(err, service.ErrSessionNotFound) {
+				utils.Error(c, http.StatusUnauthorized, "会话无效或已过期")
+			} else {
+				logger.FromContext(c.Request.Context()).Error("查询会话失败", zap.Error(err))
+				utils.Error(c, http.StatusServiceUnavailable, "认证服务暂不可用")
+			}
+			c.Abort()
+			return
+		}
+
+		ctxutil.SetUser(c, session.UserID, session.Username, session.Role)
+		ctxutil.SetSessionID(c, session.ID)
+
+		c.Next()
+	}
+}
@@ -1,19 +1,31 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/internal/model"
 	"github.com/VennLe/charlotte/internal/service"
 	"github.com/VennLe/charlotte/pkg/logger"
 	"github.com/VennLe/charlotte/pkg/utils"
 )
 
+// GetPermissionScope 获取 CheckPermission 中间件写入请求 context 的资源范围，未经过该中间件时返回空字符串
+func GetPermissionScope(c *gin.Context) string {
+	scope, _ := dao.PermissionScopeFromContext(c.Request.Context())
+	return scope.Scope
+}
+
 // SimplifiedPermissionMiddleware 简化版权限中间件
 type SimplifiedPermissionMiddleware struct {
 	permissionService *service.SimplifiedPermissionService
+	audits            *service.PermissionAuditService // 权限决策日志，为 nil 时只写运行日志
 }
 
 // NewSimplifiedPermissionMiddleware 创建简化版权限中间件实例
@@ -23,6 +35,11 @@ func NewSimplifiedPermissionMiddleware(permissionService *service.SimplifiedPerm
 	}
 }
 
+// SetAuditService 设置权限决策日志，权限拒绝和敏感操作的授权写入审计存储
+func (m *SimplifiedPermissionMiddleware) SetAuditService(audits *service.PermissionAuditService) {
+	m.audits = audits
+}
+
 // CheckPermission 权限检查中间件（简化版）
 func (m *SimplifiedPermissionMiddleware) CheckPermission(resourceType, operation string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -52,18 +69,25 @@ func (m *SimplifiedPermissionMiddleware) CheckPermission(resourceType, operation
 				zap.String("operation", operation),
 				zap.String("reason", result.Reason),
 			)
+			m.recordDecision(c, resourceType, operation, model.PermissionDecisionDeny, result.Reason)
 			utils.Error(c, http.StatusForbidden, "权限不足: "+result.Reason)
 			c.Abort()
 			return
 		}
 
-		// 将权限信息存储到上下文中
-		c.Set("user_role", result.UserRole)
-		c.Set("has_permission", true)
+		// 将权限信息存储到上下文中，权限范围只写入请求 context，供DAO通过 dao.ScopeOwnedBy 做行级过滤
+		ctxutil.SetUserRole(c, result.UserRole)
+		c.Request = c.Request.WithContext(dao.WithPermissionScope(c.Request.Context(), dao.PermissionScope{
+			UserID: userID,
+			Scope:  result.Scope,
+		}))
+
+		m.recordDecision(c, resourceType, operation, model.PermissionDecisionGrant, "scope="+result.Scope)
 
 		logger.Debug("权限验证通过",
 			zap.Uint("user_id", userID),
 			zap.String("role", result.UserRole),
+			zap.String("scope", result.Scope),
 			zap.String("resource_type", resourceType),
 			zap.String("operation", operation),
 		)
@@ -95,24 +119,70 @@ func (m *SimplifiedPermissionMiddleware) RequireRole(requiredRole string) gin.Ha
 			return
 		}
 
-		userRole, exists := c.Get("user_role")
-		if !exists {
+		role := ctxutil.UserRole(c)
+		if role == "" {
+			m.recordDecision(c, c.FullPath(), methodOperation(c.Request.Method), model.PermissionDecisionDeny, "权限信息缺失")
 			utils.Error(c, http.StatusForbidden, "权限信息缺失")
 			c.Abort()
 			return
 		}
 
-		role := userRole.(string)
 		if !m.hasRequiredRole(role, requiredRole) {
+			logger.Warn("角色权限不足",
+				zap.Uint("user_id", userID),
+				zap.String("role", role),
+				zap.String("required_role", requiredRole),
+				zap.String("path", c.FullPath()),
+			)
+			m.recordDecision(c, c.FullPath(), methodOperation(c.Request.Method), model.PermissionDecisionDeny, "需要"+requiredRole+"权限")
 			utils.Error(c, http.StatusForbidden, "需要"+requiredRole+"权限")
 			c.Abort()
 			return
 		}
 
+		m.recordDecision(c, c.FullPath(), methodOperation(c.Request.Method), model.PermissionDecisionGrant, "要求角色 "+requiredRole)
 		c.Next()
 	}
 }
 
+// recordDecision 写入权限决策日志，资源ID取路由中的第一个路径参数
+func (m *SimplifiedPermissionMiddleware) recordDecision(c *gin.Context, resource, operation, decision, reason string) {
+	if !m.audits.ShouldRecord(decision, operation) {
+		return
+	}
+	var resourceID string
+	if len(c.Params) > 0 {
+		resourceID = c.Params[0].Value
+	}
+	userID, _ := ctxutil.UserID(c)
+	m.audits.Record(c.Request.Context(), &model.PermissionAuditLog{
+		ActorID:    userID,
+		ActorName:  ctxutil.Username(c),
+		ActorRole:  ctxutil.UserRole(c),
+		Resource:   resource,
+		ResourceID: resourceID,
+		Operation:  operation,
+		Decision:   decision,
+		Reason:     reason,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		ClientIP:   utils.ClientIP(c),
+		RequestID:  ctxutil.RequestID(c),
+	})
+}
+
+// methodOperation 按请求方法对应的操作类型，用于按角色校验的路由
+func methodOperation(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "write"
+	}
+}
+
 // RequireAdmin 要求管理员权限
 func (m *SimplifiedPermissionMiddleware) RequireAdmin() gin.HandlerFunc {
 	return m.RequireRole("admin")
@@ -193,12 +263,183 @@ func (m *SimplifiedPermissionMiddleware) GetAvailableRoles() gin.HandlerFunc {
 	}
 }
 
+// GetPermissionMatrix 获取角色权限矩阵（管理员专用）
+func (m *SimplifiedPermissionMiddleware) GetPermissionMatrix() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		matrix, err := m.permissionService.GetPermissionMatrix(c.Request.Context())
+		if err != nil {
+			logger.Error("获取权限矩阵失败", zap.Error(err))
+			utils.Error(c, http.StatusInternalServerError, "获取权限矩阵失败")
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, matrix)
+		c.Abort()
+	}
+}
+
+// ListRolePermissions 分页获取角色权限列表（管理员专用）
+// 支持 role、resource_type 过滤及 page、size 分页参数
+func (m *SimplifiedPermissionMiddleware) ListRolePermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+		role := c.Query("role")
+		resourceType := c.Query("resource_type")
+
+		if page < 1 {
+			page = 1
+		}
+		if size < 1 || size > 100 {
+			size = 20
+		}
+
+		permissions, total, err := m.permissionService.ListRolePermissions(c.Request.Context(), role, resourceType, page, size)
+		if err != nil {
+			logger.Error("获取角色权限列表失败", zap.Error(err))
+			utils.Error(c, http.StatusBadRequest, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"list":  permissions,
+			"total": total,
+			"page":  page,
+			"size":  size,
+		})
+		c.Abort()
+	}
+}
+
+// ClonePermissions 复制角色权限（管理员专用）
+func (m *SimplifiedPermissionMiddleware) ClonePermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			FromRole string `json:"from_role" binding:"required"`
+			ToRole   string `json:"to_role" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.Error(c, http.StatusBadRequest, "请求参数错误")
+			c.Abort()
+			return
+		}
+
+		cloned, err := m.permissionService.ClonePermissions(c.Request.Context(), req.FromRole, req.ToRole, ctxutil.UserRole(c))
+		if errors.Is(err, service.ErrRoleAboveOperator) {
+			logger.Warn("复制角色权限被拒绝",
+				zap.Uint("operator_id", m.getUserID(c)),
+				zap.String("from_role", req.FromRole),
+				zap.String("to_role", req.ToRole),
+			)
+			utils.Error(c, http.StatusForbidden, err.Error())
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logger.Error("复制角色权限失败", zap.Error(err))
+			utils.Error(c, http.StatusBadRequest, "复制角色权限失败: "+err.Error())
+			c.Abort()
+			return
+		}
+
+		logger.Info("角色权限复制成功",
+			zap.Uint("operator_id", m.getUserID(c)),
+			zap.String("from_role", req.FromRole),
+			zap.String("to_role", req.ToRole),
+			zap.Int("cloned", cloned),
+		)
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "角色权限复制成功",
+			"from_role": req.FromRole,
+			"to_role":   req.ToRole,
+			"cloned":    cloned,
+		})
+		c.Abort()
+	}
+}
+
+// CloneGroupPermissions 复制用户组权限（管理员专用）
+func (m *SimplifiedPermissionMiddleware) CloneGroupPermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			FromGroupID uint `json:"from_group_id" binding:"required"`
+			ToGroupID   uint `json:"to_group_id" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.Error(c, http.StatusBadRequest, "请求参数错误")
+			c.Abort()
+			return
+		}
+
+		cloned, err := m.permissionService.CloneGroupPermissions(c.Request.Context(), req.FromGroupID, req.ToGroupID)
+		if err != nil {
+			logger.Error("复制用户组权限失败", zap.Error(err))
+			utils.Error(c, http.StatusBadRequest, "复制用户组权限失败: "+err.Error())
+			c.Abort()
+			return
+		}
+
+		logger.Info("用户组权限复制成功",
+			zap.Uint("operator_id", m.getUserID(c)),
+			zap.Uint("from_group_id", req.FromGroupID),
+			zap.Uint("to_group_id", req.ToGroupID),
+			zap.Int("cloned", cloned),
+		)
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":       "用户组权限复制成功",
+			"from_group_id": req.FromGroupID,
+			"to_group_id":   req.ToGroupID,
+			"cloned":        cloned,
+		})
+		c.Abort()
+	}
+}
+
+// AddUserToGroup 将用户加入用户组（管理员专用）
+func (m *SimplifiedPermissionMiddleware) AddUserToGroup() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			UserID  uint `json:"user_id" binding:"required"`
+			GroupID uint `json:"group_id" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.Error(c, http.StatusBadRequest, "请求参数错误")
+			c.Abort()
+			return
+		}
+
+		if err := m.permissionService.AddUserToGroup(c.Request.Context(), req.UserID, req.GroupID); err != nil {
+			logger.Warn("加入用户组失败",
+				zap.Uint("user_id", req.UserID),
+				zap.Uint("group_id", req.GroupID),
+				zap.Error(err))
+			utils.Error(c, http.StatusBadRequest, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "已加入用户组",
+			"user_id":  req.UserID,
+			"group_id": req.GroupID,
+		})
+		c.Abort()
+	}
+}
+
 // SetUserRole 设置用户角色（管理员专用）
 func (m *SimplifiedPermissionMiddleware) SetUserRole() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 只有管理员可以设置用户角色
-		userRole, exists := c.Get("user_role")
-		if !exists || (userRole != "admin" && userRole != "superadmin") {
+		userRole := ctxutil.UserRole(c)
+		if userRole != "admin" && userRole != "superadmin" {
 			utils.Error(c, http.StatusForbidden, "需要管理员权限")
 			c.Abort()
 			return
@@ -234,27 +475,14 @@ func (m *SimplifiedPermissionMiddleware) SetUserRole() gin.HandlerFunc {
 
 // 辅助方法
 func (m *SimplifiedPermissionMiddleware) getUserID(c *gin.Context) uint {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		return 0 // 游客
-	}
-	return userID.(uint)
+	userID, _ := ctxutil.UserID(c) // 未登录时为 0（游客）
+	return userID
 }
 
 func (m *SimplifiedPermissionMiddleware) hasRequiredRole(userRole, requiredRole string) bool {
-	// 角色权限等级检查
-	roleLevels := map[string]int{
-		"guest":      1,
-		"user":       2,
-		"vip":        3,
-		"admin":      4,
-		"superadmin": 5,
-	}
-
-	userLevel, userOk := roleLevels[userRole]
-	reqLevel, reqOk := roleLevels[requiredRole]
-
-	if !userOk || !reqOk {
+	// 角色权限等级检查，未知角色一律不满足
+	userLevel, reqLevel := model.RoleLevel(userRole), model.RoleLevel(requiredRole)
+	if userLevel == 0 || reqLevel == 0 {
 		return false
 	}
 
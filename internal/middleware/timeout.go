@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/ctxutil"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// requestBaseContextKey 请求超时前原始 context 的存储键
+const requestBaseContextKey ctxutil.Key = "request_base_context"
+
+// RequestTimeout 请求处理超时中间件
+// 为请求 context 设置截止时间，下游的数据库、Redis 等调用会在超时后取消
+// overrides 为按路由覆盖的超时，键为路由后缀（按 c.FullPath() 匹配，如 /import-export/import），
+// 多个后缀匹配时取最长的；值不大于 0 表示该路由不设超时。请求体的读取时间按路由超时限制，
+// 覆盖值大于默认超时时同时延长 http.Server 的写超时
+func RequestTimeout(timeout time.Duration, overrides map[string]time.Duration) gin.HandlerFunc {
+	suffixes := make([]string, 0, len(overrides))
+	for suffix := range overrides {
+		suffixes = append(suffixes, suffix)
+	}
+	// 长的后缀优先匹配
+	sort.Slice(suffixes, func(i, j int) bool {
+		if len(suffixes[i]) != len(suffixes[j]) {
+			return len(suffixes[i]) > len(suffixes[j])
+		}
+		return suffixes[i] < suffixes[j]
+	})
+
+	return func(c *gin.Context) {
+		routeTimeout, overridden := timeout, false
+		if fullPath := c.FullPath(); fullPath != "" {
+			for _, suffix := range suffixes {
+				if strings.HasSuffix(fullPath, suffix) {
+					routeTimeout, overridden = overrides[suffix], true
+					break
+				}
+			}
+		}
+
+		// http.Server 只限制请求头的读取时间，请求体按路由超时限制，不设超时的路由同时取消读写超时
+		if routeTimeout <= 0 {
+			if overridden {
+				setReadDeadline(c, time.Time{})
+				extendWriteDeadline(c, time.Time{})
+			}
+			c.Next()
+			return
+		}
+		setReadDeadline(c, time.Now().Add(routeTimeout))
+		if overridden && routeTimeout > timeout {
+			extendWriteDeadline(c, time.Now().Add(routeTimeout))
+		}
+
+		base := c.Request.Context()
+		ctx, cancel := context.WithTimeout(base, routeTimeout)
+		defer cancel()
+
+		c.Set(requestBaseContextKey, base)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// extendWriteDeadline 调整 http.Server 的写超时，deadline 为零值时取消写超时
+func extendWriteDeadline(c *gin.Context, deadline time.Time) {
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(deadline); err != nil {
+		logger.Debug("调整写超时失败", zap.String("path", c.Request.URL.Path), zap.Error(err))
+	}
+}
+
+// setReadDeadline 调整请求体的读取截止时间，deadline 为零值时取消读超时
+func setReadDeadline(c *gin.Context, deadline time.Time) {
+	if err := http.NewResponseController(c.Writer).SetReadDeadline(deadline); err != nil {
+		logger.Debug("调整读超时失败", zap.String("path", c.Request.URL.Path), zap.Error(err))
+	}
+}
+
+// StreamingResponse 流式响应中间件
+// 用于文件下载、流式导出等耗时较长的响应：移除请求处理超时，并取消 http.Server 的写超时
+// 客户端断开连接时请求 context 仍会被取消
+func StreamingResponse() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if base, ok := c.Get(requestBaseContextKey); ok {
+			c.Request = c.Request.WithContext(base.(context.Context))
+		}
+
+		extendWriteDeadline(c, time.Time{})
+
+		c.Next()
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/VennLe/charlotte/internal/ctxutil"
 	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
 )
 
 func ZapLogger() gin.HandlerFunc {
@@ -25,10 +27,13 @@ func ZapLogger() gin.HandlerFunc {
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
 			zap.String("query", query),
-			zap.String("ip", c.ClientIP()),
+			zap.String("ip", utils.ClientIP(c)),
 			zap.String("user-agent", c.Request.UserAgent()),
 			zap.Duration("cost", cost),
 		}
+		if requestID := ctxutil.RequestID(c); requestID != "" {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
 
 		if len(c.Errors) > 0 {
 			logger.Error("HTTP 请求错误", append(fields, zap.String("error", c.Errors.String()))...)
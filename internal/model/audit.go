@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// 审计操作类型
+const (
+	AuditActionUserMerge   = "user_merge"   // 合并重复用户
+	AuditActionTokenRotate = "token_rotate" // 轮换（吊销）用户的全部令牌
+)
+
+// AuditLog 审计日志，记录管理员执行的数据变更操作
+type AuditLog struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	Action     string `gorm:"size:50;not null;index" json:"action"`
+	OperatorID uint   `gorm:"index" json:"operator_id"`
+	TargetType string `gorm:"size:50" json:"target_type"`
+	TargetID   uint   `gorm:"index" json:"target_id"`
+	Detail     string `gorm:"type:text;comment:操作详情(JSON格式)" json:"detail"`
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// 权限决策结果
+const (
+	PermissionDecisionDeny  = "deny"  // 拒绝访问
+	PermissionDecisionGrant = "grant" // 允许访问（仅记录敏感操作）
+)
+
+// PermissionAuditLog 权限决策日志，记录权限拒绝和敏感操作的授权，用于定期访问审查
+type PermissionAuditLog struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ActorID    uint   `gorm:"index" json:"actor_id"` // 0 表示未登录
+	ActorName  string `gorm:"size:50" json:"actor_name"`
+	ActorRole  string `gorm:"size:20" json:"actor_role"`
+	Resource   string `gorm:"size:200;not null;index" json:"resource"` // 资源类型，按角色校验时为路由
+	ResourceID string `gorm:"size:100;index" json:"resource_id"`
+	Operation  string `gorm:"size:20" json:"operation"` // read/write/delete/all
+	Decision   string `gorm:"size:10;not null;index" json:"decision"`
+	Reason     string `gorm:"size:255" json:"reason"`
+	Method     string `gorm:"size:10" json:"method"`
+	Path       string `gorm:"size:255" json:"path"`
+	ClientIP   string `gorm:"size:45" json:"client_ip"`
+	RequestID  string `gorm:"size:64" json:"request_id"`
+}
+
+// TableName 指定表名
+func (PermissionAuditLog) TableName() string {
+	return "permission_audit_logs"
+}
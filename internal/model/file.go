@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// FileRecord 上传文件记录
+// 非公开文件须通过签名链接下载；开启去重时内容相同（MD5 和大小一致）的多条记录共用同一个存储文件，引用计数即 StorageKey 相同的记录数
+type FileRecord struct {
+	ID        string    `gorm:"primarykey;size:64" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	StorageKey   string `gorm:"size:512;not null;index;comment:存储后端中的键" json:"storage_key"`
+	MD5          string `gorm:"size:32;not null;index:idx_file_records_content,priority:1" json:"md5"`
+	Size         int64  `gorm:"not null;index:idx_file_records_content,priority:2" json:"size"`
+	OriginalName string `gorm:"size:255" json:"original_name"`
+	MimeType     string `gorm:"size:100" json:"mime_type"`
+	Category     string `gorm:"size:100;index" json:"category"`
+	UploaderID   uint   `gorm:"index" json:"uploader_id"`
+	IsPublic     bool   `gorm:"not null;default:false;comment:公开文件无需签名即可下载" json:"is_public"`
+}
+
+// TableName 指定表名
+func (FileRecord) TableName() string {
+	return "file_records"
+}
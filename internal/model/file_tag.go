@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// FileTag 文件标签，一个文件可以有多个标签，用于文件检索和过滤
+type FileTag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	FileID string `gorm:"size:64;not null;uniqueIndex:idx_file_tags_file_tag,priority:1" json:"file_id"`
+	Tag    string `gorm:"size:50;not null;uniqueIndex:idx_file_tags_file_tag,priority:2;index;comment:小写标签名" json:"tag"`
+}
+
+// TableName 指定表名
+func (FileTag) TableName() string {
+	return "file_tags"
+}
@@ -21,12 +21,31 @@ const (
 	PermissionDelete = "delete" // 删除权限
 	PermissionAll    = "all"    // 所有权限
 
+	// 资源范围
+	ResourceScopeAll    = "all"    // 全部资源
+	ResourceScopeOwn    = "own"    // 仅本人的资源
+	ResourceScopePublic = "public" // 仅公开资源
+
 	// 权限级别
 	PermissionLevelLow    = 1 // 低权限级别
 	PermissionLevelMedium = 2 // 中权限级别
 	PermissionLevelHigh   = 3 // 高权限级别
 )
 
+// roleLevels 角色等级，等级高的角色拥有等级低的角色的全部权限
+var roleLevels = map[string]int{
+	RoleGuest:      1,
+	RoleUser:       2,
+	RoleVIP:        3,
+	RoleAdmin:      4,
+	RoleSuperAdmin: 5,
+}
+
+// RoleLevel 角色等级，未知角色为 0
+func RoleLevel(role string) int {
+	return roleLevels[role]
+}
+
 // UserGroup 用户组模型
 type UserGroup struct {
 	ID          uint           `gorm:"primarykey" json:"id"`
@@ -38,7 +57,7 @@ type UserGroup struct {
 	Description string `gorm:"size:255" json:"description"`
 	Level       int    `gorm:"default:1;comment:权限级别 1-低 2-中 3-高" json:"level"`
 	IsDefault   bool   `gorm:"default:false;comment:是否为默认组" json:"is_default"`
-	Status      int    `gorm:"default:1;comment:1启用 2禁用" json:"status"`
+	Status      Status `gorm:"default:1;comment:1启用 2禁用" json:"status"`
 }
 
 // PermissionTag 权限标签模型
@@ -62,10 +81,10 @@ type UserGroupPermission struct {
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 
-	UserGroupID     uint          `gorm:"not null;index" json:"user_group_id"`
+	UserGroupID     uint          `gorm:"not null;index;index:idx_group_permissions_group_resource,priority:1" json:"user_group_id"`
 	PermissionTagID uint          `gorm:"not null;index" json:"permission_tag_id"`
 	Operations      string        `gorm:"size:100;comment:允许的操作(read,write,delete,all)" json:"operations"`
-	ResourceType    string        `gorm:"size:50;comment:资源类型(user,article,comment等)" json:"resource_type"`
+	ResourceType    string        `gorm:"size:50;index:idx_group_permissions_group_resource,priority:2;comment:资源类型(user,article,comment等)" json:"resource_type"`
 	ResourceScope   string        `gorm:"size:100;comment:资源范围(all,own,system等)" json:"resource_scope"`
 	Conditions      string        `gorm:"type:text;comment:权限条件(JSON格式)" json:"conditions"`
 
@@ -84,7 +103,7 @@ type UserGroupMember struct {
 	UserGroupID uint      `gorm:"not null;uniqueIndex:idx_user_group" json:"user_group_id"`
 	JoinedAt    time.Time `json:"joined_at"`
 	ExpiredAt   time.Time `json:"expired_at"`
-	Status      int       `gorm:"default:1;comment:1正常 2禁用" json:"status"`
+	Status      Status    `gorm:"default:1;comment:1正常 2禁用" json:"status"`
 
 	User        User      `gorm:"foreignKey:UserID" json:"-"`
 	UserGroup   UserGroup `gorm:"foreignKey:UserGroupID" json:"-"`
@@ -97,10 +116,10 @@ type UserPermission struct {
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 
-	UserID          uint          `gorm:"not null;index" json:"user_id"`
+	UserID          uint          `gorm:"not null;index;index:idx_user_permissions_user_resource,priority:1" json:"user_id"`
 	PermissionTagID uint          `gorm:"not null;index" json:"permission_tag_id"`
 	Operations      string        `gorm:"size:100;comment:允许的操作" json:"operations"`
-	ResourceType    string        `gorm:"size:50" json:"resource_type"`
+	ResourceType    string        `gorm:"size:50;index:idx_user_permissions_user_resource,priority:2" json:"resource_type"`
 	ResourceScope   string        `gorm:"size:100" json:"resource_scope"`
 	IsGrant         bool          `gorm:"default:true;comment:true授予权限 false撤销权限" json:"is_grant"`
 	ExpiredAt       time.Time     `json:"expired_at"`
@@ -123,6 +142,7 @@ type PermissionCheckResult struct {
 	Reason       string `json:"reason,omitempty"`
 	UserRole     string `json:"user_role"`
 	AllowedOps   string `json:"allowed_operations"`
+	ResourceScope string `json:"resource_scope,omitempty"` // 命中权限的资源范围
 }
 
 // TableName 方法定义
@@ -152,7 +172,7 @@ func (ug *UserGroup) BeforeCreate(tx *gorm.DB) error {
 		ug.Level = PermissionLevelLow
 	}
 	if ug.Status == 0 {
-		ug.Status = 1
+		ug.Status = StatusActive
 	}
 	return nil
 }
@@ -169,7 +189,7 @@ func (ugm *UserGroupMember) BeforeCreate(tx *gorm.DB) error {
 		ugm.JoinedAt = time.Now()
 	}
 	if ugm.Status == 0 {
-		ugm.Status = 1
+		ugm.Status = StatusActive
 	}
 	return nil
 }
\ No newline at end of file
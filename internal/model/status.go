@@ -0,0 +1,51 @@
+package model
+
+// Status 用户、用户组、用户组成员的状态，数据库和 JSON 中均为整数
+type Status int
+
+// 状态值，1、2 与历史数据兼容
+const (
+	StatusActive   Status = 1 // 正常/启用
+	StatusDisabled Status = 2 // 禁用（管理员操作）
+	StatusPending  Status = 3 // 待激活（如待邮箱验证、待审核）
+	StatusLocked   Status = 4 // 锁定（如多次登录失败）
+	StatusDeleted  Status = 5 // 已注销，记录保留用于审计
+)
+
+// statusNames 状态的英文标识
+var statusNames = map[Status]string{
+	StatusActive:   "active",
+	StatusDisabled: "disabled",
+	StatusPending:  "pending",
+	StatusLocked:   "locked",
+	StatusDeleted:  "deleted",
+}
+
+// IsActive 是否为正常状态，只有正常状态的用户可以登录和获得权限，只有正常的用户组和成员关系生效
+func (s Status) IsActive() bool {
+	return s == StatusActive
+}
+
+// IsValid 是否为已定义的状态
+func (s Status) IsValid() bool {
+	_, ok := statusNames[s]
+	return ok
+}
+
+// String 状态的英文标识，未定义的状态返回 unknown
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// StatusModel 带状态列的模型，可通过 BaseDAOImpl.SetStatusBatch 批量修改状态
+type StatusModel interface {
+	// StatusColumn 状态列名
+	StatusColumn() string
+}
+
+func (User) StatusColumn() string            { return "status" }
+func (UserGroup) StatusColumn() string       { return "status" }
+func (UserGroupMember) StatusColumn() string { return "status" }
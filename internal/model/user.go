@@ -12,15 +12,18 @@ type User struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	Username  string    `gorm:"size:50;not null;uniqueIndex" json:"username" binding:"required,min=3,max=50"`
-	Email     string    `gorm:"size:100;not null;uniqueIndex" json:"email" binding:"required,email"`
+	// 用户名、邮箱使用部分唯一索引（仅约束未删除的记录），软删除后可重新注册；MySQL 不支持部分索引，退化为普通唯一索引
+	Username  string    `gorm:"size:50;not null;uniqueIndex:idx_users_username_active,where:deleted_at IS NULL" json:"username" binding:"required,min=3,max=50"`
+	Email     string    `gorm:"size:100;not null;uniqueIndex:idx_users_email_active,where:deleted_at IS NULL" json:"email" binding:"required,email"`
 	Password  string    `gorm:"size:255;not null" json:"-"` // 密码不序列化
 	Nickname  string    `gorm:"size:50" json:"nickname"`
 	Avatar    string    `gorm:"size:255" json:"avatar"`
 	Phone     string    `gorm:"size:20" json:"phone"`
-	Status    int       `gorm:"default:1;comment:1正常 2禁用" json:"status"`
-	Role      string    `gorm:"size:20;default:user" json:"role"` // guest/user/vip/admin/superadmin
+	Status    Status    `gorm:"default:1;index:idx_users_status;comment:1正常 2禁用 3待激活 4锁定 5已注销" json:"status"`
+	Role      string    `gorm:"size:20;default:user;index:idx_users_role" json:"role"` // guest/user/vip/admin/superadmin
 	LastLogin time.Time `json:"last_login"`
+	// TokenVersion 令牌版本，签发的 JWT 携带该值，递增后此前签发的令牌全部失效
+	TokenVersion int `gorm:"not null;default:0" json:"-"`
 	
 	// 权限相关字段
 	PermissionLevel int    `gorm:"default:1;comment:权限级别 1-低 2-中 3-高" json:"permission_level"`
@@ -49,7 +52,7 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 		u.Role = "user"
 	}
 	if u.Status == 0 {
-		u.Status = 1
+		u.Status = StatusActive
 	}
 	return nil
 }
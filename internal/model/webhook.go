@@ -0,0 +1,81 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// 领域事件类型，同时用作 Webhook 订阅的事件类型
+const (
+	EventUserCreated     = "user_created"
+	EventUserUpdated     = "user_updated"
+	EventUserDeleted     = "user_deleted"
+	EventFileUploaded    = "file_uploaded"
+	EventImportCompleted = "import_completed"
+
+	// EventAll 订阅全部事件
+	EventAll = "*"
+)
+
+// WebhookEventTypes 可订阅的事件类型
+var WebhookEventTypes = []string{
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventFileUploaded,
+	EventImportCompleted,
+}
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending   = "pending"   // 等待投递或等待重试
+	WebhookDeliverySucceeded = "succeeded" // 接收方返回 2xx
+	WebhookDeliveryFailed    = "failed"    // 重试次数用尽或订阅已删除
+)
+
+// WebhookSubscription Webhook 订阅，事件发生时以签名的 HTTP POST 请求推送到 URL
+type WebhookSubscription struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name       string   `gorm:"size:100" json:"name"`
+	URL        string   `gorm:"size:500;not null" json:"url"`
+	Secret     string   `gorm:"size:128;not null;comment:HMAC签名密钥" json:"-"`
+	EventTypes []string `gorm:"type:text;serializer:json;comment:订阅的事件类型" json:"event_types"`
+	Active     bool     `gorm:"not null;default:true;index" json:"active"`
+	CreatedBy  uint     `json:"created_by"`
+}
+
+// TableName 指定表名
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Subscribes 是否订阅了该事件类型
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	return s.Active && (slices.Contains(s.EventTypes, eventType) || slices.Contains(s.EventTypes, EventAll))
+}
+
+// WebhookDelivery Webhook 投递记录，每个事件对每个订阅生成一条
+type WebhookDelivery struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SubscriptionID uint       `gorm:"not null;index" json:"subscription_id"`
+	EventID        string     `gorm:"size:64;not null;index" json:"event_id"`
+	EventType      string     `gorm:"size:50;not null" json:"event_type"`
+	Payload        string     `gorm:"type:text" json:"payload"`
+	Status         string     `gorm:"size:20;not null;index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at,omitempty"`
+	ResponseCode   int        `json:"response_code,omitempty"` // 最近一次投递的响应状态码
+	LastError      string     `gorm:"size:500" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
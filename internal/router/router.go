@@ -1,21 +1,36 @@
 package router
 
 import (
+	"slices"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 
 	"github.com/VennLe/charlotte/internal/config"
 	"github.com/VennLe/charlotte/internal/handler"
 	"github.com/VennLe/charlotte/internal/middleware"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/metrics"
 )
 
 // Dependencies 路由依赖
 type Dependencies struct {
-	UserHandler          *handler.UserHandler
-	HealthHandler        *handler.HealthHandler
-	ImportExportHandler  *handler.ImportExportHandler
-	RedisClient          *redis.Client
-	PermissionMiddleware *middleware.SimplifiedPermissionMiddleware
+	UserHandler            *handler.UserHandler
+	HealthHandler          *handler.HealthHandler
+	ImportExportHandler    *handler.ImportExportHandler
+	NotificationHandler    *handler.NotificationHandler
+	CacheHandler           *handler.CacheHandler
+	SchedulerHandler       *handler.SchedulerHandler
+	WebhookHandler         *handler.WebhookHandler
+	ReadOnlyHandler        *handler.ReadOnlyHandler
+	PermissionAuditHandler *handler.PermissionAuditHandler
+	RedisClient            *redis.Client
+	PermissionMiddleware   *middleware.SimplifiedPermissionMiddleware
+	TokenVersion           middleware.TokenVersionFunc // 用于拒绝已吊销的令牌，为 nil 时不检查
+	Session                middleware.SessionFunc      // 不为 nil 时使用服务端会话认证代替 JWT 认证
+	ReadOnly               middleware.ReadOnlyFunc     // 只读模式判断，为 nil 时不限制写请求
 }
 
 // NewRouter 创建路由
@@ -26,103 +41,311 @@ func NewRouter(deps *Dependencies) *gin.Engine {
 
 	r := gin.New()
 
+	// 受信任代理，决定是否采信 X-Forwarded-For；配置无效时不信任任何代理
+	if err := r.SetTrustedProxies(config.Global.Server.TrustedProxies); err != nil {
+		logger.Error("受信任代理配置无效，将不信任任何代理", zap.Error(err))
+		_ = r.SetTrustedProxies(nil)
+	}
+
 	// 全局中间件
+	r.Use(middleware.RequestID(config.Global.Server.RequestIDHeader))
+	if config.Global.Monitoring.TracingEnabled {
+		r.Use(middleware.Tracing())
+	}
 	r.Use(middleware.ZapLogger())
 	r.Use(middleware.Recovery())
-	r.Use(middleware.CORS())
+	corsPolicies := newCORSPolicies()
+	if config.Global.Security.CORSEnabled {
+		r.Use(corsPolicies.Middleware())
+	}
+	r.Use(middleware.RequestTimeout(time.Duration(config.Global.Performance.RequestTimeout)*time.Second, routeTimeouts()))
+
+	// 只读模式：拒绝写请求，登录、登出和关闭只读模式的接口除外
+	if deps.ReadOnly != nil {
+		r.Use(middleware.ReadOnly(deps.ReadOnly, "/auth/login", "/auth/logout", "/admin/read-only"))
+	}
 
 	// 使用新的限流中间件
-	if deps.RedisClient != nil {
-		r.Use(middleware.DefaultRateLimiter(deps.RedisClient))
+	if deps.RedisClient != nil && config.Global.Security.RateLimitEnabled && config.Global.Security.RateLimitPerMinute > 0 {
+		// 全局限流在认证之前执行，无法按登录用户计数
+		keyFunc, err := middleware.RateLimitKeyByName(config.Global.Security.RateLimitKey, config.Global.Security.APIKeyHeader)
+		if err != nil {
+			logger.Warn("限流键配置无效，按客户端IP限流", zap.Error(err))
+		}
+		r.Use(middleware.NewRateLimiter(middleware.RateLimiterConfig{
+			RedisClient: deps.RedisClient,
+			MaxRequests: int64(config.Global.Security.RateLimitPerMinute),
+			WindowSize:  time.Minute,
+			Algorithm:   config.Global.Security.RateLimitAlgorithm,
+			KeyFunc:     keyFunc,
+		}))
 	}
-	
+
 	// 健康检查 (公开)
 	r.GET("/health", deps.HealthHandler.Check)
 	r.GET("/ready", deps.HealthHandler.Check)
 
-	// API v1
-	v1 := r.Group("/api/v1")
+	// Prometheus 指标
+	if config.Global.Monitoring.MetricsEnabled && config.Global.Monitoring.MetricsPath != "" {
+		r.GET(config.Global.Monitoring.MetricsPath, gin.WrapH(metrics.Handler()))
+	}
+
+	// API 路由：v2 与 v1 共用未发生破坏性变更的接口，新版本独有的接口单独注册到 v2
+	api := NewVersionedRouter(r, APIVersionV1, APIVersionV2)
+	for _, version := range []string{APIVersionV1, APIVersionV2} {
+		api.Register(func(group *gin.RouterGroup) {
+			registerAPIRoutes(group, version, deps, corsPolicies)
+		}, version)
+	}
+
+	// 文件下载 (公开)
+	r.GET("/files/download/:file_id", middleware.StreamingResponse(), deps.ImportExportHandler.DownloadFile)
+
+	return r
+}
+
+// registerAPIRoutes 注册各版本共用的 API 路由，version 用于区分有破坏性变更的接口
+func registerAPIRoutes(group *gin.RouterGroup, version string, deps *Dependencies, corsPolicies *middleware.CORSPolicies) {
+	// 认证相关 (公开)
+	auth := group.Group("/auth")
+	auth.Use(middleware.RequireJSON())
 	{
-		// 认证相关 (公开)
-		auth := v1.Group("/auth")
+		auth.POST("/register", deps.UserHandler.Register)
+		auth.POST("/login", deps.UserHandler.Login)
+		auth.POST("/logout", deps.UserHandler.Logout)
+	}
+
+	// 需要认证：启用会话时校验服务端会话，否则校验 JWT
+	authorized := group.Group("")
+	if deps.Session != nil {
+		authorized.Use(middleware.SessionAuth(config.Global.Session.CookieName, deps.Session))
+	} else {
+		authorized.Use(middleware.JWTAuth(deps.TokenVersion))
+	}
+	{
+		// 用户查询 - 按权限配置的资源范围过滤，范围为 own 的角色只能查到本人
+		userReads := authorized.Group("/users")
+		userReads.Use(middleware.RequireJSON())
 		{
-			auth.POST("/register", deps.UserHandler.Register)
-			auth.POST("/login", deps.UserHandler.Login)
+			userReads.GET("", deps.PermissionMiddleware.CheckPermission("user", "read"), deps.UserHandler.GetUsers)
+			userReads.GET("/:id", deps.PermissionMiddleware.CheckPermission("user", "read"), deps.UserHandler.GetUser)
 		}
 
-		// 需要 JWT 认证
-		authorized := v1.Group("")
-		authorized.Use(middleware.JWTAuth())
+		// 用户管理 - 需要管理员权限
+		users := authorized.Group("/users")
+		corsPolicies.Apply(users, "admin")
+		users.Use(deps.PermissionMiddleware.RequireAdmin(), middleware.RequireJSON())
 		{
-			// 用户管理 - 需要管理员权限
-			users := authorized.Group("/users")
-			users.Use(deps.PermissionMiddleware.RequireAdmin())
-			{
-				users.GET("", deps.UserHandler.GetUsers)
-				users.GET("/:id", deps.UserHandler.GetUser)
-				users.POST("", deps.UserHandler.CreateUser)
+			users.GET("/stats", deps.UserHandler.GetUserStats)
+			users.POST("/merge", deps.PermissionMiddleware.RequireSuperAdmin(), deps.UserHandler.MergeUsers)
+			users.POST("", deps.UserHandler.CreateUser)
+			// PATCH 部分更新：只修改提交的字段，零值同样生效
+			users.PATCH("/:id", deps.UserHandler.UpdateUser)
+			// PUT 整体替换：v2 要求完整表示，未提交的可选字段会被清空；v1 保持原有的部分更新语义
+			if version == APIVersionV1 {
 				users.PUT("/:id", deps.UserHandler.UpdateUser)
-				users.DELETE("/:id", deps.UserHandler.DeleteUser)
+			} else {
+				users.PUT("/:id", deps.UserHandler.ReplaceUser)
 			}
+			users.DELETE("/:id", deps.UserHandler.DeleteUser)
 
-			// 当前用户信息 - 需要登录
-			authorized.GET("/profile", deps.PermissionMiddleware.RequireLogin(), deps.UserHandler.GetProfile)
-			authorized.PUT("/password", deps.PermissionMiddleware.RequireLogin(), deps.UserHandler.ChangePassword)
+			// 用户的活跃会话
+			users.GET("/:id/sessions", deps.UserHandler.ListUserSessions)
+			users.DELETE("/:id/sessions", deps.UserHandler.TerminateUserSessions)
+			users.DELETE("/:id/sessions/:session_id", deps.UserHandler.TerminateUserSession)
+		}
 
-			// 导入导出功能 - 需要VIP或以上权限
-			importExport := authorized.Group("/import-export")
-			importExport.Use(deps.PermissionMiddleware.RequireVIP())
-			{
-				// 获取支持的数据类型
-				importExport.GET("/supported-types", deps.ImportExportHandler.GetSupportedDataTypes)
+		// 运维管理 - 需要管理员权限
+		admin := authorized.Group("/admin")
+		corsPolicies.Apply(admin, "admin")
+		admin.Use(deps.PermissionMiddleware.RequireAdmin(), middleware.RequireJSON())
+		{
+			// 发送测试通知
+			admin.POST("/notifications/test", deps.NotificationHandler.SendTestNotification)
 
-				// 数据导入
-				importExport.POST("/import", deps.ImportExportHandler.ImportData)
+			// 清除缓存 - 需要超级管理员权限
+			admin.DELETE("/cache/:model", deps.PermissionMiddleware.RequireSuperAdmin(), deps.CacheHandler.InvalidateCacheModel)
+			admin.DELETE("/cache/:model/:id", deps.PermissionMiddleware.RequireSuperAdmin(), deps.CacheHandler.InvalidateCacheKey)
 
-				// 数据导出
-				importExport.POST("/export", deps.ImportExportHandler.ExportData)
+			// 定时任务运行状态
+			admin.GET("/jobs", deps.SchedulerHandler.ListJobs)
 
-				// 获取导入模板
-				importExport.GET("/template", deps.ImportExportHandler.GetImportTemplate)
-			}
+			// 导入导出并发与排队情况
+			admin.GET("/import-export/load", deps.ImportExportHandler.GetLoad)
 
-			// 文件管理功能 - 需要登录
-			files := authorized.Group("/files")
-			files.Use(deps.PermissionMiddleware.RequireLogin())
-			{
-				// 文件上传
-				files.POST("/upload", deps.ImportExportHandler.UploadFile)
+			// 重复文件报告，用于清理存储空间
+			admin.GET("/files/duplicates", deps.ImportExportHandler.FindDuplicateFiles)
 
-				// 文件列表
-				files.GET("", deps.ImportExportHandler.ListFiles)
+			// 只读模式状态与运行时切换
+			admin.GET("/read-only", deps.ReadOnlyHandler.GetReadOnly)
+			admin.PUT("/read-only", deps.PermissionMiddleware.RequireSuperAdmin(), deps.ReadOnlyHandler.SetReadOnly)
 
-				// 文件信息
-				files.GET("/:file_id/info", deps.ImportExportHandler.GetFileInfo)
+			// Webhook 订阅管理与投递记录
+			admin.GET("/webhooks", deps.WebhookHandler.ListWebhooks)
+			admin.POST("/webhooks", deps.WebhookHandler.CreateWebhook)
+			admin.DELETE("/webhooks/:id", deps.WebhookHandler.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", deps.WebhookHandler.ListDeliveries)
 
-				// 文件删除
-				files.DELETE("/:file_id", deps.ImportExportHandler.DeleteFile)
-			}
+			// 权限决策日志，用于访问审查
+			admin.GET("/permission-audits", deps.PermissionAuditHandler.ListPermissionAudits)
 
-			// 权限相关API
-			permissions := authorized.Group("/permissions")
-			{
-				// 获取当前用户权限信息
-				permissions.GET("/current", deps.PermissionMiddleware.GetUserPermissions())
+			// 进程运行时状态，比 pprof 轻量，用于故障排查
+			admin.GET("/runtime", deps.HealthHandler.Runtime)
+		}
 
-				// 获取权限摘要
-				permissions.GET("/summary", deps.PermissionMiddleware.GetPermissionSummary())
+		// 轮换用户的全部令牌 - 本人或超级管理员
+		authorized.POST("/users/:id/tokens/rotate", deps.PermissionMiddleware.RequireLogin(), middleware.RequireJSON(), deps.UserHandler.RotateTokens)
 
-				// 获取可用角色列表
-				permissions.GET("/roles", deps.PermissionMiddleware.GetAvailableRoles())
+		// 当前用户信息 - 需要登录
+		authorized.GET("/profile", deps.PermissionMiddleware.RequireLogin(), deps.UserHandler.GetProfile)
+		authorized.PUT("/password", deps.PermissionMiddleware.RequireLogin(), middleware.RequireJSON(), deps.UserHandler.ChangePassword)
 
-				// 设置用户角色 - 需要管理员权限
-				permissions.POST("/set-role", deps.PermissionMiddleware.RequireAdmin(), deps.PermissionMiddleware.SetUserRole())
-			}
+		// 当前用户的活跃会话 - 需要登录，DELETE /sessions 保留发起请求的会话
+		authorized.GET("/sessions", deps.PermissionMiddleware.RequireLogin(), deps.UserHandler.ListMySessions)
+		authorized.DELETE("/sessions", deps.PermissionMiddleware.RequireLogin(), deps.UserHandler.TerminateMyOtherSessions)
+		authorized.DELETE("/sessions/:session_id", deps.PermissionMiddleware.RequireLogin(), deps.UserHandler.TerminateMySession)
+
+		// 导入导出功能 - 需要VIP或以上权限
+		importExport := authorized.Group("/import-export")
+		importExport.Use(deps.PermissionMiddleware.RequireVIP())
+		// 导出参数以表单提交
+		exportForm := middleware.RequireContentType(middleware.MIMEForm, middleware.MIMEMultipartForm)
+		{
+			// 获取支持的数据类型
+			importExport.GET("/supported-types", deps.ImportExportHandler.GetSupportedDataTypes)
+
+			// 获取数据类型的字段结构，用于生成导入映射界面
+			importExport.GET("/schema", deps.ImportExportHandler.GetDataSchema)
+
+			// 数据导入
+			importExport.POST("/import", middleware.RequireMultipart(), deps.ImportExportHandler.ImportData)
+
+			// 异步导入任务
+			importExport.POST("/import/async", middleware.RequireMultipart(), deps.ImportExportHandler.SubmitImportJob)
+			importExport.GET("/jobs/:job_id", deps.ImportExportHandler.GetImportJob)
+			// 只重新导入任务中失败的行，可上传修正后的文件
+			importExport.POST("/jobs/:job_id/reimport", middleware.RequireMultipart(), deps.ImportExportHandler.ReimportImportJob)
+
+			// 导入前的数据概况，不导入数据
+			importExport.POST("/import/profile", middleware.RequireMultipart(), deps.ImportExportHandler.ProfileImport)
+
+			// 数据导出
+			importExport.POST("/export", exportForm, middleware.StreamingResponse(), deps.ImportExportHandler.ExportData)
+
+			// 异步导出任务
+			importExport.POST("/export/async", exportForm, deps.ImportExportHandler.StartExportJob)
+			importExport.GET("/export/jobs/:job_id", deps.ImportExportHandler.GetExportJob)
+			importExport.POST("/export/jobs/:job_id/cancel", deps.ImportExportHandler.CancelExportJob)
+			importExport.GET("/export/jobs/:job_id/download", middleware.StreamingResponse(), deps.ImportExportHandler.DownloadExportJob)
+
+			// 获取导入模板
+			importExport.GET("/template", deps.ImportExportHandler.GetImportTemplate)
+		}
+
+		// 文件管理功能 - 需要登录
+		files := authorized.Group("/files")
+		files.Use(deps.PermissionMiddleware.RequireLogin())
+		{
+			// 文件上传
+			files.POST("/upload", middleware.RequireMultipart(), deps.ImportExportHandler.UploadFile)
+
+			// 批量文件上传
+			files.POST("/upload/batch", middleware.RequireMultipart(), deps.ImportExportHandler.UploadFiles)
+
+			// 分片上传：创建会话、上传分片（可乱序、重传）、查询已上传的分片、合并
+			files.POST("/upload/init", middleware.RequireJSON(), deps.ImportExportHandler.InitChunkUpload)
+			files.POST("/upload/chunk", middleware.RequireMultipart(), deps.ImportExportHandler.UploadChunk)
+			files.POST("/upload/complete", middleware.RequireJSON(), deps.ImportExportHandler.CompleteChunkUpload)
+			files.GET("/upload/:upload_id", deps.ImportExportHandler.GetChunkUpload)
+
+			// 文件列表
+			files.GET("", deps.ImportExportHandler.ListFiles)
+
+			// 文件信息
+			files.GET("/:file_id/info", deps.ImportExportHandler.GetFileInfo)
+
+			// 生成限时下载链接
+			files.GET("/:file_id/presign", deps.ImportExportHandler.PresignFileURL)
+
+			// 文件删除
+			files.DELETE("/:file_id", middleware.RequireJSON(), deps.ImportExportHandler.DeleteFile)
+
+			// 文件标签：替换、添加、移除
+			files.PUT("/:file_id/tags", middleware.RequireJSON(), deps.ImportExportHandler.SetFileTags)
+			files.POST("/:file_id/tags", middleware.RequireJSON(), deps.ImportExportHandler.AddFileTags)
+			files.DELETE("/:file_id/tags", middleware.RequireJSON(), deps.ImportExportHandler.RemoveFileTags)
+
+			// 批量为文件添加标签
+			files.POST("/tags", middleware.RequireJSON(), deps.ImportExportHandler.BulkAddFileTags)
+		}
+
+		// 权限相关API
+		permissions := authorized.Group("/permissions")
+		permissions.Use(middleware.RequireJSON())
+		{
+			// 获取当前用户权限信息
+			permissions.GET("/current", deps.PermissionMiddleware.GetUserPermissions())
+
+			// 获取权限摘要
+			permissions.GET("/summary", deps.PermissionMiddleware.GetPermissionSummary())
+
+			// 获取可用角色列表
+			permissions.GET("/roles", deps.PermissionMiddleware.GetAvailableRoles())
+
+			// 获取角色权限矩阵 - 需要管理员权限
+			permissions.GET("/matrix", deps.PermissionMiddleware.RequireAdmin(), deps.PermissionMiddleware.GetPermissionMatrix())
+
+			// 分页获取角色权限列表 - 需要管理员权限
+			permissions.GET("/role-permissions", deps.PermissionMiddleware.RequireAdmin(), deps.PermissionMiddleware.ListRolePermissions())
+
+			// 复制角色/用户组权限 - 需要管理员权限
+			permissions.POST("/clone-role", deps.PermissionMiddleware.RequireAdmin(), deps.PermissionMiddleware.ClonePermissions())
+			permissions.POST("/clone-group", deps.PermissionMiddleware.RequireAdmin(), deps.PermissionMiddleware.CloneGroupPermissions())
+
+			// 将用户加入用户组 - 需要管理员权限
+			permissions.POST("/group-members", deps.PermissionMiddleware.RequireAdmin(), deps.PermissionMiddleware.AddUserToGroup())
+
+			// 设置用户角色 - 需要管理员权限
+			permissions.POST("/set-role", deps.PermissionMiddleware.RequireAdmin(), deps.PermissionMiddleware.SetUserRole())
 		}
+	}
+}
 
-		// 文件下载 (公开)
-		r.GET("/files/download/:file_id", deps.ImportExportHandler.DownloadFile)
+// routeTimeouts 按路由覆盖的请求超时
+func routeTimeouts() map[string]time.Duration {
+	overrides := make(map[string]time.Duration, len(config.Global.Performance.RouteTimeouts))
+	for route, seconds := range config.Global.Performance.RouteTimeouts {
+		overrides[route] = time.Duration(seconds) * time.Second
 	}
+	return overrides
+}
 
-	return r
+// newCORSPolicies 根据安全配置创建CORS策略
+// 默认策略使用 cors_origins，cors_policies 中的命名策略由路由组绑定
+func newCORSPolicies() *middleware.CORSPolicies {
+	security := config.Global.Security
+
+	// 明确配置了来源时才允许携带凭证，任意来源（"*"）不允许
+	defaultPolicy := middleware.DefaultCORSPolicy()
+	if len(security.CORSOrigins) > 0 && !slices.Contains(security.CORSOrigins, "*") {
+		defaultPolicy.AllowOrigins = security.CORSOrigins
+		defaultPolicy.AllowCredentials = true
+	}
+
+	named := make(map[string]*middleware.CORSPolicy, len(security.CORSPolicies))
+	for name, cfg := range security.CORSPolicies {
+		policy := middleware.DefaultCORSPolicy()
+		policy.AllowOrigins = cfg.AllowOrigins
+		if len(cfg.AllowMethods) > 0 {
+			policy.AllowMethods = cfg.AllowMethods
+		}
+		if len(cfg.AllowHeaders) > 0 {
+			policy.AllowHeaders = cfg.AllowHeaders
+		}
+		policy.AllowCredentials = cfg.AllowCredentials
+		policy.MaxAge = cfg.MaxAge
+		named[name] = policy
+	}
+
+	return middleware.NewCORSPolicies(defaultPolicy, named)
 }
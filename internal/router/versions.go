@@ -0,0 +1,91 @@
+package router
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/middleware"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// API 版本
+const (
+	APIVersionV1 = "v1"
+	APIVersionV2 = "v2"
+)
+
+// apiBasePath API 路由前缀，版本路由组为 /api/{version}
+const apiBasePath = "/api"
+
+// RouteRegistrar 在版本路由组上注册路由
+type RouteRegistrar func(group *gin.RouterGroup)
+
+// VersionedRouter API 多版本路由
+// 未变化的接口通过 Register 同时挂载到多个版本，有破坏性变更的接口只注册到新版本
+type VersionedRouter struct {
+	versions []string
+	groups   map[string]*gin.RouterGroup
+}
+
+// NewVersionedRouter 创建多版本路由，按 api.versions 配置为已弃用版本挂载 Deprecation 中间件
+func NewVersionedRouter(engine *gin.Engine, versions ...string) *VersionedRouter {
+	vr := &VersionedRouter{
+		versions: versions,
+		groups:   make(map[string]*gin.RouterGroup, len(versions)),
+	}
+	for _, version := range versions {
+		group := engine.Group(apiBasePath + "/" + version)
+		if cfg, ok := config.Global.API.Versions[version]; ok && cfg.Deprecated {
+			group.Use(middleware.Deprecation(newDeprecationPolicy(version, cfg)))
+		}
+		vr.groups[version] = group
+	}
+	return vr
+}
+
+// Version 获取指定版本的路由组，版本未声明时返回 nil
+func (vr *VersionedRouter) Version(version string) *gin.RouterGroup {
+	return vr.groups[version]
+}
+
+// Register 将同一组路由注册到多个版本，未指定版本时注册到全部版本
+func (vr *VersionedRouter) Register(registrar RouteRegistrar, versions ...string) {
+	if len(versions) == 0 {
+		versions = vr.versions
+	}
+	for _, version := range versions {
+		group, ok := vr.groups[version]
+		if !ok {
+			logger.Warn("API 版本未声明，跳过路由注册", zap.String("version", version))
+			continue
+		}
+		registrar(group)
+	}
+}
+
+// newDeprecationPolicy 根据版本配置创建弃用策略，时间格式为 RFC3339，解析失败时忽略该项
+func newDeprecationPolicy(version string, cfg config.APIVersionConfig) middleware.DeprecationPolicy {
+	policy := middleware.DeprecationPolicy{DocLink: cfg.DocLink}
+	if cfg.Successor != "" {
+		policy.FromPrefix = apiBasePath + "/" + version
+		policy.ToPrefix = apiBasePath + "/" + cfg.Successor
+	}
+	if cfg.DeprecatedAt != "" {
+		t, err := time.Parse(time.RFC3339, cfg.DeprecatedAt)
+		if err != nil {
+			logger.Warn("API 弃用时间格式错误", zap.String("version", version), zap.Error(err))
+		}
+		policy.DeprecatedAt = t
+	}
+	if cfg.Sunset != "" {
+		t, err := time.Parse(time.RFC3339, cfg.Sunset)
+		if err != nil {
+			logger.Warn("API 下线时间格式错误", zap.String("version", version), zap.Error(err))
+		}
+		policy.Sunset = t
+	}
+	return policy
+}
@@ -0,0 +1,13 @@
+package service
+
+import "github.com/VennLe/charlotte/internal/model"
+
+// isAdminRole 是否为管理员角色，管理员可访问其他用户的任务和文件
+func isAdminRole(role string) bool {
+	return role == model.RoleAdmin || role == model.RoleSuperAdmin
+}
+
+// canAccessOwned 用户能否访问 ownerID 所有的资源，仅本人和管理员可以
+func canAccessOwned(ownerID, userID uint, role string) bool {
+	return ownerID == userID || isAdminRole(role)
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/VennLe/charlotte/internal/model"
+)
+
+// ErrAccountDisabled 账号不可用（禁用、待激活、锁定或已注销），各状态的具体错误均可用 errors.Is 匹配
+var ErrAccountDisabled = errors.New("账号已被禁用")
+
+// accountStatusMessages 非正常状态对应的提示
+var accountStatusMessages = map[model.Status]string{
+	model.StatusDisabled: "账号已被禁用",
+	model.StatusPending:  "账号尚未激活",
+	model.StatusLocked:   "账号已被锁定",
+	model.StatusDeleted:  "账号已注销",
+}
+
+// accountStatusErr 账号状态错误，提示随状态变化
+type accountStatusErr struct {
+	status model.Status
+}
+
+func (e *accountStatusErr) Error() string {
+	if msg, ok := accountStatusMessages[e.status]; ok {
+		return msg
+	}
+	return "账号状态异常"
+}
+
+func (e *accountStatusErr) Is(target error) bool {
+	return target == ErrAccountDisabled
+}
+
+// accountStatusError 非正常状态账号的错误
+func accountStatusError(status model.Status) error {
+	return &accountStatusErr{status: status}
+}
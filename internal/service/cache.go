@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VennLe/charlotte/internal/dao"
+)
+
+// ErrCacheUnavailable Redis 未初始化，缓存功能不可用
+var ErrCacheUnavailable = errors.New("缓存服务不可用")
+
+// ErrInvalidCacheModel 无效的缓存模型名
+var ErrInvalidCacheModel = dao.ErrInvalidCacheModel
+
+// CacheService 缓存运维服务
+type CacheService struct {
+	invalidator *dao.CacheInvalidator
+}
+
+// NewCacheService 创建缓存运维服务，redisClient 为 nil 时各操作返回 ErrCacheUnavailable
+func NewCacheService(redisClient *redis.Client) *CacheService {
+	s := &CacheService{}
+	if redisClient != nil {
+		s.invalidator = dao.NewCacheInvalidator(redisClient, dao.UserCachePrefix)
+	}
+	return s
+}
+
+// InvalidateKey 清除指定模型记录的缓存（同时清除该模型的列表和条件缓存）
+func (s *CacheService) InvalidateKey(ctx context.Context, modelName, id string) error {
+	if s.invalidator == nil {
+		return ErrCacheUnavailable
+	}
+	return s.invalidator.Invalidate(ctx, modelName, id)
+}
+
+// InvalidateModel 清除指定模型的全部缓存，返回删除的键数量
+func (s *CacheService) InvalidateModel(ctx context.Context, modelName string) (int64, error) {
+	if s.invalidator == nil {
+		return 0, ErrCacheUnavailable
+	}
+	return s.invalidator.InvalidateModel(ctx, modelName)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultExportFileNameTemplate 默认导出文件名模板，即 <数据类型>_<时间戳>
+const defaultExportFileNameTemplate = "{data_type}_{datetime}"
+
+// maxExportFileNameLength 导出文件名（不含扩展名）的最大字节数
+const maxExportFileNameLength = 200
+
+// exportFileNamePlaceholder 文件名模板占位符
+var exportFileNamePlaceholder = regexp.MustCompile(`\{([a-z_]*)\}`)
+
+// exportFileNameVars 文件名模板变量
+type exportFileNameVars struct {
+	DataType string
+	User     string
+	Count    int
+	Time     time.Time
+}
+
+// renderExportFileName 按模板生成导出文件名（不含扩展名）
+// 支持的占位符：{data_type} 数据类型、{date} 日期(20060102)、{datetime} 日期时间(20060102150405)、
+// {user} 导出用户、{count} 导出记录数。未知占位符返回错误，渲染结果会经过 sanitizeExportFileName 处理
+func renderExportFileName(tmpl string, vars exportFileNameVars) (string, error) {
+	var unknown string
+	name := exportFileNamePlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		switch m[1 : len(m)-1] {
+		case "data_type":
+			return vars.DataType
+		case "date":
+			return vars.Time.Format("20060102")
+		case "datetime":
+			return vars.Time.Format("20060102150405")
+		case "user":
+			if vars.User == "" {
+				return "anonymous"
+			}
+			return vars.User
+		case "count":
+			return strconv.Itoa(vars.Count)
+		default:
+			if unknown == "" {
+				unknown = m
+			}
+			return m
+		}
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("文件名模板包含未知占位符: %s", unknown)
+	}
+	return sanitizeExportFileName(name), nil
+}
+
+// validateExportFileNameTemplate 校验导出文件名模板
+func validateExportFileNameTemplate(tmpl string) error {
+	_, err := renderExportFileName(tmpl, exportFileNameVars{})
+	return err
+}
+
+// sanitizeExportFileName 清理文件名：路径分隔符、控制字符和 Windows 保留字符替换为下划线，
+// 去掉首尾的点和空白，并按 UTF-8 边界截断到 maxExportFileNameLength 字节
+func sanitizeExportFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, ". \t")
+
+	if len(name) > maxExportFileNameLength {
+		cut := maxExportFileNameLength
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = strings.TrimRight(name[:cut], ". ")
+	}
+	return name
+}
+
+// exportFileName 确定导出文件名
+// 请求指定了文件名时只做清理并补全扩展名；否则按请求模板、import_export.file_name_template、默认模板的顺序渲染
+func exportFileName(req *ExportRequest, configTemplate string, count int) (string, error) {
+	ext := "." + exportFileExtension(req.FileType)
+
+	if req.FileName != "" {
+		name := sanitizeExportFileName(strings.TrimSuffix(req.FileName, ext))
+		if name != "" {
+			return name + ext, nil
+		}
+	}
+
+	tmpl := req.FileNameTemplate
+	if tmpl == "" {
+		tmpl = configTemplate
+	}
+	if tmpl == "" {
+		tmpl = defaultExportFileNameTemplate
+	}
+	name, err := renderExportFileName(tmpl, exportFileNameVars{
+		DataType: req.DataType,
+		User:     req.User,
+		Count:    count,
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = req.DataType + "_" + time.Now().Format("20060102150405")
+	}
+	return name + ext, nil
+}
+
+// exportFileExtension 导出文件扩展名，excel 导出为 xlsx
+func exportFileExtension(fileType string) string {
+	fileType = strings.ToLower(fileType)
+	if fileType == "excel" {
+		return "xlsx"
+	}
+	return fileType
+}
+
+// exportRecordCount 导出数据的记录数，非切片时返回 0
+func exportRecordCount(data interface{}) int {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return 0
+	}
+	return v.Len()
+}
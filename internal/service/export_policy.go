@@ -0,0 +1,35 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// exportAllowedFields 角色对数据类型可导出的字段，未配置时 restricted 为 false 表示不限制
+func exportAllowedFields(role, dataType string) (allowed []string, restricted bool) {
+	byType, ok := config.Global.Permission.ExportFields[strings.ToLower(role)]
+	if !ok {
+		return nil, false
+	}
+	allowed, restricted = byType[strings.ToLower(dataType)]
+	return allowed, restricted
+}
+
+// exportOmittedFields 按导出者角色计算不允许导出的字段，data 为待导出的数据
+// 列与允许字段取交集，其余字段全部去掉，按列顺序返回
+func exportOmittedFields(role, dataType string, data interface{}, exportConfig *utils.ExportConfig) []string {
+	allowed, restricted := exportAllowedFields(role, dataType)
+	if !restricted {
+		return nil
+	}
+
+	var omitted []string
+	for _, field := range utils.ExportColumnFields(data, exportConfig) {
+		if !containsFold(allowed, field) {
+			omitted = append(omitted, field)
+		}
+	}
+	return omitted
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/VennLe/charlotte/internal/dao"
+)
+
+// defaultExportPageSize 未配置 import_export.export_page_size 时每页查询的条数
+const defaultExportPageSize = 1000
+
+// ErrInvalidExportFilter 导出过滤条件不被处理器支持
+var ErrInvalidExportFilter = errors.New("不支持的导出过滤条件")
+
+// ExportQuery 导出数据的分页查询条件，由导出流程逐页递增 Page 调用 DataProcessor.GetExportData
+type ExportQuery struct {
+	Page    int                    // 页码，从 1 开始
+	Size    int                    // 每页条数，处理器返回的条数不应超过该值
+	Keyword string                 // 关键词，匹配的字段由处理器决定
+	Filters map[string]interface{} // 过滤条件，支持的键由处理器定义，不支持的键应返回 ErrInvalidExportFilter
+}
+
+// parseExportFilters 解析导出请求中的过滤条件 JSON 对象
+func parseExportFilters(raw string) (map[string]interface{}, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var filters map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &filters); err != nil {
+		return nil, fmt.Errorf("解析导出过滤条件失败: %v", err)
+	}
+	return filters, nil
+}
+
+// collectExportData 逐页获取处理器的导出数据并合并为一个切片
+// 处理器每页返回的数据必须是同一类型的切片；声明还有数据却返回空页时视为错误，避免死循环
+func collectExportData(ctx context.Context, processor DataProcessor, query ExportQuery) (interface{}, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Size < 1 {
+		query.Size = defaultExportPageSize
+	}
+	// 与 DAO 的分页上限一致，处理器按 Size 判断是否还有下一页
+	query.Size = min(query.Size, dao.MaxPageSize)
+
+	var all reflect.Value
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data, hasMore, err := processor.GetExportData(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		page := reflect.ValueOf(data)
+		if page.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("处理器 %s 返回的导出数据不是切片: %T", processor.GetDataType(), data)
+		}
+		if !all.IsValid() {
+			all = reflect.MakeSlice(page.Type(), 0, page.Len())
+		} else if page.Type() != all.Type() {
+			return nil, fmt.Errorf("处理器 %s 各页返回的数据类型不一致: %s != %s", processor.GetDataType(), page.Type(), all.Type())
+		}
+		all = reflect.AppendSlice(all, page)
+
+		if !hasMore {
+			return all.Interface(), nil
+		}
+		if page.Len() == 0 {
+			return nil, fmt.Errorf("处理器 %s 第 %d 页为空但声明还有数据", processor.GetDataType(), query.Page)
+		}
+		query.Page++
+	}
+}
@@ -1,26 +1,47 @@
 package service
 
 import (
+	"bytes"
+	"cmp"
 	"context"
-	"crypto/md5"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/internal/model"
 	"github.com/VennLe/charlotte/pkg/logger"
 )
 
+// ErrFileNotFound 文件不存在
+var ErrFileNotFound = errors.New("文件不存在")
+
+// defaultFileCategory 未指定分类时文件保存的分类
+const defaultFileCategory = "general"
+
 // FileService 文件服务
 type FileService struct {
-	basePath string
+	basePath string          // 本地目录，local 存储的根目录，也用于异步导入导出任务的临时文件
+	storage  StorageBackend  // 上传文件的存储后端
+	webhooks *WebhookService // 文件上传事件的 Webhook，为 nil 时不推送
+	tags     *dao.FileTagDAO // 文件标签存储，为 nil 时不支持标签
+	files    *dao.FileDAO    // 文件记录存储，为 nil 时不支持去重
+
+	chunkUploads   map[string]*chunkUpload // 进行中的分片上传会话
+	chunkUploadsMu sync.Mutex
 }
 
 // NewFileService 创建文件服务
@@ -35,8 +56,17 @@ func NewFileService() *FileService {
 		logger.Error("创建上传目录失败", zap.String("path", basePath), zap.Error(err))
 	}
 
+	storage, err := newStorageBackend(config.Global.File, basePath)
+	if err != nil {
+		logger.Error("初始化文件存储失败，回退为本地存储",
+			zap.String("storage_type", config.Global.File.StorageType), zap.Error(err))
+		storage = &LocalBackend{root: basePath}
+	}
+
 	return &FileService{
-		basePath: basePath,
+		basePath:     basePath,
+		storage:      storage,
+		chunkUploads: make(map[string]*chunkUpload),
 	}
 }
 
@@ -54,6 +84,9 @@ type FileInfo struct {
 	UploadTime  time.Time `json:"upload_time"`
 	UploaderID  uint      `json:"uploader_id,omitempty"`
 	UploaderName string    `json:"uploader_name,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	IsPublic     bool      `json:"is_public"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"` // 仅图片有缩略图
 }
 
 // UploadRequest 上传请求
@@ -81,6 +114,10 @@ type ListFilesRequest struct {
 	Page     int    `form:"page" default:"1"`
 	Size     int    `form:"size" default:"20"`
 	Keyword  string `form:"keyword"`
+	// Tags 按标签过滤，支持 tags=a&tags=b 或 tags=a,b
+	Tags []string `form:"tags"`
+	// TagMatch 标签匹配方式：any 带有任一标签（默认），all 带有全部标签
+	TagMatch string `form:"tag_match" binding:"omitempty,oneof=any all"`
 }
 
 // ListFilesResponse 文件列表响应
@@ -98,157 +135,457 @@ type DeleteFileRequest struct {
 
 // UploadFile 上传文件
 func (s *FileService) UploadFile(ctx context.Context, req *UploadRequest, uploaderID uint, uploaderName string) (*UploadResponse, error) {
-	// 验证文件大小
-	maxSize := config.Global.File.MaxUploadSize
-	if maxSize == 0 {
-		maxSize = 10 * 1024 * 1024 // 默认10MB
+	src := multipartSource(req.File)
+	if err := s.validateUpload(src); err != nil {
+		return nil, err
 	}
 
-	if req.File.Size > maxSize {
-		return nil, fmt.Errorf("文件大小超过限制: %d > %d", req.File.Size, maxSize)
+	md5sum, err := s.fileMD5(src)
+	if err != nil {
+		return nil, err
 	}
 
-	// 验证文件类型
-	if err := s.validateFileType(req.File); err != nil {
+	fileInfo, err := s.saveUploadedFile(ctx, src, md5sum, req.Category, req.IsPublic, uploaderID, uploaderName)
+	if err != nil {
 		return nil, err
 	}
+	go s.webhooks.Dispatch(context.WithoutCancel(ctx), model.EventFileUploaded, fileInfo)
 
-	// 打开文件
-	srcFile, err := req.File.Open()
+	return &UploadResponse{
+		FileInfo: fileInfo,
+		Message:  "文件上传成功",
+	}, nil
+}
+
+// UploadFilesRequest 批量上传请求
+type UploadFilesRequest struct {
+	Files       []*multipart.FileHeader `form:"files[]" binding:"required"`
+	Category    string                  `form:"category"` // 文件分类
+	Description string                  `form:"description"`
+	IsPublic    bool                    `form:"is_public"`
+}
+
+// UploadFileResult 批量上传中单个文件的处理结果
+type UploadFileResult struct {
+	Index        int       `json:"index"` // 文件在请求中的序号（从0开始）
+	OriginalName string    `json:"original_name"`
+	Success      bool      `json:"success"`
+	Error        string    `json:"error,omitempty"`
+	Duplicate    bool      `json:"duplicate,omitempty"`    // 与同一请求中较早的文件内容相同，未重复保存
+	DuplicateOf  *int      `json:"duplicate_of,omitempty"` // 重复文件对应的序号
+	FileInfo     *FileInfo `json:"file_info,omitempty"`
+}
+
+// UploadFilesResponse 批量上传响应
+type UploadFilesResponse struct {
+	Total   int                 `json:"total"`
+	Success int                 `json:"success"`
+	Failed  int                 `json:"failed"`
+	Results []*UploadFileResult `json:"results"`
+	Message string              `json:"message"`
+}
+
+// UploadFiles 批量上传文件
+// 各文件在有限的并发数下独立校验、去重和保存，单个文件失败不影响其他文件，结果按请求顺序返回
+func (s *FileService) UploadFiles(ctx context.Context, req *UploadFilesRequest, uploaderID uint, uploaderName string) (*UploadFilesResponse, error) {
+	if len(req.Files) == 0 {
+		return nil, fmt.Errorf("未选择上传文件")
+	}
+
+	maxFiles := config.Global.File.MaxBatchFiles
+	if maxFiles <= 0 {
+		maxFiles = 20
+	}
+	if len(req.Files) > maxFiles {
+		return nil, fmt.Errorf("文件数量超过限制: %d > %d", len(req.Files), maxFiles)
+	}
+
+	concurrency := config.Global.File.UploadConcurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	if concurrency > len(req.Files) {
+		concurrency = len(req.Files)
+	}
+
+	results := make([]*UploadFileResult, len(req.Files))
+	md5Seen := make(map[string]int)
+	var md5Mu sync.Mutex
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = s.uploadOne(ctx, i, req.Files[i], req.Category, req.IsPublic, uploaderID, uploaderName, md5Seen, &md5Mu)
+			}
+		}()
+	}
+	for i := range req.Files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	resp := &UploadFilesResponse{
+		Total:   len(results),
+		Results: results,
+	}
+	for _, result := range results {
+		// 重复文件引用首个相同文件的保存结果
+		if result.Duplicate && result.DuplicateOf != nil {
+			first := results[*result.DuplicateOf]
+			result.Success = first.Success
+			result.Error = first.Error
+			result.FileInfo = first.FileInfo
+		}
+		if result.Success {
+			resp.Success++
+		} else {
+			resp.Failed++
+		}
+	}
+
+	switch {
+	case resp.Failed == 0:
+		resp.Message = "文件上传成功"
+	case resp.Success == 0:
+		resp.Message = "文件上传失败"
+	default:
+		resp.Message = fmt.Sprintf("部分文件上传成功: 成功%d个，失败%d个", resp.Success, resp.Failed)
+	}
+
+	logger.Info("批量上传完成",
+		zap.Int("total", resp.Total),
+		zap.Int("success", resp.Success),
+		zap.Int("failed", resp.Failed),
+		zap.Uint("uploader_id", uploaderID),
+	)
+
+	return resp, nil
+}
+
+// uploadOne 处理批量上传中的单个文件，md5Seen 记录本次请求中已处理的文件内容用于去重
+func (s *FileService) uploadOne(ctx context.Context, index int, file *multipart.FileHeader, category string, isPublic bool, uploaderID uint, uploaderName string, md5Seen map[string]int, md5Mu *sync.Mutex) *UploadFileResult {
+	result := &UploadFileResult{
+		Index:        index,
+		OriginalName: file.Filename,
+	}
+
+	if err := ctx.Err(); err != nil {
+		result.Error = "上传已取消"
+		return result
+	}
+
+	src := multipartSource(file)
+	if err := s.validateUpload(src); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	md5sum, err := s.fileMD5(src)
 	if err != nil {
-		return nil, fmt.Errorf("打开文件失败: %v", err)
+		result.Error = err.Error()
+		return result
 	}
-	defer srcFile.Close()
 
-	// 计算MD5
-	hash := md5.New()
-	if _, err := io.Copy(hash, srcFile); err != nil {
-		return nil, fmt.Errorf("计算文件MD5失败: %v", err)
+	md5Mu.Lock()
+	if first, ok := md5Seen[md5sum]; ok {
+		md5Mu.Unlock()
+		result.Duplicate = true
+		result.DuplicateOf = &first
+		return result
 	}
+	md5Seen[md5sum] = index
+	md5Mu.Unlock()
 
-	// 重置文件指针
-	srcFile.Seek(0, 0)
+	fileInfo, err := s.saveUploadedFile(ctx, src, md5sum, category, isPublic, uploaderID, uploaderName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	go s.webhooks.Dispatch(context.WithoutCancel(ctx), model.EventFileUploaded, fileInfo)
 
-	md5sum := hex.EncodeToString(hash.Sum(nil))
+	result.Success = true
+	result.FileInfo = fileInfo
+	return result
+}
 
-	// 生成文件ID和路径
-	fileID := s.generateFileID(req.File.Filename, md5sum)
-	filePath := s.generateFilePath(fileID, req.File.Filename, req.Category)
+// uploadSource 待校验和保存的上传文件，表单上传的文件和分片上传合并后的文件都转换为它
+type uploadSource struct {
+	Filename    string
+	Size        int64
+	ContentType string // 客户端声明的类型，仅在关闭严格类型校验时参与判断
+	open        func() (io.ReadCloser, error)
+}
 
-	// 创建目录
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-		return nil, fmt.Errorf("创建目录失败: %v", err)
+// Open 打开文件内容，返回的读取器可定位（multipart.File 或 *os.File）
+func (f *uploadSource) Open() (io.ReadCloser, error) {
+	return f.open()
+}
+
+// multipartSource 表单上传的文件
+func multipartSource(file *multipart.FileHeader) *uploadSource {
+	return &uploadSource{
+		Filename:    file.Filename,
+		Size:        file.Size,
+		ContentType: file.Header.Get("Content-Type"),
+		open:        func() (io.ReadCloser, error) { return file.Open() },
+	}
+}
+
+// maxUploadSize 单个文件的大小上限
+func maxUploadSize() int64 {
+	if maxSize := config.Global.File.MaxUploadSize; maxSize > 0 {
+		return maxSize
 	}
+	return 10 * 1024 * 1024 // 默认10MB
+}
+
+// validateUpload 验证上传文件的大小和类型
+func (s *FileService) validateUpload(file *uploadSource) error {
+	if maxSize := maxUploadSize(); file.Size > maxSize {
+		return fmt.Errorf("文件大小超过限制: %d > %d", file.Size, maxSize)
+	}
+
+	return s.validateFileType(file)
+}
 
-	// 保存文件
-	dstFile, err := os.Create(filePath)
+// fileMD5 计算上传文件的MD5
+func (s *FileService) fileMD5(file *uploadSource) (string, error) {
+	srcFile, err := file.Open()
 	if err != nil {
-		return nil, fmt.Errorf("创建文件失败: %v", err)
+		return "", fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer srcFile.Close()
+
+	sum, err := md5Hex(srcFile)
+	if err != nil {
+		return "", fmt.Errorf("计算文件MD5失败: %v", err)
+	}
+	return sum, nil
+}
+
+// saveUploadedFile 保存上传文件并返回文件信息，isPublic 的文件无需签名即可下载
+func (s *FileService) saveUploadedFile(ctx context.Context, file *uploadSource, md5sum, category string, isPublic bool, uploaderID uint, uploaderName string) (*FileInfo, error) {
+	srcFile, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
 	}
-	defer dstFile.Close()
+	defer srcFile.Close()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
+	// 生成文件ID和存储键
+	fileID := s.generateFileID(file.Filename, md5sum)
+	filePath := s.generateFilePath(fileID, file.Filename, category)
+
+	// 保存文件并创建文件记录，开启去重时内容相同的文件复用已存储的文件
+	if s.files != nil {
+		filePath, err = s.saveWithRecord(ctx, srcFile, &model.FileRecord{
+			ID:           fileID,
+			StorageKey:   filePath,
+			MD5:          md5sum,
+			Size:         file.Size,
+			OriginalName: file.Filename,
+			MimeType:     file.ContentType,
+			Category:     cmp.Or(category, defaultFileCategory),
+			UploaderID:   uploaderID,
+			IsPublic:     isPublic,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err := s.storage.Save(ctx, filePath, srcFile); err != nil {
 		return nil, fmt.Errorf("保存文件失败: %v", err)
 	}
 
 	// 构建文件信息
 	fileInfo := &FileInfo{
 		ID:           fileID,
-		Name:         filepath.Base(filePath),
-		OriginalName: req.File.Filename,
-		Size:         req.File.Size,
-		MimeType:     req.File.Header.Get("Content-Type"),
-		Extension:    strings.ToLower(filepath.Ext(req.File.Filename)),
+		Name:         path.Base(filePath),
+		OriginalName: file.Filename,
+		Size:         file.Size,
+		MimeType:     file.ContentType,
+		Extension:    strings.ToLower(filepath.Ext(file.Filename)),
 		Path:         filePath,
-		URL:          s.generateFileURL(fileID),
+		URL:          s.generateFileURL(fileID, isPublic),
 		MD5:          md5sum,
 		UploadTime:   time.Now(),
 		UploaderID:   uploaderID,
 		UploaderName: uploaderName,
+		IsPublic:     isPublic,
+	}
+	if s.createThumbnail(ctx, file, fileID, filePath) {
+		fileInfo.ThumbnailURL = s.generateFileURL(fileID+thumbnailIDSuffix, isPublic)
 	}
 
 	logger.Info("文件上传成功",
 		zap.String("file_id", fileID),
-		zap.String("filename", req.File.Filename),
-		zap.Int64("size", req.File.Size),
+		zap.String("filename", file.Filename),
+		zap.Int64("size", file.Size),
 		zap.String("md5", md5sum),
 		zap.Uint("uploader_id", uploaderID),
 	)
 
-	return &UploadResponse{
-		FileInfo: fileInfo,
-		Message:  "文件上传成功",
-	}, nil
+	return fileInfo, nil
 }
 
-// DownloadFile 下载文件
-func (s *FileService) DownloadFile(ctx context.Context, fileID string) (*FileInfo, *os.File, error) {
+// DownloadFile 下载文件，返回的文件内容由调用方关闭；本地存储时可转换为 io.ReadSeeker 以支持断点续传
+func (s *FileService) DownloadFile(ctx context.Context, fileID string) (*FileInfo, io.ReadCloser, error) {
 	// 查找文件
-	filePath, err := s.findFilePath(fileID)
+	object, err := s.findFile(ctx, fileID)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// 打开文件
-	file, err := os.Open(filePath)
+	file, err := s.storage.Open(ctx, object.Key)
 	if err != nil {
-		return nil, nil, fmt.Errorf("打开文件失败: %v", err)
+		return nil, nil, fmt.Errorf("打开文件失败: %w", err)
 	}
 
-	// 获取文件信息
-	fileStat, err := file.Stat()
+	// 按文件内容识别类型，不信任扩展名，避免伪装成图片的 HTML 被内联展示
+	mimeType, file, err := detectFileMimeType(file)
 	if err != nil {
 		file.Close()
-		return nil, nil, fmt.Errorf("获取文件信息失败: %v", err)
+		return nil, nil, fmt.Errorf("识别文件类型失败: %v", err)
 	}
 
 	fileInfo := &FileInfo{
-		ID:          fileID,
-		Name:        filepath.Base(filePath),
-		Size:        fileStat.Size(),
-		Path:        filePath,
-		UploadTime:  fileStat.ModTime(),
+		ID:           fileID,
+		Name:         path.Base(object.Key),
+		OriginalName: path.Base(object.Key),
+		Size:         object.Size,
+		MimeType:     mimeType,
+		Extension:    path.Ext(object.Key),
+		Path:         object.Key,
+		UploadTime:   object.ModTime,
 	}
 
 	return fileInfo, file, nil
 }
 
+// readCloser 组合读取和关闭，用于把已读出的文件头放回读取流
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// detectFileMimeType 根据文件头识别 MIME 类型，返回从头读取的文件内容：
+// 可定位的文件将偏移重置到开头，否则把已读出的文件头拼接回去
+func detectFileMimeType(file io.ReadCloser) (string, io.ReadCloser, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", file, err
+	}
+	mimeType := http.DetectContentType(buf[:n])
+
+	if seeker, ok := file.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return "", file, err
+		}
+		return mimeType, file, nil
+	}
+	return mimeType, &readCloser{Reader: io.MultiReader(bytes.NewReader(buf[:n]), file), Closer: file}, nil
+}
+
+// 文件下载方式
+const (
+	DispositionInline     = "inline"     // 浏览器内预览
+	DispositionAttachment = "attachment" // 作为附件下载
+)
+
+// ResolveDisposition 确定文件的 Content-Disposition 类型
+// 仅当文件类型在 file.inline_types 白名单中时才允许 inline，requested 为 attachment 时强制下载；
+// requested 为空时白名单类型默认预览，其余类型一律作为附件
+func (s *FileService) ResolveDisposition(fileInfo *FileInfo, requested string) string {
+	if requested == DispositionAttachment {
+		return DispositionAttachment
+	}
+
+	mediaType, _, err := mime.ParseMediaType(fileInfo.MimeType)
+	if err != nil {
+		return DispositionAttachment
+	}
+	for _, allowed := range config.Global.File.InlineTypes {
+		if strings.EqualFold(mediaType, allowed) {
+			return DispositionInline
+		}
+	}
+	return DispositionAttachment
+}
+
 // ListFiles 列出文件
 func (s *FileService) ListFiles(ctx context.Context, req *ListFilesRequest) (*ListFilesResponse, error) {
 	// 在实际应用中，这里应该查询数据库
 	// 这里简化实现，直接扫描目录
 
 	var files []*FileInfo
-	baseDir := s.basePath
+	prefix := ""
+
+	// 按标签过滤时先查出带有标签的文件ID
+	var tagged map[string]bool
+	if tags := splitFileTags(req.Tags); len(tags) > 0 {
+		var err error
+		if tagged, err = s.taggedFileIDs(ctx, tags, req.TagMatch); err != nil {
+			return nil, err
+		}
+	}
 
 	if req.Category != "" {
-		baseDir = filepath.Join(s.basePath, req.Category)
+		prefix = req.Category + "/"
 	}
 
-	// 扫描目录
-	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	// 列出存储中的文件
+	objects, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("扫描文件目录失败: %v", err)
+	}
 
-		if !info.IsDir() {
-			// 简化实现，实际应该从数据库查询
-			fileInfo := &FileInfo{
-				ID:         s.generateFileIDFromPath(path),
-				Name:       info.Name(),
-				Size:       info.Size(),
-				Path:       path,
-				UploadTime: info.ModTime(),
-			}
+	// 缩略图不作为单独的文件列出，记录在原文件的 ThumbnailURL 中
+	thumbnails := make(map[string]bool)
+	// 隐藏目录中的任务文件不对外列出
+	objects = slices.DeleteFunc(objects, func(object StorageObject) bool { return isHiddenKey(object.Key) })
 
-			files = append(files, fileInfo)
+	for _, object := range objects {
+		if isThumbnailKey(object.Key) {
+			thumbnails[strings.TrimSuffix(s.generateFileIDFromPath(object.Key), thumbnailIDSuffix)] = true
+		}
+	}
+
+	for _, object := range objects {
+		fileID := s.generateFileIDFromPath(object.Key)
+		if isThumbnailKey(object.Key) || (tagged != nil && !tagged[fileID]) {
+			continue
 		}
 
-		return nil
-	})
+		// 简化实现，实际应该从数据库查询
+		files = append(files, &FileInfo{
+			ID:         fileID,
+			Name:       path.Base(object.Key),
+			Size:       object.Size,
+			Path:       object.Key,
+			UploadTime: object.ModTime,
+		})
+	}
 
+	// 去重后引用已有存储文件的记录没有自己的存储文件，从文件记录中补充
+	references, err := s.referenceFiles(ctx, req.Category)
 	if err != nil {
-		return nil, fmt.Errorf("扫描文件目录失败: %v", err)
+		logger.FromContext(ctx).Warn("查询文件记录失败", zap.Error(err))
+	}
+	for _, file := range references {
+		if tagged == nil || tagged[file.ID] {
+			files = append(files, file)
+		}
+	}
+	// 列表对所有登录用户可见，缩略图地址不带签名，非公开文件的缩略图须通过 presign 接口获取签名链接
+	for _, file := range files {
+		if thumbnails[file.ID] {
+			file.ThumbnailURL = fileDownloadURL(file.ID + thumbnailIDSuffix)
+		}
 	}
 
 	// 分页处理
@@ -269,6 +606,10 @@ func (s *FileService) ListFiles(ctx context.Context, req *ListFilesRequest) (*Li
 		end = total
 	}
 
+	if err := s.attachTags(ctx, files[start:end]); err != nil {
+		return nil, err
+	}
+
 	return &ListFilesResponse{
 		Files: files[start:end],
 		Total: int64(total),
@@ -279,60 +620,43 @@ func (s *FileService) ListFiles(ctx context.Context, req *ListFilesRequest) (*Li
 
 // DeleteFile 删除文件
 func (s *FileService) DeleteFile(ctx context.Context, fileID string) error {
-	filePath, err := s.findFilePath(fileID)
+	// 有文件记录时只在没有其他记录引用时删除存储文件
+	key, removeBlob, err := s.releaseFileRecord(ctx, fileID)
+	if errors.Is(err, dao.ErrRecordNotFound) {
+		var object *StorageObject
+		if object, err = s.findFile(ctx, fileID); err == nil {
+			key, removeBlob = object.Key, true
+		}
+	}
 	if err != nil {
 		return err
 	}
 
-	if err := os.Remove(filePath); err != nil {
-		return fmt.Errorf("删除文件失败: %v", err)
-	}
-
-	logger.Info("文件删除成功",
-		zap.String("file_id", fileID),
-		zap.String("file_path", filePath),
-	)
-
-	return nil
-}
-
-// validateFileType 验证文件类型
-func (s *FileService) validateFileType(file *multipart.FileHeader) error {
-	allowedTypes := config.Global.File.AllowedTypes
-	if len(allowedTypes) == 0 {
-		// 默认允许的文件类型
-		allowedTypes = []string{
-			"image/jpeg", "image/png", "image/gif", "image/webp",
-			"application/pdf", "application/msword",
-			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
-			"application/vnd.ms-excel",
-			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
-			"text/plain", "text/csv", "application/json",
+	if removeBlob {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("删除文件失败: %v", err)
 		}
 	}
 
-	contentType := file.Header.Get("Content-Type")
-	for _, allowedType := range allowedTypes {
-		if contentType == allowedType {
-			return nil
+	// 缩略图按文件ID命名，不与其他记录共用；文件已删除，缩略图和标签清理失败只记录日志
+	if !isThumbnailKey(key) {
+		if err := s.storage.Delete(ctx, thumbnailKey(key, fileID)); err != nil {
+			logger.FromContext(ctx).Warn("删除缩略图失败", zap.String("file_id", fileID), zap.Error(err))
 		}
 	}
-
-	// 检查文件扩展名
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedExts := []string{
-		".jpg", ".jpeg", ".png", ".gif", ".webp",
-		".pdf", ".doc", ".docx", ".xls", ".xlsx",
-		".txt", ".csv", ".json",
-	}
-
-	for _, allowedExt := range allowedExts {
-		if ext == allowedExt {
-			return nil
+	if s.tags != nil {
+		if err := s.tags.DeleteByFile(ctx, fileID); err != nil {
+			logger.FromContext(ctx).Warn("清理文件标签失败", zap.String("file_id", fileID), zap.Error(err))
 		}
 	}
 
-	return fmt.Errorf("不支持的文件类型: %s", contentType)
+	logger.Info("文件删除成功",
+		zap.String("file_id", fileID),
+		zap.String("file_path", key),
+		zap.Bool("blob_removed", removeBlob),
+	)
+
+	return nil
 }
 
 // generateFileID 生成文件ID
@@ -341,7 +665,7 @@ func (s *FileService) generateFileID(filename, md5sum string) string {
 	return fmt.Sprintf("%s_%d", md5sum[:8], timestamp)
 }
 
-// generateFilePath 生成文件路径
+// generateFilePath 生成文件在存储中的键
 func (s *FileService) generateFilePath(fileID, filename, category string) string {
 	now := time.Now()
 	year := now.Format("2006")
@@ -349,24 +673,31 @@ func (s *FileService) generateFilePath(fileID, filename, category string) string
 	day := now.Format("02")
 
 	if category == "" {
-		category = "general"
+		category = defaultFileCategory
 	}
 
-	// 路径格式: resources/category/year/month/day/fileID_original.ext
-	path := filepath.Join(
-		s.basePath,
+	// 键格式: category/year/month/day/fileID.ext
+	return path.Join(
 		category,
 		year,
 		month,
 		day,
 		fmt.Sprintf("%s%s", fileID, filepath.Ext(filename)),
 	)
+}
 
-	return path
+// generateFileURL 生成文件访问URL，要求签名下载时非公开文件返回默认有效期的签名链接
+func (s *FileService) generateFileURL(fileID string, isPublic bool) string {
+	if !isPublic && config.Global.File.PresignedURL.Required {
+		if url, err := s.GeneratePresignedURL(fileID, 0); err == nil {
+			return url
+		}
+	}
+	return fileDownloadURL(fileID)
 }
 
-// generateFileURL 生成文件访问URL
-func (s *FileService) generateFileURL(fileID string) string {
+// fileDownloadURL 不带签名的文件下载地址
+func fileDownloadURL(fileID string) string {
 	baseURL := config.Global.Server.BaseURL
 	if baseURL == "" {
 		baseURL = "http://localhost:8080"
@@ -374,42 +705,52 @@ func (s *FileService) generateFileURL(fileID string) string {
 	return fmt.Sprintf("%s/api/v1/files/download/%s", baseURL, fileID)
 }
 
-// findFilePath 查找文件路径
-func (s *FileService) findFilePath(fileID string) (string, error) {
-	// 在实际应用中，这里应该查询数据库获取文件路径
-	// 这里简化实现，扫描目录查找文件
-
-	var foundPath string
-	err := filepath.Walk(s.basePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if !info.IsDir() {
-			filename := info.Name()
-			if strings.HasPrefix(filename, fileID) {
-				foundPath = path
-				return filepath.SkipAll
+// findFile 查找文件在存储中的位置
+func (s *FileService) findFile(ctx context.Context, fileID string) (*StorageObject, error) {
+	// 优先查询文件记录，未设置文件记录存储时上传的文件没有记录，列出存储中的文件查找
+	if s.files != nil {
+		// 缩略图没有自己的记录，按原文件的记录定位，不扫描存储（S3 上每次请求都会列出整个存储桶）
+		if parentID, ok := strings.CutSuffix(fileID, thumbnailIDSuffix); ok {
+			record, err := s.files.GetByID(ctx, parentID)
+			switch {
+			case err == nil:
+				return &StorageObject{Key: thumbnailKey(record.StorageKey, parentID), ModTime: record.CreatedAt}, nil
+			case errors.Is(err, dao.ErrRecordNotFound):
+				return nil, fmt.Errorf("%w: %s", ErrFileNotFound, fileID)
+			default:
+				return nil, fmt.Errorf("查询文件记录失败: %w", err)
 			}
 		}
 
-		return nil
-	})
+		record, err := s.files.GetByID(ctx, fileID)
+		switch {
+		case err == nil:
+			return &StorageObject{Key: record.StorageKey, Size: record.Size, ModTime: record.CreatedAt}, nil
+		case !errors.Is(err, dao.ErrRecordNotFound):
+			logger.FromContext(ctx).Warn("查询文件记录失败，改为扫描存储", zap.String("file_id", fileID), zap.Error(err))
+		}
+	}
 
+	objects, err := s.storage.List(ctx, "")
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if foundPath == "" {
-		return "", fmt.Errorf("文件不存在: %s", fileID)
+	for i := range objects {
+		if isHiddenKey(objects[i].Key) {
+			continue
+		}
+		if s.generateFileIDFromPath(objects[i].Key) == fileID {
+			return &objects[i], nil
+		}
 	}
 
-	return foundPath, nil
+	return nil, fmt.Errorf("%w: %s", ErrFileNotFound, fileID)
 }
 
 // generateFileIDFromPath 从文件路径生成文件ID
-func (s *FileService) generateFileIDFromPath(path string) string {
-	filename := filepath.Base(path)
-	ext := filepath.Ext(filename)
+func (s *FileService) generateFileIDFromPath(key string) string {
+	filename := path.Base(key)
+	ext := path.Ext(filename)
 	return strings.TrimSuffix(filename, ext)
 }
\ No newline at end of file
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/model"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// 分片上传相关错误
+var (
+	ErrChunkUploadNotFound   = errors.New("分片上传会话不存在或已过期")
+	ErrInvalidChunkUpload    = errors.New("分片上传参数无效")
+	ErrChunkUploadIncomplete = errors.New("分片尚未全部上传")
+	ErrChunkUploadBusy       = errors.New("分片上传正在合并")
+	ErrChunkMD5Mismatch      = errors.New("文件MD5校验失败")
+)
+
+// chunkUploadDir 分片的临时目录，位于上传目录下；以 . 开头，不会出现在文件列表中
+const chunkUploadDir = ".chunks"
+
+// assembledChunkFile 分片按序合并后的文件名
+const assembledChunkFile = "assembled"
+
+// chunkUpload 分片上传会话，仅保存在本实例内存中，同一会话的请求须到达同一实例
+type chunkUpload struct {
+	id          string
+	filename    string
+	size        int64 // 客户端声明的文件大小
+	category    string
+	isPublic    bool
+	uploaderID  uint
+	totalChunks int           // 由首个分片确定，之后的分片须一致
+	received    map[int]int64 // 已接收的分片序号及大小
+	completing  bool          // 正在合并，期间拒绝新的分片
+	updatedAt   time.Time     // 最近一次收到分片的时间，超过 TTL 未更新的会话被清理
+}
+
+// InitChunkUploadRequest 创建分片上传会话请求
+type InitChunkUploadRequest struct {
+	Filename string `json:"filename" binding:"required"`
+	Size     int64  `json:"size" binding:"required,gt=0"`
+	Category string `json:"category"`
+	IsPublic bool   `json:"is_public"`
+}
+
+// UploadChunkRequest 上传分片请求，分片可乱序上传，失败的分片重传即可
+type UploadChunkRequest struct {
+	UploadID    string                `form:"upload_id" binding:"required"`
+	ChunkIndex  int                   `form:"chunk_index" binding:"min=0"` // 从0开始
+	TotalChunks int                   `form:"total_chunks" binding:"required,gt=0"`
+	Chunk       *multipart.FileHeader `form:"chunk" binding:"required"`
+}
+
+// CompleteChunkUploadRequest 完成分片上传请求
+type CompleteChunkUploadRequest struct {
+	UploadID string `json:"upload_id" binding:"required"`
+	MD5      string `json:"md5" binding:"required,len=32,hexadecimal"` // 完整文件的MD5
+}
+
+// ChunkUploadStatus 分片上传会话状态，客户端据此断点续传
+type ChunkUploadStatus struct {
+	UploadID       string    `json:"upload_id"`
+	Filename       string    `json:"filename"`
+	Size           int64     `json:"size"`
+	ChunkMaxSize   int64     `json:"chunk_max_size"`
+	TotalChunks    int       `json:"total_chunks"`    // 首个分片上传前为0
+	ReceivedChunks []int     `json:"received_chunks"` // 已接收的分片序号，升序
+	ReceivedBytes  int64     `json:"received_bytes"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// chunkMaxSize 单个分片的大小上限
+func chunkMaxSize() int64 {
+	if size := config.Global.File.ChunkMaxSize; size > 0 {
+		return size
+	}
+	return 8 * 1024 * 1024
+}
+
+// chunkUploadTTL 会话无新分片多久后清理
+func chunkUploadTTL() time.Duration {
+	if hours := config.Global.File.ChunkUploadTTL; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// InitChunkUpload 创建分片上传会话
+// 此时只校验文件大小和扩展名，文件内容在合并后按普通上传的规则校验
+func (s *FileService) InitChunkUpload(ctx context.Context, req *InitChunkUploadRequest, uploaderID uint) (*ChunkUploadStatus, error) {
+	if maxSize := maxUploadSize(); req.Size > maxSize {
+		return nil, fmt.Errorf("%w: 文件大小超过限制: %d > %d", ErrInvalidChunkUpload, req.Size, maxSize)
+	}
+	if err := validateFileExtension(req.Filename); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChunkUpload, err)
+	}
+
+	uploadID, err := newRandomID()
+	if err != nil {
+		return nil, fmt.Errorf("生成上传ID失败: %v", err)
+	}
+	if err := os.MkdirAll(s.chunkDir(uploadID), 0755); err != nil {
+		return nil, fmt.Errorf("创建分片目录失败: %v", err)
+	}
+
+	upload := &chunkUpload{
+		id:         uploadID,
+		filename:   filepath.Base(req.Filename),
+		size:       req.Size,
+		category:   req.Category,
+		isPublic:   req.IsPublic,
+		uploaderID: uploaderID,
+		received:   make(map[int]int64),
+		updatedAt:  time.Now(),
+	}
+
+	s.chunkUploadsMu.Lock()
+	s.chunkUploads[uploadID] = upload
+	status := upload.status()
+	s.chunkUploadsMu.Unlock()
+
+	logger.Info("创建分片上传",
+		zap.String("upload_id", uploadID),
+		zap.String("filename", upload.filename),
+		zap.Int64("size", req.Size),
+		zap.Uint("uploader_id", uploaderID),
+	)
+	return status, nil
+}
+
+// UploadChunk 保存一个分片，同一序号重复上传时覆盖
+func (s *FileService) UploadChunk(ctx context.Context, req *UploadChunkRequest, uploaderID uint) (*ChunkUploadStatus, error) {
+	if req.Chunk.Size <= 0 {
+		return nil, fmt.Errorf("%w: 分片为空", ErrInvalidChunkUpload)
+	}
+	if maxSize := chunkMaxSize(); req.Chunk.Size > maxSize {
+		return nil, fmt.Errorf("%w: 分片大小超过限制: %d > %d", ErrInvalidChunkUpload, req.Chunk.Size, maxSize)
+	}
+
+	s.chunkUploadsMu.Lock()
+	upload, err := s.lookupChunkUpload(req.UploadID, uploaderID)
+	if err == nil {
+		err = upload.checkChunk(req.ChunkIndex, req.TotalChunks, req.Chunk.Size)
+	}
+	s.chunkUploadsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// 分片在锁外写入临时文件，并发上传的分片互不阻塞
+	tmp, written, err := s.writeChunkTemp(req.UploadID, req.Chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重命名和登记在锁内完成，合并开始后不再有分片文件被替换
+	s.chunkUploadsMu.Lock()
+	defer s.chunkUploadsMu.Unlock()
+	// 写入期间会话可能已开始合并、已完成或被清理
+	if upload, err = s.lookupChunkUpload(req.UploadID, uploaderID); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, chunkPath(s.chunkDir(req.UploadID), req.ChunkIndex)); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("保存分片失败: %v", err)
+	}
+	upload.totalChunks = req.TotalChunks
+	upload.received[req.ChunkIndex] = written
+	upload.updatedAt = time.Now()
+	return upload.status(), nil
+}
+
+// GetChunkUpload 查询分片上传会话状态
+func (s *FileService) GetChunkUpload(uploadID string, uploaderID uint) (*ChunkUploadStatus, error) {
+	s.chunkUploadsMu.Lock()
+	defer s.chunkUploadsMu.Unlock()
+
+	upload, err := s.lookupChunkUpload(uploadID, uploaderID)
+	if err != nil {
+		return nil, err
+	}
+	return upload.status(), nil
+}
+
+// CompleteChunkUpload 按序合并全部分片，校验MD5后按普通上传保存
+// 合并或校验失败时会话保留，客户端可重传分片后再次完成
+func (s *FileService) CompleteChunkUpload(ctx context.Context, req *CompleteChunkUploadRequest, uploaderID uint, uploaderName string) (*UploadResponse, error) {
+	s.chunkUploadsMu.Lock()
+	upload, err := s.lookupChunkUpload(req.UploadID, uploaderID)
+	if err == nil {
+		if missing := upload.missingChunks(); missing > 0 {
+			err = fmt.Errorf("%w: 还缺少%d个分片", ErrChunkUploadIncomplete, missing)
+		}
+	}
+	if err != nil {
+		s.chunkUploadsMu.Unlock()
+		return nil, err
+	}
+	upload.completing = true
+	s.chunkUploadsMu.Unlock()
+
+	completed := false
+	defer func() {
+		s.chunkUploadsMu.Lock()
+		if completed {
+			delete(s.chunkUploads, upload.id)
+		} else {
+			upload.completing = false
+			upload.updatedAt = time.Now()
+		}
+		s.chunkUploadsMu.Unlock()
+	}()
+
+	dir := s.chunkDir(upload.id)
+	assembled := filepath.Join(dir, assembledChunkFile)
+	defer os.Remove(assembled)
+
+	md5sum, err := assembleChunks(assembled, dir, upload.totalChunks, upload.size)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(md5sum, req.MD5) {
+		return nil, fmt.Errorf("%w: 期望 %s，实际 %s", ErrChunkMD5Mismatch, strings.ToLower(req.MD5), md5sum)
+	}
+
+	src := &uploadSource{
+		Filename:    upload.filename,
+		Size:        upload.size,
+		ContentType: mimeTypeByExtension(strings.ToLower(filepath.Ext(upload.filename))),
+		open:        func() (io.ReadCloser, error) { return os.Open(assembled) },
+	}
+	if err := s.validateUpload(src); err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := s.saveUploadedFile(ctx, src, md5sum, upload.category, upload.isPublic, uploaderID, uploaderName)
+	if err != nil {
+		return nil, err
+	}
+	go s.webhooks.Dispatch(context.WithoutCancel(ctx), model.EventFileUploaded, fileInfo)
+
+	completed = true
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Warn("删除分片目录失败", zap.String("upload_id", upload.id), zap.Error(err))
+	}
+
+	return &UploadResponse{
+		FileInfo: fileInfo,
+		Message:  "文件上传成功",
+	}, nil
+}
+
+// CleanupChunkUploads 清理超过 ttl 未收到新分片的会话，以及服务重启后遗留的分片目录，返回清理的数量
+func (s *FileService) CleanupChunkUploads(ttl time.Duration) int {
+	cutoff := time.Now().Add(-ttl)
+
+	s.chunkUploadsMu.Lock()
+	var expired []string
+	for id, upload := range s.chunkUploads {
+		if !upload.completing && upload.updatedAt.Before(cutoff) {
+			expired = append(expired, id)
+			delete(s.chunkUploads, id)
+		}
+	}
+	s.chunkUploadsMu.Unlock()
+
+	for _, id := range expired {
+		if err := os.RemoveAll(s.chunkDir(id)); err != nil {
+			logger.Warn("删除过期分片目录失败", zap.String("upload_id", id), zap.Error(err))
+		}
+	}
+
+	// 内存中没有对应会话的目录无法再完成上传
+	entries, err := os.ReadDir(filepath.Join(s.basePath, chunkUploadDir))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("读取分片目录失败", zap.Error(err))
+		}
+		return len(expired)
+	}
+	removed := len(expired)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		s.chunkUploadsMu.Lock()
+		_, active := s.chunkUploads[entry.Name()]
+		s.chunkUploadsMu.Unlock()
+		if active {
+			continue
+		}
+		if err := os.RemoveAll(s.chunkDir(entry.Name())); err != nil {
+			logger.Warn("删除遗留分片目录失败", zap.String("upload_id", entry.Name()), zap.Error(err))
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+// lookupChunkUpload 查找当前用户的分片上传会话，调用方须持有 chunkUploadsMu
+// 其他用户的会话同样返回 ErrChunkUploadNotFound，不暴露上传ID是否存在
+func (s *FileService) lookupChunkUpload(uploadID string, uploaderID uint) (*chunkUpload, error) {
+	upload, ok := s.chunkUploads[uploadID]
+	if !ok || upload.uploaderID != uploaderID {
+		return nil, ErrChunkUploadNotFound
+	}
+	if upload.completing {
+		return nil, ErrChunkUploadBusy
+	}
+	return upload, nil
+}
+
+// chunkDir 会话的分片目录
+func (s *FileService) chunkDir(uploadID string) string {
+	return filepath.Join(s.basePath, chunkUploadDir, uploadID)
+}
+
+// writeChunkTemp 将分片写入会话目录下的临时文件，返回临时文件路径和写入的字节数
+func (s *FileService) writeChunkTemp(uploadID string, chunk *multipart.FileHeader) (string, int64, error) {
+	src, err := chunk.Open()
+	if err != nil {
+		return "", 0, fmt.Errorf("打开分片失败: %v", err)
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(s.chunkDir(uploadID), "chunk-*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("创建分片文件失败: %v", err)
+	}
+	written, err := io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("保存分片失败: %v", err)
+	}
+	return tmp.Name(), written, nil
+}
+
+// chunkPath 分片文件路径
+func chunkPath(dir string, index int) string {
+	return filepath.Join(dir, strconv.Itoa(index)+".part")
+}
+
+// assembleChunks 将分片按序合并到 dst，校验合并后的大小并返回MD5
+func assembleChunks(dst, dir string, totalChunks int, size int64) (string, error) {
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", fmt.Errorf("创建合并文件失败: %v", err)
+	}
+	defer out.Close()
+
+	hash := md5.New()
+	w := io.MultiWriter(out, hash)
+	var written int64
+	for i := 0; i < totalChunks; i++ {
+		n, err := appendChunk(w, chunkPath(dir, i))
+		if err != nil {
+			return "", fmt.Errorf("合并第%d个分片失败: %v", i, err)
+		}
+		written += n
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("写入合并文件失败: %v", err)
+	}
+	if written != size {
+		return "", fmt.Errorf("%w: 合并后文件大小 %d 与声明的 %d 不一致", ErrInvalidChunkUpload, written, size)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// appendChunk 将分片文件内容写入 w
+func appendChunk(w io.Writer, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+// checkChunk 校验分片序号、总数和大小，调用方须持有 chunkUploadsMu
+func (u *chunkUpload) checkChunk(index, totalChunks int, size int64) error {
+	if u.totalChunks != 0 && totalChunks != u.totalChunks {
+		return fmt.Errorf("%w: 分片总数与之前的分片不一致: %d != %d", ErrInvalidChunkUpload, totalChunks, u.totalChunks)
+	}
+	// 每个分片至少1字节、至多 chunkMaxSize 字节
+	if int64(totalChunks) > u.size || int64(totalChunks)*chunkMaxSize() < u.size {
+		return fmt.Errorf("%w: 分片总数 %d 与文件大小 %d 不匹配", ErrInvalidChunkUpload, totalChunks, u.size)
+	}
+	if index >= totalChunks {
+		return fmt.Errorf("%w: 分片序号超出范围: %d >= %d", ErrInvalidChunkUpload, index, totalChunks)
+	}
+
+	// 重传的分片替换之前的大小
+	received := size
+	for i, n := range u.received {
+		if i != index {
+			received += n
+		}
+	}
+	if received > u.size {
+		return fmt.Errorf("%w: 已上传 %d 字节，超过文件大小 %d", ErrInvalidChunkUpload, received, u.size)
+	}
+	return nil
+}
+
+// missingChunks 尚未上传的分片数量，首个分片上传前返回1
+func (u *chunkUpload) missingChunks() int {
+	if u.totalChunks == 0 {
+		return 1
+	}
+	return u.totalChunks - len(u.received)
+}
+
+// status 会话状态，调用方须持有 chunkUploadsMu
+func (u *chunkUpload) status() *ChunkUploadStatus {
+	status := &ChunkUploadStatus{
+		UploadID:       u.id,
+		Filename:       u.filename,
+		Size:           u.size,
+		ChunkMaxSize:   chunkMaxSize(),
+		TotalChunks:    u.totalChunks,
+		ReceivedChunks: slices.Sorted(maps.Keys(u.received)),
+		ExpiresAt:      u.updatedAt.Add(chunkUploadTTL()),
+	}
+	for _, n := range u.received {
+		status.ReceivedBytes += n
+	}
+	if status.ReceivedChunks == nil {
+		status.ReceivedChunks = []int{}
+	}
+	return status
+}
+
+// newRandomID 生成不可猜测的随机ID，用于上传会话和异步任务
+func newRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/internal/model"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// SetFileDAO 设置文件记录存储，未设置时不支持上传去重和非公开文件的签名下载
+func (s *FileService) SetFileDAO(files *dao.FileDAO) {
+	s.files = files
+}
+
+// dedupEnabled 是否开启上传去重
+func (s *FileService) dedupEnabled() bool {
+	return s.files != nil && config.Global.File.DedupEnabled
+}
+
+// saveWithRecord 保存上传文件并创建文件记录，返回实际使用的存储键
+// 开启去重且已有内容相同的记录时新记录引用其存储文件，不再重复写入
+func (s *FileService) saveWithRecord(ctx context.Context, src io.Reader, record *model.FileRecord) (string, error) {
+	var existing *model.FileRecord
+	if s.dedupEnabled() {
+		var err error
+		existing, err = s.files.FindByContent(ctx, record.MD5, record.Size)
+		if err != nil && !errors.Is(err, dao.ErrRecordNotFound) {
+			return "", fmt.Errorf("查询重复文件失败: %v", err)
+		}
+	}
+
+	if existing == nil {
+		if err := s.storage.Save(ctx, record.StorageKey, src); err != nil {
+			return "", fmt.Errorf("保存文件失败: %v", err)
+		}
+		if err := s.files.Create(ctx, record); err != nil {
+			// 没有记录引用的存储文件无法再被找到，直接删除
+			if delErr := s.storage.Delete(ctx, record.StorageKey); delErr != nil {
+				logger.FromContext(ctx).Warn("删除未记录的文件失败", zap.String("key", record.StorageKey), zap.Error(delErr))
+			}
+			return "", fmt.Errorf("保存文件记录失败: %v", err)
+		}
+		return record.StorageKey, nil
+	}
+
+	// 先创建引用再确认存储文件存在，并发删除最后一个引用时补写文件
+	record.StorageKey = existing.StorageKey
+	if err := s.files.Create(ctx, record); err != nil {
+		return "", fmt.Errorf("保存文件记录失败: %v", err)
+	}
+	exists, err := s.storage.Exists(record.StorageKey)
+	if err != nil || !exists {
+		if err := s.storage.Save(ctx, record.StorageKey, src); err != nil {
+			return "", fmt.Errorf("保存文件失败: %v", err)
+		}
+	}
+
+	logger.FromContext(ctx).Info("上传文件内容重复，复用已存储的文件",
+		zap.String("file_id", record.ID),
+		zap.String("duplicate_of", existing.ID),
+		zap.String("key", record.StorageKey),
+	)
+	return record.StorageKey, nil
+}
+
+// releaseFileRecord 删除文件记录，removeBlob 表示已没有其他记录引用其存储文件
+// 文件没有记录（未设置文件记录存储时上传）时返回 dao.ErrRecordNotFound
+func (s *FileService) releaseFileRecord(ctx context.Context, fileID string) (key string, removeBlob bool, err error) {
+	if s.files == nil {
+		return "", false, dao.ErrRecordNotFound
+	}
+	record, lastRef, err := s.files.Release(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, dao.ErrRecordNotFound) {
+			return "", false, err
+		}
+		return "", false, fmt.Errorf("删除文件记录失败: %w", err)
+	}
+	return record.StorageKey, lastRef, nil
+}
+
+// referenceFiles 去重后引用其他文件的存储文件的记录，这些记录在存储中没有以自己的文件ID命名的文件
+func (s *FileService) referenceFiles(ctx context.Context, category string) ([]*FileInfo, error) {
+	if s.files == nil {
+		return nil, nil
+	}
+	records, err := s.files.ListByCategory(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*FileInfo
+	for _, record := range records {
+		if s.generateFileIDFromPath(record.StorageKey) == record.ID {
+			continue
+		}
+		files = append(files, &FileInfo{
+			ID:           record.ID,
+			Name:         path.Base(record.StorageKey),
+			OriginalName: record.OriginalName,
+			Size:         record.Size,
+			MimeType:     record.MimeType,
+			Extension:    path.Ext(record.OriginalName),
+			Path:         record.StorageKey,
+			MD5:          record.MD5,
+			UploadTime:   record.CreatedAt,
+			UploaderID:   record.UploaderID,
+			IsPublic:     record.IsPublic,
+		})
+	}
+	return files, nil
+}
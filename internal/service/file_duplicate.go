@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// DuplicateFileGroup 内容完全相同的一组文件，Files 按上传时间排序，第一个为最早上传的文件
+type DuplicateFileGroup struct {
+	MD5         string      `json:"md5"`
+	Size        int64       `json:"size"`
+	Count       int         `json:"count"`
+	WastedBytes int64       `json:"wasted_bytes"` // 只保留一份时可释放的空间
+	Files       []*FileInfo `json:"files"`
+}
+
+// DuplicateFilesReport 重复文件报告
+type DuplicateFilesReport struct {
+	Groups          []*DuplicateFileGroup `json:"groups"`
+	TotalGroups     int                   `json:"total_groups"`
+	TotalDuplicates int                   `json:"total_duplicates"` // 可删除的重复文件数，不含每组保留的一份
+	WastedBytes     int64                 `json:"wasted_bytes"`
+	ScannedFiles    int                   `json:"scanned_files"`
+}
+
+// FindDuplicateFiles 查找内容完全相同的文件，按 MD5+大小分组，供清理存储空间使用
+// 文件信息尚未保存在数据库中，先扫描目录按大小分组，只对大小相同的文件计算 MD5；按可释放空间从大到小排序
+func (s *FileService) FindDuplicateFiles(ctx context.Context) (*DuplicateFilesReport, error) {
+	bySize := make(map[int64][]*FileInfo)
+	scanned := 0
+	objects, err := s.storage.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("扫描文件目录失败: %w", err)
+	}
+	for _, object := range objects {
+		// 空文件没有清理意义，缩略图随原文件删除
+		if object.Size == 0 || isThumbnailKey(object.Key) {
+			continue
+		}
+		scanned++
+		fileID := s.generateFileIDFromPath(object.Key)
+		bySize[object.Size] = append(bySize[object.Size], &FileInfo{
+			ID:         fileID,
+			Name:       path.Base(object.Key),
+			Size:       object.Size,
+			Extension:  path.Ext(object.Key),
+			Path:       object.Key,
+			URL:        s.generateFileURL(fileID, false),
+			UploadTime: object.ModTime,
+		})
+	}
+
+	report := &DuplicateFilesReport{Groups: []*DuplicateFileGroup{}, ScannedFiles: scanned}
+	for size, candidates := range bySize {
+		if len(candidates) < 2 {
+			continue
+		}
+
+		byMD5 := make(map[string][]*FileInfo)
+		for _, file := range candidates {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			sum, err := s.storedFileMD5(ctx, file.Path)
+			if err != nil {
+				// 扫描期间文件可能被删除，跳过即可
+				logger.FromContext(ctx).Warn("计算文件MD5失败", zap.String("path", file.Path), zap.Error(err))
+				continue
+			}
+			file.MD5 = sum
+			byMD5[sum] = append(byMD5[sum], file)
+		}
+
+		for sum, files := range byMD5 {
+			if len(files) < 2 {
+				continue
+			}
+			sort.Slice(files, func(i, j int) bool {
+				return files[i].UploadTime.Before(files[j].UploadTime)
+			})
+			group := &DuplicateFileGroup{
+				MD5:         sum,
+				Size:        size,
+				Count:       len(files),
+				WastedBytes: size * int64(len(files)-1),
+				Files:       files,
+			}
+			report.Groups = append(report.Groups, group)
+			report.TotalDuplicates += len(files) - 1
+			report.WastedBytes += group.WastedBytes
+		}
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].WastedBytes != report.Groups[j].WastedBytes {
+			return report.Groups[i].WastedBytes > report.Groups[j].WastedBytes
+		}
+		return report.Groups[i].MD5 < report.Groups[j].MD5
+	})
+	report.TotalGroups = len(report.Groups)
+	return report, nil
+}
+
+// storedFileMD5 计算已保存文件的MD5
+func (s *FileService) storedFileMD5(ctx context.Context, key string) (string, error) {
+	f, err := s.storage.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return md5Hex(f)
+}
+
+// md5Hex 计算读取内容的MD5，返回十六进制字符串
+func md5Hex(r io.Reader) (string, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
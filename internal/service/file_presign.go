@@ -0,0 +1,164 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// 签名下载相关错误
+var (
+	ErrInvalidDownloadSignature = errors.New("下载链接无效或已过期")
+	ErrInvalidPresignTTL        = errors.New("链接有效期无效")
+	ErrFileAccessDenied         = errors.New("无权访问该文件")
+)
+
+// 签名链接的查询参数
+const (
+	PresignExpiresParam   = "expires"
+	PresignSignatureParam = "signature"
+)
+
+// PresignedURLResponse 签名下载链接
+type PresignedURLResponse struct {
+	FileID    string    `json:"file_id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// GeneratePresignedURL 生成文件的限时下载链接，ttl 为 0 时使用默认有效期，超过上限时返回 ErrInvalidPresignTTL
+func (s *FileService) GeneratePresignedURL(fileID string, ttl time.Duration) (string, error) {
+	expiresAt, err := presignExpiry(ttl)
+	if err != nil {
+		return "", err
+	}
+	return presignedURL(fileID, expiresAt), nil
+}
+
+// PresignDownload 确认文件存在且用户有权访问后生成限时下载链接
+// 公开文件任何登录用户都可生成；非公开文件仅上传者和管理员可以，否则返回 ErrFileAccessDenied
+func (s *FileService) PresignDownload(ctx context.Context, fileID string, ttl time.Duration, userID uint, role string) (*PresignedURLResponse, error) {
+	expiresAt, err := presignExpiry(ttl)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.findFile(ctx, fileID); err != nil {
+		return nil, err
+	}
+	if err := s.authorizePresign(ctx, fileID, userID, role); err != nil {
+		return nil, err
+	}
+	return &PresignedURLResponse{
+		FileID:    fileID,
+		URL:       presignedURL(fileID, expiresAt),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// AuthorizeDownload 校验下载请求
+// 带签名参数时必须签名正确且未过期；不带签名时，要求签名下载的配置下只允许下载公开文件
+func (s *FileService) AuthorizeDownload(ctx context.Context, fileID, expires, signature string) error {
+	if expires != "" || signature != "" {
+		return verifyPresignature(fileID, expires, signature)
+	}
+	if !config.Global.File.PresignedURL.Required || s.isPublicFile(ctx, fileID) {
+		return nil
+	}
+	return fmt.Errorf("%w: 缺少签名", ErrInvalidDownloadSignature)
+}
+
+// authorizePresign 校验用户能否为文件生成签名链接，没有文件记录时无法确认上传者，仅管理员可以
+func (s *FileService) authorizePresign(ctx context.Context, fileID string, userID uint, role string) error {
+	if isAdminRole(role) {
+		return nil
+	}
+	if s.files == nil {
+		return ErrFileAccessDenied
+	}
+	record, err := s.files.GetByID(ctx, strings.TrimSuffix(fileID, thumbnailIDSuffix))
+	if errors.Is(err, dao.ErrRecordNotFound) {
+		return ErrFileAccessDenied
+	}
+	if err != nil {
+		return fmt.Errorf("查询文件记录失败: %w", err)
+	}
+	if !record.IsPublic && !canAccessOwned(record.UploaderID, userID, role) {
+		return ErrFileAccessDenied
+	}
+	return nil
+}
+
+// isPublicFile 文件是否公开，缩略图与原文件相同；没有文件记录或查询失败时视为非公开
+func (s *FileService) isPublicFile(ctx context.Context, fileID string) bool {
+	if s.files == nil {
+		return false
+	}
+	record, err := s.files.GetByID(ctx, strings.TrimSuffix(fileID, thumbnailIDSuffix))
+	if err != nil {
+		if !errors.Is(err, dao.ErrRecordNotFound) {
+			logger.FromContext(ctx).Warn("查询文件记录失败，按非公开文件处理", zap.String("file_id", fileID), zap.Error(err))
+		}
+		return false
+	}
+	return record.IsPublic
+}
+
+// presignExpiry 按有效期计算链接的过期时间
+func presignExpiry(ttl time.Duration) (time.Time, error) {
+	cfg := config.Global.File.PresignedURL
+	if ttl == 0 {
+		ttl = time.Duration(cmp.Or(cfg.DefaultTTL, 3600)) * time.Second
+	}
+	maxTTL := time.Duration(cmp.Or(cfg.MaxTTL, 7*24*3600)) * time.Second
+	if ttl < 0 || ttl > maxTTL {
+		return time.Time{}, fmt.Errorf("%w: 须在 0 到 %s 之间", ErrInvalidPresignTTL, maxTTL)
+	}
+	// 签名精确到秒，向上取整避免链接比请求的有效期更早过期
+	return time.Now().Add(ttl).Truncate(time.Second).Add(time.Second), nil
+}
+
+// presignedURL 带过期时间和签名的下载地址
+func presignedURL(fileID string, expiresAt time.Time) string {
+	expires := expiresAt.Unix()
+	query := url.Values{}
+	query.Set(PresignExpiresParam, strconv.FormatInt(expires, 10))
+	query.Set(PresignSignatureParam, presignature(fileID, expires))
+	return fileDownloadURL(fileID) + "?" + query.Encode()
+}
+
+// verifyPresignature 校验签名和过期时间
+func verifyPresignature(fileID, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || signature == "" {
+		return fmt.Errorf("%w: 签名参数不完整", ErrInvalidDownloadSignature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(presignature(fileID, expiresAt))) {
+		return fmt.Errorf("%w: 签名不匹配", ErrInvalidDownloadSignature)
+	}
+	if time.Now().Unix() > expiresAt {
+		return fmt.Errorf("%w: 链接已过期", ErrInvalidDownloadSignature)
+	}
+	return nil
+}
+
+// presignature 文件ID和过期时间的 HMAC-SHA256 签名
+func presignature(fileID string, expires int64) string {
+	key := cmp.Or(config.Global.File.PresignedURL.SigningKey, config.Global.JWT.Secret)
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%s\n%d", fileID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/VennLe/charlotte/internal/config"
+)
+
+// signedParams 解析签名链接中的过期时间和签名
+func signedParams(t *testing.T, rawURL string) (expires, signature string) {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("签名链接格式错误: %v", err)
+	}
+	return u.Query().Get(PresignExpiresParam), u.Query().Get(PresignSignatureParam)
+}
+
+func TestVerifyPresignature(t *testing.T) {
+	config.Global = &config.Config{}
+	config.Global.File.PresignedURL.SigningKey = "test-signing-key"
+
+	const fileID = "file_a"
+	expires, signature := signedParams(t, presignedURL(fileID, time.Now().Add(time.Hour)))
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	expiredExpires := strconv.FormatInt(expired, 10)
+	expiredSignature := presignature(fileID, expired)
+
+	tampered := []byte(signature)
+	tampered[0] ^= 1
+
+	tests := []struct {
+		name      string
+		fileID    string
+		expires   string
+		signature string
+		wantErr   bool
+	}{
+		{"有效链接", fileID, expires, signature, false},
+		{"已过期", fileID, expiredExpires, expiredSignature, true},
+		{"签名被篡改", fileID, expires, string(tampered), true},
+		{"延长过期时间", fileID, strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10), signature, true},
+		{"其他文件ID", "file_b", expires, signature, true},
+		{"缺少签名", fileID, expires, "", true},
+		{"过期时间格式错误", fileID, "tomorrow", signature, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyPresignature(tt.fileID, tt.expires, tt.signature)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("verifyPresignature() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidDownloadSignature) {
+				t.Fatalf("verifyPresignature() error = %v，期望 ErrInvalidDownloadSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifyPresignatureSigningKey(t *testing.T) {
+	config.Global = &config.Config{}
+	config.Global.File.PresignedURL.SigningKey = "old-key"
+
+	expires, signature := signedParams(t, presignedURL("file_a", time.Now().Add(time.Hour)))
+
+	config.Global.File.PresignedURL.SigningKey = "new-key"
+	if err := verifyPresignature("file_a", expires, signature); !errors.Is(err, ErrInvalidDownloadSignature) {
+		t.Fatalf("更换密钥后 error = %v，期望 ErrInvalidDownloadSignature", err)
+	}
+}
+
+func TestAuthorizeDownloadRequiresSignature(t *testing.T) {
+	config.Global = &config.Config{}
+	config.Global.File.PresignedURL.Required = true
+	config.Global.File.PresignedURL.SigningKey = "test-signing-key"
+
+	// 没有文件记录时无法确认是否公开，按非公开文件处理
+	s := &FileService{}
+	ctx := context.Background()
+
+	if err := s.AuthorizeDownload(ctx, "file_a", "", ""); !errors.Is(err, ErrInvalidDownloadSignature) {
+		t.Fatalf("不带签名 error = %v，期望 ErrInvalidDownloadSignature", err)
+	}
+
+	expires, signature := signedParams(t, presignedURL("file_a", time.Now().Add(time.Hour)))
+	if err := s.AuthorizeDownload(ctx, "file_a", expires, signature); err != nil {
+		t.Fatalf("有效签名 error = %v", err)
+	}
+	if err := s.AuthorizeDownload(ctx, "file_b", expires, signature); !errors.Is(err, ErrInvalidDownloadSignature) {
+		t.Fatalf("其他文件的签名 error = %v，期望 ErrInvalidDownloadSignature", err)
+	}
+}
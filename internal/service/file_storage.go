@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VennLe/charlotte/internal/config"
+)
+
+// 文件存储后端类型
+const (
+	StorageTypeLocal = "local"
+	StorageTypeS3    = "s3"
+)
+
+// StorageObject 存储后端中的文件
+type StorageObject struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// StorageBackend 上传文件的存储后端
+// 键为以 / 分隔的相对路径，如 general/2024/01/02/fileID.png；文件不存在时 Open 返回 ErrFileNotFound
+type StorageBackend interface {
+	Save(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Exists(key string) (bool, error)
+	// List 列出键以 prefix 开头的全部文件，文件信息尚未保存在数据库中，按文件ID查找和列表都依赖它
+	List(ctx context.Context, prefix string) ([]StorageObject, error)
+}
+
+// newStorageBackend 按配置创建存储后端，basePath 为本地存储目录
+func newStorageBackend(cfg config.FileConfig, basePath string) (StorageBackend, error) {
+	switch strings.ToLower(cfg.StorageType) {
+	case "", StorageTypeLocal:
+		return NewLocalBackend(basePath)
+	case StorageTypeS3:
+		return NewS3Backend(cfg.Storage.S3)
+	default:
+		return nil, fmt.Errorf("不支持的文件存储类型: %s", cfg.StorageType)
+	}
+}
+
+// isHiddenKey 键中含以 . 开头的路径段，如分片上传和导入导出任务的临时文件，不作为存储文件对外提供
+func isHiddenKey(key string) bool {
+	for _, segment := range strings.Split(key, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalBackend 本地目录存储，多实例部署时各实例的文件互不可见
+type LocalBackend struct {
+	root string
+}
+
+// NewLocalBackend 创建本地目录存储，目录不存在时自动创建
+func NewLocalBackend(root string) (*LocalBackend, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("创建上传目录失败: %w", err)
+	}
+	return &LocalBackend{root: root}, nil
+}
+
+// path 键对应的本地路径，拒绝 .. 等指向存储目录之外的键
+func (b *LocalBackend) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("非法的文件键: %s", key)
+	}
+	return filepath.Join(b.root, name), nil
+}
+
+// Save 保存文件，已存在时覆盖
+func (b *LocalBackend) Save(ctx context.Context, key string, r io.Reader) error {
+	filePath, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		dst.Close()
+		os.Remove(filePath)
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return dst.Close()
+}
+
+// Open 打开文件
+func (b *LocalBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	filePath, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, key)
+	}
+	return file, err
+}
+
+// Delete 删除文件，文件不存在时不报错
+func (b *LocalBackend) Delete(ctx context.Context, key string) error {
+	filePath, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Exists 文件是否存在
+func (b *LocalBackend) Exists(key string) (bool, error) {
+	filePath, err := b.path(key)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+// List 遍历存储目录，prefix 按键的字符串前缀匹配，不包含隐藏目录中的文件
+func (b *LocalBackend) List(ctx context.Context, prefix string) ([]StorageObject, error) {
+	// 从前缀所在的目录开始遍历，避免扫描整个存储目录
+	dir := b.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		sub, err := b.path(prefix[:i])
+		if err != nil {
+			return nil, err
+		}
+		dir = sub
+	}
+
+	var objects []StorageObject
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 前缀对应的目录不存在时没有文件
+			if p == dir && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// 跳过隐藏目录，如分片上传和导入导出任务的临时目录；前缀本身指向隐藏目录时同样跳过
+			if p != b.root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, StorageObject{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/VennLe/charlotte/internal/config"
+)
+
+// S3Backend S3 兼容对象存储，基于 aws-sdk-go-v2
+type S3Backend struct {
+	cfg      config.S3StorageConfig
+	client   *s3.Client
+	uploader *manager.Uploader
+}
+
+// NewS3Backend 创建 S3 存储
+func NewS3Backend(cfg config.S3StorageConfig) (*S3Backend, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("未配置 S3 存储桶")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("未配置 S3 访问密钥")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+
+	credentials := aws.Credentials{
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		SessionToken:    cfg.SessionToken,
+		Source:          "charlotte config",
+	}
+	opts := s3.Options{
+		Region: cfg.Region,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return credentials, nil
+		}),
+		UsePathStyle: cfg.UsePathStyle,
+	}
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("S3 服务地址格式错误: %s", cfg.Endpoint)
+		}
+		opts.BaseEndpoint = aws.String(cfg.Endpoint)
+		// MinIO 等兼容服务不一定支持 SDK 默认附加的 CRC 校验和，只在接口要求时计算
+		opts.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		opts.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+	}
+
+	client := s3.New(opts)
+	return &S3Backend{
+		cfg:      cfg,
+		client:   client,
+		uploader: manager.NewUploader(client),
+	}, nil
+}
+
+// Save 上传文件，已存在时覆盖
+// 通过上传管理器流式上传，大文件自动分片，不会整个读入内存
+func (b *S3Backend) Save(ctx context.Context, key string, r io.Reader) error {
+	_, err := b.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.cfg.Bucket),
+		Key:    aws.String(b.objectKey(key)),
+		Body:   r,
+	})
+	if err != nil {
+		return fmt.Errorf("S3 上传文件失败: %w", err)
+	}
+	return nil
+}
+
+// Open 下载文件
+func (b *S3Backend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.cfg.Bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, fmt.Errorf("%w: %s", ErrFileNotFound, key)
+		}
+		return nil, fmt.Errorf("S3 下载文件失败: %w", err)
+	}
+	return out.Body, nil
+}
+
+// Delete 删除文件，文件不存在时 S3 同样返回成功
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.cfg.Bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("S3 删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// Exists 文件是否存在
+func (b *S3Backend) Exists(key string) (bool, error) {
+	_, err := b.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(b.cfg.Bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("S3 查询文件失败: %w", err)
+	}
+	return true, nil
+}
+
+// List 分页列出键以 prefix 开头的全部文件
+func (b *S3Backend) List(ctx context.Context, prefix string) ([]StorageObject, error) {
+	var objects []StorageObject
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.cfg.Bucket),
+		Prefix: aws.String(b.objectKey(prefix)),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("S3 列出文件失败: %w", err)
+		}
+		for _, item := range page.Contents {
+			key := aws.ToString(item.Key)
+			// 跳过控制台创建的目录占位对象
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+			objects = append(objects, StorageObject{
+				Key:     b.storageKey(key),
+				Size:    aws.ToInt64(item.Size),
+				ModTime: aws.ToTime(item.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// objectKey 加上配置的前缀
+func (b *S3Backend) objectKey(key string) string {
+	if b.cfg.Prefix == "" {
+		return key
+	}
+	return b.cfg.Prefix + "/" + key
+}
+
+// storageKey 去掉配置的前缀
+func (b *S3Backend) storageKey(objectKey string) string {
+	if b.cfg.Prefix == "" {
+		return objectKey
+	}
+	return strings.TrimPrefix(objectKey, b.cfg.Prefix+"/")
+}
+
+// isS3NotFound 是否为对象不存在，HEAD 请求没有响应体，只能按状态码判断
+func isS3NotFound(err error) bool {
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+const (
+	// maxFileTags 单个文件最多的标签数
+	maxFileTags = 20
+	// maxFileTagLength 标签最大长度（字符数）
+	maxFileTagLength = 50
+	// maxBulkTagFiles 批量打标签一次最多的文件数
+	maxBulkTagFiles = 100
+)
+
+// 文件标签匹配方式
+const (
+	TagMatchAny = "any" // 带有任一标签
+	TagMatchAll = "all" // 带有全部标签
+)
+
+var (
+	// ErrFileTagsUnavailable 未配置文件标签存储
+	ErrFileTagsUnavailable = errors.New("文件标签不可用")
+	// ErrInvalidFileTag 标签为空、过长、包含逗号或数量超过上限
+	ErrInvalidFileTag = errors.New("文件标签不合法")
+)
+
+// FileTagsRequest 设置、添加或移除文件标签请求
+type FileTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+// BulkFileTagsRequest 批量为文件添加标签请求
+type BulkFileTagsRequest struct {
+	FileIDs []string `json:"file_ids" binding:"required,min=1"`
+	Tags    []string `json:"tags" binding:"required,min=1"`
+}
+
+// FileTagsResponse 文件标签响应
+type FileTagsResponse struct {
+	FileID string   `json:"file_id"`
+	Tags   []string `json:"tags"`
+}
+
+// SetTagDAO 设置文件标签存储，未设置时标签相关操作返回 ErrFileTagsUnavailable
+func (s *FileService) SetTagDAO(tags *dao.FileTagDAO) {
+	s.tags = tags
+}
+
+// FileTags 获取文件的标签
+func (s *FileService) FileTags(ctx context.Context, fileID string) ([]string, error) {
+	if s.tags == nil {
+		return nil, ErrFileTagsUnavailable
+	}
+	tagsByFile, err := s.tags.TagsByFiles(ctx, []string{fileID})
+	if err != nil {
+		return nil, err
+	}
+	return nonNilTags(tagsByFile[fileID]), nil
+}
+
+// AddTags 为文件添加标签，已有的标签忽略，返回文件当前的全部标签
+func (s *FileService) AddTags(ctx context.Context, fileID string, tags []string) ([]string, error) {
+	if err := s.BulkAddTags(ctx, []string{fileID}, tags); err != nil {
+		return nil, err
+	}
+	return s.FileTags(ctx, fileID)
+}
+
+// RemoveTags 移除文件的指定标签，返回文件当前的全部标签
+func (s *FileService) RemoveTags(ctx context.Context, fileID string, tags []string) ([]string, error) {
+	if s.tags == nil {
+		return nil, ErrFileTagsUnavailable
+	}
+	normalized, err := normalizeFileTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.findFile(ctx, fileID); err != nil {
+		return nil, err
+	}
+
+	if err := s.tags.RemoveTags(ctx, fileID, normalized); err != nil {
+		return nil, fmt.Errorf("移除文件标签失败: %w", err)
+	}
+	logger.FromContext(ctx).Info("文件标签已移除", zap.String("file_id", fileID), zap.Strings("tags", normalized))
+	return s.FileTags(ctx, fileID)
+}
+
+// SetTags 将文件的标签替换为 tags，tags 为空时清空标签
+func (s *FileService) SetTags(ctx context.Context, fileID string, tags []string) ([]string, error) {
+	if s.tags == nil {
+		return nil, ErrFileTagsUnavailable
+	}
+	normalized, err := normalizeFileTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) > maxFileTags {
+		return nil, fmt.Errorf("%w: 单个文件最多 %d 个标签", ErrInvalidFileTag, maxFileTags)
+	}
+	if _, err := s.findFile(ctx, fileID); err != nil {
+		return nil, err
+	}
+
+	if err := s.tags.SetTags(ctx, fileID, normalized); err != nil {
+		return nil, fmt.Errorf("设置文件标签失败: %w", err)
+	}
+	logger.FromContext(ctx).Info("文件标签已设置", zap.String("file_id", fileID), zap.Strings("tags", normalized))
+	return s.FileTags(ctx, fileID)
+}
+
+// BulkAddTags 为多个文件添加相同的标签，任一文件不存在或标签数超过上限时都不添加
+func (s *FileService) BulkAddTags(ctx context.Context, fileIDs, tags []string) error {
+	if s.tags == nil {
+		return ErrFileTagsUnavailable
+	}
+	normalized, err := normalizeFileTags(tags)
+	if err != nil {
+		return err
+	}
+	if len(normalized) == 0 {
+		return fmt.Errorf("%w: 标签不能为空", ErrInvalidFileTag)
+	}
+
+	fileIDs = slices.Compact(slices.Sorted(slices.Values(fileIDs)))
+	if len(fileIDs) > maxBulkTagFiles {
+		return fmt.Errorf("%w: 一次最多为 %d 个文件添加标签", ErrInvalidFileTag, maxBulkTagFiles)
+	}
+	for _, fileID := range fileIDs {
+		if _, err := s.findFile(ctx, fileID); err != nil {
+			return err
+		}
+	}
+
+	existing, err := s.tags.TagsByFiles(ctx, fileIDs)
+	if err != nil {
+		return fmt.Errorf("查询文件标签失败: %w", err)
+	}
+	for _, fileID := range fileIDs {
+		merged := slices.Compact(slices.Sorted(slices.Values(append(slices.Clone(existing[fileID]), normalized...))))
+		if len(merged) > maxFileTags {
+			return fmt.Errorf("%w: 文件 %s 的标签将超过 %d 个", ErrInvalidFileTag, fileID, maxFileTags)
+		}
+	}
+
+	if err := s.tags.AddTags(ctx, fileIDs, normalized); err != nil {
+		return fmt.Errorf("添加文件标签失败: %w", err)
+	}
+	logger.FromContext(ctx).Info("文件标签已添加",
+		zap.Int("file_count", len(fileIDs)),
+		zap.Strings("tags", normalized),
+	)
+	return nil
+}
+
+// taggedFileIDs 查询带有标签的文件ID集合，match 为 all 时需带有全部标签
+func (s *FileService) taggedFileIDs(ctx context.Context, tags []string, match string) (map[string]bool, error) {
+	if s.tags == nil {
+		return nil, ErrFileTagsUnavailable
+	}
+	normalized, err := normalizeFileTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	fileIDs, err := s.tags.FileIDsByTags(ctx, normalized, match == TagMatchAll)
+	if err != nil {
+		return nil, fmt.Errorf("按标签查询文件失败: %w", err)
+	}
+	set := make(map[string]bool, len(fileIDs))
+	for _, fileID := range fileIDs {
+		set[fileID] = true
+	}
+	return set, nil
+}
+
+// attachTags 填充文件的标签，未配置标签存储时跳过
+func (s *FileService) attachTags(ctx context.Context, files []*FileInfo) error {
+	if s.tags == nil || len(files) == 0 {
+		return nil
+	}
+
+	fileIDs := make([]string, len(files))
+	for i, file := range files {
+		fileIDs[i] = file.ID
+	}
+	tagsByFile, err := s.tags.TagsByFiles(ctx, fileIDs)
+	if err != nil {
+		return fmt.Errorf("查询文件标签失败: %w", err)
+	}
+	for _, file := range files {
+		file.Tags = tagsByFile[file.ID]
+	}
+	return nil
+}
+
+// normalizeFileTags 标签去除首尾空白并转为小写，去重后按名称排序
+// 逗号用于查询参数中分隔多个标签，不允许出现在标签中
+func normalizeFileTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "":
+			return nil, fmt.Errorf("%w: 标签不能为空", ErrInvalidFileTag)
+		case utf8.RuneCountInString(tag) > maxFileTagLength:
+			return nil, fmt.Errorf("%w: 标签不能超过 %d 个字符", ErrInvalidFileTag, maxFileTagLength)
+		case strings.Contains(tag, ","):
+			return nil, fmt.Errorf("%w: 标签不能包含逗号", ErrInvalidFileTag)
+		}
+		normalized = append(normalized, tag)
+	}
+	return slices.Compact(slices.Sorted(slices.Values(normalized))), nil
+}
+
+// splitFileTags 拆分查询参数中的标签，支持 tags=a&tags=b 和 tags=a,b 两种写法
+func splitFileTags(values []string) []string {
+	var tags []string
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// nonNilTags 没有标签时返回空切片，响应中输出 [] 而不是 null
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
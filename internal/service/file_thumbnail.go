@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // 注册 PNG 解码器
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+const (
+	// thumbnailIDSuffix 缩略图的文件ID为原文件ID加此后缀，可通过文件下载接口获取
+	thumbnailIDSuffix = "_thumb"
+	thumbnailExt      = ".jpg"
+	thumbnailQuality  = 85
+	// thumbnailMaxPixels 超过此像素数的图片不生成缩略图，避免解码时占用过多内存
+	thumbnailMaxPixels = 50 * 1000 * 1000
+)
+
+// thumbnailKey 缩略图的存储键，与原文件位于同一目录
+func thumbnailKey(fileKey, fileID string) string {
+	return path.Join(path.Dir(fileKey), fileID+thumbnailIDSuffix+thumbnailExt)
+}
+
+// isThumbnailKey 存储键是否为缩略图
+func isThumbnailKey(key string) bool {
+	return strings.HasSuffix(path.Base(key), thumbnailIDSuffix+thumbnailExt)
+}
+
+// createThumbnail 为 JPEG、PNG 图片生成缩略图并保存到原文件所在目录，返回是否已生成
+// 不支持的格式、无法解码的图片或保存失败时只记录日志，不影响上传
+func (s *FileService) createThumbnail(ctx context.Context, file *uploadSource, fileID, fileKey string) bool {
+	maxSize := config.Global.File.ThumbnailSize
+	if maxSize <= 0 {
+		return false
+	}
+	switch mimeTypeByExtension(strings.ToLower(filepath.Ext(file.Filename))) {
+	case "image/jpeg", "image/png":
+	default:
+		return false
+	}
+
+	log := logger.FromContext(ctx).With(zap.String("file_id", fileID))
+	thumbnail, err := renderThumbnail(file, maxSize)
+	if err != nil {
+		log.Warn("生成缩略图失败，已跳过", zap.Error(err))
+		return false
+	}
+	if err := s.storage.Save(ctx, thumbnailKey(fileKey, fileID), bytes.NewReader(thumbnail)); err != nil {
+		log.Warn("保存缩略图失败", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// renderThumbnail 解码图片并等比缩放到最长边不超过 maxSize，编码为 JPEG
+func renderThumbnail(file *uploadSource, maxSize int) ([]byte, error) {
+	// 先读取尺寸，拒绝解码后过大的图片
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	cfg, _, err := image.DecodeConfig(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取图片尺寸失败: %v", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > thumbnailMaxPixels {
+		return nil, fmt.Errorf("图片尺寸不支持: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	f, err = file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	src, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败: %v", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := thumbnailDimensions(bounds.Dx(), bounds.Dy(), maxSize)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	// JPEG 不支持透明，透明区域以白色填充
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("编码缩略图失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// thumbnailDimensions 等比缩放后的尺寸，最长边不超过 maxSize，小图保持原尺寸
+func thumbnailDimensions(width, height, maxSize int) (int, int) {
+	if width <= maxSize && height <= maxSize {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width)
+	}
+	return max(1, width*maxSize/height), maxSize
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/VennLe/charlotte/internal/config"
+)
+
+// 默认允许上传的 MIME 类型和扩展名，未配置 file.allowed_types / file.allowed_extensions 时使用
+var (
+	defaultAllowedTypes = []string{
+		"image/jpeg", "image/png", "image/gif", "image/webp",
+		"application/pdf", "application/msword",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.ms-excel",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"text/plain", "text/csv", "application/json",
+	}
+	defaultAllowedExtensions = []string{
+		".jpg", ".jpeg", ".png", ".gif", ".webp",
+		".pdf", ".doc", ".docx", ".xls", ".xlsx",
+		".txt", ".csv", ".json",
+	}
+)
+
+// extensionMIMETypes 扩展名对应的 MIME 类型，未列出的扩展名使用 mime.TypeByExtension
+var extensionMIMETypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".pdf":  "application/pdf",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".txt":  "text/plain",
+	".csv":  "text/csv",
+	".json": "application/json",
+}
+
+// oleStorageMIMEType 旧版 Office 文档（.doc/.xls）使用的 OLE 复合文档格式
+const oleStorageMIMEType = "application/x-ole-storage"
+
+// oleStorageSignature OLE 复合文档的文件头
+var oleStorageSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// sniffedContainerTypes 文件头只能识别到容器格式的类型，具体类型由扩展名确定（须在列表内）
+var sniffedContainerTypes = map[string][]string{
+	"application/zip": {
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	},
+	oleStorageMIMEType: {
+		"application/msword",
+		"application/vnd.ms-excel",
+	},
+	"text/plain": {
+		"text/plain",
+		"text/csv",
+		"application/json",
+	},
+}
+
+// mimeTypeByExtension 获取扩展名对应的 MIME 类型（不含参数），未知扩展名返回空字符串
+func mimeTypeByExtension(ext string) string {
+	if t, ok := extensionMIMETypes[ext]; ok {
+		return t
+	}
+	mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(ext))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// sniffContentType 根据文件头识别 MIME 类型（不含参数）
+func sniffContentType(head []byte) string {
+	if bytes.HasPrefix(head, oleStorageSignature) {
+		return oleStorageMIMEType
+	}
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// resolveContentType 结合文件内容和扩展名确定文件的实际类型
+// 文件头能识别出具体类型时必须与扩展名一致；只能识别出容器格式（zip、OLE、纯文本）时，
+// 扩展名对应的类型必须属于该容器格式。不一致时返回错误
+func resolveContentType(ext string, head []byte) (string, error) {
+	sniffed := sniffContentType(head)
+	expected := mimeTypeByExtension(ext)
+	if expected == "" {
+		return "", fmt.Errorf("无法识别的文件扩展名: %s", ext)
+	}
+	if sniffed == expected {
+		return sniffed, nil
+	}
+	for _, t := range sniffedContainerTypes[sniffed] {
+		if t == expected {
+			return expected, nil
+		}
+	}
+	return "", fmt.Errorf("文件内容与扩展名不符: 扩展名 %s，内容类型 %s", ext, sniffed)
+}
+
+// validateFileType 验证文件类型
+// 严格模式（file.strict_type_check，默认开启）下扩展名须在 allowed_extensions 中、按文件内容识别的类型须在
+// allowed_types 中，且两者一致（如 .png 文件的内容必须是 PNG）；客户端声明的 Content-Type 不参与判断。
+// 关闭严格模式时沿用旧规则：Content-Type 或扩展名任一在允许列表中即可
+func (s *FileService) validateFileType(file *uploadSource) error {
+	fileCfg := config.Global.File
+	allowedTypes := fileCfg.AllowedTypes
+	if len(allowedTypes) == 0 {
+		allowedTypes = defaultAllowedTypes
+	}
+	allowedExts := fileCfg.AllowedExtensions
+	if len(allowedExts) == 0 {
+		allowedExts = defaultAllowedExtensions
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if !fileCfg.StrictTypeCheck {
+		contentType := file.ContentType
+		if containsFold(allowedTypes, contentType) || containsFold(allowedExts, ext) {
+			return nil
+		}
+		return fmt.Errorf("不支持的文件类型: %s", contentType)
+	}
+
+	if !containsFold(allowedExts, ext) {
+		return fmt.Errorf("不支持的文件扩展名: %s", ext)
+	}
+
+	head, err := readFileHead(file)
+	if err != nil {
+		return err
+	}
+	contentType, err := resolveContentType(ext, head)
+	if err != nil {
+		return err
+	}
+	if !containsFold(allowedTypes, contentType) {
+		return fmt.Errorf("不支持的文件类型: %s", contentType)
+	}
+	return nil
+}
+
+// validateFileExtension 仅按文件名校验扩展名，用于文件内容尚未上传时提前拒绝不允许的文件；
+// 关闭严格模式时扩展名对应的类型在 allowed_types 中也可
+func validateFileExtension(filename string) error {
+	fileCfg := config.Global.File
+	allowedExts := fileCfg.AllowedExtensions
+	if len(allowedExts) == 0 {
+		allowedExts = defaultAllowedExtensions
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if containsFold(allowedExts, ext) {
+		return nil
+	}
+	if !fileCfg.StrictTypeCheck {
+		allowedTypes := fileCfg.AllowedTypes
+		if len(allowedTypes) == 0 {
+			allowedTypes = defaultAllowedTypes
+		}
+		if contentType := mimeTypeByExtension(ext); contentType != "" && containsFold(allowedTypes, contentType) {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的文件扩展名: %s", ext)
+}
+
+// readFileHead 读取上传文件的前 512 字节用于类型识别
+func readFileHead(file *uploadSource) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return buf[:n], nil
+}
+
+// containsFold 判断列表中是否包含指定值（不区分大小写）
+func containsFold(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
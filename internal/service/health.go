@@ -2,46 +2,74 @@ package service
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+
+	"github.com/VennLe/charlotte/internal/config"
 )
 
+// BuildInfo 构建信息
+type BuildInfo struct {
+	Version   string `json:"version"`
+	BuildTime string `json:"build_time"`
+	GitCommit string `json:"git_commit"`
+	GoVersion string `json:"go_version"`
+}
+
 // HealthChecker 健康检查接口
 type HealthChecker struct {
-	db       *gorm.DB
-	redis    *redis.Client
-	producer sarama.SyncProducer
+	db        *gorm.DB
+	redis     *redis.Client
+	producer  sarama.SyncProducer
+	buildInfo BuildInfo
+	startedAt time.Time // 服务启动时间，用于计算运行时长
 }
 
 // NewHealthChecker 创建健康检查器
-func NewHealthChecker(db *gorm.DB, redis *redis.Client, producer sarama.SyncProducer) *HealthChecker {
+func NewHealthChecker(db *gorm.DB, redis *redis.Client, producer sarama.SyncProducer, buildInfo BuildInfo) *HealthChecker {
 	return &HealthChecker{
-		db:       db,
-		redis:    redis,
-		producer: producer,
+		db:        db,
+		redis:     redis,
+		producer:  producer,
+		buildInfo: buildInfo,
+		startedAt: time.Now(),
 	}
 }
 
 // HealthStatus 健康状态
 type HealthStatus struct {
-	Status    string                 `json:"status"`
-	Timestamp int64                  `json:"timestamp"`
-	Service   string                 `json:"service"`
-	Version   string                 `json:"version"`
-	Checks    map[string]interface{} `json:"checks"`
+	Status       string                 `json:"status"`
+	Timestamp    int64                  `json:"timestamp"`
+	Service      string                 `json:"service"`
+	Version      string                 `json:"version"`
+	BuildTime    string                 `json:"build_time"`
+	GitCommit    string                 `json:"git_commit"`
+	GoVersion    string                 `json:"go_version"`
+	Checks       map[string]interface{} `json:"checks"`
+	Dependencies map[string]string      `json:"dependencies"` // 已连接依赖的服务端版本
 }
 
 // Check 执行健康检查
 func (h *HealthChecker) Check(ctx context.Context) *HealthStatus {
+	serviceName := "charlotte-api"
+	if config.Global != nil && config.Global.Server.Name != "" {
+		serviceName = config.Global.Server.Name
+	}
+
 	status := &HealthStatus{
-		Status:    "ok",
-		Timestamp: time.Now().Unix(),
-		Service:   "enterprise-api",
-		Version:   "1.0.0",
-		Checks:    make(map[string]interface{}),
+		Status:       "ok",
+		Timestamp:    time.Now().Unix(),
+		Service:      serviceName,
+		Version:      h.buildInfo.Version,
+		BuildTime:    h.buildInfo.BuildTime,
+		GitCommit:    h.buildInfo.GitCommit,
+		GoVersion:    h.buildInfo.GoVersion,
+		Checks:       make(map[string]interface{}),
+		Dependencies: make(map[string]string),
 	}
 
 	// 检查数据库
@@ -49,6 +77,9 @@ func (h *HealthChecker) Check(ctx context.Context) *HealthStatus {
 		sqlDB, err := h.db.DB()
 		if err == nil && sqlDB.Ping() == nil {
 			status.Checks["database"] = "connected"
+			if version := h.databaseVersion(ctx); version != "" {
+				status.Dependencies[h.db.Dialector.Name()] = version
+			}
 		} else {
 			status.Checks["database"] = "disconnected"
 			status.Status = "degraded"
@@ -61,6 +92,9 @@ func (h *HealthChecker) Check(ctx context.Context) *HealthStatus {
 	if h.redis != nil {
 		if err := h.redis.Ping(ctx).Err(); err == nil {
 			status.Checks["redis"] = "connected"
+			if version := h.redisVersion(ctx); version != "" {
+				status.Dependencies["redis"] = version
+			}
 		} else {
 			status.Checks["redis"] = "disconnected"
 			status.Status = "degraded"
@@ -78,3 +112,38 @@ func (h *HealthChecker) Check(ctx context.Context) *HealthStatus {
 
 	return status
 }
+
+// databaseVersion 查询数据库服务端版本，失败时返回空字符串
+func (h *HealthChecker) databaseVersion(ctx context.Context) string {
+	var query string
+	switch h.db.Dialector.Name() {
+	case "postgres":
+		query = "SHOW server_version"
+	case "mysql":
+		query = "SELECT VERSION()"
+	case "sqlite":
+		query = "SELECT sqlite_version()"
+	default:
+		return ""
+	}
+
+	var version string
+	if err := h.db.WithContext(ctx).Raw(query).Scan(&version).Error; err != nil {
+		return ""
+	}
+	return version
+}
+
+// redisVersion 从 INFO server 中解析 Redis 版本，失败时返回空字符串
+func (h *HealthChecker) redisVersion(ctx context.Context) string {
+	info, err := h.redis.Info(ctx, "server").Result()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(line, "redis_version:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "redis_version:"))
+		}
+	}
+	return ""
+}
@@ -3,51 +3,155 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/VennLe/charlotte/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/internal/model"
 	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/tracing"
 )
 
 // ImportExportService 导入导出服务
 type ImportExportService struct {
-	fileService *FileService
+	fileService   *FileService
+	templateStore TemplateStore
+	limiter       *operationLimiter // 全局并发限制，为 nil 时不限制
+	webhooks      *WebhookService   // 导入完成事件的 Webhook，为 nil 时不推送
+
+	exportJobs   map[string]*ExportJob
+	exportJobsMu sync.RWMutex
+
+	importJobs   map[string]*ImportJobStatus
+	importJobsMu sync.RWMutex
+
+	processors   map[string]DataProcessor // 数据处理器，按数据类型索引
+	processorsMu sync.RWMutex
 }
 
-// NewImportExportService 创建导入导出服务
+// NewImportExportService 创建导入导出服务，导入模板默认缓存在进程内
+// 默认注册用户数据处理器，其他数据类型通过 RegisterDataProcessor 注册
 func NewImportExportService(fileService *FileService) *ImportExportService {
+	user := &UserDataProcessor{}
 	return &ImportExportService{
-		fileService: fileService,
+		fileService:   fileService,
+		templateStore: NewMemoryTemplateStore(),
+		exportJobs:    make(map[string]*ExportJob),
+		importJobs:    make(map[string]*ImportJobStatus),
+		processors:    map[string]DataProcessor{user.GetDataType(): user},
 	}
 }
 
+// 导出任务状态
+const (
+	ExportJobPending   = "pending"
+	ExportJobRunning   = "running"
+	ExportJobCompleted = "completed"
+	ExportJobFailed    = "failed"
+	ExportJobCancelled = "cancelled"
+)
+
+// ErrExportJobNotFound 导出任务不存在
+var ErrExportJobNotFound = errors.New("导出任务不存在")
+
+// ErrUnsupportedDataType 数据类型未注册处理器
+var ErrUnsupportedDataType = errors.New("不支持的数据类型")
+
+// ExportJob 异步导出任务
+type ExportJob struct {
+	ID            string     `json:"id"`
+	UserID        uint       `json:"user_id"` // 提交任务的用户，仅本人和管理员可查询、取消和下载
+	DataType      string     `json:"data_type"`
+	FileType      string     `json:"file_type"`
+	Status        string     `json:"status"`
+	Progress      int        `json:"progress"` // 0-100
+	ProcessedRows int        `json:"processed_rows"`
+	TotalRows     int        `json:"total_rows"`
+	FileName      string     `json:"file_name,omitempty"`
+	FileSize      int        `json:"file_size,omitempty"`
+	Message       string     `json:"message,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	// OmittedColumns 因导出者角色无权导出而去掉的字段
+	OmittedColumns []string `json:"omitted_columns,omitempty"`
+
+	filePath string
+	cancel   context.CancelFunc
+}
+
 // ImportRequest 导入请求
 type ImportRequest struct {
 	File       *multipart.FileHeader `form:"file" binding:"required"`
-	FileType   string                `form:"file_type" binding:"required,oneof=csv excel json"`
+	FileType   string                `form:"file_type" binding:"required,oneof=csv excel json xml"`
 	DataType   string                `form:"data_type" binding:"required"` // 数据类型标识
 	HasHeader  bool                  `form:"has_header"`                   // 是否有表头
 	StartRow   int                   `form:"start_row" default:"1"`        // 数据开始行
 	SheetName  string                `form:"sheet_name"`                   // Excel工作表名
 	DateFormat string                `form:"date_format"`                  // 日期格式
 	TimeFormat string                `form:"time_format"`                  // 时间格式
+	Encoding   string                `form:"encoding"`                     // CSV文件编码，如 gbk，为空时为 UTF-8
+
+	HeaderRow        int  `form:"header_row"`         // Excel表头所在行，0表示第1行
+	AutoDetectHeader bool `form:"auto_detect_header"` // 按导出表头自动检测Excel表头行
+
+	// Locale 文件的区域格式，与导出时一致才能正确解析日期和小数，为空时使用 import_export.default_locale
+	Locale             string `form:"locale"`
+	DecimalSeparator   string `form:"decimal_separator"`   // 小数点，覆盖区域预设
+	ThousandsSeparator string `form:"thousands_separator"` // 千分位分隔符，覆盖区域预设
+
+	// ColumnMapping 列映射JSON：源文件表头 -> 字段名，如 {"邮箱":"Email"}，需有表头，覆盖按列顺序的对应关系
+	ColumnMapping string `form:"column_mapping"`
+	// DedupeKey 文件内去重的字段名，逗号分隔，如 Email 或 FirstName,LastName，与前面的行重复时该行导入失败
+	DedupeKey string `form:"dedupe_key"`
+
+	// UserID 导入用户ID，由处理器根据登录信息填写，异步导入时记录为任务的所有者
+	UserID uint `form:"-" json:"-"`
 }
 
 // ExportRequest 导出请求
 type ExportRequest struct {
 	DataType   string      `form:"data_type" binding:"required"` // 数据类型标识
-	FileType   string      `form:"file_type" binding:"required,oneof=csv excel json"`
-	FileName   string      `form:"file_name"`   // 文件名
+	FileType   string      `form:"file_type" binding:"required,oneof=csv excel json xml"`
+	FileName   string      `form:"file_name"`   // 文件名，指定时不使用文件名模板
 	Headers    []string    `form:"headers"`     // 表头
 	FieldMap   string      `form:"field_map"`   // 字段映射JSON
 	DateFormat string      `form:"date_format"` // 日期格式
 	TimeFormat string      `form:"time_format"` // 时间格式
-	Data       interface{} `json:"data"`        // 要导出的数据
+	Data       interface{} `json:"data"`        // 要导出的数据，为空时从处理器分页获取
+	Keyword    string      `form:"keyword"`     // 从处理器获取数据时的关键词
+	Filters    string      `form:"filters"`     // 从处理器获取数据时的过滤条件JSON，支持的键由处理器定义
+
+	// Locale 区域格式（如 de-DE、en-GB），为空时使用 import_export.default_locale
+	Locale             string `form:"locale"`
+	DecimalSeparator   string `form:"decimal_separator"`   // 小数点，覆盖区域预设
+	ThousandsSeparator string `form:"thousands_separator"` // 千分位分隔符，覆盖区域预设
+
+	// FileNameTemplate 文件名模板，如 "{data_type}_{user}_{date}"，为空时使用 import_export.file_name_template
+	// 支持 {data_type}、{date}、{datetime}、{user}、{count}
+	FileNameTemplate string `form:"file_name_template"`
+	// User 导出用户名，由处理器根据登录信息填写，用于文件名模板的 {user}
+	User string `form:"-" json:"-"`
+	// Role 导出用户角色，由处理器根据登录信息填写，按 permission.export_fields 限制可导出的字段
+	Role string `form:"-" json:"-"`
+	// UserID 导出用户ID，由处理器根据登录信息填写，异步导出时记录为任务的所有者
+	UserID uint `form:"-" json:"-"`
 }
 
 // ImportResponse 导入响应
@@ -58,6 +162,7 @@ type ImportResponse struct {
 	SuccessRows int                        `json:"success_rows"`
 	FailedRows  int                        `json:"failed_rows"`
 	Errors      []*utils.ImportExportError `json:"errors,omitempty"`
+	FailedLines []int                      `json:"failed_lines,omitempty"` // 未导入的数据行的文件行号，导入失败时为全部数据行
 	Data        interface{}                `json:"data,omitempty"`
 }
 
@@ -69,6 +174,9 @@ type ExportResponse struct {
 	FileSize int    `json:"file_size"`
 	FileType string `json:"file_type"`
 	Data     []byte `json:"-"` // 文件数据
+
+	// OmittedColumns 因导出者角色无权导出而去掉的字段
+	OmittedColumns []string `json:"omitted_columns,omitempty"`
 }
 
 // DataProcessor 数据处理接口
@@ -78,52 +186,115 @@ type DataProcessor interface {
 	// CreateEmptySlice 创建空的数据切片
 	CreateEmptySlice() interface{}
 	// ValidateData 验证数据
+	// 应校验全部行后返回 utils.ValidationErrors，其中 Line 为数据的序号（从1开始），
+	// ImportData 会将其转换为文件中的行号
 	ValidateData(data interface{}) error
 	// ProcessData 处理导入的数据
 	ProcessData(ctx context.Context, data interface{}) error
-	// GetExportData 获取导出的数据
-	GetExportData(ctx context.Context, params map[string]interface{}) (interface{}, error)
+	// GetExportData 按分页条件获取一页导出数据，data 必须为切片，hasMore 表示之后的页是否还有数据
+	GetExportData(ctx context.Context, query ExportQuery) (data interface{}, hasMore bool, err error)
 	// GetExportHeaders 获取导出表头
 	GetExportHeaders() []string
 	// GetExportFieldMap 获取导出字段映射
 	GetExportFieldMap() map[string]string
 }
 
+// ExportOnlyProcessor 可选接口：ExportOnly 返回 true 表示处理器不支持导入
+type ExportOnlyProcessor interface {
+	ExportOnly() bool
+}
+
+// DataSchema 数据类型的导入导出能力描述
+type DataSchema struct {
+	DataType   string              `json:"data_type"`
+	Importable bool                `json:"importable"`
+	Exportable bool                `json:"exportable"`
+	FileTypes  []string            `json:"file_types"`
+	Headers    []string            `json:"headers"` // 导出表头
+	Fields     []utils.FieldSchema `json:"fields"`
+}
+
+// FlatteningProcessor 可选接口：导出行包含嵌套结构体（如关联数据）时实现
+// FlattenNested 返回 true 时，CSV/Excel 导出会将嵌套结构体展开为多列，JSON 导出保持嵌套结构
+type FlatteningProcessor interface {
+	FlattenNested() bool
+}
+
+// ComputedColumnsProcessor 可选接口：导出不对应结构体字段的计算列（如由生日计算的年龄、关联数据的名称）
+// 计算列按返回顺序追加在结构体字段之后，GetExportHeaders 只需包含结构体字段的表头；导入时不使用
+type ComputedColumnsProcessor interface {
+	ComputedColumns() []utils.ComputedColumn
+}
+
 // ImportData 通用数据导入
+// 数据行数超过 import_export.max_import_rows 时不写入任何数据，返回包装了 utils.ErrTooManyRows 的错误，
+// 同时返回已读取部分的统计和错误详情
 func (s *ImportExportService) ImportData(ctx context.Context, req *ImportRequest, processor DataProcessor) (*ImportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ImportExportService.ImportData", attribute.String("data_type", req.DataType))
+	defer span.End()
+
 	// 验证数据类型
 	if processor.GetDataType() != req.DataType {
 		return nil, fmt.Errorf("数据类型不匹配: %s != %s", processor.GetDataType(), req.DataType)
 	}
 
-	// 创建空的数据切片
-	dataSlice := processor.CreateEmptySlice()
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	// 配置导入参数
-	importConfig := &utils.ImportConfig{
-		FileType:   req.FileType,
-		HasHeader:  req.HasHeader,
-		StartRow:   req.StartRow,
-		SheetName:  req.SheetName,
-		DateFormat: req.DateFormat,
-		TimeFormat: req.TimeFormat,
+	file, err := req.File.Open()
+	if err != nil {
+		return nil, fmt.Errorf("导入失败: %w", &utils.ImportExportError{Message: "打开文件失败: " + err.Error()})
 	}
+	defer file.Close()
+
+	return s.importFrom(ctx, req, processor, file, nil)
+}
+
+// importFrom 从 reader 读取并导入数据，调用方负责并发限制
+// only 不为 nil 时只导入其中的文件行（用于重新导入失败行），其他行连同其错误一并忽略
+func (s *ImportExportService) importFrom(ctx context.Context, req *ImportRequest, processor DataProcessor, reader io.Reader, only map[int]bool) (*ImportResponse, error) {
+	// 创建空的数据切片
+	dataSlice := processor.CreateEmptySlice()
 
 	// 执行导入
-	result, err := utils.ImportData(dataSlice, req.File, importConfig)
+	importConfig, err := newImportConfig(req, processor)
 	if err != nil {
-		return nil, fmt.Errorf("导入失败: %v", err)
+		return nil, err
+	}
+	result, err := utils.ImportDataFrom(dataSlice, reader, importConfig)
+	if errors.Is(err, utils.ErrTooManyRows) {
+		var limitErr *utils.ImportExportError
+		errors.As(err, &limitErr)
+		return &ImportResponse{
+			Success:     false,
+			Message:     err.Error(),
+			TotalRows:   result.TotalRows,
+			SuccessRows: 0,
+			FailedRows:  result.TotalRows,
+			Errors:      append(result.Errors, limitErr),
+		}, fmt.Errorf("导入失败: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("导入失败: %w", err)
+	}
+	if only != nil {
+		keepImportLines(dataSlice, result, only)
 	}
 
-	// 验证数据
+	// 验证数据，解析错误与全部校验错误一并返回
 	if err := processor.ValidateData(dataSlice); err != nil {
+		errs := mergeValidationErrors(result, err)
 		return &ImportResponse{
 			Success:     false,
 			Message:     "数据验证失败: " + err.Error(),
 			TotalRows:   result.TotalRows,
 			SuccessRows: 0,
 			FailedRows:  result.TotalRows,
-			Errors:      result.Errors,
+			Errors:      errs,
+			FailedLines: importFailedLines(result, false, only),
 		}, nil
 	}
 
@@ -136,6 +307,7 @@ func (s *ImportExportService) ImportData(ctx context.Context, req *ImportRequest
 			SuccessRows: 0,
 			FailedRows:  result.TotalRows,
 			Errors:      result.Errors,
+			FailedLines: importFailedLines(result, false, only),
 		}, nil
 	}
 
@@ -146,6 +318,14 @@ func (s *ImportExportService) ImportData(ctx context.Context, req *ImportRequest
 		zap.Int("success_rows", result.SuccessRows),
 		zap.Int("failed_rows", result.FailedRows),
 	)
+	go s.webhooks.Dispatch(context.WithoutCancel(ctx), model.EventImportCompleted, ImportCompletedEvent{
+		DataType:    req.DataType,
+		FileType:    req.FileType,
+		FileName:    req.File.Filename,
+		TotalRows:   result.TotalRows,
+		SuccessRows: result.SuccessRows,
+		FailedRows:  result.FailedRows,
+	})
 
 	return &ImportResponse{
 		Success:     true,
@@ -154,27 +334,284 @@ func (s *ImportExportService) ImportData(ctx context.Context, req *ImportRequest
 		SuccessRows: result.SuccessRows,
 		FailedRows:  result.FailedRows,
 		Errors:      result.Errors,
+		FailedLines: importFailedLines(result, true, only),
 		Data:        dataSlice,
 	}, nil
 }
 
+// keepImportLines 只保留 lines 中的数据行，去掉其他行的数据、错误并重新计数
+func keepImportLines(dataSlice interface{}, result *utils.ImportResult, lines map[int]bool) {
+	data := reflect.ValueOf(dataSlice).Elem()
+	kept := reflect.MakeSlice(data.Type(), 0, len(lines))
+	var keptLines []int
+	for i, line := range result.Lines {
+		if lines[line] {
+			kept = reflect.Append(kept, data.Index(i))
+			keptLines = append(keptLines, line)
+		}
+	}
+	data.Set(kept)
+	result.Lines = keptLines
+
+	var failedLines []int
+	for _, line := range result.FailedLines {
+		if lines[line] {
+			failedLines = append(failedLines, line)
+		}
+	}
+	result.FailedLines = failedLines
+
+	errs := make([]*utils.ImportExportError, 0, len(result.Errors))
+	for _, e := range result.Errors {
+		if lines[e.Line] {
+			errs = append(errs, e)
+		}
+	}
+	result.Errors = errs
+
+	result.SuccessRows = len(keptLines)
+	result.FailedRows = len(failedLines)
+	result.TotalRows = result.SuccessRows + result.FailedRows
+}
+
+// importFailedLines 未导入的数据行的文件行号：imported 为 true 时是解析失败的行，否则是全部行；
+// 指定了 only 时，only 中未出现在文件里的行同样算作未导入
+func importFailedLines(result *utils.ImportResult, imported bool, only map[int]bool) []int {
+	var lines []int
+	switch {
+	case only != nil:
+		done := make(map[int]bool, len(result.Lines))
+		if imported {
+			for _, line := range result.Lines {
+				done[line] = true
+			}
+		}
+		for line := range only {
+			if !done[line] {
+				lines = append(lines, line)
+			}
+		}
+	case imported:
+		lines = append(lines, result.FailedLines...)
+	default:
+		lines = append(append(lines, result.Lines...), result.FailedLines...)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// newImportConfig 按导入请求生成导入配置
+func newImportConfig(req *ImportRequest, processor DataProcessor) (*utils.ImportConfig, error) {
+	importConfig := &utils.ImportConfig{
+		FileType:   req.FileType,
+		HasHeader:  req.HasHeader,
+		StartRow:   req.StartRow,
+		SheetName:  req.SheetName,
+		DateFormat: req.DateFormat,
+		TimeFormat: req.TimeFormat,
+		Encoding:   req.Encoding,
+		HeaderRow:  req.HeaderRow,
+		MaxRows:    config.Global.ImportExport.MaxImportRows,
+
+		Locale:             req.Locale,
+		DecimalSeparator:   req.DecimalSeparator,
+		ThousandsSeparator: req.ThousandsSeparator,
+	}
+	if importConfig.Locale == "" {
+		importConfig.Locale = config.Global.ImportExport.DefaultLocale
+	}
+	if req.AutoDetectHeader {
+		importConfig.ExpectedHeaders = processor.GetExportHeaders()
+	}
+	if req.ColumnMapping != "" {
+		if err := json.Unmarshal([]byte(req.ColumnMapping), &importConfig.ColumnMapping); err != nil {
+			return nil, fmt.Errorf("%w: 解析列映射失败: %v", utils.ErrInvalidColumnMapping, err)
+		}
+	}
+	for _, key := range strings.Split(req.DedupeKey, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			importConfig.DedupeKey = append(importConfig.DedupeKey, key)
+		}
+	}
+	if err := utils.ValidateDedupeKey(processor.CreateEmptySlice(), importConfig.DedupeKey); err != nil {
+		return nil, err
+	}
+	return importConfig, nil
+}
+
+// ProfileImport 扫描导入文件并按列统计数据概况（推断类型、空值数、去重数、无法转换为目标字段类型的值），
+// 不校验也不写入数据，用于在导入前发现类型不匹配等问题
+func (s *ImportExportService) ProfileImport(ctx context.Context, req *ImportRequest, processor DataProcessor) (*utils.ImportProfile, error) {
+	ctx, span := tracing.Start(ctx, "ImportExportService.ProfileImport", attribute.String("data_type", req.DataType))
+	defer span.End()
+
+	if processor.GetDataType() != req.DataType {
+		return nil, fmt.Errorf("数据类型不匹配: %s != %s", processor.GetDataType(), req.DataType)
+	}
+
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	importConfig, err := newImportConfig(req, processor)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ProfileImport(processor.CreateEmptySlice(), req.File, importConfig)
+}
+
+// mergeValidationErrors 合并解析错误和校验错误，校验错误的数据序号原地转换为文件行号，按行号排序
+func mergeValidationErrors(result *utils.ImportResult, err error) []*utils.ImportExportError {
+	var validationErrs utils.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return result.Errors
+	}
+
+	merged := make([]*utils.ImportExportError, 0, len(result.Errors)+len(validationErrs))
+	merged = append(merged, result.Errors...)
+	for _, e := range validationErrs {
+		if e.Line > 0 && e.Line <= len(result.Lines) {
+			e.Line = result.Lines[e.Line-1]
+		}
+		merged = append(merged, e)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Line < merged[j].Line
+	})
+	return merged
+}
+
 // ExportData 通用数据导出
+// ctx 被取消（如客户端断开连接）时导出会中途终止，返回的错误可用 errors.Is(err, context.Canceled) 判断
 func (s *ImportExportService) ExportData(ctx context.Context, req *ExportRequest, processor DataProcessor) (*ExportResponse, error) {
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return s.exportData(ctx, req, processor, nil)
+}
+
+// ExportDataTo 流式导出，边生成边写入 w，不在内存中缓存完整文件
+// onStart 在第一个字节写入 w 之前调用（传入的 resp 中 FileSize 尚为 0），用于设置响应头；
+// 若返回错误时 onStart 尚未被调用，说明 w 未被写入，调用方仍可返回普通错误响应
+func (s *ImportExportService) ExportDataTo(ctx context.Context, w io.Writer, req *ExportRequest, processor DataProcessor, onStart func(resp *ExportResponse)) (*ExportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ImportExportService.ExportDataTo", attribute.String("data_type", req.DataType))
+	defer span.End()
+
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	exportConfig, err := s.prepareExport(ctx, req, processor, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ExportResponse{
+		Success:        true,
+		Message:        "数据导出成功",
+		FileName:       exportConfig.FileName,
+		FileType:       req.FileType,
+		OmittedColumns: exportConfig.OmitFields,
+	}
+	sw := &exportStreamWriter{w: w, onStart: func() {
+		if onStart != nil {
+			onStart(resp)
+		}
+	}}
+
+	if err := utils.ExportDataTo(ctx, sw, req.Data, exportConfig); err != nil {
+		return nil, s.wrapExportError(req, err)
+	}
+	resp.FileSize = sw.written
+
+	logger.Info("数据导出成功",
+		zap.String("data_type", req.DataType),
+		zap.String("file_type", req.FileType),
+		zap.String("file_name", resp.FileName),
+		zap.Int("file_size", resp.FileSize),
+	)
+	return resp, nil
+}
+
+// exportStreamWriter 记录写入字节数，并在首次写入前触发回调
+type exportStreamWriter struct {
+	w       io.Writer
+	onStart func()
+	started bool
+	written int
+}
+
+func (sw *exportStreamWriter) Write(p []byte) (int, error) {
+	if !sw.started {
+		sw.started = true
+		sw.onStart()
+	}
+	n, err := sw.w.Write(p)
+	sw.written += n
+	return n, err
+}
+
+// exportData 导出实现，progress 用于异步任务上报进度
+func (s *ImportExportService) exportData(ctx context.Context, req *ExportRequest, processor DataProcessor, progress func(processed, total int)) (*ExportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ImportExportService.ExportData", attribute.String("data_type", req.DataType))
+	defer span.End()
+
+	exportConfig, err := s.prepareExport(ctx, req, processor, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	// 执行导出
+	fileData, err := utils.ExportDataWithContext(ctx, req.Data, exportConfig)
+	if err != nil {
+		return nil, s.wrapExportError(req, err)
+	}
+
+	logger.Info("数据导出成功",
+		zap.String("data_type", req.DataType),
+		zap.String("file_type", req.FileType),
+		zap.String("file_name", exportConfig.FileName),
+		zap.Int("file_size", len(fileData)),
+	)
+
+	return &ExportResponse{
+		Success:        true,
+		Message:        "数据导出成功",
+		FileName:       exportConfig.FileName,
+		FileSize:       len(fileData),
+		FileType:       req.FileType,
+		Data:           fileData,
+		OmittedColumns: exportConfig.OmitFields,
+	}, nil
+}
+
+// prepareExport 获取导出数据并生成导出配置
+func (s *ImportExportService) prepareExport(ctx context.Context, req *ExportRequest, processor DataProcessor, progress func(processed, total int)) (*utils.ExportConfig, error) {
 	// 验证数据类型
 	if processor.GetDataType() != req.DataType {
 		return nil, fmt.Errorf("数据类型不匹配: %s != %s", processor.GetDataType(), req.DataType)
 	}
 
-	// 获取导出数据
-	params := make(map[string]interface{})
-	if req.Data != nil {
-		// 如果直接提供了数据，则使用提供的数据
-		params["data"] = req.Data
-	} else {
-		// 否则从处理器获取数据
-		data, err := processor.GetExportData(ctx, params)
+	// 获取导出数据：未直接提供数据时从处理器分页获取
+	if req.Data == nil {
+		filters, err := parseExportFilters(req.Filters)
+		if err != nil {
+			return nil, err
+		}
+		data, err := collectExportData(ctx, processor, ExportQuery{
+			Size:    config.Global.ImportExport.ExportPageSize,
+			Keyword: req.Keyword,
+			Filters: filters,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("获取导出数据失败: %v", err)
+			return nil, fmt.Errorf("获取导出数据失败: %w", err)
 		}
 		req.Data = data
 	}
@@ -185,6 +622,34 @@ func (s *ImportExportService) ExportData(ctx context.Context, req *ExportRequest
 		FileName:   req.FileName,
 		DateFormat: req.DateFormat,
 		TimeFormat: req.TimeFormat,
+		Progress:   progress,
+
+		Locale:             req.Locale,
+		DecimalSeparator:   req.DecimalSeparator,
+		ThousandsSeparator: req.ThousandsSeparator,
+	}
+	if exportConfig.Locale == "" {
+		exportConfig.Locale = config.Global.ImportExport.DefaultLocale
+	}
+
+	// 反规范化的关联数据（如用户及其用户组）展开为多列
+	if fp, ok := processor.(FlatteningProcessor); ok {
+		exportConfig.FlattenNested = fp.FlattenNested()
+	}
+
+	// 处理器提供的计算列
+	if cp, ok := processor.(ComputedColumnsProcessor); ok {
+		exportConfig.ComputedColumns = cp.ComputedColumns()
+	}
+
+	// 按导出者角色去掉无权导出的字段
+	exportConfig.OmitFields = exportOmittedFields(req.Role, req.DataType, req.Data, exportConfig)
+	if len(exportConfig.OmitFields) > 0 {
+		logger.FromContext(ctx).Info("导出字段受角色限制",
+			zap.String("data_type", req.DataType),
+			zap.String("role", req.Role),
+			zap.Strings("omitted_columns", exportConfig.OmitFields),
+		)
 	}
 
 	// 设置表头
@@ -206,37 +671,308 @@ func (s *ImportExportService) ExportData(ctx context.Context, req *ExportRequest
 	}
 
 	// 生成文件名
-	if exportConfig.FileName == "" {
-		exportConfig.FileName = s.generateFileName(req.DataType, req.FileType)
+	fileName, err := exportFileName(req, config.Global.ImportExport.FileNameTemplate, exportRecordCount(req.Data))
+	if err != nil {
+		return nil, err
 	}
+	exportConfig.FileName = fileName
 
-	// 执行导出
-	fileData, err := utils.ExportData(req.Data, exportConfig)
+	return exportConfig, nil
+}
+
+// wrapExportError 包装导出错误，取消类错误原样返回以便调用方判断
+func (s *ImportExportService) wrapExportError(req *ExportRequest, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		logger.Warn("数据导出已取消",
+			zap.String("data_type", req.DataType),
+			zap.String("file_type", req.FileType),
+			zap.Error(err),
+		)
+		return err
+	}
+	return fmt.Errorf("导出失败: %v", err)
+}
+
+// StartExportJob 提交异步导出任务，立即返回任务信息
+// 任务使用独立的 context 运行，不受发起请求结束的影响，可通过 CancelExportJob 取消；并发和排队都已满时返回 ErrImportExportBusy
+func (s *ImportExportService) StartExportJob(req *ExportRequest, processor DataProcessor) (*ExportJob, error) {
+	if processor.GetDataType() != req.DataType {
+		return nil, fmt.Errorf("数据类型不匹配: %s != %s", processor.GetDataType(), req.DataType)
+	}
+	if err := validateExportFileNameTemplate(req.FileNameTemplate); err != nil {
+		return nil, err
+	}
+
+	id, err := newRandomID()
 	if err != nil {
-		return nil, fmt.Errorf("导出失败: %v", err)
+		return nil, fmt.Errorf("生成任务ID失败: %v", err)
 	}
 
-	logger.Info("数据导出成功",
-		zap.String("data_type", req.DataType),
-		zap.String("file_type", req.FileType),
-		zap.String("file_name", exportConfig.FileName),
-		zap.Int("file_size", len(fileData)),
+	wait, err := s.limiter.reserve()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &ExportJob{
+		ID:        "export_" + id,
+		UserID:    req.UserID,
+		DataType:  req.DataType,
+		FileType:  req.FileType,
+		Status:    ExportJobPending,
+		CreatedAt: time.Now(),
+		cancel:    cancel,
+	}
+
+	s.exportJobsMu.Lock()
+	s.exportJobs[job.ID] = job
+	s.exportJobsMu.Unlock()
+
+	go s.runExportJob(ctx, job, req, processor, wait)
+
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// GetExportJob 获取异步导出任务状态，仅任务所有者和管理员可查询
+func (s *ImportExportService) GetExportJob(jobID string, userID uint, role string) (*ExportJob, error) {
+	_, snapshot, err := s.lookupExportJob(jobID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// CancelExportJob 取消异步导出任务，仅任务所有者和管理员可取消
+func (s *ImportExportService) CancelExportJob(jobID string, userID uint, role string) error {
+	job, snapshot, err := s.lookupExportJob(jobID, userID, role)
+	if err != nil {
+		return err
+	}
+	if snapshot.Status != ExportJobPending && snapshot.Status != ExportJobRunning {
+		return fmt.Errorf("导出任务已结束，无法取消: %s", snapshot.Status)
+	}
+
+	job.cancel()
+	logger.Info("导出任务已请求取消", zap.String("job_id", jobID), zap.Uint("user_id", userID))
+	return nil
+}
+
+// OpenExportJobFile 打开已完成导出任务的结果文件，仅任务所有者和管理员可下载
+func (s *ImportExportService) OpenExportJobFile(jobID string, userID uint, role string) (*ExportJob, *os.File, error) {
+	_, snapshot, err := s.lookupExportJob(jobID, userID, role)
+	if err != nil {
+		return nil, nil, err
+	}
+	if snapshot.Status != ExportJobCompleted {
+		return nil, nil, fmt.Errorf("导出任务未完成: %s", snapshot.Status)
+	}
+
+	file, err := os.Open(snapshot.filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开导出文件失败: %v", err)
+	}
+	return snapshot, file, nil
+}
+
+// lookupExportJob 查找任务并返回其快照，无权访问的任务与不存在的任务一样返回 ErrExportJobNotFound
+func (s *ImportExportService) lookupExportJob(jobID string, userID uint, role string) (*ExportJob, *ExportJob, error) {
+	s.exportJobsMu.RLock()
+	defer s.exportJobsMu.RUnlock()
+
+	job, ok := s.exportJobs[jobID]
+	if !ok || !canAccessOwned(job.UserID, userID, role) {
+		return nil, nil, ErrExportJobNotFound
+	}
+	snapshot := *job
+	return job, &snapshot, nil
+}
+
+// runExportJob 执行异步导出任务
+func (s *ImportExportService) runExportJob(ctx context.Context, job *ExportJob, req *ExportRequest, processor DataProcessor, wait slotWaiter) {
+	defer job.cancel()
+
+	// 并发已满时保持 pending 状态等待名额
+	release, err := wait(ctx)
+	if err != nil {
+		s.finishExportJob(job, ExportJobCancelled, "导出已取消")
+		return
+	}
+	defer release()
+
+	s.updateExportJob(job, func(j *ExportJob) {
+		j.Status = ExportJobRunning
+	})
+
+	progress := func(processed, total int) {
+		s.updateExportJob(job, func(j *ExportJob) {
+			j.ProcessedRows = processed
+			j.TotalRows = total
+			if total > 0 {
+				j.Progress = processed * 100 / total
+			}
+		})
+	}
+
+	resp, err := s.exportData(ctx, req, processor, progress)
+	if err == nil {
+		err = s.saveExportJobFile(ctx, job, resp)
+	}
+
+	if err != nil {
+		status, message := ExportJobFailed, err.Error()
+		if errors.Is(err, context.Canceled) {
+			status, message = ExportJobCancelled, "导出已取消"
+		}
+		s.finishExportJob(job, status, message)
+		logger.Warn("异步导出任务未完成",
+			zap.String("job_id", job.ID),
+			zap.String("status", status),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.updateExportJob(job, func(j *ExportJob) {
+		j.FileName = resp.FileName
+		j.FileSize = resp.FileSize
+		j.OmittedColumns = resp.OmittedColumns
+		j.Progress = 100
+	})
+	s.finishExportJob(job, ExportJobCompleted, "数据导出成功")
+
+	logger.Info("异步导出任务完成",
+		zap.String("job_id", job.ID),
+		zap.String("file_name", resp.FileName),
+		zap.Int("file_size", resp.FileSize),
 	)
+}
 
-	return &ExportResponse{
-		Success:  true,
-		Message:  "数据导出成功",
-		FileName: exportConfig.FileName,
-		FileSize: len(fileData),
-		FileType: req.FileType,
-		Data:     fileData,
-	}, nil
+// saveExportJobFile 将导出结果写入任务文件，失败或取消时清理不完整的文件
+func (s *ImportExportService) saveExportJobFile(ctx context.Context, job *ExportJob, resp *ExportResponse) (err error) {
+	filePath := filepath.Join(s.exportJobDir(), job.ID+filepath.Ext(resp.FileName))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("创建导出目录失败: %v", err)
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(filePath)
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filePath, resp.Data, 0644); err != nil {
+		return fmt.Errorf("保存导出文件失败: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.updateExportJob(job, func(j *ExportJob) {
+		j.filePath = filePath
+	})
+	return nil
+}
+
+// exportJobDirName 异步导出文件的目录名，以 . 开头
+const exportJobDirName = ".exports"
+
+// exportJobDir 异步导出文件目录，位于上传目录下的隐藏目录中，不会出现在文件列表和下载接口中
+func (s *ImportExportService) exportJobDir() string {
+	basePath := "resources"
+	if s.fileService != nil {
+		basePath = s.fileService.basePath
+	}
+	return filepath.Join(basePath, exportJobDirName)
+}
+
+// updateExportJob 在锁保护下更新任务
+func (s *ImportExportService) updateExportJob(job *ExportJob, fn func(j *ExportJob)) {
+	s.exportJobsMu.Lock()
+	defer s.exportJobsMu.Unlock()
+	fn(job)
+}
+
+// finishExportJob 标记任务结束
+func (s *ImportExportService) finishExportJob(job *ExportJob, status, message string) {
+	now := time.Now()
+	s.updateExportJob(job, func(j *ExportJob) {
+		j.Status = status
+		j.Message = message
+		j.FinishedAt = &now
+	})
 }
 
-// generateFileName 生成文件名
-func (s *ImportExportService) generateFileName(dataType, fileType string) string {
-	timestamp := time.Now().Format("20060102150405")
-	return fmt.Sprintf("%s_%s.%s", dataType, timestamp, strings.ToLower(fileType))
+// CleanupExportJobs 清理结束超过 retention 的导出任务及其结果文件，返回清理的任务数
+// 任务保存在进程内，每个实例需各自清理
+func (s *ImportExportService) CleanupExportJobs(ctx context.Context, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+
+	s.exportJobsMu.Lock()
+	var expired []*ExportJob
+	for id, job := range s.exportJobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			expired = append(expired, job)
+			delete(s.exportJobs, id)
+		}
+	}
+	s.exportJobsMu.Unlock()
+
+	for _, job := range expired {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if job.filePath == "" {
+			continue
+		}
+		if err := os.Remove(job.filePath); err != nil && !os.IsNotExist(err) {
+			logger.Warn("删除过期导出文件失败", zap.String("job_id", job.ID), zap.Error(err))
+		}
+	}
+
+	// 任务记录只在内存中，进程重启前留下的结果文件不属于任何任务，按修改时间清理
+	s.exportJobsMu.RLock()
+	live := make(map[string]bool, len(s.exportJobs))
+	for _, job := range s.exportJobs {
+		live[job.filePath] = true
+	}
+	s.exportJobsMu.RUnlock()
+	if removed := removeStaleFiles(s.exportJobDir(), live, cutoff); removed > 0 {
+		logger.Info("已清理无主的导出文件", zap.Int("count", removed))
+	}
+	return len(expired), nil
+}
+
+// removeStaleFiles 删除 dir 中修改时间早于 cutoff 且不在 keep 中的文件，返回删除的文件数
+func removeStaleFiles(dir string, keep map[string]bool, cutoff time.Time) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("读取任务文件目录失败", zap.String("dir", dir), zap.Error(err))
+		}
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || keep[path] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			logger.Warn("删除无主的任务文件失败", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		removed++
+	}
+	return removed
 }
 
 // UserDataProcessor 用户数据处理器示例
@@ -256,17 +992,41 @@ func (p *UserDataProcessor) ValidateData(data interface{}) error {
 		return fmt.Errorf("数据类型错误，期望*[]UserInfo")
 	}
 
+	var errs utils.ValidationErrors
+	usernames := make(map[string]bool, len(*users))
+	emails := make(map[string]bool, len(*users))
 	for i, user := range *users {
+		row := i + 1
 		if user.Username == "" {
-			return fmt.Errorf("第%d行: 用户名不能为空", i+1)
+			errs.Add(row, "Username", "用户名不能为空")
+		} else if usernames[user.Username] {
+			errs.Add(row, "Username", "用户名在文件中重复")
+		} else {
+			usernames[user.Username] = true
 		}
-		if user.Email == "" {
-			return fmt.Errorf("第%d行: 邮箱不能为空", i+1)
+
+		email := strings.ToLower(user.Email)
+		switch {
+		case email == "":
+			errs.Add(row, "Email", "邮箱不能为空")
+		case !isValidEmail(email):
+			errs.Add(row, "Email", "邮箱格式错误")
+		default:
+			if emails[email] {
+				errs.Add(row, "Email", "邮箱在文件中重复")
+			} else {
+				emails[email] = true
+			}
 		}
-		// 更多验证规则...
 	}
 
-	return nil
+	return errs.Err()
+}
+
+// isValidEmail 校验邮箱格式（不含显示名）
+func isValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
 }
 
 func (p *UserDataProcessor) ProcessData(ctx context.Context, data interface{}) error {
@@ -293,37 +1053,34 @@ func (p *UserDataProcessor) ProcessData(ctx context.Context, data interface{}) e
 	return nil
 }
 
-func (p *UserDataProcessor) GetExportData(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+func (p *UserDataProcessor) GetExportData(ctx context.Context, query ExportQuery) (interface{}, bool, error) {
 	// 这里可以从数据库获取用户数据
 	// 示例：
 	// userService := NewUserService(db)
-	// page := 1
-	// size := 1000
-	// if p, ok := params["page"].(int); ok {
-	//     page = p
+	// users, total, err := userService.GetUserList(ctx, query.Page, query.Size, query.Keyword)
+	// if err != nil {
+	//     return nil, false, err
 	// }
-	// if s, ok := params["size"].(int); ok {
-	//     size = s
-	// }
-	//
-	// users, _, err := userService.GetUserList(ctx, page, size, "")
-	// return users, err
+	// return users, int64(query.Page*query.Size) < total, nil
 
 	// 返回空数据示例
-	return []UserInfo{}, nil
+	return []UserInfo{}, false, nil
 }
 
+// GetExportHeaders 表头按位置对应 UserInfo 的字段顺序
 func (p *UserDataProcessor) GetExportHeaders() []string {
 	return []string{
 		"ID",
 		"用户名",
 		"邮箱",
 		"昵称",
+		"头像",
 		"手机号",
-		"角色",
 		"状态",
+		"角色",
 		"最后登录时间",
 		"创建时间",
+		"更新时间",
 	}
 }
 
@@ -333,30 +1090,238 @@ func (p *UserDataProcessor) GetExportFieldMap() map[string]string {
 		"Username":  "username",
 		"Email":     "email",
 		"Nickname":  "nickname",
+		"Avatar":    "avatar",
 		"Phone":     "phone",
-		"Role":      "role",
 		"Status":    "status",
+		"Role":      "role",
 		"LastLogin": "last_login",
 		"CreatedAt": "created_at",
+		"UpdatedAt": "updated_at",
+	}
+}
+
+// UserWithGroups 用户及其所属用户组（反规范化导出行）
+type UserWithGroups struct {
+	UserInfo
+	Groups     []string `json:"groups"`
+	GroupCount int      `json:"group_count"`
+}
+
+// UserGroupsDataProcessor 用户及用户组关联数据处理器，仅支持导出
+type UserGroupsDataProcessor struct {
+	userDAO       *dao.UserDAO
+	permissionDAO *dao.UnifiedPermissionDAO
+}
+
+// NewUserGroupsDataProcessor 创建用户及用户组关联数据处理器
+func NewUserGroupsDataProcessor(db *gorm.DB) *UserGroupsDataProcessor {
+	return &UserGroupsDataProcessor{
+		userDAO:       dao.NewUserDAO(db),
+		permissionDAO: dao.NewUnifiedPermissionDAO(db),
+	}
+}
+
+func (p *UserGroupsDataProcessor) GetDataType() string {
+	return "user_groups"
+}
+
+func (p *UserGroupsDataProcessor) CreateEmptySlice() interface{} {
+	return &[]UserWithGroups{}
+}
+
+func (p *UserGroupsDataProcessor) ValidateData(data interface{}) error {
+	return fmt.Errorf("数据类型 %s 不支持导入", p.GetDataType())
+}
+
+func (p *UserGroupsDataProcessor) ProcessData(ctx context.Context, data interface{}) error {
+	return fmt.Errorf("数据类型 %s 不支持导入", p.GetDataType())
+}
+
+// userGroupsExportFilters 用户组关联导出支持的过滤条件
+var userGroupsExportFilters = map[string]string{
+	"role":   "role = ?",
+	"status": "status = ?",
+}
+
+// GetExportData 分页查询用户并关联其所属用户组，每个用户一行
+// 支持按 keyword 搜索用户名、邮箱、昵称，按 role、status 过滤
+func (p *UserGroupsDataProcessor) GetExportData(ctx context.Context, query ExportQuery) (interface{}, bool, error) {
+	filters := make(map[string]interface{}, len(query.Filters))
+	for key, value := range query.Filters {
+		condition, ok := userGroupsExportFilters[key]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: %s", ErrInvalidExportFilter, key)
+		}
+		filters[condition] = value
+	}
+
+	// 逐页导出不需要总数，跳过每页的 COUNT(*)，取满一页即认为可能还有下一页
+	users, _, err := p.userDAO.List(ctx, &dao.QueryOptions{
+		Page:      query.Page,
+		Size:      query.Size,
+		Keyword:   query.Keyword,
+		Filters:   filters,
+		OrderBy:   "id",
+		OrderDir:  "asc",
+		SkipCount: true,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := len(users) == query.Size
+
+	userIDs := make([]uint, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	groupNames, err := p.permissionDAO.GetUserGroupNames(ctx, userIDs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rows := make([]UserWithGroups, len(users))
+	for i, user := range users {
+		groups := groupNames[user.ID]
+		rows[i] = UserWithGroups{
+			UserInfo: UserInfo{
+				ID:        user.ID,
+				Username:  user.Username,
+				Email:     user.Email,
+				Nickname:  user.Nickname,
+				Avatar:    user.Avatar,
+				Phone:     user.Phone,
+				Status:    user.Status,
+				Role:      user.Role,
+				LastLogin: user.LastLogin,
+				CreatedAt: user.CreatedAt,
+				UpdatedAt: user.UpdatedAt,
+			},
+			Groups:     groups,
+			GroupCount: len(groups),
+		}
+	}
+	return rows, hasMore, nil
+}
+
+func (p *UserGroupsDataProcessor) GetExportHeaders() []string {
+	return []string{
+		"ID",
+		"用户名",
+		"邮箱",
+		"昵称",
+		"头像",
+		"手机号",
+		"状态",
+		"角色",
+		"最后登录时间",
+		"创建时间",
+		"更新时间",
+		"用户组",
+		"用户组数量",
+	}
+}
+
+func (p *UserGroupsDataProcessor) GetExportFieldMap() map[string]string {
+	return map[string]string{
+		"ID":         "id",
+		"Username":   "username",
+		"Email":      "email",
+		"Nickname":   "nickname",
+		"Avatar":     "avatar",
+		"Phone":      "phone",
+		"Status":     "status",
+		"Role":       "role",
+		"LastLogin":  "last_login",
+		"CreatedAt":  "created_at",
+		"UpdatedAt":  "updated_at",
+		"Groups":     "groups",
+		"GroupCount": "group_count",
+	}
+}
+
+// FlattenNested 嵌入的 UserInfo 展开为多列，用户组名称连接为一列
+func (p *UserGroupsDataProcessor) FlattenNested() bool {
+	return true
+}
+
+// ExportOnly 关联数据仅支持导出
+func (p *UserGroupsDataProcessor) ExportOnly() bool {
+	return true
+}
+
+// GetDataSchema 获取数据类型的字段结构与导入导出能力，字段通过反射 CreateEmptySlice 的元素类型得到
+func (s *ImportExportService) GetDataSchema(processor DataProcessor) (*DataSchema, error) {
+	fields, err := utils.DescribeFields(processor.CreateEmptySlice())
+	if err != nil {
+		return nil, fmt.Errorf("解析数据类型 %s 的字段失败: %w", processor.GetDataType(), err)
+	}
+
+	importable := true
+	if p, ok := processor.(ExportOnlyProcessor); ok && p.ExportOnly() {
+		importable = false
 	}
+
+	headers := slices.Clip(processor.GetExportHeaders())
+	if cp, ok := processor.(ComputedColumnsProcessor); ok {
+		for _, column := range cp.ComputedColumns() {
+			headers = append(headers, column.Name)
+		}
+	}
+
+	return &DataSchema{
+		DataType:   processor.GetDataType(),
+		Importable: importable,
+		Exportable: true,
+		FileTypes:  s.GetSupportedFileTypes(),
+		Headers:    headers,
+		Fields:     fields,
+	}, nil
 }
 
-// RegisterDataProcessor 注册数据处理器
+// RegisterDataProcessor 注册数据处理器，dataType 为空时使用处理器的 GetDataType()
+// 同一数据类型只能注册一次，需要替换默认处理器时应使用不同的数据类型
 func (s *ImportExportService) RegisterDataProcessor(dataType string, processor DataProcessor) error {
-	// 在实际应用中，这里应该维护一个处理器映射表
-	// 这里简化实现
+	if processor == nil {
+		return errors.New("数据处理器不能为空")
+	}
+	if dataType == "" {
+		dataType = processor.GetDataType()
+	}
+	if dataType == "" {
+		return errors.New("数据类型不能为空")
+	}
+
+	s.processorsMu.Lock()
+	defer s.processorsMu.Unlock()
+	if _, exists := s.processors[dataType]; exists {
+		return fmt.Errorf("数据类型 %s 已注册处理器", dataType)
+	}
+	s.processors[dataType] = processor
 	return nil
 }
 
-// GetSupportedDataTypes 获取支持的数据类型
+// GetDataProcessor 根据数据类型获取处理器，未注册时返回 ErrUnsupportedDataType
+func (s *ImportExportService) GetDataProcessor(dataType string) (DataProcessor, error) {
+	s.processorsMu.RLock()
+	processor, ok := s.processors[dataType]
+	s.processorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDataType, dataType)
+	}
+	return processor, nil
+}
+
+// GetSupportedDataTypes 获取已注册的数据类型，按名称排序
 func (s *ImportExportService) GetSupportedDataTypes() []string {
-	return []string{
-		"user",     // 用户数据
-		"product",  // 产品数据
-		"order",    // 订单数据
-		"customer", // 客户数据
-		// 可以扩展更多数据类型
+	s.processorsMu.RLock()
+	defer s.processorsMu.RUnlock()
+
+	dataTypes := make([]string, 0, len(s.processors))
+	for dataType := range s.processors {
+		dataTypes = append(dataTypes, dataType)
 	}
+	sort.Strings(dataTypes)
+	return dataTypes
 }
 
 // GetSupportedFileTypes 获取支持的文件类型
@@ -365,5 +1330,6 @@ func (s *ImportExportService) GetSupportedFileTypes() []string {
 		"csv",
 		"excel",
 		"json",
+		"xml",
 	}
 }
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/VennLe/charlotte/pkg/metrics"
+)
+
+// 导入导出并发指标，同一进程内的实例共享
+var (
+	importExportRunning = metrics.NewGaugeVec("charlotte_import_export_running",
+		"正在执行的导入导出数").WithLabelValues()
+	importExportQueued = metrics.NewGaugeVec("charlotte_import_export_queued",
+		"排队等待执行名额的导入导出数，sync 为同步请求，async 为异步任务", "mode")
+	importExportRejected = metrics.NewCounterVec("charlotte_import_export_rejected_total",
+		"因排队已满或等待超时被拒绝的导入导出数", "mode")
+)
+
+// ErrImportExportBusy 同时进行的导入导出过多，排队已满或等待超时
+var ErrImportExportBusy = errors.New("导入导出任务繁忙，请稍后重试")
+
+// ImportExportLoad 导入导出并发情况（本实例）
+type ImportExportLoad struct {
+	MaxConcurrent int   `json:"max_concurrent"` // 0 表示不限制
+	Running       int   `json:"running"`
+	Queued        int64 `json:"queued"` // 排队等待的同步请求和异步任务数
+	MaxQueue      int   `json:"max_queue"`
+	Rejected      int64 `json:"rejected"` // 累计因繁忙被拒绝的请求数
+}
+
+// operationLimiter 全局限制同时进行的导入导出数量
+// 同步请求超出并发数时排队等待，排队数超过 maxQueue 或等待超过 queueTimeout 时返回 ErrImportExportBusy；
+// 异步任务提交时占用同一个排队上限，排队已满时拒绝提交
+type operationLimiter struct {
+	slots        chan struct{}
+	maxQueue     int
+	queueTimeout time.Duration
+
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+// newOperationLimiter 创建并发限制器，maxConcurrent <= 0 时不限制并返回 nil
+func newOperationLimiter(maxConcurrent, maxQueue int, queueTimeout time.Duration) *operationLimiter {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &operationLimiter{
+		slots:        make(chan struct{}, maxConcurrent),
+		maxQueue:     maxQueue,
+		queueTimeout: queueTimeout,
+	}
+}
+
+// acquire 获取执行名额，成功后需调用 release 归还
+func (l *operationLimiter) acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.tryAcquire() {
+		return l.release, nil
+	}
+
+	if !l.enqueue("sync") {
+		return nil, ErrImportExportBusy
+	}
+	defer l.dequeue("sync")
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		importExportRunning.Add(1)
+		return l.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		l.reject("sync")
+		return nil, ErrImportExportBusy
+	}
+}
+
+// slotWaiter 等待异步任务预留的执行名额，获得名额后返回 release
+type slotWaiter func(ctx context.Context) (release func(), err error)
+
+// reserve 提交异步任务时调用，有空闲名额时直接占用，否则占用一个排队位置，排队已满时返回 ErrImportExportBusy
+// 返回的 wait 在任务 goroutine 中调用，阻塞直到获得名额或 ctx 取消，不受 queueTimeout 限制；wait 必须且只能调用一次
+func (l *operationLimiter) reserve() (slotWaiter, error) {
+	if l == nil {
+		return func(context.Context) (func(), error) { return func() {}, nil }, nil
+	}
+
+	if l.tryAcquire() {
+		return func(ctx context.Context) (func(), error) {
+			if err := ctx.Err(); err != nil {
+				l.release()
+				return nil, err
+			}
+			return l.release, nil
+		}, nil
+	}
+
+	if !l.enqueue("async") {
+		return nil, ErrImportExportBusy
+	}
+	return func(ctx context.Context) (func(), error) {
+		defer l.dequeue("async")
+
+		select {
+		case l.slots <- struct{}{}:
+			importExportRunning.Add(1)
+			return l.release, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}, nil
+}
+
+// tryAcquire 有空闲名额时占用并返回 true
+func (l *operationLimiter) tryAcquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		importExportRunning.Add(1)
+		return true
+	default:
+		return false
+	}
+}
+
+// enqueue 占用一个排队位置，排队已满时计入拒绝数并返回 false
+func (l *operationLimiter) enqueue(mode string) bool {
+	if l.queued.Add(1) > int64(l.maxQueue) {
+		l.queued.Add(-1)
+		l.reject(mode)
+		return false
+	}
+	importExportQueued.WithLabelValues(mode).Add(1)
+	return true
+}
+
+func (l *operationLimiter) dequeue(mode string) {
+	l.queued.Add(-1)
+	importExportQueued.WithLabelValues(mode).Add(-1)
+}
+
+func (l *operationLimiter) reject(mode string) {
+	l.rejected.Add(1)
+	importExportRejected.WithLabelValues(mode).Inc()
+}
+
+func (l *operationLimiter) release() {
+	<-l.slots
+	importExportRunning.Add(-1)
+}
+
+func (l *operationLimiter) load() ImportExportLoad {
+	if l == nil {
+		return ImportExportLoad{}
+	}
+	return ImportExportLoad{
+		MaxConcurrent: cap(l.slots),
+		Running:       len(l.slots),
+		Queued:        l.queued.Load(),
+		MaxQueue:      l.maxQueue,
+		Rejected:      l.rejected.Load(),
+	}
+}
+
+// SetConcurrencyLimit 设置全局导入导出并发限制，maxConcurrent <= 0 时不限制
+// 超出并发数时同步请求和异步任务合计最多排队 maxQueue 个，排队已满时返回 ErrImportExportBusy；
+// 同步请求最长等待 queueTimeout（为 0 时不超时），已提交的异步任务一直等到获得名额或被取消
+func (s *ImportExportService) SetConcurrencyLimit(maxConcurrent, maxQueue int, queueTimeout time.Duration) {
+	s.limiter = newOperationLimiter(maxConcurrent, maxQueue, queueTimeout)
+}
+
+// Load 当前导入导出并发情况
+func (s *ImportExportService) Load() ImportExportLoad {
+	return s.limiter.load()
+}
+
+// RetryAfter 返回 ErrImportExportBusy 时建议客户端重试的等待时间
+func (s *ImportExportService) RetryAfter() time.Duration {
+	if s.limiter == nil || s.limiter.queueTimeout < time.Second {
+		return time.Second
+	}
+	return s.limiter.queueTimeout
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+type importTestRow struct {
+	Name  string
+	Count int
+}
+
+// recordingProcessor 记录写入的数据，不访问数据库
+type recordingProcessor struct {
+	saved []importTestRow
+}
+
+func (p *recordingProcessor) GetDataType() string            { return "import_test" }
+func (p *recordingProcessor) CreateEmptySlice() interface{}  { return &[]importTestRow{} }
+func (p *recordingProcessor) ValidateData(interface{}) error { return nil }
+func (p *recordingProcessor) GetExportHeaders() []string     { return []string{"Name", "Count"} }
+func (p *recordingProcessor) GetExportFieldMap() map[string]string {
+	return nil
+}
+
+func (p *recordingProcessor) ProcessData(ctx context.Context, data interface{}) error {
+	p.saved = append(p.saved, *data.(*[]importTestRow)...)
+	return nil
+}
+
+func (p *recordingProcessor) GetExportData(ctx context.Context, query ExportQuery) (interface{}, bool, error) {
+	return []importTestRow{}, false, nil
+}
+
+// newImportTestFile 将内容作为上传文件返回
+func newImportTestFile(t *testing.T, filename string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(int64(len(content)) + 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func TestImportDataMaxRows(t *testing.T) {
+	config.Global = &config.Config{}
+	config.Global.ImportExport.MaxImportRows = 10000
+
+	var csv strings.Builder
+	csv.WriteString("Name,Count\n")
+	for i := 1; i <= 10001; i++ {
+		fmt.Fprintf(&csv, "user%d,%d\n", i, i)
+	}
+
+	processor := &recordingProcessor{}
+	s := NewImportExportService(nil)
+	resp, err := s.ImportData(context.Background(), &ImportRequest{
+		File:      newImportTestFile(t, "rows.csv", []byte(csv.String())),
+		FileType:  "csv",
+		DataType:  processor.GetDataType(),
+		HasHeader: true,
+	}, processor)
+
+	if !errors.Is(err, utils.ErrTooManyRows) {
+		t.Fatalf("err = %v，期望 ErrTooManyRows", err)
+	}
+	if len(processor.saved) != 0 {
+		t.Fatalf("超过上限时写入了 %d 行，期望不写入", len(processor.saved))
+	}
+	if resp == nil || resp.Success || resp.SuccessRows != 0 {
+		t.Fatalf("resp = %+v，期望失败且成功行数为 0", resp)
+	}
+	if resp.TotalRows != 10000 {
+		t.Errorf("TotalRows = %d，期望上限前读取的 10000 行", resp.TotalRows)
+	}
+
+	var limitErr *utils.ImportExportError
+	if !errors.As(err, &limitErr) || limitErr.Line != 10002 {
+		t.Errorf("超限错误 = %v，期望指向文件第 10002 行", err)
+	}
+}
+
+func TestOperationLimiterReserveRespectsQueue(t *testing.T) {
+	l := newOperationLimiter(1, 1, time.Second)
+
+	running, err := l.reserve()
+	if err != nil {
+		t.Fatalf("有空闲名额时 reserve 失败: %v", err)
+	}
+	queued, err := l.reserve()
+	if err != nil {
+		t.Fatalf("排队未满时 reserve 失败: %v", err)
+	}
+	if _, err := l.reserve(); !errors.Is(err, ErrImportExportBusy) {
+		t.Fatalf("排队已满时 err = %v，期望 ErrImportExportBusy", err)
+	}
+	if _, err := l.acquire(context.Background()); !errors.Is(err, ErrImportExportBusy) {
+		t.Fatalf("异步任务占满排队后同步请求 err = %v，期望 ErrImportExportBusy", err)
+	}
+	if load := l.load(); load.Running != 1 || load.Queued != 1 || load.Rejected != 2 {
+		t.Fatalf("load = %+v，期望运行 1、排队 1、拒绝 2", load)
+	}
+
+	release, err := running(context.Background())
+	if err != nil {
+		t.Fatalf("已占用名额的任务等待失败: %v", err)
+	}
+
+	done := make(chan func(), 1)
+	go func() {
+		release, err := queued(context.Background())
+		if err != nil {
+			t.Errorf("排队任务等待失败: %v", err)
+		}
+		done <- release
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("名额未归还时排队任务不应开始执行")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+
+	select {
+	case release := <-done:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("名额归还后排队任务未开始执行")
+	}
+	if load := l.load(); load.Running != 0 || load.Queued != 0 {
+		t.Errorf("load = %+v，期望全部归还", load)
+	}
+}
+
+func TestOperationLimiterReserveCancelled(t *testing.T) {
+	l := newOperationLimiter(1, 1, time.Second)
+
+	running, _ := l.reserve()
+	queued, err := l.reserve()
+	if err != nil {
+		t.Fatalf("排队未满时 reserve 失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := queued(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("取消后排队任务 err = %v，期望 context.Canceled", err)
+	}
+	if _, err := running(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("取消后已占用名额的任务 err = %v，期望 context.Canceled", err)
+	}
+	if load := l.load(); load.Running != 0 || load.Queued != 0 {
+		t.Errorf("load = %+v，期望取消后归还名额和排队位置", load)
+	}
+}
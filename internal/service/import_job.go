@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// 导入任务状态
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+var (
+	// ErrImportJobNotFound 导入任务不存在
+	ErrImportJobNotFound = errors.New("导入任务不存在")
+	// ErrImportJobRunning 导入任务尚未结束
+	ErrImportJobRunning = errors.New("导入任务正在执行")
+	// ErrNoFailedRows 导入任务没有可重新导入的失败行
+	ErrNoFailedRows = errors.New("导入任务没有可重新导入的失败行")
+)
+
+// ImportJobStatus 异步导入任务状态，行数统计在导入结束后填充
+type ImportJobStatus struct {
+	ID          string                     `json:"id"`
+	UserID      uint                       `json:"user_id"` // 提交任务的用户，仅本人和管理员可查询
+	DataType    string                     `json:"data_type"`
+	FileType    string                     `json:"file_type"`
+	FileName    string                     `json:"file_name"`
+	Status      string                     `json:"status"`
+	TotalRows   int                        `json:"total_rows"`
+	SuccessRows int                        `json:"success_rows"`
+	FailedRows  int                        `json:"failed_rows"`
+	FailedLines []int                      `json:"failed_lines,omitempty"` // 尚未导入的数据行的文件行号
+	Retries     int                        `json:"retries,omitempty"`      // 重新导入失败行的次数
+	Message     string                     `json:"message,omitempty"`
+	Errors      []*utils.ImportExportError `json:"errors,omitempty"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	StartedAt   *time.Time                 `json:"started_at,omitempty"`
+	FinishedAt  *time.Time                 `json:"finished_at,omitempty"`
+
+	req      ImportRequest // 导入参数，重新导入时沿用
+	filePath string
+}
+
+// SubmitImportJob 提交异步导入任务，立即返回任务ID
+// 上传文件先另存到导入目录（请求结束后 multipart 临时文件会被删除），存在失败行时保留到任务被清理，供 ReimportFailed 使用；
+// 任务不受发起请求结束的影响，状态保存在进程内，只能在提交任务的实例上查询；并发和排队都已满时返回 ErrImportExportBusy
+func (s *ImportExportService) SubmitImportJob(ctx context.Context, req *ImportRequest, processor DataProcessor) (string, error) {
+	if processor.GetDataType() != req.DataType {
+		return "", fmt.Errorf("数据类型不匹配: %s != %s", processor.GetDataType(), req.DataType)
+	}
+	// 提前校验参数，避免提交注定失败的任务
+	if _, err := newImportConfig(req, processor); err != nil {
+		return "", err
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return "", fmt.Errorf("生成任务ID失败: %v", err)
+	}
+
+	job := &ImportJobStatus{
+		ID:        "import_" + id,
+		UserID:    req.UserID,
+		DataType:  req.DataType,
+		FileType:  req.FileType,
+		FileName:  req.File.Filename,
+		Status:    ImportJobPending,
+		CreatedAt: time.Now(),
+		req:       *req,
+	}
+	// 只保留文件名，不持有上传内容
+	job.req.File = &multipart.FileHeader{Filename: req.File.Filename, Size: req.File.Size}
+
+	job.filePath = filepath.Join(s.importJobDir(), job.ID+filepath.Ext(req.File.Filename))
+	if err := saveImportJobFile(job.filePath, req.File); err != nil {
+		return "", err
+	}
+
+	wait, err := s.limiter.reserve()
+	if err != nil {
+		os.Remove(job.filePath)
+		return "", err
+	}
+
+	s.importJobsMu.Lock()
+	s.importJobs[job.ID] = job
+	s.importJobsMu.Unlock()
+
+	go s.runImportJob(context.WithoutCancel(ctx), job, processor, wait)
+
+	return job.ID, nil
+}
+
+// GetImportJobStatus 获取异步导入任务状态，仅任务所有者和管理员可查询
+// 错误详情中会包含导入文件的内容，无权访问的任务与不存在的任务一样返回 ErrImportJobNotFound
+func (s *ImportExportService) GetImportJobStatus(jobID string, userID uint, role string) (*ImportJobStatus, error) {
+	s.importJobsMu.RLock()
+	defer s.importJobsMu.RUnlock()
+
+	job, ok := s.importJobs[jobID]
+	if !ok || !canAccessOwned(job.UserID, userID, role) {
+		return nil, ErrImportJobNotFound
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// importJobSnapshot 在锁保护下复制任务状态
+func (s *ImportExportService) importJobSnapshot(job *ImportJobStatus) *ImportJobStatus {
+	s.importJobsMu.RLock()
+	defer s.importJobsMu.RUnlock()
+	snapshot := *job
+	return &snapshot
+}
+
+// ReimportFailed 重新导入任务中尚未导入的行，按行号只处理这些行，结果合并到任务中
+// corrected 为修正后的完整文件（失败行在原位置修改，行号保持不变），为 nil 时按保存的文件重试（适用于写库失败等临时错误）；
+// 修正文件替换保存的文件，仍失败的行可继续修正后重试；仅任务所有者和管理员可重新导入
+func (s *ImportExportService) ReimportFailed(ctx context.Context, jobID string, corrected *multipart.FileHeader, userID uint, role string) (*ImportJobStatus, error) {
+	s.importJobsMu.Lock()
+	job, ok := s.importJobs[jobID]
+	if !ok || !canAccessOwned(job.UserID, userID, role) {
+		s.importJobsMu.Unlock()
+		return nil, ErrImportJobNotFound
+	}
+	if job.Status == ImportJobPending || job.Status == ImportJobRunning {
+		s.importJobsMu.Unlock()
+		return nil, ErrImportJobRunning
+	}
+	if len(job.FailedLines) == 0 {
+		s.importJobsMu.Unlock()
+		return nil, ErrNoFailedRows
+	}
+	// 标记为执行中，防止同一任务被并发重试
+	previous := job.Status
+	job.Status = ImportJobRunning
+	req, only := job.req, make(map[int]bool, len(job.FailedLines))
+	for _, line := range job.FailedLines {
+		only[line] = true
+	}
+	s.importJobsMu.Unlock()
+
+	resp, err := s.reimportJobFile(ctx, job, &req, corrected, only)
+	if err != nil && resp == nil {
+		s.updateImportJob(job, func(j *ImportJobStatus) {
+			j.Status = previous
+		})
+		return nil, err
+	}
+
+	now := time.Now()
+	s.updateImportJob(job, func(j *ImportJobStatus) {
+		j.Retries++
+		j.FinishedAt = &now
+		j.Errors = resp.Errors
+		j.Status, j.Message = ImportJobFailed, resp.Message
+		if err != nil {
+			j.Message = err.Error()
+			return
+		}
+		if resp.Success {
+			j.Status = ImportJobCompleted
+			j.SuccessRows += resp.SuccessRows
+		}
+		j.FailedLines = resp.FailedLines
+		j.FailedRows = len(resp.FailedLines)
+	})
+	s.removeImportJobFileIfDone(job)
+
+	snapshot := s.importJobSnapshot(job)
+	logger.FromContext(ctx).Info("导入任务失败行已重新导入",
+		zap.String("job_id", job.ID),
+		zap.Int("retries", snapshot.Retries),
+		zap.Int("success_rows", resp.SuccessRows),
+		zap.Int("remaining_failed_rows", snapshot.FailedRows),
+	)
+	return snapshot, nil
+}
+
+// reimportJobFile 保存修正文件（如有）并导入其中 only 指定的行
+func (s *ImportExportService) reimportJobFile(ctx context.Context, job *ImportJobStatus, req *ImportRequest, corrected *multipart.FileHeader, only map[int]bool) (*ImportResponse, error) {
+	processor, err := s.GetDataProcessor(job.DataType)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// 先写入临时文件再替换，保存失败时原文件保持不变
+	if corrected != nil {
+		uploaded := job.filePath + ".upload"
+		if err := saveImportJobFile(uploaded, corrected); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(uploaded, job.filePath); err != nil {
+			os.Remove(uploaded)
+			return nil, fmt.Errorf("保存导入文件失败: %v", err)
+		}
+	}
+
+	file, err := os.Open(job.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开导入文件失败: %v", err)
+	}
+	defer file.Close()
+
+	return s.importFrom(ctx, req, processor, file, only)
+}
+
+// saveImportJobFile 将上传文件另存到 filePath
+func saveImportJobFile(filePath string, upload *multipart.FileHeader) error {
+	src, err := upload.Open()
+	if err != nil {
+		return &utils.ImportExportError{Message: "打开文件失败: " + err.Error()}
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("创建导入目录失败: %v", err)
+	}
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("保存导入文件失败: %v", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(filePath)
+		return fmt.Errorf("保存导入文件失败: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(filePath)
+		return fmt.Errorf("保存导入文件失败: %v", err)
+	}
+	return nil
+}
+
+// runImportJob 执行异步导入任务
+func (s *ImportExportService) runImportJob(ctx context.Context, job *ImportJobStatus, processor DataProcessor, wait slotWaiter) {
+	defer s.removeImportJobFileIfDone(job)
+
+	// 并发已满时保持 pending 状态等待名额
+	release, err := wait(ctx)
+	if err != nil {
+		s.finishImportJob(job, ImportJobFailed, err.Error(), nil)
+		return
+	}
+	defer release()
+
+	now := time.Now()
+	s.updateImportJob(job, func(j *ImportJobStatus) {
+		j.Status = ImportJobRunning
+		j.StartedAt = &now
+	})
+
+	resp, err := s.importJobFile(ctx, job, processor)
+	switch {
+	case err != nil:
+		s.finishImportJob(job, ImportJobFailed, err.Error(), resp)
+	case !resp.Success:
+		s.finishImportJob(job, ImportJobFailed, resp.Message, resp)
+	default:
+		s.finishImportJob(job, ImportJobCompleted, resp.Message, resp)
+	}
+
+	snapshot := s.importJobSnapshot(job)
+	logger.FromContext(ctx).Info("异步导入任务结束",
+		zap.String("job_id", job.ID),
+		zap.String("status", snapshot.Status),
+		zap.Int("total_rows", snapshot.TotalRows),
+		zap.Int("success_rows", snapshot.SuccessRows),
+		zap.Int("failed_rows", snapshot.FailedRows),
+	)
+}
+
+// importJobFile 从任务文件执行导入
+func (s *ImportExportService) importJobFile(ctx context.Context, job *ImportJobStatus, processor DataProcessor) (*ImportResponse, error) {
+	file, err := os.Open(job.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开导入文件失败: %v", err)
+	}
+	defer file.Close()
+
+	return s.importFrom(ctx, &job.req, processor, file, nil)
+}
+
+// removeImportJobFileIfDone 没有待重新导入的行时删除任务文件
+func (s *ImportExportService) removeImportJobFileIfDone(job *ImportJobStatus) {
+	s.importJobsMu.RLock()
+	done := len(job.FailedLines) == 0 && job.Status != ImportJobRunning
+	s.importJobsMu.RUnlock()
+	if !done {
+		return
+	}
+	if err := os.Remove(job.filePath); err != nil && !os.IsNotExist(err) {
+		logger.Warn("删除导入任务文件失败", zap.String("job_id", job.ID), zap.Error(err))
+	}
+}
+
+// updateImportJob 在锁保护下更新任务
+func (s *ImportExportService) updateImportJob(job *ImportJobStatus, fn func(j *ImportJobStatus)) {
+	s.importJobsMu.Lock()
+	defer s.importJobsMu.Unlock()
+	fn(job)
+}
+
+// finishImportJob 标记任务结束，resp 不为 nil 时记录行数统计、未导入的行和错误详情
+func (s *ImportExportService) finishImportJob(job *ImportJobStatus, status, message string, resp *ImportResponse) {
+	now := time.Now()
+	s.updateImportJob(job, func(j *ImportJobStatus) {
+		j.Status = status
+		j.Message = message
+		j.FinishedAt = &now
+		if resp != nil {
+			j.TotalRows = resp.TotalRows
+			j.SuccessRows = resp.SuccessRows
+			j.FailedRows = resp.FailedRows
+			j.FailedLines = resp.FailedLines
+			j.Errors = resp.Errors
+		}
+	})
+}
+
+// importJobDirName 异步导入文件的目录名，以 . 开头
+const importJobDirName = ".imports"
+
+// importJobDir 异步导入文件目录，位于上传目录下的隐藏目录中，不会出现在文件列表和下载接口中
+func (s *ImportExportService) importJobDir() string {
+	basePath := "resources"
+	if s.fileService != nil {
+		basePath = s.fileService.basePath
+	}
+	return filepath.Join(basePath, importJobDirName)
+}
+
+// CleanupImportJobs 清理结束超过 retention 的导入任务及其保留的文件，返回清理的任务数
+// 任务保存在进程内，每个实例需各自清理
+func (s *ImportExportService) CleanupImportJobs(retention time.Duration) int {
+	cutoff := time.Now().Add(-retention)
+
+	s.importJobsMu.Lock()
+	var expired []*ImportJobStatus
+	for id, job := range s.importJobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) && job.Status != ImportJobRunning {
+			expired = append(expired, job)
+			delete(s.importJobs, id)
+		}
+	}
+	s.importJobsMu.Unlock()
+
+	for _, job := range expired {
+		if err := os.Remove(job.filePath); err != nil && !os.IsNotExist(err) {
+			logger.Warn("删除过期导入文件失败", zap.String("job_id", job.ID), zap.Error(err))
+		}
+	}
+	return len(expired)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/utils"
+)
+
+// importTemplateKeyPrefix 导入模板缓存键前缀
+const importTemplateKeyPrefix = "import_template:"
+
+// importTemplateRedisTTL Redis 中模板的过期时间
+// 键中包含表头版本哈希，处理器定义变化后旧键不再命中，过期时间仅用于清理旧版本
+const importTemplateRedisTTL = 24 * time.Hour
+
+// TemplateStore 导入模板存储，可替换为 Redis、对象存储等实现
+type TemplateStore interface {
+	// Get 获取模板内容，不存在时 found 为 false
+	Get(ctx context.Context, key string) (data []byte, found bool, err error)
+	// Set 保存模板内容
+	Set(ctx context.Context, key string, data []byte) error
+}
+
+// MemoryTemplateStore 进程内模板存储
+type MemoryTemplateStore struct {
+	mu        sync.RWMutex
+	templates map[string][]byte
+}
+
+// NewMemoryTemplateStore 创建进程内模板存储
+func NewMemoryTemplateStore() *MemoryTemplateStore {
+	return &MemoryTemplateStore{templates: make(map[string][]byte)}
+}
+
+// Get 获取模板内容
+func (m *MemoryTemplateStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.templates[key]
+	return data, ok, nil
+}
+
+// Set 保存模板内容
+func (m *MemoryTemplateStore) Set(_ context.Context, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.templates[key] = data
+	return nil
+}
+
+// RedisTemplateStore 基于 Redis 的模板存储，多实例部署时共享
+type RedisTemplateStore struct {
+	client *redis.Client
+}
+
+// NewRedisTemplateStore 创建 Redis 模板存储
+func NewRedisTemplateStore(client *redis.Client) *RedisTemplateStore {
+	return &RedisTemplateStore{client: client}
+}
+
+// Get 获取模板内容
+func (r *RedisTemplateStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set 保存模板内容
+func (r *RedisTemplateStore) Set(ctx context.Context, key string, data []byte) error {
+	return r.client.Set(ctx, key, data, importTemplateRedisTTL).Err()
+}
+
+// ImportTemplate 导入模板文件
+type ImportTemplate struct {
+	FileName string
+	Data     []byte
+	Cached   bool // 是否命中缓存
+}
+
+// SetTemplateStore 设置导入模板存储，传入 nil 时每次重新生成
+func (s *ImportExportService) SetTemplateStore(store TemplateStore) {
+	s.templateStore = store
+}
+
+// GetImportTemplate 获取导入模板
+// 模板按 数据类型+文件类型+表头版本哈希 缓存，处理器表头或字段映射变化后自动重新生成
+func (s *ImportExportService) GetImportTemplate(ctx context.Context, processor DataProcessor, fileType string) (*ImportTemplate, error) {
+	dataType := processor.GetDataType()
+	exportConfig := &utils.ExportConfig{
+		FileType: fileType,
+		FileName: fmt.Sprintf("%s_template.%s", dataType, fileType),
+		Headers:  processor.GetExportHeaders(),
+		FieldMap: processor.GetExportFieldMap(),
+	}
+	key := importTemplateKey(dataType, fileType, exportConfig.Headers, exportConfig.FieldMap)
+
+	if s.templateStore != nil {
+		data, found, err := s.templateStore.Get(ctx, key)
+		if err != nil {
+			logger.FromContext(ctx).Warn("读取导入模板缓存失败", zap.String("key", key), zap.Error(err))
+		} else if found {
+			return &ImportTemplate{FileName: exportConfig.FileName, Data: data, Cached: true}, nil
+		}
+	}
+
+	data, err := utils.ExportDataWithContext(ctx, processor.CreateEmptySlice(), exportConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.templateStore != nil {
+		if err := s.templateStore.Set(ctx, key, data); err != nil {
+			logger.FromContext(ctx).Warn("保存导入模板缓存失败", zap.String("key", key), zap.Error(err))
+		}
+	}
+
+	return &ImportTemplate{FileName: exportConfig.FileName, Data: data}, nil
+}
+
+// importTemplateKey 生成模板缓存键，版本哈希由表头顺序和字段映射决定
+func importTemplateKey(dataType, fileType string, headers []string, fieldMap map[string]string) string {
+	h := sha256.New()
+	for _, header := range headers {
+		h.Write([]byte(header))
+		h.Write([]byte{0})
+	}
+
+	fields := make([]string, 0, len(fieldMap))
+	for field := range fieldMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{1})
+		h.Write([]byte(fieldMap[field]))
+		h.Write([]byte{0})
+	}
+
+	version := hex.EncodeToString(h.Sum(nil))[:16]
+	return fmt.Sprintf("%s%s:%s:%s", importTemplateKeyPrefix, dataType, fileType, version)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/internal/model"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// ErrPermissionAuditDisabled 未启用权限决策日志
+var ErrPermissionAuditDisabled = errors.New("未启用权限决策日志")
+
+// permissionAuditWriteTimeout 异步写入权限决策日志的超时
+const permissionAuditWriteTimeout = 5 * time.Second
+
+// PermissionAuditService 权限决策日志服务，记录权限拒绝和敏感操作的授权，供访问审查查询
+type PermissionAuditService struct {
+	audits    *dao.PermissionAuditDAO
+	sensitive map[string]bool
+}
+
+// NewPermissionAuditService 创建权限决策日志服务，未启用时返回 nil，Record 不做处理
+func NewPermissionAuditService(db *gorm.DB, cfg config.PermissionAuditConfig) *PermissionAuditService {
+	if !cfg.Enabled {
+		return nil
+	}
+	sensitive := make(map[string]bool, len(cfg.SensitiveOperations))
+	for _, operation := range cfg.SensitiveOperations {
+		sensitive[strings.ToLower(operation)] = true
+	}
+	return &PermissionAuditService{
+		audits:    dao.NewPermissionAuditDAO(db),
+		sensitive: sensitive,
+	}
+}
+
+// ShouldRecord 决策是否需要记录：拒绝全部记录，授权只记录敏感操作
+func (s *PermissionAuditService) ShouldRecord(decision, operation string) bool {
+	if s == nil {
+		return false
+	}
+	return decision == model.PermissionDecisionDeny || s.sensitive[strings.ToLower(operation)]
+}
+
+// Record 异步写入权限决策日志，不阻塞请求；写入失败只记录日志
+func (s *PermissionAuditService) Record(ctx context.Context, entry *model.PermissionAuditLog) {
+	if !s.ShouldRecord(entry.Decision, entry.Operation) {
+		return
+	}
+	go func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, permissionAuditWriteTimeout)
+		defer cancel()
+		if err := s.audits.Create(ctx, entry); err != nil {
+			logger.FromContext(ctx).Error("写入权限决策日志失败",
+				zap.Uint("actor_id", entry.ActorID),
+				zap.String("resource", entry.Resource),
+				zap.String("decision", entry.Decision),
+				zap.Error(err),
+			)
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// ListPermissionAuditsRequest 查询权限决策日志请求
+type ListPermissionAuditsRequest struct {
+	ActorID    uint      `form:"actor_id"`
+	Resource   string    `form:"resource"`
+	ResourceID string    `form:"resource_id"`
+	Operation  string    `form:"operation"`
+	Decision   string    `form:"decision" binding:"omitempty,oneof=deny grant"`
+	Start      time.Time `form:"start" time_format:"2006-01-02T15:04:05Z07:00"` // 起始时间（含），RFC3339
+	End        time.Time `form:"end" time_format:"2006-01-02T15:04:05Z07:00"`   // 截止时间（不含），RFC3339
+	Page       int       `form:"page"`
+	Size       int       `form:"size"`
+}
+
+// List 按条件分页查询权限决策日志，按时间倒序
+func (s *PermissionAuditService) List(ctx context.Context, req *ListPermissionAuditsRequest) ([]*model.PermissionAuditLog, int64, error) {
+	if s == nil {
+		return nil, 0, ErrPermissionAuditDisabled
+	}
+	filters := make(map[string]interface{})
+	if req.ActorID != 0 {
+		filters["actor_id = ?"] = req.ActorID
+	}
+	if req.Resource != "" {
+		filters["resource = ?"] = req.Resource
+	}
+	if req.ResourceID != "" {
+		filters["resource_id = ?"] = req.ResourceID
+	}
+	if req.Operation != "" {
+		filters["operation = ?"] = strings.ToLower(req.Operation)
+	}
+	if req.Decision != "" {
+		filters["decision = ?"] = req.Decision
+	}
+	if !req.Start.IsZero() {
+		filters["created_at >= ?"] = req.Start
+	}
+	if !req.End.IsZero() {
+		filters["created_at < ?"] = req.End
+	}
+
+	return s.audits.List(ctx, &dao.QueryOptions{
+		Page:     req.Page,
+		Size:     req.Size,
+		Filters:  filters,
+		OrderBy:  "id",
+		OrderDir: "desc",
+	})
+}
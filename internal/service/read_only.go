@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// readOnlyKey 运行时只读模式状态的 Redis 键
+const readOnlyKey = "readonly:mode"
+
+// readOnlyRefresh 从 Redis 刷新运行时状态的间隔，避免每个请求都访问 Redis
+const readOnlyRefresh = 2 * time.Second
+
+// ErrReadOnlyLocked 配置文件启用了只读模式，运行时无法关闭
+var ErrReadOnlyLocked = errors.New("只读模式由配置文件启用，需修改配置后重启才能关闭")
+
+// ReadOnlyStatus 只读模式状态
+type ReadOnlyStatus struct {
+	Enabled   bool       `json:"enabled"`
+	Message   string     `json:"message,omitempty"`
+	Source    string     `json:"source,omitempty"` // config：配置文件启用；runtime：运行时开启
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// ReadOnlyMode 只读模式开关
+// 配置启用时始终只读；运行时状态保存在 Redis 中多实例共享，Redis 不可用时仅作用于本实例
+type ReadOnlyMode struct {
+	static  bool
+	message string
+	client  *redis.Client
+
+	mu       sync.RWMutex
+	runtime  ReadOnlyStatus
+	loadedAt time.Time
+}
+
+// NewReadOnlyMode 创建只读模式开关，client 为 nil 时运行时状态只保存在本实例内存中
+func NewReadOnlyMode(client *redis.Client, cfg config.ReadOnlyConfig) *ReadOnlyMode {
+	return &ReadOnlyMode{
+		static:  cfg.Enabled,
+		message: cfg.Message,
+		client:  client,
+	}
+}
+
+// Check 判断当前是否只读，返回拒绝写请求时的提示，供只读中间件使用
+// Redis 读取失败时沿用上次的状态
+func (m *ReadOnlyMode) Check(ctx context.Context) (bool, string) {
+	status := m.status(ctx)
+	return status.Enabled, status.Message
+}
+
+// Status 获取当前只读模式状态
+func (m *ReadOnlyMode) Status(ctx context.Context) *ReadOnlyStatus {
+	status := m.status(ctx)
+	return &status
+}
+
+// Set 运行时开启或关闭只读模式，message 为空时使用配置的提示
+func (m *ReadOnlyMode) Set(ctx context.Context, enabled bool, message, operator string) (*ReadOnlyStatus, error) {
+	if m.static && !enabled {
+		return nil, ErrReadOnlyLocked
+	}
+
+	now := time.Now()
+	runtime := ReadOnlyStatus{UpdatedBy: operator, UpdatedAt: &now}
+	if enabled {
+		runtime.Enabled = true
+		runtime.Message = message
+		runtime.Source = "runtime"
+	}
+
+	if m.client != nil {
+		data, err := json.Marshal(runtime)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.client.Set(ctx, readOnlyKey, data, 0).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	m.runtime = runtime
+	m.loadedAt = now
+	m.mu.Unlock()
+
+	logger.FromContext(ctx).Warn("只读模式已切换",
+		zap.Bool("enabled", enabled),
+		zap.String("message", message),
+		zap.String("operator", operator),
+	)
+	return m.Status(ctx), nil
+}
+
+// status 合并配置与运行时状态
+func (m *ReadOnlyMode) status(ctx context.Context) ReadOnlyStatus {
+	status := m.runtimeStatus(ctx)
+	if m.static && !status.Enabled {
+		status = ReadOnlyStatus{Enabled: true, Source: "config"}
+	}
+	if status.Enabled && status.Message == "" {
+		status.Message = m.message
+	}
+	return status
+}
+
+// runtimeStatus 获取运行时状态，超过刷新间隔时从 Redis 重新读取
+func (m *ReadOnlyMode) runtimeStatus(ctx context.Context) ReadOnlyStatus {
+	m.mu.RLock()
+	runtime, fresh := m.runtime, time.Since(m.loadedAt) < readOnlyRefresh
+	m.mu.RUnlock()
+	if m.client == nil || fresh {
+		return runtime
+	}
+
+	data, err := m.client.Get(ctx, readOnlyKey).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+		runtime = ReadOnlyStatus{}
+	case err != nil:
+		logger.FromContext(ctx).Warn("读取只读模式状态失败，沿用上次状态", zap.Error(err))
+	default:
+		var loaded ReadOnlyStatus
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			logger.FromContext(ctx).Warn("解析只读模式状态失败，沿用上次状态", zap.Error(err))
+		} else {
+			runtime = loaded
+		}
+	}
+
+	// 失败时同样更新读取时间，Redis 故障期间不会每个请求都重试
+	m.mu.Lock()
+	m.runtime = runtime
+	m.loadedAt = time.Now()
+	m.mu.Unlock()
+	return runtime
+}
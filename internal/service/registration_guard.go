@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// 注册防护错误
+var (
+	ErrRegistrationRateLimited = errors.New("注册过于频繁，请稍后再试")
+	ErrEmailDomainNotAllowed   = errors.New("不支持使用该邮箱域名注册")
+	ErrCaptchaRequired         = errors.New("缺少人机验证令牌")
+	ErrCaptchaInvalid          = errors.New("人机验证失败")
+)
+
+// registrationIPKeyPrefix 单IP注册计数键前缀
+const registrationIPKeyPrefix = "register:ip:"
+
+// CaptchaVerifier 人机验证器，可替换为自定义实现
+// 校验不通过时返回错误（通常为 ErrCaptchaInvalid）
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// HTTPCaptchaVerifier 基于 siteverify 接口的人机验证器（兼容 reCAPTCHA、hCaptcha、Turnstile）
+type HTTPCaptchaVerifier struct {
+	verifyURL string
+	secret    string
+	client    *http.Client
+}
+
+// NewHTTPCaptchaVerifier 创建 siteverify 人机验证器
+func NewHTTPCaptchaVerifier(verifyURL, secret string, timeout time.Duration) *HTTPCaptchaVerifier {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &HTTPCaptchaVerifier{
+		verifyURL: verifyURL,
+		secret:    secret,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Verify 调用 siteverify 接口校验令牌
+func (v *HTTPCaptchaVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建人机验证请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("人机验证请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("人机验证服务返回状态码 %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析人机验证响应失败: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrCaptchaInvalid, strings.Join(result.ErrorCodes, ","))
+	}
+	return nil
+}
+
+// RegistrationGuard 注册防护，在全局限流之外限制单IP注册频率、邮箱域名并校验人机验证令牌
+type RegistrationGuard struct {
+	redis           *redis.Client
+	maxPerIPPerHour int64
+	allowedDomains  map[string]struct{}
+	deniedDomains   map[string]struct{}
+	verifier        CaptchaVerifier
+}
+
+// reserveRegistrationScript 占用一个注册名额，超过上限时归还并返回 0，否则返回占用后的计数
+// 计数和过期时间在同一脚本中设置，并发注册不会同时通过检查
+var reserveRegistrationScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("EXPIRE", KEYS[1], ARGV[2])
+end
+if count > tonumber(ARGV[1]) then
+	redis.call("DECR", KEYS[1])
+	return 0
+end
+return count
+`)
+
+// NewRegistrationGuard 创建注册防护
+// 启用人机验证时使用 HTTPCaptchaVerifier（verify_url 由配置校验保证不为空），可通过 SetCaptchaVerifier 替换
+// redisClient 为 nil 时不限制单IP注册频率
+func NewRegistrationGuard(redisClient *redis.Client, cfg config.RegistrationGuardConfig) *RegistrationGuard {
+	g := &RegistrationGuard{
+		redis:           redisClient,
+		maxPerIPPerHour: int64(cfg.MaxPerIPPerHour),
+		allowedDomains:  newDomainSet(cfg.AllowedEmailDomains),
+		deniedDomains:   newDomainSet(cfg.DeniedEmailDomains),
+	}
+	if cfg.Captcha.Enabled {
+		g.verifier = NewHTTPCaptchaVerifier(cfg.Captcha.VerifyURL, cfg.Captcha.Secret,
+			time.Duration(cfg.Captcha.Timeout)*time.Second)
+	}
+	return g
+}
+
+// SetCaptchaVerifier 设置人机验证器，传入 nil 表示不校验
+func (g *RegistrationGuard) SetCaptchaVerifier(verifier CaptchaVerifier) {
+	g.verifier = verifier
+}
+
+// Check 注册前检查，依次校验邮箱域名、占用单IP注册名额和校验人机验证令牌
+// 通过时返回的 release 用于注册失败后归还名额，注册成功时不调用；检查未通过时已占用的名额会自动归还
+func (g *RegistrationGuard) Check(ctx context.Context, email, captchaToken, remoteIP string) (func(), error) {
+	if err := g.checkEmailDomain(email); err != nil {
+		return nil, err
+	}
+
+	release, err := g.reserveIP(ctx, remoteIP)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.verifier != nil {
+		if captchaToken == "" {
+			release()
+			return nil, ErrCaptchaRequired
+		}
+		if err := g.verifier.Verify(ctx, captchaToken, remoteIP); err != nil {
+			release()
+			if !errors.Is(err, ErrCaptchaInvalid) {
+				logger.FromContext(ctx).Warn("人机验证调用失败", zap.Error(err))
+				return nil, fmt.Errorf("%w: %v", ErrCaptchaInvalid, err)
+			}
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// reserveIP 原子地占用该IP当前小时的一个注册名额，返回归还名额的函数
+// 达到上限时返回 ErrRegistrationRateLimited；Redis 不可用时放行，返回的函数不做任何处理
+func (g *RegistrationGuard) reserveIP(ctx context.Context, remoteIP string) (func(), error) {
+	noop := func() {}
+	if g.redis == nil || g.maxPerIPPerHour <= 0 || remoteIP == "" {
+		return noop, nil
+	}
+
+	key := registrationIPKey(remoteIP)
+	count, err := reserveRegistrationScript.Run(ctx, g.redis, []string{key},
+		g.maxPerIPPerHour, int64(time.Hour/time.Second)).Int64()
+	if err != nil {
+		logger.FromContext(ctx).Warn("占用注册名额失败，已放行", zap.String("ip", remoteIP), zap.Error(err))
+		return noop, nil
+	}
+	if count == 0 {
+		return nil, ErrRegistrationRateLimited
+	}
+
+	return func() {
+		// 请求可能已被取消，归还名额不受影响
+		if err := g.redis.Decr(context.WithoutCancel(ctx), key).Err(); err != nil {
+			logger.FromContext(ctx).Warn("归还注册名额失败", zap.String("ip", remoteIP), zap.Error(err))
+		}
+	}, nil
+}
+
+// checkEmailDomain 校验邮箱域名黑白名单，子域名按父域名匹配
+func (g *RegistrationGuard) checkEmailDomain(email string) error {
+	if len(g.allowedDomains) == 0 && len(g.deniedDomains) == 0 {
+		return nil
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ErrEmailDomainNotAllowed
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+
+	if domainInSet(domain, g.deniedDomains) {
+		return ErrEmailDomainNotAllowed
+	}
+	if len(g.allowedDomains) > 0 && !domainInSet(domain, g.allowedDomains) {
+		return ErrEmailDomainNotAllowed
+	}
+	return nil
+}
+
+// registrationIPKey 单IP注册计数键，按小时分桶
+func registrationIPKey(remoteIP string) string {
+	return fmt.Sprintf("%s%s:%s", registrationIPKeyPrefix, remoteIP, time.Now().UTC().Format("2006010215"))
+}
+
+// newDomainSet 构建小写域名集合
+func newDomainSet(domains []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(d, "@")))
+		if d != "" {
+			set[d] = struct{}{}
+		}
+	}
+	return set
+}
+
+// domainInSet 判断域名或其任一父域名是否在集合中
+func domainInSet(domain string, set map[string]struct{}) bool {
+	for domain != "" {
+		if _, ok := set[domain]; ok {
+			return true
+		}
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+	return false
+}
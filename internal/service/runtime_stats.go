@@ -0,0 +1,104 @@
+package service
+
+import (
+	"runtime"
+	"time"
+)
+
+// recentGCPauses 返回最近多少次 GC 的暂停时间
+const recentGCPauses = 10
+
+// RuntimeStatus 进程运行时状态，用于故障排查时快速查看，无需开启 pprof
+type RuntimeStatus struct {
+	StartedAt     time.Time    `json:"started_at"`
+	Uptime        string       `json:"uptime"`
+	UptimeSeconds int64        `json:"uptime_seconds"`
+	GoVersion     string       `json:"go_version"`
+	NumCPU        int          `json:"num_cpu"`
+	GOMAXPROCS    int          `json:"gomaxprocs"`
+	NumGoroutine  int          `json:"num_goroutine"`
+	Memory        MemoryStatus `json:"memory"`
+	GC            GCStatus     `json:"gc"`
+}
+
+// MemoryStatus 内存使用情况，单位字节
+type MemoryStatus struct {
+	Sys          uint64 `json:"sys"`           // 从操作系统获取的内存总量
+	HeapAlloc    uint64 `json:"heap_alloc"`    // 堆上存活及尚未回收的对象
+	HeapInuse    uint64 `json:"heap_inuse"`    // 正在使用的堆内存
+	HeapIdle     uint64 `json:"heap_idle"`     // 空闲的堆内存
+	HeapReleased uint64 `json:"heap_released"` // 已归还操作系统的堆内存
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	TotalAlloc   uint64 `json:"total_alloc"` // 累计分配，只增不减
+	Mallocs      uint64 `json:"mallocs"`
+	Frees        uint64 `json:"frees"`
+}
+
+// GCStatus 垃圾回收情况
+type GCStatus struct {
+	NumGC         uint32     `json:"num_gc"`
+	NumForcedGC   uint32     `json:"num_forced_gc"`
+	LastGC        *time.Time `json:"last_gc,omitempty"`
+	NextGC        uint64     `json:"next_gc"`         // 下次 GC 的堆大小目标，单位字节
+	PauseTotalMs  float64    `json:"pause_total_ms"`  // 累计暂停时间
+	RecentPauseMs []float64  `json:"recent_pause_ms"` // 最近几次暂停时间，最新的在前
+	CPUFraction   float64    `json:"cpu_fraction"`    // 启动以来 GC 占用的 CPU 比例
+}
+
+// RuntimeStats 采集当前进程的 goroutine、内存和 GC 状态
+// runtime.ReadMemStats 会短暂暂停所有 goroutine，不宜高频调用
+func (h *HealthChecker) RuntimeStats() *RuntimeStatus {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	uptime := time.Since(h.startedAt)
+	status := &RuntimeStatus{
+		StartedAt:     h.startedAt,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		GoVersion:     runtime.Version(),
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumGoroutine:  runtime.NumGoroutine(),
+		Memory: MemoryStatus{
+			Sys:          m.Sys,
+			HeapAlloc:    m.HeapAlloc,
+			HeapInuse:    m.HeapInuse,
+			HeapIdle:     m.HeapIdle,
+			HeapReleased: m.HeapReleased,
+			HeapObjects:  m.HeapObjects,
+			StackInuse:   m.StackInuse,
+			TotalAlloc:   m.TotalAlloc,
+			Mallocs:      m.Mallocs,
+			Frees:        m.Frees,
+		},
+		GC: GCStatus{
+			NumGC:         m.NumGC,
+			NumForcedGC:   m.NumForcedGC,
+			NextGC:        m.NextGC,
+			PauseTotalMs:  durationMs(m.PauseTotalNs),
+			RecentPauseMs: []float64{},
+			CPUFraction:   m.GCCPUFraction,
+		},
+	}
+
+	if m.LastGC > 0 {
+		lastGC := time.Unix(0, int64(m.LastGC))
+		status.GC.LastGC = &lastGC
+	}
+
+	// PauseNs 为环形缓冲区，最近一次 GC 位于 (NumGC+255)%256
+	n := min(int(m.NumGC), recentGCPauses)
+	for i := 0; i < n; i++ {
+		idx := (int(m.NumGC) - 1 - i + len(m.PauseNs)) % len(m.PauseNs)
+		status.GC.RecentPauseMs = append(status.GC.RecentPauseMs, durationMs(m.PauseNs[idx]))
+	}
+
+	return status
+}
+
+// durationMs 纳秒转换为毫秒
+func durationMs(ns uint64) float64 {
+	return float64(ns) / float64(time.Millisecond)
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/model"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/tracing"
+)
+
+var (
+	// ErrSessionNotFound 会话不存在、已过期或已登出
+	ErrSessionNotFound = errors.New("会话不存在或已过期")
+	// ErrSessionsDisabled 未启用服务端会话认证
+	ErrSessionsDisabled = errors.New("未启用会话认证")
+)
+
+// Session 服务端会话
+type Session struct {
+	ID         string    `json:"-"` // 仅下发给客户端，Redis 中只保存其摘要
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	ClientIP   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SessionInfo 会话列表项，ID 为会话ID的摘要，可用于终止会话但不能用于登录
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	ClientIP   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // 是否为发起请求的会话
+}
+
+// SessionStore 基于 Redis 的会话存储，多实例部署时共享
+// 会话以会话ID的 SHA-256 摘要为键保存，Redis 数据泄露时无法直接用于冒充登录；
+// 同时按用户维护会话ID摘要集合，用于吊销用户的全部会话
+type SessionStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// defaultSessionTTL 未配置会话有效期时的默认值
+const defaultSessionTTL = 24 * time.Hour
+
+// sessionTouchInterval 刷新会话最近使用时间的最小间隔，避免每个请求都写 Redis
+const sessionTouchInterval = time.Minute
+
+// NewSessionStore 创建会话存储，ttl 为会话有效期，不大于 0 时为 24 小时
+func NewSessionStore(client *redis.Client, ttl time.Duration) *SessionStore {
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	return &SessionStore{client: client, ttl: ttl}
+}
+
+// TTL 会话有效期
+func (s *SessionStore) TTL() time.Duration {
+	return s.ttl
+}
+
+// Create 为用户创建会话
+func (s *SessionStore) Create(ctx context.Context, user *model.User, clientIP, userAgent string) (*Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:         id,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Role:       user.Role,
+		ClientIP:   clientIP,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sessionDigest(id)
+	userKey := userSessionsKey(user.ID)
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, sessionKey(digest), data, s.ttl)
+	pipe.SAdd(ctx, userKey, digest)
+	pipe.Expire(ctx, userKey, s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("保存会话失败: %w", err)
+	}
+	return session, nil
+}
+
+// Get 获取会话，不存在或已过期时返回 ErrSessionNotFound
+func (s *SessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	if id == "" {
+		return nil, ErrSessionNotFound
+	}
+	data, err := s.client.Get(ctx, sessionKey(sessionDigest(id))).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("解析会话失败: %w", err)
+	}
+	session.ID = id
+	return &session, nil
+}
+
+// Delete 删除会话，会话不存在时不报错
+func (s *SessionStore) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return nil
+	}
+	digest := sessionDigest(id)
+	key := sessionKey(digest)
+
+	data, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, key)
+	var session Session
+	if json.Unmarshal(data, &session) == nil {
+		pipe.SRem(ctx, userSessionsKey(session.UserID), digest)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Touch 刷新会话的最近使用时间，距上次刷新不足 sessionTouchInterval 时不写 Redis
+// 保留会话原有的过期时间，会话已被删除时不会重新写入
+func (s *SessionStore) Touch(ctx context.Context, session *Session) error {
+	now := time.Now()
+	if now.Sub(session.LastUsedAt) < sessionTouchInterval {
+		return nil
+	}
+	session.LastUsedAt = now
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.client.SetXX(ctx, sessionKey(sessionDigest(session.ID)), data, redis.KeepTTL).Err()
+}
+
+// List 列出用户的有效会话，按最近使用时间倒序；顺带清理集合中已过期的会话摘要
+func (s *SessionStore) List(ctx context.Context, userID uint) ([]*SessionInfo, error) {
+	userKey := userSessionsKey(userID)
+	digests, err := s.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(digests) == 0 {
+		return []*SessionInfo{}, nil
+	}
+
+	keys := make([]string, len(digests))
+	for i, digest := range digests {
+		keys[i] = sessionKey(digest)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*SessionInfo, 0, len(digests))
+	var expired []interface{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, digests[i])
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			logger.FromContext(ctx).Warn("解析会话失败", zap.String("session", digests[i]), zap.Error(err))
+			continue
+		}
+		sessions = append(sessions, &SessionInfo{
+			ID:         digests[i],
+			ClientIP:   session.ClientIP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+	if len(expired) > 0 {
+		if err := s.client.SRem(ctx, userKey, expired...).Err(); err != nil {
+			logger.FromContext(ctx).Warn("清理过期会话失败", zap.Uint("user_id", userID), zap.Error(err))
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// DeleteByDigest 按会话摘要删除用户的会话，会话不属于该用户或已过期时返回 ErrSessionNotFound
+func (s *SessionStore) DeleteByDigest(ctx context.Context, userID uint, digest string) error {
+	userKey := userSessionsKey(userID)
+	member, err := s.client.SIsMember(ctx, userKey, digest).Result()
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrSessionNotFound
+	}
+
+	pipe := s.client.TxPipeline()
+	deleted := pipe.Del(ctx, sessionKey(digest))
+	pipe.SRem(ctx, userKey, digest)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	if deleted.Val() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// DeleteUserSessions 删除用户的全部会话，keepDigest 不为空时保留该会话，返回删除的会话数
+func (s *SessionStore) DeleteUserSessions(ctx context.Context, userID uint, keepDigest string) (int, error) {
+	userKey := userSessionsKey(userID)
+	digests, err := s.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make([]string, 0, len(digests))
+	members := make([]interface{}, 0, len(digests))
+	for _, digest := range digests {
+		if digest == keepDigest {
+			continue
+		}
+		keys = append(keys, sessionKey(digest))
+		members = append(members, digest)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	// 集合中可能残留已过期的会话，以实际删除的会话键数为准
+	pipe := s.client.TxPipeline()
+	deleted := pipe.Del(ctx, keys...)
+	pipe.SRem(ctx, userKey, members...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return int(deleted.Val()), nil
+}
+
+// newSessionID 生成 256 位随机会话ID
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成会话ID失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func sessionDigest(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+func sessionKey(digest string) string {
+	return "session:" + digest
+}
+
+func userSessionsKey(userID uint) string {
+	return "session:user:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// SetSessionStore 设置会话存储，为 nil 时登录只签发 JWT
+func (s *UserService) SetSessionStore(store *SessionStore) {
+	s.sessions = store
+}
+
+// SessionsEnabled 是否使用服务端会话认证
+func (s *UserService) SessionsEnabled() bool {
+	return s.sessions != nil
+}
+
+// LoginSession 用户登录并创建服务端会话，返回的会话ID需由调用方下发给客户端
+func (s *UserService) LoginSession(ctx context.Context, req *LoginRequest, clientIP, userAgent string) (*LoginResponse, *Session, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginSession")
+	defer span.End()
+
+	if s.sessions == nil {
+		return nil, nil, ErrSessionsDisabled
+	}
+
+	user, err := s.authenticate(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := s.sessions.Create(ctx, user, clientIP, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 脱敏处理
+	user.Password = ""
+
+	return &LoginResponse{
+		ExpiresAt: session.ExpiresAt.Unix(),
+		User:      user,
+	}, session, nil
+}
+
+// Session 获取会话并刷新最近使用时间，供会话认证中间件使用
+func (s *UserService) Session(ctx context.Context, sessionID string) (*Session, error) {
+	if s.sessions == nil {
+		return nil, ErrSessionNotFound
+	}
+	session, err := s.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Touch(ctx, session); err != nil {
+		logger.FromContext(ctx).Warn("刷新会话使用时间失败", zap.Uint("user_id", session.UserID), zap.Error(err))
+	}
+	return session, nil
+}
+
+// ListSessions 列出用户的有效会话，currentSessionID 为发起请求的会话ID，用于标记当前会话
+func (s *UserService) ListSessions(ctx context.Context, userID uint, currentSessionID string) ([]*SessionInfo, error) {
+	if s.sessions == nil {
+		return nil, ErrSessionsDisabled
+	}
+	sessions, err := s.sessions.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if currentSessionID != "" {
+		current := sessionDigest(currentSessionID)
+		for _, session := range sessions {
+			session.Current = session.ID == current
+		}
+	}
+	return sessions, nil
+}
+
+// TerminateSession 终止用户的指定会话，sessionID 为会话列表中的 ID
+func (s *UserService) TerminateSession(ctx context.Context, userID uint, sessionID string) error {
+	if s.sessions == nil {
+		return ErrSessionsDisabled
+	}
+	if err := s.sessions.DeleteByDigest(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("用户会话已终止", zap.Uint("user_id", userID), zap.String("session", sessionID))
+	return nil
+}
+
+// TerminateAllSessions 终止用户的全部会话，keepSessionID 不为空时保留该会话（用于退出其他设备），返回终止的会话数
+func (s *UserService) TerminateAllSessions(ctx context.Context, userID uint, keepSessionID string) (int, error) {
+	if s.sessions == nil {
+		return 0, ErrSessionsDisabled
+	}
+	var keep string
+	if keepSessionID != "" {
+		keep = sessionDigest(keepSessionID)
+	}
+	n, err := s.sessions.DeleteUserSessions(ctx, userID, keep)
+	if err != nil {
+		return 0, err
+	}
+	logger.FromContext(ctx).Info("用户会话已终止", zap.Uint("user_id", userID), zap.Int("sessions", n))
+	return n, nil
+}
+
+// Logout 删除会话，会话立即失效；未启用会话认证时无需处理
+func (s *UserService) Logout(ctx context.Context, sessionID string) error {
+	if s.sessions == nil {
+		return nil
+	}
+	return s.sessions.Delete(ctx, sessionID)
+}
+
+// revokeSessions 吊销用户的全部会话
+func (s *UserService) revokeSessions(ctx context.Context, userID uint) error {
+	if s.sessions == nil {
+		return nil
+	}
+	n, err := s.sessions.DeleteUserSessions(ctx, userID, "")
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("用户会话已吊销", zap.Uint("user_id", userID), zap.Int("sessions", n))
+	return nil
+}
@@ -3,17 +3,23 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 
+	"github.com/VennLe/charlotte/internal/config"
 	"github.com/VennLe/charlotte/internal/dao"
 	"github.com/VennLe/charlotte/internal/model"
 )
 
+// ErrRoleAboveOperator 操作的角色高于操作者自身的角色
+var ErrRoleAboveOperator = errors.New("不能操作高于自身的角色")
+
 // SimplifiedPermissionService 简化版权限服务
 // 使用统一权限DAO，简化权限检查逻辑
 
 type SimplifiedPermissionService struct {
-	userDAO            *dao.UserDAO
-	permissionDAO      *dao.UnifiedPermissionDAO
+	userDAO       *dao.UserDAO
+	permissionDAO *dao.UnifiedPermissionDAO
 }
 
 // NewSimplifiedPermissionService 创建简化版权限服务实例
@@ -37,8 +43,10 @@ type PermissionCheckRequest struct {
 // PermissionCheckResult 权限检查结果（简化版）
 type PermissionCheckResult struct {
 	HasPermission bool   `json:"has_permission"`
-	Reason       string `json:"reason,omitempty"`
-	UserRole     string `json:"user_role"`
+	Reason        string `json:"reason,omitempty"`
+	UserRole      string `json:"user_role"`
+	// Scope 命中权限的资源范围（all/own/public），为 own 时调用方应只返回当前用户的数据
+	Scope string `json:"scope,omitempty"`
 }
 
 // CheckPermission 检查用户权限（简化版）
@@ -47,24 +55,28 @@ func (s *SimplifiedPermissionService) CheckPermission(ctx context.Context, req *
 	user, err := s.userDAO.GetByID(ctx, req.UserID)
 	if err != nil {
 		// 用户不存在，视为游客
-		return &PermissionCheckResult{
+		result := &PermissionCheckResult{
 			HasPermission: s.checkGuestPermission(req.ResourceType, req.Operation),
-			Reason:       "用户不存在，按游客权限处理",
-			UserRole:     model.RoleGuest,
-		}, nil
+			Reason:        "用户不存在，按游客权限处理",
+			UserRole:      model.RoleGuest,
+		}
+		if result.HasPermission {
+			result.Scope = model.ResourceScopePublic
+		}
+		return result, nil
 	}
 
 	// 检查用户状态
-	if user.Status != 1 {
+	if !user.Status.IsActive() {
 		return &PermissionCheckResult{
 			HasPermission: false,
-			Reason:       "用户已被禁用",
-			UserRole:     user.Role,
+			Reason:        accountStatusError(user.Status).Error(),
+			UserRole:      user.Role,
 		}, nil
 	}
 
 	// 使用统一权限DAO检查权限
-	hasPermission, err := s.permissionDAO.CheckPermission(ctx, req.UserID, req.ResourceType, req.Operation)
+	scope, hasPermission, err := s.permissionDAO.CheckPermissionScope(ctx, req.UserID, req.ResourceType, req.Operation)
 	if err != nil {
 		return nil, err
 	}
@@ -77,18 +89,111 @@ func (s *SimplifiedPermissionService) CheckPermission(ctx context.Context, req *
 	if hasPermission {
 		return &PermissionCheckResult{
 			HasPermission: true,
-			Reason:       "权限验证通过",
-			UserRole:     userRole,
+			Reason:        "权限验证通过",
+			UserRole:      userRole,
+			Scope:         scope,
 		}, nil
 	}
 
 	return &PermissionCheckResult{
 		HasPermission: false,
-		Reason:       "权限不足",
-		UserRole:     userRole,
+		Reason:        "权限不足",
+		UserRole:      userRole,
 	}, nil
 }
 
+// ItemAuthorizer 批量鉴权器
+// 一次加载用户角色和权限后，可对任意数量的资源逐条判断，避免列表渲染时逐条查询
+type ItemAuthorizer struct {
+	userID   uint
+	allowAll bool // 拥有不限范围的权限
+	allowOwn bool // 仅拥有 own 范围的权限
+}
+
+// Allowed 判断用户是否可以操作属于 ownerID 的资源
+func (a *ItemAuthorizer) Allowed(ownerID uint) bool {
+	if a.allowAll {
+		return true
+	}
+	return a.allowOwn && a.userID != 0 && ownerID == a.userID
+}
+
+// NewItemAuthorizer 为用户创建指定资源类型和操作的批量鉴权器
+// 权限判断与 CheckPermission 一致；scope 为 own 的权限只对 ownerOf 返回当前用户的资源生效
+func (s *SimplifiedPermissionService) NewItemAuthorizer(ctx context.Context, userID uint, resourceType, operation string) (*ItemAuthorizer, error) {
+	authorizer := &ItemAuthorizer{userID: userID}
+
+	user, err := s.userDAO.GetByID(ctx, userID)
+	if err != nil {
+		// 用户不存在，按游客权限处理
+		authorizer.userID = 0
+		authorizer.allowAll = s.checkGuestPermission(resourceType, operation)
+		return authorizer, nil
+	}
+	if !user.Status.IsActive() {
+		return authorizer, nil
+	}
+
+	role, permissions, err := s.permissionDAO.GetResourcePermissions(ctx, userID, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	if role == model.RoleSuperAdmin {
+		authorizer.allowAll = true
+		return authorizer, nil
+	}
+
+	for _, perm := range permissions {
+		if !perm.AllowsOperation(operation) {
+			continue
+		}
+		if perm.Scope == model.ResourceScopeOwn {
+			authorizer.allowOwn = true
+		} else {
+			authorizer.allowAll = true
+		}
+	}
+
+	return authorizer, nil
+}
+
+// AuthorizedItem 带鉴权结果的资源
+type AuthorizedItem[T any] struct {
+	Item       T    `json:"item"`
+	Authorized bool `json:"authorized"`
+}
+
+// AnnotateAuthorized 批量标注用户能否对每个资源执行指定操作（只加载一次权限）
+// ownerOf 返回资源所有者ID，用于判断 own 范围的权限
+func AnnotateAuthorized[T any](ctx context.Context, s *SimplifiedPermissionService, userID uint, resourceType, operation string, items []T, ownerOf func(item T) uint) ([]AuthorizedItem[T], error) {
+	authorizer, err := s.NewItemAuthorizer(ctx, userID, resourceType, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]AuthorizedItem[T], len(items))
+	for i, item := range items {
+		result[i] = AuthorizedItem[T]{Item: item, Authorized: authorizer.Allowed(ownerOf(item))}
+	}
+	return result, nil
+}
+
+// FilterAuthorized 过滤出用户可以执行指定操作的资源（只加载一次权限）
+func FilterAuthorized[T any](ctx context.Context, s *SimplifiedPermissionService, userID uint, resourceType, operation string, items []T, ownerOf func(item T) uint) ([]T, error) {
+	authorizer, err := s.NewItemAuthorizer(ctx, userID, resourceType, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if authorizer.Allowed(ownerOf(item)) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
 // SetUserRole 设置用户角色
 func (s *SimplifiedPermissionService) SetUserRole(ctx context.Context, userID uint, role string) error {
 	// 验证角色是否有效
@@ -131,12 +236,12 @@ func (s *SimplifiedPermissionService) GetUserPermissions(ctx context.Context, us
 	}
 
 	result := map[string]interface{}{
-		"user_id":     user.ID,
-		"username":    user.Username,
-		"role":        role,
-		"is_active":   user.Status == 1,
+		"user_id":        user.ID,
+		"username":       user.Username,
+		"role":           role,
+		"is_active":      user.Status.IsActive(),
 		"is_super_admin": user.IsSuperAdmin,
-		"permissions": permissions["permissions"],
+		"permissions":    permissions["permissions"],
 	}
 
 	return result, nil
@@ -179,6 +284,172 @@ func (s *SimplifiedPermissionService) GetRolePermissions(ctx context.Context, ro
 	return result, nil
 }
 
+// ClonePermissions 将源角色的权限复制到目标角色，跳过目标角色已有的资源类型
+// 源角色和目标角色都不能高于操作者的角色，避免管理员复制超级管理员的权限
+func (s *SimplifiedPermissionService) ClonePermissions(ctx context.Context, fromRole, toRole, operatorRole string) (int, error) {
+	if !s.isValidRole(fromRole) {
+		return 0, errors.New("无效的用户角色: " + fromRole)
+	}
+	if !s.isValidRole(toRole) {
+		return 0, errors.New("无效的用户角色: " + toRole)
+	}
+	if fromRole == toRole {
+		return 0, errors.New("源角色与目标角色相同")
+	}
+	operatorLevel := model.RoleLevel(operatorRole)
+	if model.RoleLevel(fromRole) > operatorLevel || model.RoleLevel(toRole) > operatorLevel {
+		return 0, ErrRoleAboveOperator
+	}
+
+	return s.permissionDAO.ClonePermissions(ctx, fromRole, toRole)
+}
+
+// CloneGroupPermissions 将源用户组的权限复制到目标用户组，跳过目标组已有的权限
+func (s *SimplifiedPermissionService) CloneGroupPermissions(ctx context.Context, fromGroupID, toGroupID uint) (int, error) {
+	if fromGroupID == toGroupID {
+		return 0, errors.New("源用户组与目标用户组相同")
+	}
+
+	cloned, err := s.permissionDAO.CloneGroupPermissions(ctx, fromGroupID, toGroupID)
+	if errors.Is(err, dao.ErrRecordNotFound) {
+		return 0, errors.New("用户组不存在")
+	}
+	return cloned, err
+}
+
+// AddUserToGroup 将用户加入用户组，受 permission.max_groups_per_user 限制
+func (s *SimplifiedPermissionService) AddUserToGroup(ctx context.Context, userID, groupID uint) error {
+	if _, err := s.userDAO.GetByID(ctx, userID); err != nil {
+		return errors.New("用户不存在")
+	}
+
+	maxGroups := config.Global.Permission.MaxGroupsPerUser
+	err := s.permissionDAO.AddUserToGroup(ctx, userID, groupID, maxGroups)
+	switch {
+	case errors.Is(err, dao.ErrRecordNotFound):
+		return errors.New("用户组不存在")
+	case errors.Is(err, dao.ErrRecordExists):
+		return errors.New("用户已在该用户组中")
+	case errors.Is(err, dao.ErrUserGroupLimitExceeded):
+		return fmt.Errorf("%w（最多%d个）", err, maxGroups)
+	}
+	return err
+}
+
+// ListRolePermissions 分页获取角色权限
+// role 为空时列出所有角色的权限，resourceType 为空时不过滤资源类型
+func (s *SimplifiedPermissionService) ListRolePermissions(ctx context.Context, role, resourceType string, page, size int) ([]map[string]interface{}, int64, error) {
+	if role != "" && !s.isValidRole(role) {
+		return nil, 0, errors.New("无效的用户角色: " + role)
+	}
+
+	permissions, total, err := s.permissionDAO.ListRolePermissions(ctx, role, resourceType, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(permissions))
+	for _, perm := range permissions {
+		result = append(result, map[string]interface{}{
+			"role":          perm.Role,
+			"resource_type": perm.ResourceType,
+			"operations":    perm.Operations,
+			"scope":         perm.Scope,
+		})
+	}
+
+	return result, total, nil
+}
+
+// PermissionMatrix 角色×资源×操作权限矩阵
+type PermissionMatrix struct {
+	Roles      []string                                    `json:"roles"`
+	Resources  []string                                    `json:"resources"`
+	Operations []string                                    `json:"operations"`
+	Matrix     map[string]map[string]*PermissionMatrixCell `json:"matrix"` // 角色 -> 资源类型 -> 权限
+}
+
+// PermissionMatrixCell 权限矩阵单元格
+type PermissionMatrixCell struct {
+	Operations map[string]bool `json:"operations"`
+	Scope      string          `json:"scope,omitempty"`
+	Wildcard   bool            `json:"wildcard"` // 是否有操作来自通配资源("*")配置或超级管理员特权
+}
+
+// matrixOperations 权限矩阵展示的操作
+var matrixOperations = []string{"read", "create", "write", model.PermissionDelete}
+
+// GetPermissionMatrix 根据当前角色权限配置生成权限矩阵
+func (s *SimplifiedPermissionService) GetPermissionMatrix(ctx context.Context) (*PermissionMatrix, error) {
+	permissions, _, err := s.permissionDAO.ListRolePermissions(ctx, "", "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按角色整理权限配置，收集资源类型
+	byRole := make(map[string]map[string]dao.RolePermission)
+	resourceSet := make(map[string]bool)
+	for _, perm := range permissions {
+		if byRole[perm.Role] == nil {
+			byRole[perm.Role] = make(map[string]dao.RolePermission)
+		}
+		byRole[perm.Role][perm.ResourceType] = perm
+		if perm.ResourceType != "*" {
+			resourceSet[perm.ResourceType] = true
+		}
+	}
+
+	resources := make([]string, 0, len(resourceSet))
+	for resourceType := range resourceSet {
+		resources = append(resources, resourceType)
+	}
+	sort.Strings(resources)
+
+	roles := []string{
+		model.RoleSuperAdmin,
+		model.RoleAdmin,
+		model.RoleVIP,
+		model.RoleUser,
+		model.RoleGuest,
+	}
+
+	matrix := make(map[string]map[string]*PermissionMatrixCell, len(roles))
+	for _, role := range roles {
+		matrix[role] = make(map[string]*PermissionMatrixCell, len(resources))
+		for _, resourceType := range resources {
+			cell := &PermissionMatrixCell{Operations: make(map[string]bool, len(matrixOperations))}
+
+			// 与 CheckPermission 一致：超级管理员拥有所有权限，其余角色取精确资源与通配资源配置的并集
+			exact, hasExact := byRole[role][resourceType]
+			wildcard, hasWildcard := byRole[role]["*"]
+			for _, op := range matrixOperations {
+				allowed := role == model.RoleSuperAdmin ||
+					(hasExact && exact.AllowsOperation(op)) ||
+					(hasWildcard && wildcard.AllowsOperation(op))
+				cell.Operations[op] = allowed
+				if allowed && !(hasExact && exact.AllowsOperation(op)) {
+					cell.Wildcard = true
+				}
+			}
+			switch {
+			case hasExact:
+				cell.Scope = exact.Scope
+			case hasWildcard:
+				cell.Scope = wildcard.Scope
+			}
+
+			matrix[role][resourceType] = cell
+		}
+	}
+
+	return &PermissionMatrix{
+		Roles:      roles,
+		Resources:  resources,
+		Operations: matrixOperations,
+		Matrix:     matrix,
+	}, nil
+}
+
 // GetAvailableRoles 获取可用角色列表
 func (s *SimplifiedPermissionService) GetAvailableRoles() []map[string]interface{} {
 	return []map[string]interface{}{
@@ -291,4 +562,4 @@ func (s *SimplifiedPermissionService) getRoleDescription(role string) string {
 	default:
 		return "未知角色"
 	}
-}
\ No newline at end of file
+}
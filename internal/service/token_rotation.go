@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/internal/model"
+	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/tracing"
+)
+
+// tokenVersionCacheTTL 令牌版本缓存时间，轮换时会立即更新缓存
+const tokenVersionCacheTTL = 10 * time.Minute
+
+// RotateTokensRequest 轮换令牌请求
+type RotateTokensRequest struct {
+	IssueNew bool `json:"issue_new"` // 是否在吊销后签发一个新令牌
+}
+
+// RotateTokensResult 轮换令牌结果
+type RotateTokensResult struct {
+	UserID       uint      `json:"user_id"`
+	TokenVersion int       `json:"token_version"`
+	RotatedAt    time.Time `json:"rotated_at"`
+	Token        string    `json:"token,omitempty"`
+	ExpiresAt    int64     `json:"expires_at,omitempty"`
+}
+
+// SetTokenVersionCache 设置令牌版本缓存，为 nil 时每次认证都查询数据库
+func (s *UserService) SetTokenVersionCache(client *redis.Client) {
+	s.tokenVersionCache = client
+}
+
+// RotateAllTokens 吊销用户此前签发的全部令牌和会话，issueNew 为 true 时签发一个新令牌
+// 通过递增用户的令牌版本实现，版本递增和审计日志在同一事务中写入
+func (s *UserService) RotateAllTokens(ctx context.Context, userID, operatorID uint, issueNew bool) (*RotateTokensResult, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RotateAllTokens")
+	defer span.End()
+
+	var user *model.User
+	err := dao.RunInTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if user, err = s.dao.GetByID(ctx, userID); err != nil {
+			return err
+		}
+		if issueNew && !user.Status.IsActive() {
+			return accountStatusError(user.Status)
+		}
+		if user.TokenVersion, err = s.dao.IncrementTokenVersion(ctx, userID); err != nil {
+			return err
+		}
+		return s.auditDAO.Record(ctx, model.AuditActionTokenRotate, operatorID, "user", userID, map[string]interface{}{
+			"token_version": user.TokenVersion,
+			"issued_new":    issueNew,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheTokenVersion(ctx, userID, user.TokenVersion)
+	if err := s.revokeSessions(ctx, userID); err != nil {
+		return nil, fmt.Errorf("令牌已吊销，但吊销会话失败: %w", err)
+	}
+
+	result := &RotateTokensResult{
+		UserID:       userID,
+		TokenVersion: user.TokenVersion,
+		RotatedAt:    time.Now(),
+	}
+	if issueNew {
+		if result.Token, result.ExpiresAt, err = s.generateToken(user); err != nil {
+			return nil, fmt.Errorf("令牌已吊销，但签发新令牌失败: %w", err)
+		}
+	}
+
+	logger.FromContext(ctx).Info("用户令牌已轮换",
+		zap.Uint("user_id", userID),
+		zap.Uint("operator", operatorID),
+		zap.Int("token_version", user.TokenVersion),
+		zap.Bool("issued_new", issueNew))
+	return result, nil
+}
+
+// TokenVersion 获取用户当前的令牌版本，供 JWT 认证中间件拒绝已吊销的令牌
+// 优先读取缓存，缓存不可用时查询数据库
+func (s *UserService) TokenVersion(ctx context.Context, userID uint) (int, error) {
+	if s.tokenVersionCache != nil {
+		v, err := s.tokenVersionCache.Get(ctx, tokenVersionCacheKey(userID)).Int()
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			logger.FromContext(ctx).Warn("读取令牌版本缓存失败，改为查询数据库", zap.Uint("user_id", userID), zap.Error(err))
+		}
+	}
+
+	version, err := s.dao.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if s.tokenVersionCache != nil {
+		// 仅在缓存不存在时回填，避免覆盖并发轮换写入的新版本
+		s.tokenVersionCache.SetNX(ctx, tokenVersionCacheKey(userID), version, tokenVersionCacheTTL)
+	}
+	return version, nil
+}
+
+// cacheTokenVersion 写入令牌版本缓存，写入失败时删除旧值，避免已吊销的令牌继续通过认证
+func (s *UserService) cacheTokenVersion(ctx context.Context, userID uint, version int) {
+	if s.tokenVersionCache == nil {
+		return
+	}
+	key := tokenVersionCacheKey(userID)
+	if err := s.tokenVersionCache.Set(ctx, key, version, tokenVersionCacheTTL).Err(); err != nil {
+		logger.FromContext(ctx).Error("写入令牌版本缓存失败", zap.Uint("user_id", userID), zap.Error(err))
+		s.tokenVersionCache.Del(ctx, key)
+	}
+}
+
+func tokenVersionCacheKey(userID uint) string {
+	return "user:token_version:" + strconv.FormatUint(uint64(userID), 10)
+}
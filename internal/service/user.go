@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"github.com/VennLe/charlotte/internal/config"
@@ -15,29 +16,66 @@ import (
 	"github.com/VennLe/charlotte/internal/model"
 	"github.com/VennLe/charlotte/pkg/kafka"
 	"github.com/VennLe/charlotte/pkg/logger"
+	"github.com/VennLe/charlotte/pkg/tracing"
 )
 
+// ErrFieldNotUpdatable 更新了不允许修改的用户字段
+var ErrFieldNotUpdatable = dao.ErrFieldNotUpdatable
+
+// ErrMergeSameUser 不能将用户合并到自身
+var ErrMergeSameUser = dao.ErrMergeSameUser
+
+// ErrEmptyUpdate 更新请求中没有任何字段
+var ErrEmptyUpdate = errors.New("没有需要更新的字段")
+
+// ErrInvalidUserField 用户字段值不合法
+var ErrInvalidUserField = errors.New("字段值不合法")
+
+// ErrRecordNotFound 记录不存在
+var ErrRecordNotFound = dao.ErrRecordNotFound
+
 // UserService 用户服务
 type UserService struct {
+	db       *gorm.DB
 	dao      *dao.UserDAO
+	auditDAO *dao.AuditDAO
 	producer kafka.Producer
+	guard    *RegistrationGuard
+
+	// tokenVersionCache 令牌版本缓存，为 nil 时每次认证都查询数据库
+	tokenVersionCache *redis.Client
+	// sessions 服务端会话存储，为 nil 时使用 JWT 认证
+	sessions *SessionStore
+	// webhooks 领域事件 Webhook，为 nil 时用户事件只发送到 Kafka
+	webhooks *WebhookService
 }
 
 // NewUserService 创建服务实例
 func NewUserService(db *gorm.DB) *UserService {
+	userDAO := dao.NewUserDAO(db)
+	userDAO.SetSearchUnaccent(config.Global.Database.SearchUnaccent)
 	return &UserService{
-		dao:      dao.NewUserDAO(db),
+		db:       db,
+		dao:      userDAO,
+		auditDAO: dao.NewAuditDAO(db),
 		producer: kafka.GetProducer(),
 	}
 }
 
+// SetRegistrationGuard 设置注册防护，为 nil 时不做注册防护检查
+func (s *UserService) SetRegistrationGuard(guard *RegistrationGuard) {
+	s.guard = guard
+}
+
 // RegisterRequest 注册请求
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6,max=32"`
-	Nickname string `json:"nickname"`
-	Phone    string `json:"phone"`
+	Username     string `json:"username" binding:"required,min=3,max=50"`
+	Email        string `json:"email" binding:"required,email"`
+	Password     string `json:"password" binding:"required,min=6,max=32"`
+	Nickname     string `json:"nickname"`
+	Phone        string `json:"phone"`
+	CaptchaToken string `json:"captcha_token"`
+	ClientIP     string `json:"-"` // 由处理器填充，用于注册防护
 }
 
 // LoginRequest 登录请求
@@ -48,27 +86,42 @@ type LoginRequest struct {
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token     string      `json:"token"`
+	Token     string      `json:"token,omitempty"` // 会话认证模式下为空，会话ID通过 Cookie 下发
 	ExpiresAt int64       `json:"expires_at"`
 	User      *model.User `json:"user"`
 }
 
 // UserInfo 用户信息 (脱敏)
+// import 标签描述导入约束（必填、可选值、最大长度），导入时校验并供导入导出 schema 接口使用；charlotte 标签为导出表头，有表头的导入按列名匹配字段
 type UserInfo struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Nickname  string    `json:"nickname"`
-	Avatar    string    `json:"avatar"`
-	Phone     string    `json:"phone"`
-	Status    int       `json:"status"`
-	Role      string    `json:"role"`
-	LastLogin time.Time `json:"last_login"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uint         `json:"id" charlotte:"ID"`
+	Username  string       `json:"username" charlotte:"用户名" import:"required,max=50"`
+	Email     string       `json:"email" charlotte:"邮箱" import:"required,max=100"`
+	Nickname  string       `json:"nickname" charlotte:"昵称" import:"max=50"`
+	Avatar    string       `json:"avatar" charlotte:"头像" import:"max=255"`
+	Phone     string       `json:"phone" charlotte:"手机号" import:"max=20"`
+	Status    model.Status `json:"status" charlotte:"状态" import:"enum=1|2|3|4|5"`
+	Role      string       `json:"role" charlotte:"角色" import:"enum=guest|user|vip|admin|superadmin"`
+	LastLogin time.Time    `json:"last_login" charlotte:"最后登录时间"`
+	CreatedAt time.Time    `json:"created_at" charlotte:"创建时间"`
+	UpdatedAt time.Time    `json:"updated_at" charlotte:"更新时间"`
 }
 
 // Register 用户注册
 func (s *UserService) Register(ctx context.Context, req *RegisterRequest) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer span.End()
+
+	// 注册名额在创建用户前占用，创建失败时归还
+	releaseGuard := func() {}
+	if s.guard != nil {
+		release, err := s.guard.Check(ctx, req.Email, req.CaptchaToken, req.ClientIP)
+		if err != nil {
+			return nil, err
+		}
+		releaseGuard = release
+	}
+
 	user := &model.User{
 		Username: req.Username,
 		Email:    req.Email,
@@ -78,17 +131,44 @@ func (s *UserService) Register(ctx context.Context, req *RegisterRequest) (*mode
 	}
 
 	if err := s.dao.Create(ctx, user); err != nil {
+		releaseGuard()
 		return nil, err
 	}
 
 	// 发送 Kafka 事件
-	go s.publishUserEvent("user_created", user)
+	go s.publishUserEvent(context.WithoutCancel(ctx), model.EventUserCreated, user)
 
 	return user, nil
 }
 
 // Login 用户登录
 func (s *UserService) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+
+	user, err := s.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 生成 JWT
+	token, expiresAt, err := s.generateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// 脱敏处理
+	user.Password = ""
+
+	return &LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
+	}, nil
+}
+
+// authenticate 校验登录凭据和账号状态，成功后更新最后登录时间
+func (s *UserService) authenticate(ctx context.Context, req *LoginRequest) (*model.User, error) {
 	// 先尝试用户名登录，再尝试邮箱登录
 	user, err := s.dao.GetByUsername(ctx, req.Username)
 	if err != nil {
@@ -104,36 +184,36 @@ func (s *UserService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 	}
 
 	// 检查状态
-	if user.Status != 1 {
-		return nil, errors.New("账号已被禁用")
+	if !user.Status.IsActive() {
+		return nil, accountStatusError(user.Status)
 	}
 
 	// 验证密码
-	if !s.dao.CheckPassword(user.Password, req.Password) {
+	ok, needsRehash := s.dao.CheckPassword(user.Password, req.Password)
+	if !ok {
 		return nil, errors.New("用户名或密码错误")
 	}
 
-	// 更新最后登录时间
-	go s.dao.UpdateLastLogin(ctx, user.ID)
-
-	// 生成 JWT
-	token, expiresAt, err := s.generateToken(user)
-	if err != nil {
-		return nil, err
+	// 旧算法或旧参数的哈希在登录成功时升级为当前配置，失败不影响登录，下次登录再试
+	if needsRehash {
+		if err := s.dao.UpdatePassword(ctx, user.ID, req.Password); err != nil {
+			logger.FromContext(ctx).Warn("升级密码哈希失败", zap.Uint("user_id", user.ID), zap.Error(err))
+		} else {
+			logger.FromContext(ctx).Info("密码哈希已升级", zap.Uint("user_id", user.ID))
+		}
 	}
 
-	// 脱敏处理
-	user.Password = ""
+	// 更新最后登录时间
+	go s.dao.UpdateLastLogin(ctx, user.ID)
 
-	return &LoginResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		User:      user,
-	}, nil
+	return user, nil
 }
 
 // GetUserByID 获取用户信息
 func (s *UserService) GetUserByID(ctx context.Context, id uint) (*UserInfo, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
 	user, err := s.dao.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -143,14 +223,17 @@ func (s *UserService) GetUserByID(ctx context.Context, id uint) (*UserInfo, erro
 
 // GetUserList 获取用户列表
 func (s *UserService) GetUserList(ctx context.Context, page, size int, keyword string) ([]*UserInfo, int64, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserList")
+	defer span.End()
+
 	options := &dao.QueryOptions{
-		Page:    page,
-		Size:    size,
-		Keyword: keyword,
-		OrderBy: "created_at",
+		Page:     page,
+		Size:     size,
+		Keyword:  keyword,
+		OrderBy:  "created_at",
 		OrderDir: "desc",
 	}
-	
+
 	users, total, err := s.dao.List(ctx, options)
 	if err != nil {
 		return nil, 0, err
@@ -164,31 +247,130 @@ func (s *UserService) GetUserList(ctx context.Context, page, size int, keyword s
 	return list, total, err
 }
 
-// UpdateUser 更新用户信息
+// UserStats 用户统计
+type UserStats struct {
+	Total    int64            `json:"total"`
+	ByRole   map[string]int64 `json:"by_role"`
+	ByStatus map[string]int64 `json:"by_status"`
+}
+
+// GetUserStats 获取按角色、状态分组的用户统计
+func (s *UserService) GetUserStats(ctx context.Context) (*UserStats, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserStats")
+	defer span.End()
+
+	byRole, err := s.dao.CountBy(ctx, "role", nil)
+	if err != nil {
+		return nil, err
+	}
+	byStatus, err := s.dao.CountBy(ctx, "status", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &UserStats{ByRole: byRole, ByStatus: byStatus}
+	for _, count := range byRole {
+		stats.Total += count
+	}
+	return stats, nil
+}
+
+// UpdateUser 部分更新用户信息（PATCH 语义）
+// 只修改 updates 中出现的字段，未出现的字段保持不变；出现的字段按原值写入，包括零值：
+// nickname/avatar/phone/tags 传 "" 或 null 即清空。username、email 不能清空。
+// 只允许更新 dao.UserUpdatableFields 中的字段，否则返回 ErrFieldNotUpdatable；值不合法时返回 ErrInvalidUserField
 func (s *UserService) UpdateUser(ctx context.Context, id uint, updates map[string]interface{}) error {
-	// 检查用户是否存在
-	_, err := s.dao.GetByID(ctx, id)
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	updates, err := normalizeUserUpdates(updates)
 	if err != nil {
 		return err
 	}
 
+	// 检查用户是否存在
+	if _, err := s.dao.GetByID(ctx, id); err != nil {
+		return err
+	}
+
 	if err := s.dao.Update(ctx, id, updates); err != nil {
 		return err
 	}
 
-	// 发送更新事件
+	// 发送更新事件（保留请求ID，但不随请求结束而取消）
+	eventCtx := context.WithoutCancel(ctx)
 	go func() {
-		user, _ := s.dao.GetByID(context.Background(), id)
+		user, _ := s.dao.GetByID(eventCtx, id)
 		if user != nil {
-			s.publishUserEvent("user_updated", user)
+			s.publishUserEvent(eventCtx, model.EventUserUpdated, user)
 		}
 	}()
 
 	return nil
 }
 
+// ReplaceUserRequest 整体替换用户信息（PUT 语义）的完整表示
+// 所有可修改字段都会被写入，未传的可选字段按空值处理，即被清空
+type ReplaceUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Email    string `json:"email" binding:"required,email,max=100"`
+	Nickname string `json:"nickname" binding:"max=50"`
+	Avatar   string `json:"avatar" binding:"max=255"`
+	Phone    string `json:"phone" binding:"max=20"`
+	Tags     string `json:"tags" binding:"max=255"`
+}
+
+// ReplaceUser 整体替换用户信息（PUT 语义），请求中未包含的可选字段会被清空
+func (s *UserService) ReplaceUser(ctx context.Context, id uint, req *ReplaceUserRequest) error {
+	return s.UpdateUser(ctx, id, map[string]interface{}{
+		"username": req.Username,
+		"email":    req.Email,
+		"nickname": req.Nickname,
+		"avatar":   req.Avatar,
+		"phone":    req.Phone,
+		"tags":     req.Tags,
+	})
+}
+
+// MergeUsersRequest 合并用户请求
+type MergeUsersRequest struct {
+	CanonicalID uint `json:"canonical_id" binding:"required"` // 保留的用户
+	DuplicateID uint `json:"duplicate_id" binding:"required"` // 被合并并删除的重复用户
+}
+
+// MergeUsers 合并重复用户
+// 将重复用户的用户组成员关系、特殊权限和角色转移到保留用户并软删除重复用户，
+// 与审计记录在同一事务中完成；上传文件未持久化归属信息，不在合并范围内
+func (s *UserService) MergeUsers(ctx context.Context, canonicalID, duplicateID, operatorID uint) (*dao.UserMergeResult, error) {
+	ctx, span := tracing.Start(ctx, "UserService.MergeUsers")
+	defer span.End()
+
+	var result *dao.UserMergeResult
+	var duplicate *model.User
+	err := dao.RunInTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if duplicate, err = s.dao.GetByID(ctx, duplicateID); err != nil {
+			return err
+		}
+		if result, err = s.dao.MergeInto(ctx, canonicalID, duplicateID); err != nil {
+			return err
+		}
+		return s.auditDAO.Record(ctx, model.AuditActionUserMerge, operatorID, "user", canonicalID, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.publishUserEvent(context.WithoutCancel(ctx), model.EventUserDeleted, duplicate)
+
+	return result, nil
+}
+
 // DeleteUser 删除用户
 func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
 	user, err := s.dao.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -199,20 +381,23 @@ func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
 	}
 
 	// 发送删除事件
-	go s.publishUserEvent("user_deleted", user)
+	go s.publishUserEvent(context.WithoutCancel(ctx), model.EventUserDeleted, user)
 
 	return nil
 }
 
 // ChangePassword 修改密码
 func (s *UserService) ChangePassword(ctx context.Context, id uint, oldPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+
 	user, err := s.dao.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
 	// 验证旧密码
-	if !s.dao.CheckPassword(user.Password, oldPassword) {
+	if ok, _ := s.dao.CheckPassword(user.Password, oldPassword); !ok {
 		return errors.New("原密码错误")
 	}
 
@@ -232,6 +417,7 @@ func (s *UserService) generateToken(user *model.User) (string, int64, error) {
 		"user_id":  user.ID,
 		"username": user.Username,
 		"role":     user.Role,
+		"tv":       user.TokenVersion,
 		"exp":      expiresAt,
 		"iat":      time.Now().Unix(),
 	}
@@ -258,11 +444,15 @@ func (s *UserService) toUserInfo(user *model.User) *UserInfo {
 		Role:      user.Role,
 		LastLogin: user.LastLogin,
 		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
 	}
 }
 
-// publishUserEvent 发送用户事件到 Kafka
-func (s *UserService) publishUserEvent(eventType string, user *model.User) {
+// publishUserEvent 发送用户事件到 Kafka 并推送给订阅了该事件的 Webhook，ctx 中的请求ID会随消息头传递给消费端
+// Webhook 面向外部系统，只推送脱敏的用户信息
+func (s *UserService) publishUserEvent(ctx context.Context, eventType string, user *model.User) {
+	s.webhooks.Dispatch(ctx, eventType, s.toUserInfo(user))
+
 	event := model.UserEvent{
 		EventType: eventType,
 		UserID:    user.ID,
@@ -277,13 +467,13 @@ func (s *UserService) publishUserEvent(eventType string, user *model.User) {
 
 	eventJSON, _ := json.Marshal(event)
 
-	if err := s.producer.SendMessage("user-events", string(eventJSON)); err != nil {
-		logger.Error("发送用户事件失败",
+	if err := s.producer.SendMessageWithContext(ctx, config.Global.Kafka.Topic, "", string(eventJSON)); err != nil {
+		logger.FromContext(ctx).Error("发送用户事件失败",
 			zap.String("event_type", eventType),
 			zap.Uint("user_id", user.ID),
 			zap.Error(err))
 	} else {
-		logger.Info("用户事件发送成功",
+		logger.FromContext(ctx).Info("用户事件发送成功",
 			zap.String("event_type", eventType),
 			zap.Uint("user_id", user.ID))
 	}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/VennLe/charlotte/internal/dao"
+)
+
+// userFieldMaxLength 可修改用户字段的最大长度，与 model.User 的列定义一致
+var userFieldMaxLength = map[string]int{
+	"username": 50,
+	"email":    100,
+	"nickname": 50,
+	"avatar":   255,
+	"phone":    20,
+	"tags":     255,
+}
+
+// userRequiredFields 不能清空的用户字段
+var userRequiredFields = map[string]bool{
+	"username": true,
+	"email":    true,
+}
+
+// normalizeUserUpdates 校验部分更新的字段和值
+// 字段名统一为小写，null 转为空字符串（列为 NOT NULL 语义的字符串，清空即写入 ""）
+func normalizeUserUpdates(updates map[string]interface{}) (map[string]interface{}, error) {
+	if len(updates) == 0 {
+		return nil, ErrEmptyUpdate
+	}
+
+	updatable := make(map[string]bool, len(dao.UserUpdatableFields))
+	for _, field := range dao.UserUpdatableFields {
+		updatable[field] = true
+	}
+
+	normalized := make(map[string]interface{}, len(updates))
+	for key, value := range updates {
+		field := strings.ToLower(key)
+		if !updatable[field] {
+			return nil, fmt.Errorf("%w: %s", dao.ErrFieldNotUpdatable, key)
+		}
+
+		var str string
+		switch v := value.(type) {
+		case nil:
+		case string:
+			str = strings.TrimSpace(v)
+		default:
+			return nil, fmt.Errorf("%w: %s 必须是字符串或 null", ErrInvalidUserField, key)
+		}
+
+		if str == "" && userRequiredFields[field] {
+			return nil, fmt.Errorf("%w: %s 不能为空", ErrInvalidUserField, key)
+		}
+		if max := userFieldMaxLength[field]; utf8.RuneCountInString(str) > max {
+			return nil, fmt.Errorf("%w: %s 长度不能超过 %d", ErrInvalidUserField, key, max)
+		}
+		switch field {
+		case "username":
+			if utf8.RuneCountInString(str) < 3 {
+				return nil, fmt.Errorf("%w: username 长度不能少于 3", ErrInvalidUserField)
+			}
+		case "email":
+			if addr, err := mail.ParseAddress(str); err != nil || addr.Address != str {
+				return nil, fmt.Errorf("%w: email 格式错误", ErrInvalidUserField)
+			}
+		}
+
+		normalized[field] = str
+	}
+	return normalized, nil
+}
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/VennLe/charlotte/internal/config"
+	"github.com/VennLe/charlotte/internal/dao"
+	"github.com/VennLe/charlotte/internal/model"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// Webhook 请求头
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	// webhookMaxBackoff 重试间隔上限
+	webhookMaxBackoff = time.Hour
+	// webhookRetryBatch 每次重试任务最多处理的投递数
+	webhookRetryBatch = 100
+)
+
+// ErrInvalidWebhook Webhook 订阅参数无效
+var ErrInvalidWebhook = errors.New("无效的 Webhook 订阅")
+
+// WebhookEvent 推送给订阅方的事件内容
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// CreateWebhookRequest 创建 Webhook 订阅请求
+type CreateWebhookRequest struct {
+	Name       string   `json:"name" binding:"max=100"`
+	URL        string   `json:"url" binding:"required,max=500"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=128"` // 为空时自动生成
+	EventTypes []string `json:"event_types" binding:"required,min=1"`      // 事件类型，* 表示全部
+}
+
+// CreateWebhookResponse 创建 Webhook 订阅响应，签名密钥只在创建时返回
+type CreateWebhookResponse struct {
+	*model.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhookService 领域事件 Webhook 服务：维护订阅，对订阅了事件的端点签名推送并记录投递状态
+// 事件先写入投递记录再异步投递，失败的投递由定时任务按指数退避重试，进程重启不会丢失待投递的事件
+type WebhookService struct {
+	subscriptions *dao.WebhookSubscriptionDAO
+	deliveries    *dao.WebhookDeliveryDAO
+	client        *http.Client
+	allowPrivate  bool // 允许投递到内网地址
+
+	maxAttempts int
+	backoff     time.Duration
+	slots       chan struct{} // 限制本实例同时进行的投递数
+}
+
+// NewWebhookService 创建 Webhook 服务
+func NewWebhookService(db *gorm.DB, cfg config.WebhookConfig) *WebhookService {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	backoff := time.Duration(cfg.RetryBackoff) * time.Second
+	if backoff <= 0 {
+		backoff = 30 * time.Second
+	}
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	return &WebhookService{
+		subscriptions: dao.NewWebhookSubscriptionDAO(db),
+		deliveries:    dao.NewWebhookDeliveryDAO(db),
+		client:        newWebhookClient(timeout, cfg.AllowPrivateNetworks),
+		allowPrivate:  cfg.AllowPrivateNetworks,
+		maxAttempts:   maxAttempts,
+		backoff:       backoff,
+		slots:         make(chan struct{}, concurrency),
+	}
+}
+
+// CreateSubscription 创建 Webhook 订阅
+func (s *WebhookService) CreateSubscription(ctx context.Context, req *CreateWebhookRequest, operatorID uint) (*CreateWebhookResponse, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: URL 必须是 http 或 https 地址", ErrInvalidWebhook)
+	}
+	// 域名解析到的地址在投递时检查，这里只拒绝直接填写的内网地址
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !s.allowPrivate && !isPublicWebhookAddr(addr) {
+		return nil, fmt.Errorf("%w: 不允许投递到内网地址", ErrInvalidWebhook)
+	}
+	for _, eventType := range req.EventTypes {
+		if eventType != model.EventAll && !slices.Contains(model.WebhookEventTypes, eventType) {
+			return nil, fmt.Errorf("%w: 不支持的事件类型 %s", ErrInvalidWebhook, eventType)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := &model.WebhookSubscription{
+		Name:       req.Name,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+		Active:     true,
+		CreatedBy:  operatorID,
+	}
+	if err := s.subscriptions.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	return &CreateWebhookResponse{WebhookSubscription: sub, Secret: secret}, nil
+}
+
+// ListSubscriptions 获取全部 Webhook 订阅
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	subs, _, err := s.subscriptions.List(ctx, &dao.QueryOptions{OrderBy: "id", OrderDir: "asc"})
+	return subs, err
+}
+
+// DeleteSubscription 删除 Webhook 订阅，未完成的投递在下次重试时标记为失败
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id uint) error {
+	return s.subscriptions.Delete(ctx, id)
+}
+
+// ListDeliveries 分页获取订阅的投递记录，按时间倒序
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID uint, page, size int) ([]*model.WebhookDelivery, int64, error) {
+	return s.deliveries.List(ctx, &dao.QueryOptions{
+		Page:     page,
+		Size:     size,
+		Filters:  map[string]interface{}{"subscription_id = ?": subscriptionID},
+		OrderBy:  "id",
+		OrderDir: "desc",
+	})
+}
+
+// Dispatch 向订阅了该事件类型的端点推送事件，服务为 nil 时不做处理
+// 每个订阅生成一条投递记录后立即异步投递，本实例投递繁忙时留给重试任务处理；失败只记录日志，不影响业务流程
+func (s *WebhookService) Dispatch(ctx context.Context, eventType string, data interface{}) {
+	if s == nil {
+		return
+	}
+	log := logger.FromContext(ctx).With(zap.String("event_type", eventType))
+
+	subs, err := s.subscriptions.GetMany(ctx, map[string]interface{}{"active = ?": true})
+	if err != nil {
+		log.Error("查询 Webhook 订阅失败", zap.Error(err))
+		return
+	}
+	subs = slices.DeleteFunc(subs, func(sub *model.WebhookSubscription) bool { return !sub.Subscribes(eventType) })
+	if len(subs) == 0 {
+		return
+	}
+
+	eventID, err := newRandomID()
+	if err != nil {
+		log.Error("生成 Webhook 事件ID失败", zap.Error(err))
+		return
+	}
+	payload, err := json.Marshal(WebhookEvent{ID: eventID, Type: eventType, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		log.Error("序列化 Webhook 事件失败", zap.Error(err))
+		return
+	}
+
+	// 投递期间的租约，首次投递未完成（如进程退出）时租约到期后由重试任务接手
+	lease := time.Now().Add(s.lease())
+	deliveries := make([]*model.WebhookDelivery, len(subs))
+	for i, sub := range subs {
+		deliveries[i] = &model.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         model.WebhookDeliveryPending,
+			NextAttemptAt:  &lease,
+		}
+	}
+	if err := s.deliveries.CreateBatch(ctx, deliveries); err != nil {
+		log.Error("保存 Webhook 投递记录失败", zap.Error(err))
+		return
+	}
+
+	for i, delivery := range deliveries {
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			log.Warn("Webhook 投递繁忙，留给重试任务处理", zap.Uint("delivery_id", delivery.ID))
+			continue
+		}
+		go func(delivery *model.WebhookDelivery, sub *model.WebhookSubscription) {
+			defer func() { <-s.slots }()
+			s.deliver(context.WithoutCancel(ctx), delivery, sub)
+		}(delivery, subs[i])
+	}
+}
+
+// RetryDue 重试到期的投递，返回本次尝试投递的数量，由定时任务调用
+func (s *WebhookService) RetryDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.deliveries.ListDue(ctx, now, webhookRetryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	subs := make(map[uint]*model.WebhookSubscription)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	attempted := 0
+	for _, delivery := range due {
+		claimed, err := s.deliveries.Claim(ctx, delivery.ID, now, time.Now().Add(s.lease()))
+		if err != nil {
+			return attempted, err
+		}
+		if !claimed {
+			continue
+		}
+
+		sub, ok := subs[delivery.SubscriptionID]
+		if !ok {
+			sub, err = s.subscriptions.GetByID(ctx, delivery.SubscriptionID)
+			if err != nil && !errors.Is(err, dao.ErrRecordNotFound) {
+				return attempted, err
+			}
+			subs[delivery.SubscriptionID] = sub
+		}
+		if sub == nil || !sub.Active {
+			s.finish(ctx, delivery, 0, errors.New("订阅已删除或停用"), true)
+			continue
+		}
+
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return attempted, ctx.Err()
+		}
+		attempted++
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery, sub *model.WebhookSubscription) {
+			defer wg.Done()
+			defer func() { <-s.slots }()
+			s.deliver(ctx, delivery, sub)
+		}(delivery, sub)
+	}
+	return attempted, nil
+}
+
+// deliver 投递一次并记录结果
+func (s *WebhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery, sub *model.WebhookSubscription) {
+	code, err := s.post(ctx, delivery, sub)
+	s.finish(ctx, delivery, code, err, false)
+}
+
+// post 发送签名的 POST 请求，返回响应状态码
+func (s *WebhookService) post(ctx context.Context, delivery *model.WebhookDelivery, sub *model.WebhookSubscription) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(sub.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("请求Webhook失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 响应内容不保存到投递记录，避免通过投递记录读取内网服务的响应
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("Webhook返回状态码 %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// finish 记录投递结果，失败且未用尽次数时按指数退避安排下次投递；giveUp 为 true 时直接标记失败
+func (s *WebhookService) finish(ctx context.Context, delivery *model.WebhookDelivery, code int, deliverErr error, giveUp bool) {
+	attempts := delivery.Attempts
+	if !giveUp {
+		attempts++
+	}
+	updates := map[string]interface{}{
+		"attempts":      attempts,
+		"response_code": code,
+		"last_error":    "",
+	}
+
+	now := time.Now()
+	switch {
+	case deliverErr == nil:
+		updates["status"] = model.WebhookDeliverySucceeded
+		updates["next_attempt_at"] = nil
+		updates["delivered_at"] = now
+	case giveUp || attempts >= s.maxAttempts:
+		updates["status"] = model.WebhookDeliveryFailed
+		updates["next_attempt_at"] = nil
+	default:
+		updates["next_attempt_at"] = now.Add(s.retryDelay(attempts))
+	}
+	if deliverErr != nil {
+		msg := []rune(deliverErr.Error())
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		updates["last_error"] = string(msg)
+	}
+
+	log := logger.FromContext(ctx).With(
+		zap.Uint("delivery_id", delivery.ID),
+		zap.Uint("subscription_id", delivery.SubscriptionID),
+		zap.String("event_type", delivery.EventType),
+		zap.Int("attempts", attempts),
+	)
+	if err := s.deliveries.Update(ctx, delivery.ID, updates); err != nil {
+		log.Error("更新 Webhook 投递记录失败", zap.Error(err))
+	}
+	if deliverErr != nil {
+		log.Warn("Webhook 投递失败", zap.Any("status", updates["status"]), zap.Error(deliverErr))
+	}
+}
+
+// retryDelay 第 attempts 次投递失败后的重试间隔
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.backoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxBackoff)
+}
+
+// lease 单次投递的租约时长，需长于请求超时
+func (s *WebhookService) lease() time.Duration {
+	return 2 * s.client.Timeout
+}
+
+// SignWebhookPayload 计算 Webhook 签名：sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+// 接收方用相同方式计算并比对 X-Webhook-Signature，同时校验 X-Webhook-Timestamp 防止重放
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成 Webhook 密钥失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// errWebhookAddrNotAllowed 投递地址解析到了内网地址
+var errWebhookAddrNotAllowed = errors.New("不允许投递到内网地址")
+
+// cgnatPrefix 运营商级 NAT 地址段，部分云厂商的元数据服务位于其中
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// newWebhookClient 创建投递用的 HTTP 客户端
+// 不跟随重定向；allowPrivate 为 false 时在建立连接前检查解析后的地址，拒绝回环、内网、链路本地等地址
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		// Control 在 DNS 解析之后、连接之前调用，检查的是实际连接的地址
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicWebhookAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errWebhookAddrNotAllowed, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 经代理转发时检查的是代理的地址，投递不使用环境变量中的代理
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPublicWebhookAddr 地址是否可以作为投递目标
+func isPublicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() && !cgnatPrefix.Contains(addr)
+}
+
+// ImportCompletedEvent 导入完成事件内容
+type ImportCompletedEvent struct {
+	DataType    string `json:"data_type"`
+	FileType    string `json:"file_type"`
+	FileName    string `json:"file_name"`
+	TotalRows   int    `json:"total_rows"`
+	SuccessRows int    `json:"success_rows"`
+	FailedRows  int    `json:"failed_rows"`
+}
+
+// SetWebhookService 设置用户事件的 Webhook，为 nil 时用户事件只发送到 Kafka
+func (s *UserService) SetWebhookService(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
+
+// SetWebhookService 设置文件上传事件的 Webhook，为 nil 时不推送
+func (s *FileService) SetWebhookService(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
+
+// SetWebhookService 设置导入完成事件的 Webhook，为 nil 时不推送
+func (s *ImportExportService) SetWebhookService(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/internal/model"
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// CacheInvalidator 缓存失效接口
+type CacheInvalidator interface {
+	InvalidateUser(ctx context.Context, userID uint) error
+}
+
+// CacheInvalidationHandler 缓存失效消费者处理器
+// 每个实例使用独立的消费者组，保证所有实例都能收到用户变更事件并清除本地写入的缓存
+type CacheInvalidationHandler struct {
+	invalidator CacheInvalidator
+}
+
+func NewCacheInvalidationHandler(invalidator CacheInvalidator) *CacheInvalidationHandler {
+	return &CacheInvalidationHandler{
+		invalidator: invalidator,
+	}
+}
+
+func (h *CacheInvalidationHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *CacheInvalidationHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *CacheInvalidationHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case message := <-claim.Messages():
+			if message == nil {
+				return nil
+			}
+
+			h.handleMessage(messageContext(session.Context(), message), message.Value)
+			session.MarkMessage(message, "")
+
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// handleMessage 处理用户事件，只订阅了配置的用户事件 topic，无需按 topic 区分
+func (h *CacheInvalidationHandler) handleMessage(ctx context.Context, data []byte) {
+	log := logger.FromContext(ctx)
+
+	var event model.UserEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Error("解析用户事件失败", zap.Error(err))
+		return
+	}
+
+	switch event.EventType {
+	case "user_updated", "user_deleted":
+		if err := h.invalidator.InvalidateUser(ctx, event.UserID); err != nil {
+			log.Error("清理用户缓存失败",
+				zap.Uint("user_id", event.UserID),
+				zap.String("event_type", event.EventType),
+				zap.Error(err))
+			return
+		}
+		log.Info("用户缓存已清理",
+			zap.Uint("user_id", event.UserID),
+			zap.String("event_type", event.EventType))
+	}
+}
+
+// InitCacheInvalidationConsumer 初始化缓存失效消费者组
+// 消费者组首次创建时从最新位置开始消费，之前的事件与已写入的缓存无关；重启后从上次提交的位置继续
+func InitCacheInvalidationConsumer(brokers []string, groupID string, topics []string, invalidator CacheInvalidator) (sarama.ConsumerGroup, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	consumerGroup, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		handler := NewCacheInvalidationHandler(invalidator)
+		for {
+			if err := consumerGroup.Consume(context.Background(), topics, handler); err != nil {
+				if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+					return
+				}
+				logger.Error("缓存失效消费错误", zap.Error(err))
+			}
+		}
+	}()
+
+	return consumerGroup, nil
+}
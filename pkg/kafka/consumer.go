@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"slices"
 
 	"github.com/IBM/sarama"
 	"go.uber.org/zap"
@@ -13,12 +14,14 @@ import (
 
 // ConsumerGroupHandler 消费者组处理器
 type ConsumerGroupHandler struct {
-	ready chan bool
+	ready      chan bool
+	userTopics []string // 用户事件所在的 topic，来自 kafka.topic 配置
 }
 
-func NewConsumerGroupHandler() *ConsumerGroupHandler {
+func NewConsumerGroupHandler(userTopics []string) *ConsumerGroupHandler {
 	return &ConsumerGroupHandler{
-		ready: make(chan bool),
+		ready:      make(chan bool),
+		userTopics: userTopics,
 	}
 }
 
@@ -39,7 +42,7 @@ func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 				return nil
 			}
 
-			h.handleMessage(message.Topic, message.Value)
+			h.handleMessage(messageContext(session.Context(), message), message.Topic, message.Value)
 			session.MarkMessage(message, "")
 
 		case <-session.Context().Done():
@@ -48,26 +51,28 @@ func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 	}
 }
 
-func (h *ConsumerGroupHandler) handleMessage(topic string, data []byte) {
-	logger.Info("收到 Kafka 消息",
+func (h *ConsumerGroupHandler) handleMessage(ctx context.Context, topic string, data []byte) {
+	log := logger.FromContext(ctx)
+	log.Info("收到 Kafka 消息",
 		zap.String("topic", topic),
 		zap.String("data", string(data)))
 
-	switch topic {
-	case "user-events":
-		var event model.UserEvent
-		if err := json.Unmarshal(data, &event); err != nil {
-			logger.Error("解析用户事件失败", zap.Error(err))
-			return
-		}
-		h.handleUserEvent(event)
-	default:
-		logger.Warn("未知 topic", zap.String("topic", topic))
+	if !slices.Contains(h.userTopics, topic) {
+		log.Warn("未知 topic", zap.String("topic", topic))
+		return
+	}
+
+	var event model.UserEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Error("解析用户事件失败", zap.Error(err))
+		return
 	}
+	h.handleUserEvent(ctx, event)
 }
 
-func (h *ConsumerGroupHandler) handleUserEvent(event model.UserEvent) {
-	logger.Info("处理用户事件",
+func (h *ConsumerGroupHandler) handleUserEvent(ctx context.Context, event model.UserEvent) {
+	log := logger.FromContext(ctx)
+	log.Info("处理用户事件",
 		zap.String("event_type", event.EventType),
 		zap.Uint("user_id", event.UserID),
 		zap.String("username", event.Username))
@@ -78,14 +83,24 @@ func (h *ConsumerGroupHandler) handleUserEvent(event model.UserEvent) {
 	switch event.EventType {
 	case "user_created":
 		// 发送欢迎邮件
-		logger.Info("新用户注册，发送欢迎邮件", zap.String("email", event.Email))
+		log.Info("新用户注册，发送欢迎邮件", zap.String("email", event.Email))
 	case "user_updated":
 		// 更新缓存
-		logger.Info("用户信息更新，清理缓存", zap.Uint("user_id", event.UserID))
+		log.Info("用户信息更新，清理缓存", zap.Uint("user_id", event.UserID))
 	case "user_deleted":
 		// 清理相关数据
-		logger.Info("用户删除，清理相关数据", zap.Uint("user_id", event.UserID))
+		log.Info("用户删除，清理相关数据", zap.Uint("user_id", event.UserID))
+	}
+}
+
+// messageContext 根据消息头中的请求ID构造 context，供消费端日志使用
+func messageContext(ctx context.Context, message *sarama.ConsumerMessage) context.Context {
+	for _, header := range message.Headers {
+		if header != nil && string(header.Key) == RequestIDHeader {
+			return logger.WithRequestID(ctx, string(header.Value))
+		}
 	}
+	return ctx
 }
 
 // InitConsumerGroup 初始化消费者组
@@ -102,7 +117,7 @@ func InitConsumerGroup(brokers []string, groupID string, topics []string) (saram
 
 	// 启动消费
 	go func() {
-		handler := NewConsumerGroupHandler()
+		handler := NewConsumerGroupHandler(topics)
 		for {
 			if err := consumerGroup.Consume(context.Background(), topics, handler); err != nil {
 				logger.Error("Kafka 消费错误", zap.Error(err))
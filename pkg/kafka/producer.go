@@ -1,6 +1,9 @@
 package kafka
 
 import (
+	"context"
+	"sync"
+
 	"github.com/IBM/sarama"
 	"go.uber.org/zap"
 
@@ -11,17 +14,34 @@ import (
 type Producer interface {
 	SendMessage(topic string, message string) error
 	SendMessageWithKey(topic string, key string, message string) error
+	// SendMessageWithContext 发送消息，并将 ctx 中的请求ID写入消息头 RequestIDHeader
+	SendMessageWithContext(ctx context.Context, topic string, key string, message string) error
 	Close() error
 }
 
+// RequestIDHeader 携带请求ID的Kafka消息头，用于串联HTTP请求与消费端日志
+const RequestIDHeader = "X-Request-ID"
+
 type kafkaProducer struct {
 	producer sarama.SyncProducer
 }
 
-var defaultProducer Producer
+// 默认生产者，进程内唯一；producerMu 保证并发或重复初始化时只创建一个 sarama 生产者
+var (
+	producerMu      sync.Mutex
+	defaultProducer *kafkaProducer
+)
 
-// InitProducer 初始化生产者
+// InitProducer 初始化默认生产者
+// 已初始化时直接返回现有生产者，不会重复创建；初始化失败时不记录状态，可再次调用重试
 func InitProducer(brokers []string) (Producer, error) {
+	producerMu.Lock()
+	defer producerMu.Unlock()
+
+	if defaultProducer != nil {
+		return defaultProducer, nil
+	}
+
 	config := sarama.NewConfig()
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = 3
@@ -37,16 +57,50 @@ func InitProducer(brokers []string) (Producer, error) {
 	return defaultProducer, nil
 }
 
-// GetProducer 获取默认生产者
+// GetProducer 获取默认生产者，未初始化时返回丢弃消息的空实现，调用方无需判断 nil
 func GetProducer() Producer {
+	producerMu.Lock()
+	defer producerMu.Unlock()
+
+	if defaultProducer == nil {
+		return noopProducer{}
+	}
 	return defaultProducer
 }
 
+// SyncProducer 获取默认生产者底层的 sarama 生产者，未初始化时返回 nil
+func SyncProducer() sarama.SyncProducer {
+	producerMu.Lock()
+	defer producerMu.Unlock()
+
+	if defaultProducer == nil {
+		return nil
+	}
+	return defaultProducer.producer
+}
+
+// CloseProducer 关闭默认生产者，之后 GetProducer 返回空实现
+func CloseProducer() error {
+	producerMu.Lock()
+	defer producerMu.Unlock()
+
+	if defaultProducer == nil {
+		return nil
+	}
+	err := defaultProducer.Close()
+	defaultProducer = nil
+	return err
+}
+
 func (p *kafkaProducer) SendMessage(topic string, message string) error {
 	return p.SendMessageWithKey(topic, "", message)
 }
 
 func (p *kafkaProducer) SendMessageWithKey(topic string, key string, message string) error {
+	return p.SendMessageWithContext(context.Background(), topic, key, message)
+}
+
+func (p *kafkaProducer) SendMessageWithContext(ctx context.Context, topic string, key string, message string) error {
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.StringEncoder(message),
@@ -56,15 +110,22 @@ func (p *kafkaProducer) SendMessageWithKey(topic string, key string, message str
 		msg.Key = sarama.StringEncoder(key)
 	}
 
+	if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{
+			Key:   []byte(RequestIDHeader),
+			Value: []byte(requestID),
+		})
+	}
+
 	partition, offset, err := p.producer.SendMessage(msg)
 	if err != nil {
-		logger.Error("Kafka 发送消息失败",
+		logger.FromContext(ctx).Error("Kafka 发送消息失败",
 			zap.String("topic", topic),
 			zap.Error(err))
 		return err
 	}
 
-	logger.Debug("Kafka 消息已发送",
+	logger.FromContext(ctx).Debug("Kafka 消息已发送",
 		zap.String("topic", topic),
 		zap.Int32("partition", partition),
 		zap.Int64("offset", offset))
@@ -75,3 +136,23 @@ func (p *kafkaProducer) SendMessageWithKey(topic string, key string, message str
 func (p *kafkaProducer) Close() error {
 	return p.producer.Close()
 }
+
+// noopProducer Kafka 未初始化（未配置或连接失败）时使用的空实现，消息直接丢弃
+type noopProducer struct{}
+
+func (noopProducer) SendMessage(topic string, message string) error {
+	return nil
+}
+
+func (noopProducer) SendMessageWithKey(topic string, key string, message string) error {
+	return nil
+}
+
+func (noopProducer) SendMessageWithContext(ctx context.Context, topic string, key string, message string) error {
+	logger.FromContext(ctx).Debug("Kafka 未初始化，消息已丢弃", zap.String("topic", topic))
+	return nil
+}
+
+func (noopProducer) Close() error {
+	return nil
+}
@@ -0,0 +1,174 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// defaultStopTimeout 未指定时单个组件的关闭超时
+const defaultStopTimeout = 10 * time.Second
+
+// Component 受管理的组件
+type Component struct {
+	// Name 组件名，用于日志和按组件配置关闭超时
+	Name string
+	// Start 启动组件，不能阻塞；为 nil 时只参与关闭
+	Start func(ctx context.Context) error
+	// Stop 关闭组件，应在 ctx 取消时尽快返回；为 nil 时只参与启动
+	Stop func(ctx context.Context) error
+	// StopTimeout 关闭超时，为 0 时使用 Manager 的默认值
+	StopTimeout time.Duration
+}
+
+// Manager 按注册顺序启动组件，按相反顺序关闭
+// 依赖其他组件的组件应后注册，如 HTTP 服务在数据库之后注册，关闭时先停止接收请求再断开数据库
+type Manager struct {
+	stopTimeout time.Duration
+
+	mu         sync.Mutex
+	components []Component
+	started    []Component
+	stopped    bool
+
+	failOnce sync.Once
+	failed   chan error
+}
+
+// New 创建生命周期管理器，stopTimeout 为组件未指定关闭超时时使用的默认值
+func New(stopTimeout time.Duration) *Manager {
+	if stopTimeout <= 0 {
+		stopTimeout = defaultStopTimeout
+	}
+	return &Manager{
+		stopTimeout: stopTimeout,
+		failed:      make(chan error, 1),
+	}
+}
+
+// Register 注册组件，须在 Start 之前调用
+func (m *Manager) Register(c Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, c)
+}
+
+// Start 按注册顺序启动组件
+// 某个组件启动失败时，已启动的组件按相反顺序关闭后返回错误
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	components := m.components
+	m.mu.Unlock()
+
+	for _, c := range components {
+		if c.Start != nil {
+			begin := time.Now()
+			if err := c.Start(ctx); err != nil {
+				err = fmt.Errorf("启动组件 %s 失败: %w", c.Name, err)
+				if stopErr := m.Stop(context.Background()); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+				return err
+			}
+			logger.Debug("组件已启动", zap.String("component", c.Name), zap.Duration("elapsed", time.Since(begin)))
+		}
+
+		m.mu.Lock()
+		m.started = append(m.started, c)
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Fail 报告组件运行期间的致命错误，如 HTTP 服务监听中断，Wait 收到后返回
+// 只有第一次报告的错误生效
+func (m *Manager) Fail(err error) {
+	m.failOnce.Do(func() {
+		m.failed <- err
+	})
+}
+
+// Wait 阻塞直到收到指定信号、ctx 取消或有组件报告致命错误
+// 因信号或 ctx 取消返回时错误为 nil
+func (m *Manager) Wait(ctx context.Context, signals ...os.Signal) error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, signals...)
+	defer signal.Stop(quit)
+
+	select {
+	case sig := <-quit:
+		logger.Info("收到退出信号", zap.String("signal", sig.String()))
+		return nil
+	case <-ctx.Done():
+		return nil
+	case err := <-m.failed:
+		return err
+	}
+}
+
+// Stop 按启动的相反顺序关闭已启动的组件，重复调用时不做任何处理
+// 每个组件使用各自的关闭超时，且不超过 ctx 的截止时间；某个组件关闭失败或超时不影响后续组件
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.Stop == nil {
+			continue
+		}
+		if err := m.stopComponent(ctx, c); err != nil {
+			logger.Error("组件关闭失败", zap.String("component", c.Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("关闭组件 %s 失败: %w", c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopComponent 在超时时间内关闭组件，超时后不再等待 Stop 返回
+func (m *Manager) stopComponent(ctx context.Context, c Component) error {
+	timeout := c.StopTimeout
+	if timeout <= 0 {
+		timeout = m.stopTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	begin := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- c.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		logger.Info("组件已关闭", zap.String("component", c.Name), zap.Duration("elapsed", time.Since(begin)))
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("超过 %s 未完成: %w", timeout, ctx.Err())
+	}
+}
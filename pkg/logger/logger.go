@@ -3,6 +3,7 @@ package logger
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -23,6 +24,17 @@ type Config struct {
 	MaxAge     int    `mapstructure:"max_age" json:"max_age" yaml:"max_age"`             // 保留天数
 	Compress   bool   `mapstructure:"compress" json:"compress" yaml:"compress"`          // 是否压缩
 	Console    bool   `mapstructure:"console" json:"console" yaml:"console"`             // 是否输出到控制台
+
+	Sampling SamplingConfig `mapstructure:"sampling" json:"sampling" yaml:"sampling"` // 日志采样
+}
+
+// SamplingConfig 日志采样配置
+// 每个 Tick 周期内，相同级别和内容的日志先完整输出 Initial 条，之后每 Thereafter 条输出一条
+type SamplingConfig struct {
+	Enabled    bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	Initial    int  `mapstructure:"initial" json:"initial" yaml:"initial"`
+	Thereafter int  `mapstructure:"thereafter" json:"thereafter" yaml:"thereafter"`
+	Tick       int  `mapstructure:"tick" json:"tick" yaml:"tick"` // 采样周期（秒）
 }
 
 func Init(cfg *Config) *zap.Logger {
@@ -59,6 +71,11 @@ func Init(cfg *Config) *zap.Logger {
 	// 核心配置
 	core := zapcore.NewCore(encoder, writeSyncer, level)
 
+	// 日志采样，减少重复日志的输出量
+	if cfg.Sampling.Enabled {
+		core = newSamplerCore(core, cfg.Sampling)
+	}
+
 	// 添加调用者信息
 	log = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1), zap.AddStacktrace(zapcore.ErrorLevel))
 	sugar = log.Sugar()
@@ -66,6 +83,23 @@ func Init(cfg *Config) *zap.Logger {
 	return log
 }
 
+// newSamplerCore 使用采样配置包装日志核心，未设置的参数使用默认值
+func newSamplerCore(core zapcore.Core, cfg SamplingConfig) zapcore.Core {
+	tick := time.Duration(cfg.Tick) * time.Second
+	if tick <= 0 {
+		tick = time.Second
+	}
+	initial := cfg.Initial
+	if initial <= 0 {
+		initial = 100
+	}
+	thereafter := cfg.Thereafter
+	if thereafter <= 0 {
+		thereafter = 100
+	}
+	return zapcore.NewSamplerWithOptions(core, tick, initial, thereafter)
+}
+
 func getLogLevel(level string) zapcore.Level {
 	switch level {
 	case "debug":
@@ -0,0 +1,272 @@
+// Package metrics 进程内计数器和仪表盘，以 Prometheus 文本格式（text/plain; version=0.0.4）暴露
+// 只提供计数器和整数仪表盘，满足缓存命中率、熔断状态等简单指标；各实例的指标由 Prometheus 抓取后汇总
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// contentType Prometheus 文本格式的 Content-Type
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Counter 只增不减的计数器
+type Counter struct {
+	value atomic.Uint64
+}
+
+// Inc 计数加一
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add 计数增加 n
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+// Value 当前计数
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// CounterVec 按标签区分的一组计数器
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu       sync.RWMutex
+	counters map[string]*labeledCounter
+}
+
+type labeledCounter struct {
+	values []string
+	Counter
+}
+
+// Gauge 可增可减的整数仪表盘，如当前状态、当前连接数
+type Gauge struct {
+	value atomic.Int64
+}
+
+// Set 设置当前值
+func (g *Gauge) Set(n int64) {
+	g.value.Store(n)
+}
+
+// Add 当前值增加 n，n 为负数时减少
+func (g *Gauge) Add(n int64) {
+	g.value.Add(n)
+}
+
+// Value 当前值
+func (g *Gauge) Value() int64 {
+	return g.value.Load()
+}
+
+// GaugeVec 按标签区分的一组仪表盘
+type GaugeVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.RWMutex
+	gauges map[string]*labeledGauge
+}
+
+type labeledGauge struct {
+	values []string
+	Gauge
+}
+
+// collector 已注册的指标，按 Prometheus 文本格式输出
+type collector interface {
+	write(w io.Writer) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]collector)
+)
+
+// register 注册指标，名称重复时 panic
+func register(name string, c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("metrics: 指标 %s 重复注册", name))
+	}
+	registry[name] = c
+}
+
+// NewCounterVec 创建并注册计数器，名称重复时 panic，应在包初始化时创建
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{
+		name:     name,
+		help:     help,
+		labels:   labels,
+		counters: make(map[string]*labeledCounter),
+	}
+	register(name, v)
+	return v
+}
+
+// NewGaugeVec 创建并注册仪表盘，名称重复时 panic，应在包初始化时创建
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		gauges: make(map[string]*labeledGauge),
+	}
+	register(name, v)
+	return v
+}
+
+// WithLabelValues 获取标签值对应的计数器，不存在时创建；values 的个数必须与标签个数一致
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: 指标 %s 需要 %d 个标签值，实际 %d 个", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	c, ok := v.counters[key]
+	v.mu.RUnlock()
+	if ok {
+		return &c.Counter
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok = v.counters[key]; !ok {
+		c = &labeledCounter{values: values}
+		v.counters[key] = c
+	}
+	return &c.Counter
+}
+
+// write 按 Prometheus 文本格式输出，同一指标的样本按标签值排序
+func (v *CounterVec) write(w io.Writer) error {
+	v.mu.RLock()
+	samples := make([]sample, 0, len(v.counters))
+	for _, c := range v.counters {
+		samples = append(samples, sample{values: c.values, value: strconv.FormatUint(c.Value(), 10)})
+	}
+	v.mu.RUnlock()
+	return writeSamples(w, v.name, v.help, "counter", v.labels, samples)
+}
+
+// WithLabelValues 获取标签值对应的仪表盘，不存在时创建；values 的个数必须与标签个数一致
+func (v *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: 指标 %s 需要 %d 个标签值，实际 %d 个", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	g, ok := v.gauges[key]
+	v.mu.RUnlock()
+	if ok {
+		return &g.Gauge
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if g, ok = v.gauges[key]; !ok {
+		g = &labeledGauge{values: values}
+		v.gauges[key] = g
+	}
+	return &g.Gauge
+}
+
+// write 按 Prometheus 文本格式输出，同一指标的样本按标签值排序
+func (v *GaugeVec) write(w io.Writer) error {
+	v.mu.RLock()
+	samples := make([]sample, 0, len(v.gauges))
+	for _, g := range v.gauges {
+		samples = append(samples, sample{values: g.values, value: strconv.FormatInt(g.Value(), 10)})
+	}
+	v.mu.RUnlock()
+	return writeSamples(w, v.name, v.help, "gauge", v.labels, samples)
+}
+
+// sample 一个标签组合的当前值
+type sample struct {
+	values []string
+	value  string
+}
+
+// writeSamples 输出一个指标的 HELP、TYPE 和全部样本，没有样本时不输出
+func writeSamples(w io.Writer, name, help, typ string, labels []string, samples []sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].values, "\xff") < strings.Join(samples[j].values, "\xff")
+	})
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		pairs := make([]string, len(labels))
+		for i, label := range labels {
+			pairs[i] = label + `="` + labelEscaper.Replace(s.values[i]) + `"`
+		}
+		series := name
+		if len(pairs) > 0 {
+			series += "{" + strings.Join(pairs, ",") + "}"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", series, s.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteText 按名称顺序以 Prometheus 文本格式输出全部已注册的指标
+func WriteText(w io.Writer) error {
+	registryMu.RLock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	vecs := make([]collector, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		vecs[i] = registry[name]
+	}
+	registryMu.RUnlock()
+
+	for _, v := range vecs {
+		if err := v.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler 供 Prometheus 抓取的 HTTP 处理器
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_ = WriteText(w)
+	})
+}
+
+// labelEscaper 标签值中的反斜杠、双引号和换行需要转义
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeHelp HELP 文本中的反斜杠和换行需要转义
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
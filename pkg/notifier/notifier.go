@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// 通知渠道
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// ErrChannelNotConfigured 通知渠道未配置
+var ErrChannelNotConfigured = errors.New("通知渠道未配置")
+
+// Message 通知消息
+type Message struct {
+	To      string            `json:"to"`      // 接收者（邮箱地址等，Webhook 可为空）
+	Subject string            `json:"subject"` // 标题
+	Body    string            `json:"body"`    // 正文
+	Extra   map[string]string `json:"extra,omitempty"`
+}
+
+// Notifier 通知发送接口
+type Notifier interface {
+	// Channel 通知渠道标识
+	Channel() string
+	// Send 发送通知
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Dispatcher 按渠道分发通知
+type Dispatcher struct {
+	notifiers map[string]Notifier
+}
+
+// NewDispatcher 创建通知分发器
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{notifiers: make(map[string]Notifier, len(notifiers))}
+	for _, n := range notifiers {
+		d.notifiers[n.Channel()] = n
+	}
+	return d
+}
+
+// Send 通过指定渠道发送通知，分发器为 nil 时视为未配置任何渠道
+func (d *Dispatcher) Send(ctx context.Context, channel string, msg *Message) error {
+	if d == nil {
+		return fmt.Errorf("%w: %s", ErrChannelNotConfigured, channel)
+	}
+	n, ok := d.notifiers[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrChannelNotConfigured, channel)
+	}
+	return n.Send(ctx, msg)
+}
+
+// Channels 已配置的通知渠道
+func (d *Dispatcher) Channels() []string {
+	if d == nil {
+		return []string{}
+	}
+	channels := make([]string, 0, len(d.notifiers))
+	for channel := range d.notifiers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
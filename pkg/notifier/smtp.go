@@ -0,0 +1,80 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig SMTP配置
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMTPNotifier 邮件通知
+type SMTPNotifier struct {
+	config SMTPConfig
+}
+
+// NewSMTPNotifier 创建邮件通知实例
+func NewSMTPNotifier(config SMTPConfig) *SMTPNotifier {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &SMTPNotifier{config: config}
+}
+
+// Channel 通知渠道标识
+func (n *SMTPNotifier) Channel() string {
+	return ChannelEmail
+}
+
+// Send 发送邮件
+func (n *SMTPNotifier) Send(ctx context.Context, msg *Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("邮件接收者不能为空")
+	}
+
+	addr := net.JoinHostPort(n.config.Host, fmt.Sprintf("%d", n.config.Port))
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+	}
+
+	body := strings.Join([]string{
+		"From: " + n.config.From,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	ctx, cancel := context.WithTimeout(ctx, n.config.Timeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(addr, auth, n.config.From, []string{msg.To}, []byte(body))
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("发送邮件失败: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("发送邮件超时: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,215 @@
+// Package password 密码哈希与校验
+// 哈希值自带算法标识：bcrypt 为 $2a$/$2b$/$2y$ 前缀，argon2id 为 PHC 格式 $argon2id$v=19$m=...,t=...,p=...$salt$hash，
+// 校验时按前缀选择算法，两种哈希可以共存；算法或参数与当前配置不一致时提示需要重新哈希，用于登录时平滑升级
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 支持的哈希算法
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// argon2idPrefix argon2id 哈希的前缀
+const argon2idPrefix = "$argon2id$"
+
+// ErrUnsupportedAlgorithm 不支持的哈希算法
+var ErrUnsupportedAlgorithm = errors.New("不支持的密码哈希算法")
+
+// Argon2Params argon2id 参数
+type Argon2Params struct {
+	Memory      uint32 // 内存，KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+
+	// MaxConcurrent 同时进行的 argon2id 计算数上限，每个计算占用 Memory 大小的内存，超出时排队等待；0 表示 CPU 核数
+	MaxConcurrent int
+}
+
+// DefaultArgon2Params argon2id 默认参数（64 MiB、3 次迭代、2 并行度），参考 RFC 9106 的推荐配置
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hasher 按配置的算法生成密码哈希，校验时兼容全部支持的算法
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     Argon2Params
+
+	argon2Slots chan struct{} // 限制同时进行的 argon2id 计算，避免并发登录耗尽内存
+}
+
+// NewHasher 创建密码哈希器，algorithm 为空时使用 bcrypt；bcryptCost 不在有效范围内时使用 bcrypt.DefaultCost，
+// argon2 中为 0 的参数使用 DefaultArgon2Params 中的值
+func NewHasher(algorithm string, bcryptCost int, params Argon2Params) (*Hasher, error) {
+	algorithm = strings.ToLower(algorithm)
+	switch algorithm {
+	case "":
+		algorithm = AlgorithmBcrypt
+	case AlgorithmBcrypt, AlgorithmArgon2id:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		bcryptCost = bcrypt.DefaultCost
+	}
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2Params.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2Params.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2Params.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2Params.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2Params.KeyLength
+	}
+	if params.MaxConcurrent <= 0 {
+		params.MaxConcurrent = runtime.NumCPU()
+	}
+
+	return &Hasher{
+		algorithm:   algorithm,
+		bcryptCost:  bcryptCost,
+		argon2:      params,
+		argon2Slots: make(chan struct{}, params.MaxConcurrent),
+	}, nil
+}
+
+// Algorithm 生成新哈希使用的算法
+func (h *Hasher) Algorithm() string {
+	return h.algorithm
+}
+
+// Hash 使用配置的算法生成密码哈希
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.algorithm == AlgorithmArgon2id {
+		return h.hashArgon2id(password)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// Check 校验密码，needsRehash 表示密码正确但哈希的算法或参数与当前配置不一致，应重新哈希后保存
+func (h *Hasher) Check(hashed, password string) (ok, needsRehash bool) {
+	if strings.HasPrefix(hashed, argon2idPrefix) {
+		params, salt, key, err := decodeArgon2id(hashed)
+		if err != nil {
+			return false, false
+		}
+		computed := h.argon2IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false
+		}
+		return true, h.algorithm != AlgorithmArgon2id ||
+			params.Memory != h.argon2.Memory ||
+			params.Iterations != h.argon2.Iterations ||
+			params.Parallelism != h.argon2.Parallelism ||
+			uint32(len(key)) != h.argon2.KeyLength
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)) != nil {
+		return false, false
+	}
+	if h.algorithm != AlgorithmBcrypt {
+		return true, true
+	}
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return true, err != nil || cost < h.bcryptCost
+}
+
+// hashArgon2id 生成 PHC 格式的 argon2id 哈希
+func (h *Hasher) hashArgon2id(password string) (string, error) {
+	salt := make([]byte, h.argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("生成盐值失败: %w", err)
+	}
+	key := h.argon2IDKey([]byte(password), salt, h.argon2.Iterations, h.argon2.Memory, h.argon2.Parallelism, h.argon2.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, h.argon2.Memory, h.argon2.Iterations, h.argon2.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// argon2IDKey 计算 argon2id 哈希，同时进行的计算数达到上限时等待
+func (h *Hasher) argon2IDKey(password, salt []byte, iterations, memory uint32, parallelism uint8, keyLen uint32) []byte {
+	h.argon2Slots <- struct{}{}
+	defer func() { <-h.argon2Slots }()
+	return argon2.IDKey(password, salt, iterations, memory, parallelism, keyLen)
+}
+
+// decodeArgon2id 解析 PHC 格式的 argon2id 哈希
+func decodeArgon2id(hashed string) (params Argon2Params, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("argon2id 哈希格式错误")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("argon2id 版本格式错误: %w", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("不支持的 argon2id 版本: %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("argon2id 参数格式错误: %w", err)
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, fmt.Errorf("argon2id 盐值格式错误: %w", err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, fmt.Errorf("argon2id 哈希值格式错误: %w", err)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+var (
+	defaultHasher, _ = NewHasher(AlgorithmBcrypt, bcrypt.DefaultCost, DefaultArgon2Params)
+	defaultHasherMu  sync.RWMutex
+)
+
+// Default 全局密码哈希器，未调用 SetDefault 时使用 bcrypt 默认参数
+func Default() *Hasher {
+	defaultHasherMu.RLock()
+	defer defaultHasherMu.RUnlock()
+	return defaultHasher
+}
+
+// SetDefault 设置全局密码哈希器，应在启动时按配置调用
+func SetDefault(h *Hasher) {
+	defaultHasherMu.Lock()
+	defer defaultHasherMu.Unlock()
+	defaultHasher = h
+}
@@ -0,0 +1,224 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params 测试用的小参数，避免每次计算占用 64 MiB
+var testArgon2Params = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func newTestHasher(t *testing.T, algorithm string, bcryptCost int, params Argon2Params) *Hasher {
+	t.Helper()
+
+	h, err := NewHasher(algorithm, bcryptCost, params)
+	if err != nil {
+		t.Fatalf("NewHasher(%q) error = %v", algorithm, err)
+	}
+	return h
+}
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		want      string
+		wantErr   bool
+	}{
+		{"为空时使用 bcrypt", "", AlgorithmBcrypt, false},
+		{"bcrypt", "bcrypt", AlgorithmBcrypt, false},
+		{"忽略大小写", "Argon2ID", AlgorithmArgon2id, false},
+		{"不支持的算法", "md5", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHasher(tt.algorithm, 0, Argon2Params{})
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedAlgorithm) {
+					t.Fatalf("error = %v，期望 ErrUnsupportedAlgorithm", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if h.Algorithm() != tt.want {
+				t.Errorf("Algorithm() = %q, want %q", h.Algorithm(), tt.want)
+			}
+		})
+	}
+
+	h := newTestHasher(t, AlgorithmBcrypt, 100, Argon2Params{})
+	if h.bcryptCost != bcrypt.DefaultCost {
+		t.Errorf("无效的 bcrypt cost 未回退到默认值: %d", h.bcryptCost)
+	}
+	if h.argon2.Memory != DefaultArgon2Params.Memory || h.argon2.KeyLength != DefaultArgon2Params.KeyLength {
+		t.Errorf("argon2 参数未使用默认值: %+v", h.argon2)
+	}
+	if h.argon2.MaxConcurrent <= 0 || cap(h.argon2Slots) != h.argon2.MaxConcurrent {
+		t.Errorf("argon2 并发上限 = %d，名额数 = %d", h.argon2.MaxConcurrent, cap(h.argon2Slots))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	bcryptHasher := newTestHasher(t, AlgorithmBcrypt, bcrypt.MinCost, testArgon2Params)
+	argon2Hasher := newTestHasher(t, AlgorithmArgon2id, bcrypt.MinCost, testArgon2Params)
+
+	bcryptHash, err := bcryptHasher.Hash("secret")
+	if err != nil {
+		t.Fatalf("bcrypt Hash() error = %v", err)
+	}
+	argon2Hash, err := argon2Hasher.Hash("secret")
+	if err != nil {
+		t.Fatalf("argon2id Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(argon2Hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("argon2id 哈希格式 = %q", argon2Hash)
+	}
+
+	tests := []struct {
+		name     string
+		hasher   *Hasher
+		hashed   string
+		password string
+		wantOK   bool
+	}{
+		{"bcrypt 密码正确", bcryptHasher, bcryptHash, "secret", true},
+		{"bcrypt 密码错误", bcryptHasher, bcryptHash, "Secret", false},
+		{"argon2id 密码正确", argon2Hasher, argon2Hash, "secret", true},
+		{"argon2id 密码错误", argon2Hasher, argon2Hash, "Secret", false},
+		{"bcrypt 配置下校验 argon2id 哈希", bcryptHasher, argon2Hash, "secret", true},
+		{"argon2id 配置下校验 bcrypt 哈希", argon2Hasher, bcryptHash, "secret", true},
+		{"argon2id 哈希被截断", argon2Hasher, argon2Hash[:len(argon2Hash)-4], "secret", false},
+		{"空哈希", bcryptHasher, "", "secret", false},
+		{"未知格式", bcryptHasher, "plaintext", "plaintext", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, _ := tt.hasher.Check(tt.hashed, tt.password)
+			if ok != tt.wantOK {
+				t.Errorf("Check() ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDecodeArgon2id(t *testing.T) {
+	tests := []struct {
+		name    string
+		hashed  string
+		want    Argon2Params
+		wantErr bool
+	}{
+		{
+			name:   "标准格式",
+			hashed: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+			want:   Argon2Params{Memory: 65536, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32},
+		},
+		{name: "段数不足", hashed: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA", wantErr: true},
+		{name: "版本不支持", hashed: "$argon2id$v=16$m=65536,t=3,p=2$c2FsdA$a2V5", wantErr: true},
+		{name: "版本格式错误", hashed: "$argon2id$19$m=65536,t=3,p=2$c2FsdA$a2V5", wantErr: true},
+		{name: "参数格式错误", hashed: "$argon2id$v=19$t=3,m=65536,p=2$c2FsdA$a2V5", wantErr: true},
+		{name: "盐值不是 base64", hashed: "$argon2id$v=19$m=65536,t=3,p=2$!!!$a2V5", wantErr: true},
+		{name: "哈希值不是 base64", hashed: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$!!!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _, _, err := decodeArgon2id(tt.hashed)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望解析失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeArgon2id() error = %v", err)
+			}
+			if params != tt.want {
+				t.Errorf("decodeArgon2id() = %+v, want %+v", params, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNeedsRehash(t *testing.T) {
+	old := newTestHasher(t, AlgorithmArgon2id, bcrypt.MinCost, testArgon2Params)
+	argon2Hash, err := old.Hash("secret")
+	if err != nil {
+		t.Fatalf("argon2id Hash() error = %v", err)
+	}
+	bcryptHash, err := newTestHasher(t, AlgorithmBcrypt, bcrypt.MinCost, testArgon2Params).Hash("secret")
+	if err != nil {
+		t.Fatalf("bcrypt Hash() error = %v", err)
+	}
+
+	withParams := func(change func(p *Argon2Params)) Argon2Params {
+		p := testArgon2Params
+		change(&p)
+		return p
+	}
+
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		hashed string
+		want   bool
+	}{
+		{"argon2id 参数一致", old, argon2Hash, false},
+		{"argon2id 内存变化", newTestHasher(t, AlgorithmArgon2id, 0, withParams(func(p *Argon2Params) { p.Memory = 128 })), argon2Hash, true},
+		{"argon2id 迭代次数变化", newTestHasher(t, AlgorithmArgon2id, 0, withParams(func(p *Argon2Params) { p.Iterations = 2 })), argon2Hash, true},
+		{"argon2id 并行度变化", newTestHasher(t, AlgorithmArgon2id, 0, withParams(func(p *Argon2Params) { p.Parallelism = 2 })), argon2Hash, true},
+		{"argon2id 哈希长度变化", newTestHasher(t, AlgorithmArgon2id, 0, withParams(func(p *Argon2Params) { p.KeyLength = 64 })), argon2Hash, true},
+		{"argon2id 并发上限变化", newTestHasher(t, AlgorithmArgon2id, 0, withParams(func(p *Argon2Params) { p.MaxConcurrent = 1 })), argon2Hash, false},
+		{"argon2id 改为 bcrypt", newTestHasher(t, AlgorithmBcrypt, bcrypt.MinCost, testArgon2Params), argon2Hash, true},
+		{"bcrypt cost 一致", newTestHasher(t, AlgorithmBcrypt, bcrypt.MinCost, testArgon2Params), bcryptHash, false},
+		{"bcrypt cost 提高", newTestHasher(t, AlgorithmBcrypt, bcrypt.MinCost+1, testArgon2Params), bcryptHash, true},
+		{"bcrypt 改为 argon2id", old, bcryptHash, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash := tt.hasher.Check(tt.hashed, "secret")
+			if !ok {
+				t.Fatal("Check() 密码校验失败")
+			}
+			if needsRehash != tt.want {
+				t.Errorf("Check() needsRehash = %v, want %v", needsRehash, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgon2ConcurrencyLimit(t *testing.T) {
+	params := testArgon2Params
+	params.MaxConcurrent = 1
+	h := newTestHasher(t, AlgorithmArgon2id, 0, params)
+
+	// 占满名额，之后的计算须等待名额归还
+	h.argon2Slots <- struct{}{}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := h.Hash("secret")
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("名额已满时 argon2id 计算不应开始")
+	case <-time.After(50 * time.Millisecond):
+	}
+	<-h.argon2Slots
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("名额归还后 argon2id 计算未完成")
+	}
+}
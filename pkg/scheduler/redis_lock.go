@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+const lockKeyPrefix = "charlotte:scheduler:lock:"
+
+// 仅删除自己持有的锁，避免任务超时后锁被其他实例获取时误删
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker 基于 Redis SET NX 的分布式锁
+type RedisLocker struct {
+	client *redis.Client
+}
+
+// NewRedisLocker 创建 Redis 分布式锁
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+// TryLock 尝试获取锁，锁在 ttl 后自动过期，防止持有实例崩溃后永久占用
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	key = lockKeyPrefix + key
+	ok, err := l.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	unlock := func() {
+		// 任务 ctx 可能已取消，释放锁使用独立的 context
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := unlockScript.Run(ctx, l.client, []string{key}, token).Err(); err != nil {
+			logger.Warn("释放定时任务锁失败", zap.String("key", key), zap.Error(err))
+		}
+	}
+	return unlock, true, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
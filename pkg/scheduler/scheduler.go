@@ -0,0 +1,254 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// ErrJobExists 同名任务已注册
+var ErrJobExists = errors.New("定时任务已存在")
+
+// Job 定时任务
+type Job struct {
+	// Name 任务名，全局唯一，同时作为分布式锁的键
+	Name string
+	// Spec 调度规则，见 ParseSpec
+	Spec string
+	// Exclusive 为 true 时通过分布式锁保证多实例中每次调度只有一个实例执行；
+	// 清理本实例内存状态的任务应为 false
+	Exclusive bool
+	// Timeout 单次执行超时，为 0 时使用调度间隔
+	Timeout time.Duration
+	// Run 任务逻辑，应在 ctx 取消时尽快返回
+	Run func(ctx context.Context) error
+}
+
+// JobStatus 任务运行状态
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Spec           string     `json:"spec"`
+	Exclusive      bool       `json:"exclusive"`
+	Running        bool       `json:"running"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"`
+	RunCount       int64      `json:"run_count"`
+	FailCount      int64      `json:"fail_count"`
+	SkipCount      int64      `json:"skip_count"` // 其他实例持有锁而跳过的次数
+}
+
+// Locker 分布式锁
+type Locker interface {
+	// TryLock 尝试获取锁，未获取到时 ok 为 false；获取成功后需调用 unlock 释放
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	status   JobStatus
+}
+
+// Scheduler 定时任务调度器，统一管理后台任务的注册、互斥执行、运行状态和关闭
+type Scheduler struct {
+	locker Locker
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	started bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// New 创建调度器，locker 为 nil 时互斥任务仅在本实例内串行执行
+func New(locker Locker) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		locker:  locker,
+		entries: make(map[string]*entry),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Register 注册任务，调度器已启动时立即开始调度
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" {
+		return errors.New("定时任务名不能为空")
+	}
+	if job.Run == nil {
+		return fmt.Errorf("定时任务 %s 未设置执行函数", job.Name)
+	}
+	schedule, err := ParseSpec(job.Spec)
+	if err != nil {
+		return fmt.Errorf("定时任务 %s: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[job.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, job.Name)
+	}
+	e := &entry{
+		job:      job,
+		schedule: schedule,
+		status:   JobStatus{Name: job.Name, Spec: job.Spec, Exclusive: job.Exclusive},
+	}
+	s.entries[job.Name] = e
+
+	if s.started && s.ctx.Err() == nil {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+	return nil
+}
+
+// Start 启动调度，重复调用无副作用
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+	logger.Info("定时任务调度器已启动", zap.Int("jobs", len(s.entries)))
+}
+
+// Stop 停止调度并取消正在执行的任务，等待任务返回直到 ctx 结束
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Info("定时任务调度器已停止")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待定时任务退出超时: %w", ctx.Err())
+	}
+}
+
+// Statuses 所有任务的运行状态，按任务名排序
+func (s *Scheduler) Statuses() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		statuses = append(statuses, e.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// loop 按调度规则循环执行任务，上一次执行结束后才计算下一次时间，同一任务不会并发执行
+func (s *Scheduler) loop(e *entry) {
+	defer s.wg.Done()
+
+	for {
+		next := e.schedule.Next(time.Now())
+		s.updateStatus(e, func(st *JobStatus) { st.NextRun = &next })
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			s.updateStatus(e, func(st *JobStatus) { st.NextRun = nil })
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(e)
+	}
+}
+
+// runOnce 执行一次任务，互斥任务需先获取分布式锁
+func (s *Scheduler) runOnce(e *entry) {
+	timeout := e.job.Timeout
+	if timeout <= 0 {
+		now := time.Now()
+		timeout = e.schedule.Next(now).Sub(now)
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	log := logger.GetLogger().With(zap.String("job", e.job.Name))
+
+	if e.job.Exclusive && s.locker != nil {
+		unlock, ok, err := s.locker.TryLock(ctx, e.job.Name, timeout)
+		if err != nil {
+			log.Warn("获取定时任务锁失败，本次跳过", zap.Error(err))
+			s.updateStatus(e, func(st *JobStatus) { st.SkipCount++ })
+			return
+		}
+		if !ok {
+			log.Debug("定时任务正由其他实例执行，本次跳过")
+			s.updateStatus(e, func(st *JobStatus) { st.SkipCount++ })
+			return
+		}
+		defer unlock()
+	}
+
+	start := time.Now()
+	s.updateStatus(e, func(st *JobStatus) { st.Running = true })
+
+	err := safeRun(ctx, e.job.Run)
+	duration := time.Since(start)
+
+	s.updateStatus(e, func(st *JobStatus) {
+		st.Running = false
+		st.LastRun = &start
+		st.LastDurationMs = duration.Milliseconds()
+		st.RunCount++
+		st.LastError = ""
+		if err != nil {
+			st.FailCount++
+			st.LastError = err.Error()
+		}
+	})
+
+	if err != nil {
+		log.Error("定时任务执行失败", zap.Duration("duration", duration), zap.Error(err))
+		return
+	}
+	log.Info("定时任务执行完成", zap.Duration("duration", duration))
+}
+
+func (s *Scheduler) updateStatus(e *entry, fn func(st *JobStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&e.status)
+}
+
+// safeRun 执行任务并将 panic 转换为错误，避免单个任务拖垮进程
+func safeRun(ctx context.Context, run func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			logger.Error("定时任务 panic", zap.Any("panic", r), zap.String("stack", string(debug.Stack())))
+		}
+	}()
+	return run(ctx)
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule 调度规则
+type Schedule interface {
+	// Next 返回 t 之后的下一次执行时间
+	Next(t time.Time) time.Time
+}
+
+// ParseSpec 解析调度规则，支持：
+//   - Go 时长或 "@every <时长>"：按固定间隔执行，如 "10m"、"@every 1h30m"
+//   - "@hourly"：每个整点执行
+//   - "@daily"：每天本地时间零点执行
+func ParseSpec(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "":
+		return nil, fmt.Errorf("调度规则不能为空")
+	case "@hourly":
+		return truncateSchedule(time.Hour), nil
+	case "@daily":
+		return dailySchedule{}, nil
+	}
+
+	interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every")))
+	if err != nil {
+		return nil, fmt.Errorf("无效的调度规则 %q", spec)
+	}
+	if interval < time.Second {
+		return nil, fmt.Errorf("调度间隔不能小于 1s: %q", spec)
+	}
+	return everySchedule(interval), nil
+}
+
+// everySchedule 固定间隔
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// truncateSchedule 对齐到整周期（基于 Unix 零点，不受时区影响）
+type truncateSchedule time.Duration
+
+func (s truncateSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(s)).Add(time.Duration(s))
+}
+
+// dailySchedule 每天本地时间零点
+type dailySchedule struct{}
+
+func (dailySchedule) Next(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,146 @@
+// Package tracing OpenTelemetry 链路追踪
+// 未调用 Init 时使用 OpenTelemetry 默认的空实现，Start 返回的 span 不做任何记录
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/VennLe/charlotte/pkg/logger"
+)
+
+// InstrumentationName 追踪器名称
+const InstrumentationName = "github.com/VennLe/charlotte"
+
+// Config 链路追踪配置
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+	Endpoint       string  // OTLP/HTTP 接收地址，如 otel-collector:4318
+	Insecure       bool    // 使用 HTTP 而非 HTTPS
+	SampleRatio    float64 // 采样率 0~1，上游已采样的请求始终跟随上游决定
+}
+
+// Init 初始化全局 TracerProvider 和 W3C Trace Context 传播器，返回的函数用于关闭时刷新未发送的 span
+func Init(cfg Config) (func(context.Context) error, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("创建 OTLP 导出器失败: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("创建追踪资源失败: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithIDGenerator(requestIDGenerator{}),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer 获取全局追踪器
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start 创建子 span，用法：ctx, span := tracing.Start(ctx, "UserService.Login"); defer span.End()
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError 在 span 上记录错误并标记为失败，err 为 nil 时不做处理
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// TraceIDFromContext 获取当前 span 的追踪ID，没有有效 span 时返回空字符串
+func TraceIDFromContext(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// requestIDGenerator 根 span 的追踪ID取自请求ID（32位十六进制时），使日志中的 request_id 与追踪ID一致
+type requestIDGenerator struct{}
+
+// NewIDs 生成根 span 的追踪ID和 span ID
+func (requestIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	traceID, ok := ParseTraceID(logger.RequestIDFromContext(ctx))
+	if !ok {
+		_, _ = rand.Read(traceID[:])
+	}
+	return traceID, newSpanID()
+}
+
+// NewSpanID 生成子 span ID
+func (requestIDGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return newSpanID()
+}
+
+func newSpanID() trace.SpanID {
+	var spanID trace.SpanID
+	for !spanID.IsValid() {
+		_, _ = rand.Read(spanID[:])
+	}
+	return spanID
+}
+
+// ParseTraceID 解析32位十六进制的追踪ID，全零或格式不符时返回 false
+func ParseTraceID(s string) (trace.TraceID, bool) {
+	var traceID trace.TraceID
+	if len(s) != 32 {
+		return traceID, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(s)); err != nil {
+		return trace.TraceID{}, false
+	}
+	return traceID, traceID.IsValid()
+}
+
+// TraceIDFromTraceparent 从 W3C traceparent 请求头（00-<trace-id>-<parent-id>-<flags>）中取出追踪ID
+func TraceIDFromTraceparent(header string) (string, bool) {
+	if len(header) < 55 || header[2] != '-' || header[35] != '-' {
+		return "", false
+	}
+	traceID := header[3:35]
+	if _, ok := ParseTraceID(traceID); !ok {
+		return "", false
+	}
+	return traceID, true
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"net/netip"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP 获取真实客户端IP
+// 仅当直连地址属于受信任代理（engine.SetTrustedProxies）时才从 X-Forwarded-For 中由右向左
+// 解析出第一个不受信任的地址，否则使用连接的远端地址，避免客户端伪造请求头绕过按IP的限制。
+// IPv4 映射的 IPv6 地址统一转换为 IPv4，保证同一客户端得到相同的限流键
+func ClientIP(c *gin.Context) string {
+	ip := c.ClientIP()
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		return addr.Unmap().String()
+	}
+	return ip
+}
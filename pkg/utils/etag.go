@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListETag 根据列表的最大更新时间、当前页条数和总数生成弱ETag
+// 列表中任一记录更新、新增或删除都会导致ETag变化；variants 区分同一列表的不同表示（如稀疏字段集）
+func ListETag(maxUpdatedAt time.Time, count int, total int64, variants ...string) string {
+	key := fmt.Sprintf("%d-%d-%d", maxUpdatedAt.UnixNano(), count, total)
+	if len(variants) > 0 {
+		key += "-" + strings.Join(variants, "-")
+	}
+	sum := sha1.Sum([]byte(key))
+	return fmt.Sprintf(`W/"%x"`, sum[:8])
+}
+
+// NotModified 条件响应检查
+// 设置ETag响应头，若请求的 If-None-Match 与之匹配则返回304并返回true，调用方应直接结束处理
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+
+	ifNoneMatch := c.GetHeader("If-None-Match")
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"encoding/xml"
+	"reflect"
+	"slices"
+)
+
+// ComputedColumn 导出时由函数计算的列（如由生日计算的年龄、关联数据拼接的名称），不对应结构体字段
+// 计算列追加在结构体字段之后，同样受 OmitFields 控制
+type ComputedColumn struct {
+	// Name 列名：CSV/Excel 的表头，JSON 的字段名，XML 的元素名（须为合法的 XML 名称）
+	Name string
+	// Value 计算一行的列值，row 为导出数据中的一个元素
+	Value func(row interface{}) string
+}
+
+// computedColumns 未被 OmitFields 去掉的计算列
+func (c *ExportConfig) computedColumns() []ComputedColumn {
+	if len(c.OmitFields) == 0 {
+		return c.ComputedColumns
+	}
+	columns := make([]ComputedColumn, 0, len(c.ComputedColumns))
+	for _, column := range c.ComputedColumns {
+		if !c.omitted(column.Name) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// computedHeaders 在表头后追加计算列的列名，不修改调用方传入的表头切片
+func computedHeaders(headers []string, config *ExportConfig) []string {
+	headers = slices.Clip(headers)
+	for _, column := range config.computedColumns() {
+		headers = append(headers, column.Name)
+	}
+	return headers
+}
+
+// computedValue 计算一行的列值，nil 指针元素的计算列为空
+func computedValue(column ComputedColumn, elem reflect.Value) string {
+	if (elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface) && elem.IsNil() {
+		return ""
+	}
+	return column.Value(elem.Interface())
+}
+
+// appendComputedValues 追加一行的计算列值
+func appendComputedValues(record []string, elem reflect.Value, config *ExportConfig, sanitize bool) []string {
+	for _, column := range config.computedColumns() {
+		value := computedValue(column, elem)
+		if sanitize {
+			value = sanitizeCSVFormula(value)
+		}
+		record = append(record, value)
+	}
+	return record
+}
+
+// encodeXMLComputed 将计算列编码为记录的子元素
+func encodeXMLComputed(encoder *xml.Encoder, elem reflect.Value, config *ExportConfig) error {
+	for _, column := range config.computedColumns() {
+		value := computedValue(column, elem)
+		if err := encoder.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: column.Name}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
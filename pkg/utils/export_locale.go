@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultExportDateFormat 默认导出日期时间格式，带时区和纳秒，可无损导入
+const defaultExportDateFormat = time.RFC3339Nano
+
+// ExportLocale 导出区域格式
+// DateFormat 为 Go 时间布局，用于 CSV 等文本输出；ExcelDateFormat 为 Excel 数字格式代码，
+// Excel 导出时日期和数字写为原生单元格，数字的小数点和千分位由打开文件的电子表格按系统区域显示
+type ExportLocale struct {
+	DateFormat         string // Go 时间布局，如 02/01/2006 15:04:05
+	ExcelDateFormat    string // Excel 日期格式代码，如 dd/mm/yyyy hh:mm:ss
+	DecimalSeparator   string // 小数点
+	ThousandsSeparator string // 千分位分隔符，为空时不分组
+}
+
+// exportLocales 内置区域格式预设，键为小写的 BCP 47 语言标签
+var exportLocales = map[string]ExportLocale{
+	"en-us": {DateFormat: "01/02/2006 15:04:05", ExcelDateFormat: "mm/dd/yyyy hh:mm:ss", DecimalSeparator: ".", ThousandsSeparator: ","},
+	"en-gb": {DateFormat: "02/01/2006 15:04:05", ExcelDateFormat: "dd/mm/yyyy hh:mm:ss", DecimalSeparator: ".", ThousandsSeparator: ","},
+	"de-de": {DateFormat: "02.01.2006 15:04:05", ExcelDateFormat: "dd.mm.yyyy hh:mm:ss", DecimalSeparator: ",", ThousandsSeparator: "."},
+	"fr-fr": {DateFormat: "02/01/2006 15:04:05", ExcelDateFormat: "dd/mm/yyyy hh:mm:ss", DecimalSeparator: ",", ThousandsSeparator: " "},
+	"es-es": {DateFormat: "02/01/2006 15:04:05", ExcelDateFormat: "dd/mm/yyyy hh:mm:ss", DecimalSeparator: ",", ThousandsSeparator: "."},
+	"it-it": {DateFormat: "02/01/2006 15:04:05", ExcelDateFormat: "dd/mm/yyyy hh:mm:ss", DecimalSeparator: ",", ThousandsSeparator: "."},
+	"nl-nl": {DateFormat: "02-01-2006 15:04:05", ExcelDateFormat: "dd-mm-yyyy hh:mm:ss", DecimalSeparator: ",", ThousandsSeparator: "."},
+	"zh-cn": {DateFormat: "2006-01-02 15:04:05", ExcelDateFormat: "yyyy-mm-dd hh:mm:ss", DecimalSeparator: ".", ThousandsSeparator: ","},
+	"ja-jp": {DateFormat: "2006/01/02 15:04:05", ExcelDateFormat: "yyyy/mm/dd hh:mm:ss", DecimalSeparator: ".", ThousandsSeparator: ","},
+}
+
+// LookupExportLocale 查找内置区域格式，名称不区分大小写，支持 de_DE 写法
+func LookupExportLocale(name string) (ExportLocale, bool) {
+	locale, ok := exportLocales[normalizeLocaleName(name)]
+	return locale, ok
+}
+
+// ExportLocaleNames 返回全部内置区域名称（小写，已排序）
+func ExportLocaleNames() []string {
+	names := make([]string, 0, len(exportLocales))
+	for name := range exportLocales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func normalizeLocaleName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", "-"))
+}
+
+// resolveExportLocale 合并导出配置中的区域格式
+// 优先级：显式的 DateFormat/DecimalSeparator/ThousandsSeparator > Locale 预设 > 默认格式。
+// 未指定 Locale 时日期输出为 RFC3339（Excel 中显示为 yyyy-mm-dd hh:mm:ss），点号小数、不分组
+func resolveExportLocale(config *ExportConfig) (ExportLocale, error) {
+	locale := ExportLocale{
+		DateFormat:       defaultExportDateFormat,
+		ExcelDateFormat:  "yyyy-mm-dd hh:mm:ss",
+		DecimalSeparator: ".",
+	}
+	if config.Locale != "" {
+		preset, ok := LookupExportLocale(config.Locale)
+		if !ok {
+			return locale, &ImportExportError{Message: "不支持的区域设置: " + config.Locale}
+		}
+		locale = preset
+	}
+
+	if config.DateFormat != "" {
+		locale.DateFormat = config.DateFormat
+		locale.ExcelDateFormat = goLayoutToExcelFormat(config.DateFormat)
+	}
+	if config.DecimalSeparator != "" {
+		locale.DecimalSeparator = config.DecimalSeparator
+	}
+	if config.ThousandsSeparator != "" {
+		locale.ThousandsSeparator = config.ThousandsSeparator
+	}
+	return locale, nil
+}
+
+// resolveImportLocale 合并导入配置中的区域格式，规则与 resolveExportLocale 相同；
+// 未指定 Locale 和 DateFormat 时 DateFormat 为空，由 parseImportTime 依次尝试默认格式
+func resolveImportLocale(config *ImportConfig) (ExportLocale, error) {
+	locale, err := resolveExportLocale(&ExportConfig{
+		Locale:             config.Locale,
+		DateFormat:         config.DateFormat,
+		DecimalSeparator:   config.DecimalSeparator,
+		ThousandsSeparator: config.ThousandsSeparator,
+	})
+	if err != nil {
+		return locale, err
+	}
+	if config.Locale == "" && config.DateFormat == "" {
+		locale.DateFormat = ""
+	}
+	return locale, nil
+}
+
+// excelLayoutReplacer Go 时间布局到 Excel 格式代码的转换（覆盖常用的数字日期元素）
+var excelLayoutReplacer = strings.NewReplacer(
+	"2006", "yyyy",
+	"January", "mmmm",
+	"Jan", "mmm",
+	"Monday", "dddd",
+	"Mon", "ddd",
+	"01", "mm",
+	"02", "dd",
+	"06", "yy",
+	"15", "hh",
+	"03", "hh",
+	"04", "mm",
+	"05", "ss",
+	"PM", "AM/PM",
+)
+
+// goLayoutToExcelFormat 将 Go 时间布局转换为 Excel 日期格式代码
+func goLayoutToExcelFormat(layout string) string {
+	return excelLayoutReplacer.Replace(layout)
+}
+
+// formatLocaleFloat 按区域格式输出浮点数，整数部分按千分位分组
+func formatLocaleFloat(v float64, bitSize int, locale ExportLocale) string {
+	s := strconv.FormatFloat(v, 'f', -1, bitSize)
+	if locale.DecimalSeparator == "." && locale.ThousandsSeparator == "" {
+		return s
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if intPart == "" || intPart[0] < '0' || intPart[0] > '9' {
+		// NaN、Inf 原样输出
+		return sign + s
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	b.WriteString(groupThousands(intPart, locale.ThousandsSeparator))
+	if hasFrac {
+		b.WriteString(locale.DecimalSeparator)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+// parseLocaleFloat 解析按区域格式输出的浮点数，formatLocaleFloat 的逆操作
+func parseLocaleFloat(s string, bitSize int, locale ExportLocale) (float64, error) {
+	if locale.ThousandsSeparator != "" {
+		s = strings.ReplaceAll(s, locale.ThousandsSeparator, "")
+	}
+	if locale.DecimalSeparator != "" && locale.DecimalSeparator != "." {
+		s = strings.Replace(s, locale.DecimalSeparator, ".", 1)
+	}
+	return strconv.ParseFloat(s, bitSize)
+}
+
+// groupThousands 对整数数字串按三位分组
+func groupThousands(digits, sep string) string {
+	if sep == "" || len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
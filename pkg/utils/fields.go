@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidField 请求的字段不存在
+var ErrInvalidField = errors.New("无效的字段")
+
+// FieldSet 稀疏字段集，由 ?fields=id,username 指定只返回的 JSON 字段，为 nil 时返回全部字段
+type FieldSet []string
+
+// ParseFields 解析 fields 查询参数并按 sample 的 JSON 字段校验，未指定时返回 nil
+// sample 为列表元素类型的零值（结构体或其指针），字段名使用 json 标签名
+func ParseFields(c *gin.Context, sample interface{}) (FieldSet, error) {
+	raw := c.Query("fields")
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	allowed := jsonFields(reflect.TypeOf(sample))
+	var fields FieldSet
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := allowed[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidField, name)
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// Apply 将结构体切片转换为只包含所选字段的 map 切片，字段集为空时原样返回
+func (f FieldSet) Apply(items interface{}) interface{} {
+	if len(f) == 0 {
+		return items
+	}
+
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return items
+	}
+
+	result := make([]map[string]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		result = append(result, f.pick(v.Index(i)))
+	}
+	return result
+}
+
+// String 字段集的规范表示，用于区分不同字段集的缓存（如 ETag）
+func (f FieldSet) String() string {
+	return strings.Join(f, ",")
+}
+
+// pick 取出单个元素的所选字段，遵循 omitempty
+func (f FieldSet) pick(v reflect.Value) map[string]interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := jsonFields(v.Type())
+	m := make(map[string]interface{}, len(f))
+	for _, name := range f {
+		field, ok := fields[name]
+		if !ok {
+			continue
+		}
+		value, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			continue // 嵌入的结构体指针为 nil
+		}
+		if field.omitEmpty && value.IsZero() {
+			continue
+		}
+		m[name] = value.Interface()
+	}
+	return m
+}
+
+type jsonField struct {
+	index     []int
+	omitEmpty bool
+}
+
+// jsonFields 结构体导出字段的 JSON 名称，忽略 json:"-" 字段，展开匿名嵌入的结构体
+func jsonFields(t reflect.Type) map[string]jsonField {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fields := make(map[string]jsonField)
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" {
+			et := sf.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				for embeddedName, embedded := range jsonFields(et) {
+					if _, exists := fields[embeddedName]; !exists {
+						embedded.index = append([]int{i}, embedded.index...)
+						fields[embeddedName] = embedded
+					}
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields[name] = jsonField{index: sf.Index, omitEmpty: strings.Contains(","+opts+",", ",omitempty,")}
+	}
+	return fields
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidDedupeKey ImportConfig.DedupeKey 中的字段不存在
+var ErrInvalidDedupeKey = errors.New("去重字段不合法")
+
+// ValidateDedupeKey 导入开始前检查去重字段，dataPtr 为指向结构体切片的指针
+func ValidateDedupeKey(dataPtr interface{}, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	elemType, err := importElemType(dataPtr)
+	if err != nil {
+		return err
+	}
+	_, err = newImportDeduper(elemType, keys)
+	return err
+}
+
+// importDeduper 记录已导入行的去重键，用于发现文件内的重复行
+type importDeduper struct {
+	fields []int
+	names  string
+	seen   map[string]int // 去重键 -> 首次出现的行号
+}
+
+// newImportDeduper 按去重字段创建去重器，keys 为空时返回 nil
+func newImportDeduper(elemType reflect.Type, keys []string) (*importDeduper, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	d := &importDeduper{seen: make(map[string]int)}
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		index, ok := importFieldIndex(elemType, key)
+		if !ok {
+			return nil, fmt.Errorf("%w: 字段 %q 不存在", ErrInvalidDedupeKey, key)
+		}
+		d.fields = append(d.fields, index)
+		names = append(names, elemType.Field(index).Name)
+	}
+	d.names = strings.Join(names, ",")
+	return d, nil
+}
+
+// check 检查记录是否与前面的行重复，重复时返回错误，否则记录该行的去重键
+// 字符串比较忽略首尾空白和大小写；去重字段都为空（零值）的行不参与去重
+func (d *importDeduper) check(elem reflect.Value, lineNum int) *ImportExportError {
+	if d == nil {
+		return nil
+	}
+
+	parts := make([]string, len(d.fields))
+	empty := true
+	for i, index := range d.fields {
+		field := elem.Field(index)
+		for field.Kind() == reflect.Ptr && !field.IsNil() {
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Ptr || field.IsZero() {
+			continue
+		}
+		parts[i] = strings.ToLower(strings.TrimSpace(fmt.Sprint(field.Interface())))
+		empty = empty && parts[i] == ""
+	}
+	if empty {
+		return nil
+	}
+
+	key := strings.Join(parts, "\x00")
+	if first, ok := d.seen[key]; ok {
+		return &ImportExportError{
+			Line:    lineNum,
+			Field:   d.names,
+			Message: fmt.Sprintf("第%d行与第%d行重复", lineNum, first),
+		}
+	}
+	d.seen[key] = lineNum
+	return nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// utf8BOM Excel 等工具另存 UTF-8 CSV 时写在文件开头的字节顺序标记
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvTextReader 返回按 UTF-8 读取CSV内容的 reader：encoding 不是 UTF-8 时（如 gbk、gb18030、big5）先转码，
+// 并去掉开头的 UTF-8 BOM，避免 BOM 被当作第一个表头或字段的内容
+func csvTextReader(reader io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "utf-8", "utf8":
+	default:
+		enc, err := htmlindex.Get(encoding)
+		if err != nil {
+			return nil, &ImportExportError{Message: "不支持的文件编码: " + encoding}
+		}
+		reader = transform.NewReader(reader, enc.NewDecoder())
+	}
+
+	br := bufio.NewReader(reader)
+	if prefix, _ := br.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	return br, nil
+}
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -10,81 +14,223 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/xuri/excelize/v2"
 )
 
+// ErrTooManyRows 导入文件的数据行数超过 ImportConfig.MaxRows
+var ErrTooManyRows = errors.New("导入数据行数超过上限")
+
 // ImportExportError 导入导出错误类型
 type ImportExportError struct {
 	Message string
-	Line    int
+	Line    int // 文件行号（从1开始），Excel 为工作表中的实际行号，含表头
+	Column  int // 出错单元格的列号（从1开始），0 表示无法定位到列
 	Field   string
+
+	err error // 对应的哨兵错误（如 ErrTooManyRows），供 errors.Is 判断
+}
+
+// ValidationErrors 批量校验错误，收集全部行的校验失败后一次性返回
+type ValidationErrors []*ImportExportError
+
+func (e ValidationErrors) Error() string {
+	if len(e) == 0 {
+		return "数据验证失败"
+	}
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("共%d处错误，%s 等", len(e), e[0].Error())
+}
+
+// Add 追加一条校验错误
+func (e *ValidationErrors) Add(line int, field, message string) {
+	*e = append(*e, &ImportExportError{Line: line, Field: field, Message: message})
+}
+
+// Err 没有错误时返回 nil，避免返回非空接口的空切片
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Unwrap 返回对应的哨兵错误
+func (e *ImportExportError) Unwrap() error {
+	return e.err
 }
 
 func (e *ImportExportError) Error() string {
-	if e.Line > 0 && e.Field != "" {
-		return fmt.Sprintf("第%d行字段'%s'错误: %s", e.Line, e.Field, e.Message)
+	if e.Line <= 0 {
+		return e.Message
 	}
-	if e.Line > 0 {
-		return fmt.Sprintf("第%d行错误: %s", e.Line, e.Message)
+	position := fmt.Sprintf("第%d行", e.Line)
+	if name, err := excelize.ColumnNumberToName(e.Column); err == nil {
+		position += name + "列"
 	}
-	return e.Message
+	if e.Field != "" {
+		return fmt.Sprintf("%s字段'%s'错误: %s", position, e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s错误: %s", position, e.Message)
 }
 
+// exportProgressBatch 导出进度上报间隔（行）
+const exportProgressBatch = 100
+
 // ImportConfig 导入配置
 type ImportConfig struct {
-	FileType    string // "csv", "excel", "json"
+	FileType    string // "csv", "excel", "json", "xml"
 	HasHeader   bool   // 是否有表头
 	StartRow    int    // 数据开始行 (excel从1开始)
 	SheetName   string // Excel工作表名称
 	DateFormat  string // 日期格式
 	TimeFormat  string // 时间格式
+
+	// Encoding CSV文件的字符编码，如 gbk、gb18030、big5，为空时按 UTF-8 读取；开头的 UTF-8 BOM 总是被去掉
+	Encoding string
+
+	// Excel表头定位（仅在 HasHeader 为 true 时生效）
+	HeaderRow        int      // 表头所在行（从1开始），0 表示第1行
+	ExpectedHeaders  []string // 期望的表头列名，设置后在前 HeaderSearchRows 行中自动检测表头行
+	HeaderSearchRows int      // 自动检测表头时搜索的行数，0 表示默认值
+
+	// Locale/DecimalSeparator/ThousandsSeparator 与导出时的设置一致，用于解析按区域格式导出的日期和小数
+	Locale             string
+	DecimalSeparator   string
+	ThousandsSeparator string
+
+	// MaxRows 最多导入的数据行数，超过时停止读取并返回包装了 ErrTooManyRows 的 ImportExportError，
+	// 已读取的数据行保留在 ImportResult 中；0 表示不限制
+	MaxRows int
+
+	// StreamMode Excel 使用行迭代器逐行读取工作表，不一次性加载全部行，用于大文件导入
+	// 仅影响 Excel；CSV 始终逐行读取。ImportStream 总是以该模式读取
+	StreamMode bool
+
+	// UnescapeFormulas 导入CSV时去掉导出时为防止CSV注入添加的单引号前缀，与 ExportConfig.SanitizeFormulas 对应
+	// 为 nil 时默认启用
+	UnescapeFormulas *bool
+
+	// ColumnMapping 源文件表头 -> 结构体字段名，仅 CSV/Excel 且 HasHeader 为 true 时生效
+	// 设置后或结构体字段有 charlotte 列名标签时，按表头而不是列顺序对应字段，
+	// 未映射的表头依次按列名标签、json 标签和字段名匹配，都不匹配的列忽略并在 ImportResult.Errors 中给出提示
+	ColumnMapping map[string]string
+
+	// MaxLengths 结构体字段名 -> 字符串最大长度（字符数），覆盖字段标签（import:"max=N" 或 gorm:"size:N"），
+	// 设为 0 表示不限制。超过长度的值不写入，在 ImportResult.Errors 中报告，避免写库时才因列长度失败
+	MaxLengths map[string]int
+
+	// DedupeKey 文件内去重的字段名（可多个，组合判断），与前面某行重复的行计为失败（"第N行与第M行重复"），
+	// 字符串忽略大小写和首尾空白，去重字段都为空的行不参与去重；ImportStream 不做去重
+	DedupeKey []string
+
+	// locale 导入开始时合并得到的区域格式
+	locale ExportLocale
+	// columnFields 按表头和 ColumnMapping 解析出的每列对应的字段下标（-1 表示忽略该列），为 nil 时按列顺序对应
+	columnFields []int
+	// deduper 导入开始时按 DedupeKey 创建的去重器，为 nil 时不去重
+	deduper *importDeduper
 }
 
+// defaultHeaderSearchRows 自动检测表头时默认搜索的行数
+const defaultHeaderSearchRows = 20
+
 // ExportConfig 导出配置
 type ExportConfig struct {
-	FileType    string            // "csv", "excel", "json"
+	FileType    string            // "csv", "excel", "json", "xml"
 	FileName    string            // 文件名
 	Headers     []string          // 表头
 	FieldMap    map[string]string // 字段映射: struct字段名 -> 导出列名
-	DateFormat  string            // 日期格式
+	DateFormat  string            // 日期格式（Go 时间布局），为空时取 Locale 预设
 	TimeFormat  string            // 时间格式
+	Progress    func(processed, total int) // 导出进度回调（可选）
+
+	// Locale 区域格式预设，如 en-US、de-DE、fr-FR，决定日期格式与小数点、千分位分隔符
+	// 为空时使用 yyyy-MM-dd HH:mm:ss 和点号小数，见 ExportLocaleNames
+	Locale string
+	// DecimalSeparator/ThousandsSeparator 显式指定数字分隔符，覆盖 Locale 预设
+	// 千分位仅作用于浮点数，整数字段多为ID或数量，不做分组
+	DecimalSeparator   string
+	ThousandsSeparator string
+
+	// FlattenNested 导出CSV/Excel时将嵌套结构体展开为多列（用于包含关联数据的反规范化行）
+	// 为 false 时嵌套结构体按JSON字符串输出为一列
+	FlattenNested bool
+
+	// OmitFields 不导出的字段（结构体字段名，不区分大小写），CSV/Excel 中对应的列连同表头一并去掉
+	// FlattenNested 时可指定展开后的字段名；JSON 按顶层字段及匿名嵌入结构体的字段处理
+	OmitFields []string
+
+	// ComputedColumns 追加在结构体字段之后的计算列，列值由函数按行计算
+	ComputedColumns []ComputedColumn
+
+	// SanitizeFormulas CSV导出时是否转义公式前缀（=、+、-、@ 等），防止CSV注入
+	// 为 nil 时默认启用
+	SanitizeFormulas *bool
+
+	// StyleHeader Excel导出时表头加粗并按内容自动调整列宽，为 nil 时默认启用
+	// FreezeHeader Excel导出时冻结表头行，为 nil 时默认启用
+	// 供程序读取的文件可将两者设为 false，输出无格式的工作表
+	StyleHeader  *bool
+	FreezeHeader *bool
+
+	// locale 导出开始时合并得到的区域格式
+	locale ExportLocale
 }
 
 // ImportResult 导入结果
 type ImportResult struct {
-	TotalRows   int                 // 总行数
-	SuccessRows int                 // 成功行数
-	FailedRows  int                 // 失败行数
+	TotalRows   int                  // 总行数
+	SuccessRows int                  // 成功行数
+	FailedRows  int                  // 失败行数
 	Errors      []*ImportExportError // 错误详情
-	Data        interface{}         // 导入的数据
+	Data        interface{}          // 导入的数据
+	Lines       []int                // 导入数据中每个元素对应的文件行号（从1开始），用于定位校验错误
+	FailedLines []int                // 解析失败的数据行对应的文件行号
 }
 
 // ImportData 通用数据导入函数
 // dataPtr: 指向目标切片数据的指针，如 &[]User{}
 // file: 上传的文件
 // config: 导入配置
+//
+// 使用相同的区域设置时，ExportData 导出的 CSV、JSON 文件可无损导入为原数据（FlattenNested 导出除外）：
+// 默认日期为带时区的 RFC3339，浮点数为最短精确表示，嵌套结构体、切片、map 为 JSON。
+// 例外：导入时字符串首尾空白会被去除；按区域日期格式导出的时间只保留到格式中的精度，
+// Excel 中的日期为不带时区的序列值，精度受浮点数限制，两者都按本地时区解析
 func ImportData(dataPtr interface{}, file *multipart.FileHeader, config *ImportConfig) (*ImportResult, error) {
-	if dataPtr == nil || reflect.ValueOf(dataPtr).Kind() != reflect.Ptr {
-		return nil, &ImportExportError{Message: "dataPtr必须是指向切片的指针"}
+	// 打开文件
+	fileReader, err := file.Open()
+	if err != nil {
+		return nil, &ImportExportError{Message: "打开文件失败: " + err.Error()}
 	}
+	defer fileReader.Close()
 
-	sliceType := reflect.TypeOf(dataPtr).Elem()
-	if sliceType.Kind() != reflect.Slice {
-		return nil, &ImportExportError{Message: "dataPtr必须指向切片类型"}
-	}
+	return ImportDataFrom(dataPtr, fileReader, config)
+}
 
-	elemType := sliceType.Elem()
-	if elemType.Kind() != reflect.Struct {
-		return nil, &ImportExportError{Message: "切片元素必须是结构体类型"}
+// ImportDataFrom 从 reader 导入数据，规则与 ImportData 相同，用于上传文件已另存的场景（如异步导入）
+func ImportDataFrom(dataPtr interface{}, fileReader io.Reader, config *ImportConfig) (*ImportResult, error) {
+	elemType, err := importElemType(dataPtr)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateColumnMapping(elemType, config); err != nil {
+		return nil, err
 	}
 
-	// 打开文件
-	fileReader, err := file.Open()
+	locale, err := resolveImportLocale(config)
 	if err != nil {
-		return nil, &ImportExportError{Message: "打开文件失败: " + err.Error()}
+		return nil, err
+	}
+	config.locale = locale
+	config.columnFields = nil
+	if config.deduper, err = newImportDeduper(elemType, config.DedupeKey); err != nil {
+		return nil, err
 	}
-	defer fileReader.Close()
 
 	result := &ImportResult{
 		TotalRows:   0,
@@ -100,6 +246,8 @@ func ImportData(dataPtr interface{}, file *multipart.FileHeader, config *ImportC
 		err = importFromExcel(dataPtr, fileReader, config, result)
 	case "json":
 		err = importFromJSON(dataPtr, fileReader, config, result)
+	case "xml":
+		err = importFromXML(dataPtr, fileReader, config, result)
 	default:
 		err = &ImportExportError{Message: "不支持的文件类型: " + config.FileType}
 	}
@@ -111,78 +259,111 @@ func ImportData(dataPtr interface{}, file *multipart.FileHeader, config *ImportC
 // data: 要导出的数据切片
 // config: 导出配置
 func ExportData(data interface{}, config *ExportConfig) ([]byte, error) {
+	return ExportDataWithContext(context.Background(), data, config)
+}
+
+// ExportDataWithContext 支持取消的数据导出函数
+// ctx 被取消时（如客户端断开连接）导出会中途终止并返回包装了 ctx.Err() 的错误
+func ExportDataWithContext(ctx context.Context, data interface{}, config *ExportConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ExportDataTo(ctx, &buf, data, config); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportDataTo 将导出内容边生成边写入 w（如 HTTP 响应），不在内存中缓存完整结果
+// CSV、JSON 按行写出；Excel 使用 excelize.StreamWriter 逐行写入，最后一次性输出压缩包
+// 参数校验失败时不会向 w 写入任何内容，调用方可据此返回普通错误响应
+func ExportDataTo(ctx context.Context, w io.Writer, data interface{}, config *ExportConfig) error {
 	if data == nil {
-		return nil, &ImportExportError{Message: "导出数据不能为空"}
+		return &ImportExportError{Message: "导出数据不能为空"}
 	}
 
 	dataValue := reflect.ValueOf(data)
 	if dataValue.Kind() != reflect.Slice {
-		return nil, &ImportExportError{Message: "导出数据必须是切片类型"}
+		return &ImportExportError{Message: "导出数据必须是切片类型"}
 	}
 
 	if dataValue.Len() == 0 {
-		return nil, &ImportExportError{Message: "导出数据为空"}
+		return &ImportExportError{Message: "导出数据为空"}
+	}
+
+	locale, err := resolveExportLocale(config)
+	if err != nil {
+		return err
+	}
+	config.locale = locale
+
+	if len(config.OmitFields) > 0 && len(config.Headers) > 0 {
+		config.Headers = omitExportHeaders(dataValue.Type().Elem(), config)
+	}
+	if len(config.Headers) == 0 {
+		config.Headers = taggedExportHeaders(dataValue.Type().Elem(), config)
+	}
+	if len(config.Headers) > 0 {
+		config.Headers = computedHeaders(config.Headers, config)
 	}
 
 	switch strings.ToLower(config.FileType) {
 	case "csv":
-		return exportToCSV(data, config)
+		return exportToCSV(ctx, w, data, config)
 	case "excel":
-		return exportToExcel(data, config)
+		return exportToExcel(ctx, w, data, config)
 	case "json":
-		return exportToJSON(data, config)
+		return exportToJSON(ctx, w, data, config)
+	case "xml":
+		return exportToXML(ctx, w, data, config)
 	default:
-		return nil, &ImportExportError{Message: "不支持的文件类型: " + config.FileType}
+		return &ImportExportError{Message: "不支持的文件类型: " + config.FileType}
 	}
 }
 
 // importFromCSV CSV导入实现
 func importFromCSV(dataPtr interface{}, reader io.Reader, config *ImportConfig, result *ImportResult) error {
-	csvReader := csv.NewReader(reader)
-	csvReader.FieldsPerRecord = -1 // 允许字段数量不一致
-
-	lineNum := 0
-	for {
-		lineNum++
-		record, err := csvReader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			result.Errors = append(result.Errors, &ImportExportError{
-				Line:    lineNum,
-				Message: "读取CSV行失败: " + err.Error(),
-			})
-			result.FailedRows++
-			continue
-		}
-
-		// 跳过表头
-		if config.HasHeader && lineNum == 1 {
-			continue
-		}
-
-		// 跳过开始行之前的数据
-		if lineNum < config.StartRow {
-			continue
-		}
+	elemType := reflect.TypeOf(dataPtr).Elem().Elem()
+	return scanCSVRows(reader, config, result,
+		// 配置了列映射或字段有列名标签时按表头确定每列对应的字段
+		func(lineNum int, header []string) error {
+			return resolveColumnMapping(elemType, lineNum, header, config, result)
+		},
+		func(lineNum int, record []string) error {
+			return parseCSVRecord(dataPtr, record, lineNum, config, result)
+		},
+	)
+}
 
-		result.TotalRows++
-		if err := parseCSVRecord(dataPtr, record, lineNum, config, result); err != nil {
-			result.FailedRows++
-		} else {
-			result.SuccessRows++
+// checkImportRows 已读取的数据行数达到 MaxRows 时返回包装了 ErrTooManyRows 的 ImportExportError，line 为超出上限的行
+// 此前读取的数据行仍保留在 result 中
+func checkImportRows(result *ImportResult, config *ImportConfig, line int) error {
+	if config.MaxRows > 0 && result.TotalRows >= config.MaxRows {
+		return &ImportExportError{
+			Line:    line,
+			Message: fmt.Sprintf("%s: 最多%d行", ErrTooManyRows.Error(), config.MaxRows),
+			err:     ErrTooManyRows,
 		}
 	}
-
 	return nil
 }
 
 // importFromExcel Excel导入实现
 func importFromExcel(dataPtr interface{}, reader io.Reader, config *ImportConfig, result *ImportResult) error {
+	elemType := reflect.TypeOf(dataPtr).Elem().Elem()
+	return scanExcelRows(reader, config, result,
+		func(lineNum int, header []string) error {
+			return resolveColumnMapping(elemType, lineNum, header, config, result)
+		},
+		func(lineNum int, record []string) error {
+			return parseCSVRecord(dataPtr, record, lineNum, config, result)
+		},
+	)
+}
+
+// readExcelRows 读取Excel工作表的全部行，返回表头所在行（从1开始，无表头时为0）
+func readExcelRows(reader io.Reader, config *ImportConfig) ([][]string, int, error) {
 	file, err := excelize.OpenReader(reader)
 	if err != nil {
-		return &ImportExportError{Message: "打开Excel文件失败: " + err.Error()}
+		return nil, 0, &ImportExportError{Message: "打开Excel文件失败: " + err.Error()}
 	}
 	defer file.Close()
 
@@ -191,135 +372,302 @@ func importFromExcel(dataPtr interface{}, reader io.Reader, config *ImportConfig
 		sheetName = file.GetSheetName(0)
 	}
 
-	rows, err := file.GetRows(sheetName)
+	// 读取原始值：数字不受单元格格式影响（不会被四舍五入或加千分位），日期为序列值，由 setFieldValue 转换
+	rows, err := file.GetRows(sheetName, excelize.Options{RawCellValue: true})
 	if err != nil {
-		return &ImportExportError{Message: "读取Excel工作表失败: " + err.Error()}
+		return nil, 0, &ImportExportError{Message: "读取Excel工作表失败: " + err.Error()}
 	}
 
-	for i, row := range rows {
-		lineNum := i + 1
+	headerRow := 0
+	if config.HasHeader {
+		headerRow, err = resolveExcelHeaderRow(rows, config)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return rows, headerRow, nil
+}
 
-		// 跳过表头
-		if config.HasHeader && lineNum == 1 {
-			continue
+// resolveExcelHeaderRow 确定Excel表头所在行（从1开始）
+// 配置了 ExpectedHeaders 时在前若干行中查找包含全部期望列名的行，否则使用 HeaderRow
+func resolveExcelHeaderRow(rows [][]string, config *ImportConfig) (int, error) {
+	if len(config.ExpectedHeaders) == 0 {
+		if config.HeaderRow > 0 {
+			return config.HeaderRow, nil
 		}
+		return 1, nil
+	}
 
-		// 跳过开始行之前的数据
-		if lineNum < config.StartRow {
-			continue
+	searchRows := config.HeaderSearchRows
+	if searchRows <= 0 {
+		searchRows = defaultHeaderSearchRows
+	}
+	if searchRows > len(rows) {
+		searchRows = len(rows)
+	}
+
+	for i := 0; i < searchRows; i++ {
+		cells := make(map[string]bool, len(rows[i]))
+		for _, cell := range rows[i] {
+			cells[strings.ToLower(strings.TrimSpace(cell))] = true
 		}
 
-		result.TotalRows++
-		if err := parseCSVRecord(dataPtr, row, lineNum, config, result); err != nil {
-			result.FailedRows++
-		} else {
-			result.SuccessRows++
+		matched := true
+		for _, header := range config.ExpectedHeaders {
+			if !cells[strings.ToLower(strings.TrimSpace(header))] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i + 1, nil
 		}
 	}
 
-	return nil
+	return 0, &ImportExportError{
+		Message: fmt.Sprintf("未在前%d行中找到表头，期望包含列: %s", searchRows, strings.Join(config.ExpectedHeaders, ", ")),
+	}
+}
+
+// isBlankRow 判断是否为空行
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
 }
 
 // importFromJSON JSON导入实现
+// 流式逐个读取数组元素并直接解码到目标结构体，不在内存中保留整个文件，也避免大整数经 float64 中转丢失精度；
+// 错误中的行号为元素在数组中的序号（从1开始）
 func importFromJSON(dataPtr interface{}, reader io.Reader, config *ImportConfig, result *ImportResult) error {
 	dataValue := reflect.ValueOf(dataPtr).Elem()
 	elemType := dataValue.Type().Elem()
 
-	var dataSlice []interface{}
-	if err := json.NewDecoder(reader).Decode(&dataSlice); err != nil {
+	decoder := json.NewDecoder(reader)
+	token, err := decoder.Token()
+	if err != nil {
 		return &ImportExportError{Message: "解析JSON失败: " + err.Error()}
 	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return &ImportExportError{Message: "解析JSON失败: 数据必须是数组"}
+	}
 
-	for i, item := range dataSlice {
-		lineNum := i + 1
+	for decoder.More() {
+		if err := checkImportRows(result, config, result.TotalRows+1); err != nil {
+			return err
+		}
 		result.TotalRows++
-
-		// 将interface{}转换为目标结构体
-		itemJSON, err := json.Marshal(item)
-		if err != nil {
+		lineNum := result.TotalRows
+
+		newElem := reflect.New(elemType)
+		if err := decoder.Decode(newElem.Interface()); err != nil {
+			// 类型不匹配时解码器已读完该元素，可以继续读取下一个；语法错误时无法继续
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				return &ImportExportError{Line: lineNum, Message: "解析JSON失败: " + err.Error()}
+			}
 			result.Errors = append(result.Errors, &ImportExportError{
 				Line:    lineNum,
-				Message: "序列化数据失败: " + err.Error(),
+				Message: "反序列化数据失败: " + err.Error(),
 			})
 			result.FailedRows++
+			result.FailedLines = append(result.FailedLines, lineNum)
 			continue
 		}
-
-		newElem := reflect.New(elemType).Interface()
-		if err := json.Unmarshal(itemJSON, newElem); err != nil {
-			result.Errors = append(result.Errors, &ImportExportError{
-				Line:    lineNum,
-				Message: "反序列化数据失败: " + err.Error(),
-			})
+		if lengthErr := checkRecordLengths(newElem.Elem(), config); lengthErr != nil {
+			lengthErr.Line = lineNum
+			result.Errors = append(result.Errors, lengthErr)
 			result.FailedRows++
+			result.FailedLines = append(result.FailedLines, lineNum)
+			continue
+		}
+		if dupErr := config.deduper.check(newElem.Elem(), lineNum); dupErr != nil {
+			result.Errors = append(result.Errors, dupErr)
+			result.FailedRows++
+			result.FailedLines = append(result.FailedLines, lineNum)
 			continue
 		}
 
-		dataValue.Set(reflect.Append(dataValue, reflect.ValueOf(newElem).Elem()))
+		dataValue.Set(reflect.Append(dataValue, newElem.Elem()))
+		result.Lines = append(result.Lines, lineNum)
 		result.SuccessRows++
 	}
 
+	if _, err := decoder.Token(); err != nil {
+		return &ImportExportError{Message: "解析JSON失败: " + err.Error()}
+	}
 	return nil
 }
 
 // parseCSVRecord 解析CSV/Excel记录到结构体
+// 配置了列映射或字段有列名标签时按表头解析出的对应关系设置字段，否则列与结构体的导出字段按顺序一一对应（与导出时的列顺序一致），非导出字段不占列
 func parseCSVRecord(dataPtr interface{}, record []string, lineNum int, config *ImportConfig, result *ImportResult) error {
 	dataValue := reflect.ValueOf(dataPtr).Elem()
 	elemType := dataValue.Type().Elem()
 	newElem := reflect.New(elemType).Elem()
 
-	for i := 0; i < newElem.NumField(); i++ {
-		field := newElem.Field(i)
-		fieldType := elemType.Field(i)
-
-		// 跳过非导出字段
-		if !field.CanSet() {
-			continue
+	if config.columnFields != nil {
+		for column, index := range config.columnFields {
+			if index < 0 || column >= len(record) {
+				continue
+			}
+			if err := setRecordField(newElem.Field(index), elemType.Field(index), record[column], lineNum, column+1, config, result); err != nil {
+				return err
+			}
 		}
+	} else {
+		column := 0
+		for i := 0; i < newElem.NumField(); i++ {
+			field := newElem.Field(i)
 
-		// 检查记录长度
-		if i >= len(record) {
-			break
-		}
+			// 跳过非导出字段
+			if !field.CanSet() {
+				continue
+			}
 
-		value := strings.TrimSpace(record[i])
-		if value == "" {
-			continue
+			// 检查记录长度
+			if column >= len(record) {
+				break
+			}
+			value := record[column]
+			column++
+			if err := setRecordField(field, elemType.Field(i), value, lineNum, column, config, result); err != nil {
+				return err
+			}
 		}
+	}
 
-		if err := setFieldValue(field, fieldType.Type, value, config); err != nil {
-			result.Errors = append(result.Errors, &ImportExportError{
-				Line:    lineNum,
-				Field:   fieldType.Name,
-				Message: err.Error(),
-			})
-			return err
-		}
+	if dupErr := config.deduper.check(newElem, lineNum); dupErr != nil {
+		result.Errors = append(result.Errors, dupErr)
+		return dupErr
 	}
 
 	dataValue.Set(reflect.Append(dataValue, newElem))
+	result.Lines = append(result.Lines, lineNum)
 	return nil
 }
 
+// setRecordField 将单元格的值设置到字段，空值跳过，转换失败时记录错误，column 为单元格的列号（从1开始）
+func setRecordField(field reflect.Value, fieldType reflect.StructField, value string, lineNum, column int, config *ImportConfig, result *ImportResult) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if fieldType.Type.Kind() == reflect.String && strings.EqualFold(config.FileType, "csv") &&
+		(config.UnescapeFormulas == nil || *config.UnescapeFormulas) {
+		value = unescapeCSVFormula(value)
+	}
+
+	err := checkFieldLength(fieldType, value, config)
+	if err == nil {
+		err = setFieldValue(field, fieldType.Type, value, config)
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, &ImportExportError{
+			Line:    lineNum,
+			Column:  column,
+			Field:   fieldType.Name,
+			Message: err.Error(),
+		})
+		return err
+	}
+	return nil
+}
+
+// maxFieldLength 字段的最大长度，ImportConfig.MaxLengths 优先于字段标签；非字符串字段不限制
+func maxFieldLength(fieldType reflect.StructField, config *ImportConfig) int {
+	t := fieldType.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String {
+		return 0
+	}
+	if n, ok := config.MaxLengths[fieldType.Name]; ok {
+		return n
+	}
+	return fieldMaxLength(fieldType)
+}
+
+// checkFieldLength 检查字符串值是否超过字段的最大长度（按字符计）
+func checkFieldLength(fieldType reflect.StructField, value string, config *ImportConfig) error {
+	limit := maxFieldLength(fieldType, config)
+	if limit <= 0 {
+		return nil
+	}
+	if n := utf8.RuneCountInString(value); n > limit {
+		return fmt.Errorf("超过最大长度%d（实际%d个字符）", limit, n)
+	}
+	return nil
+}
+
+// checkRecordLengths 检查已解码记录中字符串字段的长度（用于 JSON 导入），返回第一个超长的字段
+func checkRecordLengths(elem reflect.Value, config *ImportConfig) *ImportExportError {
+	elemType := elem.Type()
+	for i := 0; i < elemType.NumField(); i++ {
+		fieldType := elemType.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		field := elem.Field(i)
+		if fieldType.Anonymous && field.Kind() == reflect.Struct {
+			if err := checkRecordLengths(field, config); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		if field.Kind() != reflect.String {
+			continue
+		}
+		if err := checkFieldLength(fieldType, field.String(), config); err != nil {
+			return &ImportExportError{Field: fieldType.Name, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// importDateFormats 未指定日期格式时依次尝试的格式
+var importDateFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+}
+
 // setFieldValue 设置字段值
 func setFieldValue(field reflect.Value, fieldType reflect.Type, value string, config *ImportConfig) error {
 	switch fieldType.Kind() {
 	case reflect.String:
 		field.SetString(value)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		intVal, err := strconv.ParseInt(value, 10, 64)
+		intVal, err := strconv.ParseInt(value, 10, fieldType.Bits())
 		if err != nil {
 			return fmt.Errorf("整数转换失败: %s", err.Error())
 		}
 		field.SetInt(intVal)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		uintVal, err := strconv.ParseUint(value, 10, 64)
+		uintVal, err := strconv.ParseUint(value, 10, fieldType.Bits())
 		if err != nil {
 			return fmt.Errorf("无符号整数转换失败: %s", err.Error())
 		}
 		field.SetUint(uintVal)
 	case reflect.Float32, reflect.Float64:
-		floatVal, err := strconv.ParseFloat(value, 64)
+		// Excel 中的数字为原生数值，不受区域分隔符影响
+		locale := config.locale
+		if strings.EqualFold(config.FileType, "excel") {
+			locale = ExportLocale{}
+		}
+		floatVal, err := parseLocaleFloat(value, fieldType.Bits(), locale)
 		if err != nil {
 			return fmt.Errorf("浮点数转换失败: %s", err.Error())
 		}
@@ -330,36 +678,30 @@ func setFieldValue(field reflect.Value, fieldType reflect.Type, value string, co
 			return fmt.Errorf("布尔值转换失败: %s", err.Error())
 		}
 		field.SetBool(boolVal)
+	case reflect.Ptr:
+		// 空值表示未填写，指针保持为 nil
+		if value == "" {
+			return nil
+		}
+		elem := reflect.New(fieldType.Elem())
+		if err := setFieldValue(elem.Elem(), fieldType.Elem(), value, config); err != nil {
+			return err
+		}
+		field.Set(elem)
 	case reflect.Struct:
-		if fieldType == reflect.TypeOf(time.Time{}) {
-			// 尝试多种日期格式
-			var timeVal time.Time
-			var err error
-
-			if config.DateFormat != "" {
-				timeVal, err = time.Parse(config.DateFormat, value)
-			} else {
-				// 默认格式
-				formats := []string{
-					"2006-01-02",
-					"2006/01/02",
-					"2006-01-02 15:04:05",
-					"2006/01/02 15:04:05",
-					time.RFC3339,
-				}
-
-				for _, format := range formats {
-					timeVal, err = time.Parse(format, value)
-					if err == nil {
-						break
-					}
-				}
-			}
-
+		if fieldType == timeType {
+			timeVal, err := parseImportTime(value, config)
 			if err != nil {
 				return fmt.Errorf("日期时间转换失败: %s", err.Error())
 			}
 			field.Set(reflect.ValueOf(timeVal))
+			return nil
+		}
+		// 其他结构体按导出时的JSON字符串解析
+		fallthrough
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if err := json.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+			return fmt.Errorf("JSON转换失败: %s", err.Error())
 		}
 	default:
 		return fmt.Errorf("不支持的字段类型: %s", fieldType.Kind())
@@ -368,93 +710,454 @@ func setFieldValue(field reflect.Value, fieldType reflect.Type, value string, co
 	return nil
 }
 
+// parseImportTime 解析日期时间
+// 指定了 DateFormat 时只使用该格式；否则依次尝试区域日期格式和 importDateFormats，不含时区的格式按本地时区解析；
+// Excel 文件中都不匹配且为数字时按日期序列值处理
+func parseImportTime(value string, config *ImportConfig) (time.Time, error) {
+	if config.DateFormat != "" {
+		return time.ParseInLocation(config.DateFormat, value, time.Local)
+	}
+
+	formats := importDateFormats
+	if config.locale.DateFormat != "" {
+		formats = append([]string{config.locale.DateFormat}, formats...)
+	}
+
+	var err error
+	for _, format := range formats {
+		var t time.Time
+		if t, err = time.ParseInLocation(format, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if strings.EqualFold(config.FileType, "excel") {
+		if serial, convErr := strconv.ParseFloat(value, 64); convErr == nil {
+			t, err := excelize.ExcelDateToTime(serial, false)
+			if err != nil {
+				return t, err
+			}
+			// 序列值保存的是墙上时间，与其他不含时区的格式一样按本地时区解释
+			return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local), nil
+		}
+	}
+	return time.Time{}, err
+}
+
 // exportToCSV CSV导出实现
-func exportToCSV(data interface{}, config *ExportConfig) ([]byte, error) {
-	var buf strings.Builder
-	csvWriter := csv.NewWriter(&buf)
+func exportToCSV(ctx context.Context, w io.Writer, data interface{}, config *ExportConfig) error {
+	csvWriter := csv.NewWriter(w)
 
 	// 写入表头
 	if len(config.Headers) > 0 {
 		if err := csvWriter.Write(config.Headers); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	dataValue := reflect.ValueOf(data)
-	for i := 0; i < dataValue.Len(); i++ {
-		record := make([]string, 0)
-		elem := dataValue.Index(i)
+	sanitize := config.SanitizeFormulas == nil || *config.SanitizeFormulas
 
-		for j := 0; j < elem.NumField(); j++ {
-			field := elem.Field(j)
-			fieldType := elem.Type().Field(j)
+	dataValue := reflect.ValueOf(data)
+	total := dataValue.Len()
+	for i := 0; i < total; i++ {
+		if err := checkExportProgress(ctx, config, i, total); err != nil {
+			return err
+		}
 
-			// 跳过非导出字段
-			if !field.CanInterface() {
-				continue
-			}
+		elem := dataValue.Index(i)
+		record := appendRecordValues(make([]string, 0), elem, elem.Type(), config, sanitize)
 
-			value := formatFieldValue(field, fieldType.Type, config)
-			record = append(record, value)
+		if err := csvWriter.Write(record); err != nil {
+			return err
 		}
 
-		if err := csvWriter.Write(record); err != nil {
-			return nil, err
+		// 按批次刷新，让数据尽早到达客户端
+		if (i+1)%exportProgressBatch == 0 {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
 		}
 	}
 
 	csvWriter.Flush()
-	return []byte(buf.String()), nil
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	reportExportProgress(config, total, total)
+	return nil
+}
+
+// sanitizeCSVFormula 转义可能被电子表格解释为公式的值
+// 以 =、+、-、@、制表符或回车开头的字符串前加单引号，使其按文本显示（数值字段不处理）；
+// 本身以单引号加上述字符开头的值同样再加一个单引号，导入时 unescapeCSVFormula 可准确还原
+func sanitizeCSVFormula(value string) string {
+	if needsFormulaEscape(value) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVFormula 去掉 sanitizeCSVFormula 添加的单引号
+func unescapeCSVFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && needsFormulaEscape(value[1:]) {
+		return value[1:]
+	}
+	return value
+}
+
+// needsFormulaEscape 判断值是否需要加单引号转义
+func needsFormulaEscape(value string) bool {
+	if value == "" {
+		return false
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return true
+	case '\'':
+		return needsFormulaEscape(value[1:])
+	}
+	return false
 }
 
 // exportToExcel Excel导出实现
-func exportToExcel(data interface{}, config *ExportConfig) ([]byte, error) {
+// 使用 StreamWriter 逐行写入，避免为每个单元格构建内存模型
+func exportToExcel(ctx context.Context, w io.Writer, data interface{}, config *ExportConfig) error {
 	file := excelize.NewFile()
-	sheetName := "Sheet1"
+	defer file.Close()
+
+	streamWriter, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		return err
+	}
+
+	// 日期单元格使用区域对应的格式代码；数字使用常规格式，由电子表格按系统区域显示小数点
+	dateStyle, err := file.NewStyle(&excelize.Style{CustomNumFmt: &config.locale.ExcelDateFormat})
+	if err != nil {
+		return err
+	}
+
+	dataValue := reflect.ValueOf(data)
+
+	// 列宽和冻结窗格必须在写入第一行之前设置
+	styleHeader := config.StyleHeader == nil || *config.StyleHeader
+	if styleHeader {
+		if err := setExcelColWidths(ctx, streamWriter, dataValue, config, dateStyle); err != nil {
+			return err
+		}
+	}
+	if (config.FreezeHeader == nil || *config.FreezeHeader) && len(config.Headers) > 0 {
+		if err := streamWriter.SetPanes(&excelize.Panes{
+			Freeze:      true,
+			YSplit:      1,
+			TopLeftCell: "A2",
+			ActivePane:  "bottomLeft",
+		}); err != nil {
+			return err
+		}
+	}
 
 	// 写入表头
 	if len(config.Headers) > 0 {
+		headerStyle := 0
+		if styleHeader {
+			if headerStyle, err = file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+				return err
+			}
+		}
+		row := make([]interface{}, len(config.Headers))
 		for i, header := range config.Headers {
-			cell, _ := excelize.CoordinatesToCellName(i+1, 1)
-			file.SetCellValue(sheetName, cell, header)
+			row[i] = excelize.Cell{StyleID: headerStyle, Value: header}
+		}
+		if err := streamWriter.SetRow("A1", row); err != nil {
+			return err
 		}
 	}
 
-	dataValue := reflect.ValueOf(data)
-	for i := 0; i < dataValue.Len(); i++ {
+	total := dataValue.Len()
+	for i := 0; i < total; i++ {
+		if err := checkExportProgress(ctx, config, i, total); err != nil {
+			return err
+		}
+
 		rowNum := i + 2 // 从第2行开始
 		elem := dataValue.Index(i)
-		colNum := 1
+		row := appendExcelCells(make([]interface{}, 0, elem.NumField()), elem, elem.Type(), config, dateStyle)
 
-		for j := 0; j < elem.NumField(); j++ {
-			field := elem.Field(j)
-			fieldType := elem.Type().Field(j)
+		cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+		if err := streamWriter.SetRow(cell, row); err != nil {
+			return err
+		}
+	}
 
-			// 跳过非导出字段
-			if !field.CanInterface() {
-				continue
+	if err := streamWriter.Flush(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("导出已取消: %w", err)
+	}
+	if err := file.Write(w); err != nil {
+		return err
+	}
+	reportExportProgress(config, total, total)
+	return nil
+}
+
+// exportToJSON JSON导出实现
+// 逐个元素编码写出，输出格式与 json.MarshalIndent 一致
+func exportToJSON(ctx context.Context, w io.Writer, data interface{}, config *ExportConfig) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString("[\n"); err != nil {
+		return err
+	}
+
+	dataValue := reflect.ValueOf(data)
+	fields := exportJSONFields(dataValue.Type().Elem(), config)
+	total := dataValue.Len()
+	for i := 0; i < total; i++ {
+		if err := checkExportProgress(ctx, config, i, total); err != nil {
+			return err
+		}
+
+		var value interface{} = dataValue.Index(i).Interface()
+		if fields != nil {
+			picked := fields.pick(dataValue.Index(i))
+			if picked != nil {
+				for _, column := range config.computedColumns() {
+					picked[column.Name] = computedValue(column, dataValue.Index(i))
+				}
 			}
+			value = picked
+		}
+		item, err := json.MarshalIndent(value, "  ", "  ")
+		if err != nil {
+			return err
+		}
 
-			cell, _ := excelize.CoordinatesToCellName(colNum, rowNum)
-			value := formatFieldValue(field, fieldType.Type, config)
-			file.SetCellValue(sheetName, cell, value)
-			colNum++
+		if i > 0 {
+			if _, err := bw.WriteString(",\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.WriteString("  "); err != nil {
+			return err
+		}
+		if _, err := bw.Write(item); err != nil {
+			return err
 		}
 	}
 
-	buffer, err := file.WriteToBuffer()
-	if err != nil {
-		return nil, err
+	if _, err := bw.WriteString("\n]"); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
 	}
-	return buffer.Bytes(), nil
+	reportExportProgress(config, total, total)
+	return nil
 }
 
-// exportToJSON JSON导出实现
-func exportToJSON(data interface{}, config *ExportConfig) ([]byte, error) {
-	return json.MarshalIndent(data, "", "  ")
+// checkExportProgress 检查导出是否已取消，并按批次上报进度
+func checkExportProgress(ctx context.Context, config *ExportConfig, processed, total int) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("导出已取消: %w", err)
+	}
+	if processed%exportProgressBatch == 0 {
+		reportExportProgress(config, processed, total)
+	}
+	return nil
+}
+
+// reportExportProgress 上报导出进度
+func reportExportProgress(config *ExportConfig, processed, total int) {
+	if config.Progress != nil {
+		config.Progress(processed, total)
+	}
+}
+
+// appendRecordValues 按字段顺序追加一行导出值
+// 开启 FlattenNested 时，嵌套结构体（含匿名嵌入和指针）展开为多列，nil 指针输出对应数量的空列；
+// 非结构体元素的切片以 ", " 连接为一列；计算列追加在最后
+func appendRecordValues(record []string, elem reflect.Value, elemType reflect.Type, config *ExportConfig, sanitize bool) []string {
+	visitRecordFields(elem, elemType, config, func(sf reflect.StructField, field reflect.Value) {
+		fieldType := sf.Type
+		value := ""
+		if field.IsValid() {
+			if config.FlattenNested && isJoinableSlice(fieldType) {
+				value = joinSliceValues(field, config)
+			} else {
+				value = formatFieldValue(field, fieldType, config)
+			}
+		}
+		if sanitize && fieldType.Kind() == reflect.String {
+			value = sanitizeCSVFormula(value)
+		}
+		record = append(record, value)
+	})
+	return appendComputedValues(record, elem, config, sanitize)
+}
+
+// appendExcelCells 生成一行 Excel 单元格
+// 数值写为原生数字，time.Time 写为带区域日期格式的日期单元格，其余字段与 CSV 一致按文本输出
+func appendExcelCells(row []interface{}, elem reflect.Value, elemType reflect.Type, config *ExportConfig, dateStyle int) []interface{} {
+	visitRecordFields(elem, elemType, config, func(sf reflect.StructField, field reflect.Value) {
+		fieldType := sf.Type
+		if !field.IsValid() {
+			row = append(row, "")
+			return
+		}
+		switch {
+		case fieldType == timeType && field.Interface().(time.Time).IsZero():
+			row = append(row, "")
+		case fieldType == timeType:
+			row = append(row, excelize.Cell{StyleID: dateStyle, Value: field.Interface()})
+		case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Float64:
+			row = append(row, field.Interface())
+		case config.FlattenNested && isJoinableSlice(fieldType):
+			row = append(row, joinSliceValues(field, config))
+		default:
+			row = append(row, formatFieldValue(field, fieldType, config))
+		}
+	})
+	for _, value := range appendComputedValues(nil, elem, config, false) {
+		row = append(row, value)
+	}
+	return row
+}
+
+// visitRecordFields 按导出列顺序遍历记录的字段，跳过 OmitFields 中的字段
+// FlattenNested 时嵌套结构体递归展开，空指针的嵌套结构体对应的字段以零值 reflect.Value 传入
+func visitRecordFields(elem reflect.Value, elemType reflect.Type, config *ExportConfig, visit func(sf reflect.StructField, field reflect.Value)) {
+	for j := 0; j < elemType.NumField(); j++ {
+		fieldType := elemType.Field(j)
+
+		// 跳过非导出字段和不导出的字段
+		if !fieldType.IsExported() || config.omitted(fieldType.Name) {
+			continue
+		}
+
+		var field reflect.Value
+		if elem.IsValid() {
+			field = elem.Field(j)
+		}
+
+		if config.FlattenNested && isFlattenableStruct(fieldType.Type) {
+			nestedType := fieldType.Type
+			if nestedType.Kind() == reflect.Ptr {
+				nestedType = nestedType.Elem()
+				if field.IsValid() {
+					if field.IsNil() {
+						field = reflect.Value{}
+					} else {
+						field = field.Elem()
+					}
+				}
+			}
+			visitRecordFields(field, nestedType, config, visit)
+			continue
+		}
+
+		visit(fieldType, field)
+	}
+}
+
+// omitted 判断字段是否在 OmitFields 中
+func (c *ExportConfig) omitted(name string) bool {
+	for _, omit := range c.OmitFields {
+		if strings.EqualFold(omit, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportColumnFields 返回导出 CSV/Excel 时各列对应的结构体字段名（按列顺序，不含 OmitFields 中的字段）
+// data 为结构体切片或指向切片的指针，只使用其元素类型
+func ExportColumnFields(data interface{}, config *ExportConfig) []string {
+	t := reflect.TypeOf(data)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	visitRecordFields(reflect.Value{}, t.Elem(), config, func(sf reflect.StructField, _ reflect.Value) {
+		names = append(names, sf.Name)
+	})
+	return names
+}
+
+// omitExportHeaders 按列位置去掉不导出字段的表头，表头多于列数时多出的部分保留
+func omitExportHeaders(elemType reflect.Type, config *ExportConfig) []string {
+	all := *config
+	all.OmitFields = nil
+	var columns []string
+	visitRecordFields(reflect.Value{}, elemType, &all, func(sf reflect.StructField, _ reflect.Value) {
+		columns = append(columns, sf.Name)
+	})
+
+	headers := make([]string, 0, len(config.Headers))
+	for i, header := range config.Headers {
+		if i < len(columns) && config.omitted(columns[i]) {
+			continue
+		}
+		headers = append(headers, header)
+	}
+	return headers
+}
+
+// exportJSONFields 导出 JSON 时保留的字段，未设置 OmitFields 且没有计算列时返回 nil 表示按原结构输出
+func exportJSONFields(elemType reflect.Type, config *ExportConfig) FieldSet {
+	if len(config.OmitFields) == 0 && len(config.ComputedColumns) == 0 {
+		return nil
+	}
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	fields := FieldSet{}
+	for name, field := range jsonFields(elemType) {
+		if !config.omitted(elemType.FieldByIndex(field.index).Name) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// isFlattenableStruct 判断字段是否为可展开的嵌套结构体（time.Time 作为普通值处理）
+func isFlattenableStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+// isJoinableSlice 判断字段是否为可连接为单列的切片（元素非结构体，且不是 []byte）
+func isJoinableSlice(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	elemKind := t.Elem().Kind()
+	return elemKind != reflect.Uint8 && !isFlattenableStruct(t.Elem())
+}
+
+// joinSliceValues 将切片各元素格式化后以 ", " 连接，小数点为逗号的区域改用 "; " 避免歧义
+func joinSliceValues(field reflect.Value, config *ExportConfig) string {
+	parts := make([]string, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		parts[i] = formatFieldValue(field.Index(i), field.Type().Elem(), config)
+	}
+	if config.locale.DecimalSeparator == "," {
+		return strings.Join(parts, "; ")
+	}
+	return strings.Join(parts, ", ")
 }
 
 // formatFieldValue 格式化字段值
+// 输出格式可由 setFieldValue 解析还原：零值时间和 nil 指针输出为空，结构体、切片、map 输出为JSON
 func formatFieldValue(field reflect.Value, fieldType reflect.Type, config *ExportConfig) string {
 	if !field.IsValid() {
 		return ""
@@ -467,19 +1170,31 @@ func formatFieldValue(field reflect.Value, fieldType reflect.Type, config *Expor
 		return strconv.FormatInt(field.Int(), 10)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return strconv.FormatUint(field.Uint(), 10)
-	case reflect.Float32, reflect.Float64:
-		return strconv.FormatFloat(field.Float(), 'f', -1, 64)
+	case reflect.Float32:
+		return formatLocaleFloat(field.Float(), 32, config.locale)
+	case reflect.Float64:
+		return formatLocaleFloat(field.Float(), 64, config.locale)
 	case reflect.Bool:
 		return strconv.FormatBool(field.Bool())
+	case reflect.Ptr:
+		if field.IsNil() {
+			return ""
+		}
+		return formatFieldValue(field.Elem(), fieldType.Elem(), config)
 	case reflect.Struct:
-		if fieldType == reflect.TypeOf(time.Time{}) {
+		if fieldType == timeType {
 			timeVal := field.Interface().(time.Time)
-			if config.DateFormat != "" {
-				return timeVal.Format(config.DateFormat)
+			if timeVal.IsZero() {
+				return ""
+			}
+			if config.locale.DateFormat != "" {
+				return timeVal.Format(config.locale.DateFormat)
 			}
-			return timeVal.Format("2006-01-02 15:04:05")
+			return timeVal.Format(defaultExportDateFormat)
 		}
 		// 其他结构体转为JSON字符串
+		fallthrough
+	case reflect.Slice, reflect.Array, reflect.Map:
 		if jsonBytes, err := json.Marshal(field.Interface()); err == nil {
 			return string(jsonBytes)
 		}
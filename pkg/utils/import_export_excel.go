@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Excel 自动列宽的上下限（字符数）
+const (
+	excelMinColWidth = 8
+	excelMaxColWidth = 60
+)
+
+// setExcelColWidths 按表头和全部数据中每列最长的内容设置列宽
+// 流式写入时列宽必须在写入行之前设置，因此需要先遍历一遍数据
+func setExcelColWidths(ctx context.Context, sw *excelize.StreamWriter, dataValue reflect.Value, config *ExportConfig, dateStyle int) error {
+	widths := make([]int, len(config.Headers))
+	measure := func(col int, width int) {
+		for len(widths) <= col {
+			widths = append(widths, 0)
+		}
+		widths[col] = max(widths[col], width)
+	}
+	for i, header := range config.Headers {
+		measure(i, displayWidth(header))
+	}
+
+	var row []interface{}
+	for i := 0; i < dataValue.Len(); i++ {
+		if i%exportProgressBatch == 0 {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("导出已取消: %w", err)
+			}
+		}
+		elem := dataValue.Index(i)
+		row = appendExcelCells(row[:0], elem, elem.Type(), config, dateStyle)
+		for col, value := range row {
+			measure(col, excelCellWidth(value, config))
+		}
+	}
+
+	for col, width := range widths {
+		width = min(max(width+2, excelMinColWidth), excelMaxColWidth)
+		if err := sw.SetColWidth(col+1, col+1, float64(width)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// excelCellWidth 单元格内容的显示宽度，日期按格式代码的长度计算
+func excelCellWidth(value interface{}, config *ExportConfig) int {
+	switch v := value.(type) {
+	case string:
+		return displayWidth(v)
+	case excelize.Cell:
+		if format := config.locale.ExcelDateFormat; format != "" {
+			return len(format)
+		}
+		return excelCellWidth(v.Value, config)
+	default:
+		return len(fmt.Sprint(v))
+	}
+}
+
+// displayWidth 字符串的显示宽度，中日韩文字和全角字符按 2 计算
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) || (r >= 0xFF00 && r <= 0xFFEF) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type roundTripRecord struct {
+	ID       int64
+	Name     string
+	Score    float64
+	Ratio    float32
+	Active   bool
+	Created  time.Time
+	Address  roundTripAddress
+	Tags     []string
+	Attrs    map[string]int
+	Manager  *roundTripAddress
+	Optional *int
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	seven := 7
+	want := []roundTripRecord{
+		{
+			ID:       math.MaxInt64,
+			Name:     "张三, \"quoted\"",
+			Score:    0.1 + 0.2,
+			Ratio:    1.0 / 3,
+			Active:   true,
+			Created:  time.Date(2024, 2, 29, 23, 59, 59, 123456789, shanghai),
+			Address:  roundTripAddress{City: "上海", Zip: "200000"},
+			Tags:     []string{"a", "b,c"},
+			Attrs:    map[string]int{"x": 1},
+			Manager:  &roundTripAddress{City: "北京"},
+			Optional: &seven,
+		},
+		{
+			ID:      -1,
+			Name:    "=SUM(A1)",
+			Score:   -1e-7,
+			Active:  false,
+			Created: time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, fileType := range []string{"csv", "json"} {
+		t.Run(fileType, func(t *testing.T) {
+			// 与服务导出一致，CSV 带表头
+			exportConfig := &ExportConfig{FileType: fileType}
+			exportConfig.Headers = ExportColumnFields(want, exportConfig)
+
+			var buf bytes.Buffer
+			if err := ExportDataTo(t.Context(), &buf, want, exportConfig); err != nil {
+				t.Fatalf("导出失败: %v", err)
+			}
+
+			var got []roundTripRecord
+			result, err := ImportDataFrom(&got, &buf, &ImportConfig{FileType: fileType, HasHeader: true})
+			if err != nil {
+				t.Fatalf("导入失败: %v", err)
+			}
+			if len(result.Errors) > 0 {
+				t.Fatalf("导入出错: %v", result.Errors[0])
+			}
+			if len(got) != len(want) {
+				t.Fatalf("导入 %d 行，期望 %d 行", len(got), len(want))
+			}
+
+			for i := range want {
+				// 时区按偏移量还原，*time.Location 不同，单独比较
+				if !got[i].Created.Equal(want[i].Created) {
+					t.Errorf("第%d行 Created = %v，期望 %v", i, got[i].Created, want[i].Created)
+				}
+				_, gotOffset := got[i].Created.Zone()
+				_, wantOffset := want[i].Created.Zone()
+				if gotOffset != wantOffset {
+					t.Errorf("第%d行 Created 时区偏移 = %d，期望 %d", i, gotOffset, wantOffset)
+				}
+
+				g, w := got[i], want[i]
+				g.Created, w.Created = time.Time{}, time.Time{}
+				if !reflect.DeepEqual(g, w) {
+					t.Errorf("第%d行 = %+v，期望 %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+type bomRecord struct {
+	Count int    `charlotte:"数量"`
+	Name  string `charlotte:"名称"`
+}
+
+func TestImportCSVStripsBOM(t *testing.T) {
+	// 读取到的第一个表头不应带 BOM；按表头名匹配字段时另有容错，单独检查读取结果
+	reader, err := csvTextReader(strings.NewReader("\xEF\xBB\xBF名称,数量\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := csv.NewReader(reader).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header[0] != "名称" {
+		t.Fatalf("第一个表头 = %q，期望 %q", header[0], "名称")
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		hasHeader bool
+	}{
+		// 表头与字段顺序不同，须按表头名对应，BOM 未去掉时第一列“名称”无法匹配
+		{name: "表头", content: "\xEF\xBB\xBF名称,数量\n张三,3\n", hasHeader: true},
+		// 无表头时第一列为数字，BOM 未去掉时无法解析
+		{name: "无表头", content: "\xEF\xBB\xBF3,张三\n", hasHeader: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []bomRecord
+			result, err := ImportDataFrom(&got, strings.NewReader(tt.content), &ImportConfig{FileType: "csv", HasHeader: tt.hasHeader})
+			if err != nil {
+				t.Fatalf("导入失败: %v", err)
+			}
+			if len(result.Errors) > 0 {
+				t.Fatalf("导入出错: %v", result.Errors[0])
+			}
+			want := []bomRecord{{Count: 3, Name: "张三"}}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("导入结果 = %+v，期望 %+v", got, want)
+			}
+		})
+	}
+}
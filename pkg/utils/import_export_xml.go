@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// xmlRootElement 导出 XML 的根元素名，导入时不限制根元素名
+const xmlRootElement = "records"
+
+var xmlNameType = reflect.TypeOf(xml.Name{})
+
+// importFromXML XML导入实现
+// 根元素下的每个子元素为一条记录，记录的子元素按 xml 标签、列名标签、json 标签或字段名（不区分大小写）对应字段，未匹配的元素忽略；
+// 字符串、数值、布尔和时间字段按与 CSV 相同的规则解析（遵循 DateFormat 与区域格式），其余字段按 encoding/xml 规则解码。
+// 流式逐条解码，错误中的行号为记录在根元素中的序号（从1开始）
+func importFromXML(dataPtr interface{}, reader io.Reader, config *ImportConfig, result *ImportResult) error {
+	dataValue := reflect.ValueOf(dataPtr).Elem()
+	elemType := dataValue.Type().Elem()
+
+	decoder := xml.NewDecoder(reader)
+	if _, err := nextXMLStart(decoder); err != nil {
+		return &ImportExportError{Message: "解析XML失败: " + err.Error()}
+	}
+
+	for {
+		_, err := nextXMLStart(decoder)
+		if errors.Is(err, errXMLEndOfParent) {
+			return nil
+		}
+		if err != nil {
+			return &ImportExportError{Message: "解析XML失败: " + err.Error()}
+		}
+
+		if err := checkImportRows(result, config, result.TotalRows+1); err != nil {
+			return err
+		}
+		result.TotalRows++
+		lineNum := result.TotalRows
+
+		newElem := reflect.New(elemType).Elem()
+		if err := decodeXMLRecord(decoder, newElem, config); err != nil {
+			var rowErr *ImportExportError
+			if !errors.As(err, &rowErr) {
+				// 语法错误时无法继续读取
+				return &ImportExportError{Line: lineNum, Message: "解析XML失败: " + err.Error()}
+			}
+			rowErr.Line = lineNum
+			result.Errors = append(result.Errors, rowErr)
+			result.FailedRows++
+			result.FailedLines = append(result.FailedLines, lineNum)
+			continue
+		}
+		if dupErr := config.deduper.check(newElem, lineNum); dupErr != nil {
+			result.Errors = append(result.Errors, dupErr)
+			result.FailedRows++
+			result.FailedLines = append(result.FailedLines, lineNum)
+			continue
+		}
+
+		dataValue.Set(reflect.Append(dataValue, newElem))
+		result.Lines = append(result.Lines, lineNum)
+		result.SuccessRows++
+	}
+}
+
+// errXMLEndOfParent 当前元素的子元素已读完
+var errXMLEndOfParent = errors.New("元素结束")
+
+// nextXMLStart 读取到下一个子元素的开始标签，遇到当前元素的结束标签时返回 errXMLEndOfParent
+func nextXMLStart(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return xml.StartElement{}, errXMLEndOfParent
+		}
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			return t, nil
+		case xml.EndElement:
+			return xml.StartElement{}, errXMLEndOfParent
+		}
+	}
+}
+
+// decodeXMLRecord 解码一条记录的子元素到结构体字段
+// 字段值转换失败时跳过记录的剩余内容，返回 *ImportExportError，调用方可继续读取下一条记录
+func decodeXMLRecord(decoder *xml.Decoder, elem reflect.Value, config *ImportConfig) error {
+	elemType := elem.Type()
+	var fieldErr *ImportExportError
+	for {
+		start, err := nextXMLStart(decoder)
+		if errors.Is(err, errXMLEndOfParent) {
+			if fieldErr != nil {
+				return fieldErr
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		index, ok := xmlFieldIndex(elemType, start.Name.Local)
+		if !ok || fieldErr != nil {
+			if err := decoder.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		field, fieldType := elem.Field(index), elemType.Field(index)
+		if !isXMLScalar(fieldType.Type) {
+			if err := decoder.DecodeElement(field.Addr().Interface(), &start); err != nil {
+				var syntaxErr *xml.SyntaxError
+				if errors.As(err, &syntaxErr) {
+					return err
+				}
+				fieldErr = &ImportExportError{Field: fieldType.Name, Message: "XML转换失败: " + err.Error()}
+			}
+			continue
+		}
+
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return err
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		err = checkFieldLength(fieldType, text, config)
+		if err == nil {
+			err = setFieldValue(field, fieldType.Type, text, config)
+		}
+		if err != nil {
+			fieldErr = &ImportExportError{Field: fieldType.Name, Message: err.Error()}
+		}
+	}
+}
+
+// xmlFieldIndex 按元素名查找字段，xml 标签优先，其次按列名标签、json 标签和字段名匹配
+func xmlFieldIndex(elemType reflect.Type, name string) (int, bool) {
+	for i := 0; i < elemType.NumField(); i++ {
+		field := elemType.Field(i)
+		tagName, _, _ := strings.Cut(field.Tag.Get("xml"), ",")
+		if field.IsExported() && tagName != "" && tagName != "-" && strings.EqualFold(tagName, name) {
+			return i, true
+		}
+	}
+	return importFieldIndex(elemType, name)
+}
+
+// isXMLScalar 按文本解析的字段类型：字符串、数值、布尔和 time.Time，以及指向它们的指针
+// 指针字段的元素为空时保持 nil
+func isXMLScalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// exportToXML XML导出实现
+// 逐条编码写出，根元素为 records，每条记录的元素名为结构体类型名（或 XMLName 字段的标签），
+// 字段按 encoding/xml 规则编码，嵌入的结构体展开，OmitFields 中的字段不输出，计算列作为最后的子元素
+func exportToXML(ctx context.Context, w io.Writer, data interface{}, config *ExportConfig) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(bw)
+	encoder.Indent("", "  ")
+	root := xml.StartElement{Name: xml.Name{Local: xmlRootElement}}
+	if err := encoder.EncodeToken(root); err != nil {
+		return err
+	}
+
+	dataValue := reflect.ValueOf(data)
+	total := dataValue.Len()
+	for i := 0; i < total; i++ {
+		if err := checkExportProgress(ctx, config, i, total); err != nil {
+			return err
+		}
+
+		elem := reflect.Indirect(dataValue.Index(i))
+		if !elem.IsValid() {
+			continue
+		}
+		start := xml.StartElement{Name: xml.Name{Local: xmlElementName(elem.Type())}}
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		if err := encodeXMLFields(encoder, elem, config); err != nil {
+			return err
+		}
+		if err := encodeXMLComputed(encoder, dataValue.Index(i), config); err != nil {
+			return err
+		}
+		if err := encoder.EncodeToken(start.End()); err != nil {
+			return err
+		}
+	}
+
+	if err := encoder.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	reportExportProgress(config, total, total)
+	return nil
+}
+
+// encodeXMLFields 按字段顺序编码结构体字段，匿名嵌入的结构体展开到当前元素中
+func encodeXMLFields(encoder *xml.Encoder, elem reflect.Value, config *ExportConfig) error {
+	elemType := elem.Type()
+	for i := 0; i < elemType.NumField(); i++ {
+		fieldType := elemType.Field(i)
+		// 非导出类型的匿名嵌入结构体，其导出字段仍然展开输出
+		if (!fieldType.IsExported() && !fieldType.Anonymous) || fieldType.Type == xmlNameType || config.omitted(fieldType.Name) {
+			continue
+		}
+		name, _, _ := strings.Cut(fieldType.Tag.Get("xml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		field := elem.Field(i)
+		if fieldType.Anonymous && isFlattenableStruct(fieldType.Type) {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			if err := encodeXMLFields(encoder, field, config); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = fieldType.Name
+		}
+		if err := encoder.EncodeElement(field.Interface(), xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xmlElementName 记录的元素名：XMLName 字段的标签，未设置时为结构体类型名
+func xmlElementName(t reflect.Type) string {
+	if field, ok := t.FieldByName("XMLName"); ok && field.Type == xmlNameType {
+		if name, _, _ := strings.Cut(field.Tag.Get("xml"), ","); name != "" {
+			return name
+		}
+	}
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return "record"
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidColumnMapping ImportConfig.ColumnMapping 不合法：未启用表头、字段不存在或多列映射到同一字段
+var ErrInvalidColumnMapping = errors.New("列映射不合法")
+
+// columnTag 字段在导入导出文件中的列名标签，如 charlotte:"邮箱"
+// 有表头的导入按列名匹配字段，导出未指定表头时以列名作为表头
+const columnTag = "charlotte"
+
+// validateColumnMapping 导入开始前检查列映射，映射的目标字段必须是结构体的导出字段
+func validateColumnMapping(elemType reflect.Type, config *ImportConfig) error {
+	if len(config.ColumnMapping) == 0 {
+		return nil
+	}
+	if !config.HasHeader {
+		return fmt.Errorf("%w: 需要启用表头", ErrInvalidColumnMapping)
+	}
+	for header, fieldName := range config.ColumnMapping {
+		if _, ok := importFieldIndex(elemType, fieldName); !ok {
+			return fmt.Errorf("%w: 列 %q 映射的字段 %q 不存在", ErrInvalidColumnMapping, header, fieldName)
+		}
+	}
+	return nil
+}
+
+// resolveColumnMapping 按表头行确定每列对应的字段，结果保存在 config.columnFields 中供 parseCSVRecord 使用
+// 仅在配置了 ColumnMapping 或字段有列名标签时生效，否则保持按列顺序对应
+// 表头在 ColumnMapping 中时映射到指定字段，否则依次按列名标签、json 标签和字段名匹配（不区分大小写），
+// 都不匹配的列忽略，result 不为 nil 时为其追加一条提示，lineNum 为表头所在行
+func resolveColumnMapping(elemType reflect.Type, lineNum int, header []string, config *ImportConfig, result *ImportResult) error {
+	if len(config.ColumnMapping) == 0 && !hasColumnTags(elemType) {
+		return nil
+	}
+
+	mapping := make(map[string]string, len(config.ColumnMapping))
+	for source, fieldName := range config.ColumnMapping {
+		mapping[normalizeHeader(source)] = fieldName
+	}
+
+	columnFields := make([]int, len(header))
+	mappedBy := make(map[int]string, len(header))
+	for column, cell := range header {
+		fieldName, ok := mapping[normalizeHeader(cell)]
+		if !ok {
+			fieldName = normalizeHeader(cell)
+		}
+
+		index, found := importFieldIndex(elemType, fieldName)
+		if !found {
+			columnFields[column] = -1
+			if result != nil && normalizeHeader(cell) != "" {
+				result.Errors = append(result.Errors, &ImportExportError{
+					Line:    lineNum,
+					Column:  column + 1,
+					Field:   strings.TrimSpace(cell),
+					Message: "表头未匹配任何字段，已忽略该列",
+				})
+			}
+			continue
+		}
+		if previous, dup := mappedBy[index]; dup {
+			return fmt.Errorf("%w: 列 %q 和 %q 映射到同一字段 %s",
+				ErrInvalidColumnMapping, previous, cell, elemType.Field(index).Name)
+		}
+		mappedBy[index] = cell
+		columnFields[column] = index
+	}
+
+	config.columnFields = columnFields
+	return nil
+}
+
+// importFieldIndex 按名称查找结构体导出字段的下标，不区分大小写
+// 依次匹配列名标签、json 标签和字段名，避免某字段的字段名与另一字段的列名相同时匹配错位
+func importFieldIndex(elemType reflect.Type, name string) (int, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, false
+	}
+
+	names := []func(reflect.StructField) string{
+		columnTagName,
+		func(field reflect.StructField) string {
+			jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if jsonName == "-" {
+				return ""
+			}
+			return jsonName
+		},
+		func(field reflect.StructField) string { return field.Name },
+	}
+	for _, fieldName := range names {
+		for i := 0; i < elemType.NumField(); i++ {
+			field := elemType.Field(i)
+			if field.IsExported() && strings.EqualFold(fieldName(field), name) {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// columnTagName 字段的列名标签，未设置时为空
+func columnTagName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get(columnTag), ",")
+	return strings.TrimSpace(name)
+}
+
+// hasColumnTags 结构体（含导出时展开的嵌套结构体）是否有字段设置了列名标签
+func hasColumnTags(elemType reflect.Type) bool {
+	found := false
+	visitRecordFields(reflect.Value{}, elemType, &ExportConfig{FlattenNested: true}, func(sf reflect.StructField, _ reflect.Value) {
+		found = found || columnTagName(sf) != ""
+	})
+	return found
+}
+
+// taggedExportHeaders 未指定表头时按列名标签生成导出表头，没有设置列名标签的字段使用字段名
+// 结构体没有任何列名标签时返回 nil，保持不输出表头
+func taggedExportHeaders(elemType reflect.Type, config *ExportConfig) []string {
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct || !hasColumnTags(elemType) {
+		return nil
+	}
+
+	var headers []string
+	visitRecordFields(reflect.Value{}, elemType, config, func(sf reflect.StructField, _ reflect.Value) {
+		if name := columnTagName(sf); name != "" {
+			headers = append(headers, name)
+		} else {
+			headers = append(headers, sf.Name)
+		}
+	})
+	return headers
+}
+
+// normalizeHeader 表头比较时忽略首尾空白、大小写和 Excel 另存 CSV 时写入的 UTF-8 BOM
+func normalizeHeader(header string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+}
@@ -0,0 +1,360 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxProfileInvalidSamples 每列最多返回的无法转换值示例数
+const maxProfileInvalidSamples = 10
+
+// ImportProfile 导入文件的数据概况，按列统计数据类型、空值和无法转换的值，不导入任何数据
+type ImportProfile struct {
+	TotalRows int             `json:"total_rows"`
+	Columns   []ColumnProfile `json:"columns"`
+	Warnings  []string        `json:"warnings,omitempty"` // 按列汇总的问题，如类型不匹配、必填列有空值
+}
+
+// ColumnProfile 单列的数据概况，列与结构体导出字段按顺序对应（与 ImportData 一致）
+type ColumnProfile struct {
+	Column        int            `json:"column"`                   // 列序号（从0开始）
+	Header        string         `json:"header,omitempty"`         // 表头列名，无表头时为空
+	Field         string         `json:"field,omitempty"`          // 对应的结构体字段名，多出的列为空
+	Kind          string         `json:"kind,omitempty"`           // 目标字段类型分类，同 FieldSchema.Kind
+	Required      bool           `json:"required"`                 // 目标字段是否必填
+	DetectedType  string         `json:"detected_type"`            // 按非空值推断的类型：int/float/bool/time/string，全为空时为 empty
+	NullCount     int            `json:"null_count"`               // 空值数，包括行中缺失的列
+	DistinctCount int            `json:"distinct_count"`           // 非空值去重数
+	InvalidCount  int            `json:"invalid_count"`            // 无法转换为目标字段类型的值数
+	InvalidValues []InvalidValue `json:"invalid_values,omitempty"` // 无法转换的值示例
+}
+
+// InvalidValue 无法转换为目标字段类型的值
+type InvalidValue struct {
+	Line    int    `json:"line"` // 文件行号（从1开始）
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// ProfileImport 扫描导入文件，按列统计数据概况，用于导入前发现类型不匹配等问题
+// 参数与 ImportData 相同，表头、开始行、区域格式等按相同规则处理；仅支持 CSV 和 Excel
+func ProfileImport(dataPtr interface{}, file *multipart.FileHeader, config *ImportConfig) (*ImportProfile, error) {
+	elemType, err := importElemType(dataPtr)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateColumnMapping(elemType, config); err != nil {
+		return nil, err
+	}
+
+	locale, err := resolveImportLocale(config)
+	if err != nil {
+		return nil, err
+	}
+	config.locale = locale
+	config.columnFields = nil
+
+	fileReader, err := file.Open()
+	if err != nil {
+		return nil, &ImportExportError{Message: "打开文件失败: " + err.Error()}
+	}
+	defer fileReader.Close()
+
+	p := newImportProfiler(elemType, config)
+	switch strings.ToLower(config.FileType) {
+	case "csv":
+		err = p.scanCSV(fileReader)
+	case "excel":
+		err = p.scanExcel(fileReader)
+	default:
+		err = &ImportExportError{Message: "数据概况仅支持CSV和Excel文件: " + config.FileType}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.profile(), nil
+}
+
+// importElemType 校验 dataPtr 为指向结构体切片的指针，返回元素类型
+func importElemType(dataPtr interface{}) (reflect.Type, error) {
+	if dataPtr == nil || reflect.ValueOf(dataPtr).Kind() != reflect.Ptr {
+		return nil, &ImportExportError{Message: "dataPtr必须是指向切片的指针"}
+	}
+
+	sliceType := reflect.TypeOf(dataPtr).Elem()
+	if sliceType.Kind() != reflect.Slice {
+		return nil, &ImportExportError{Message: "dataPtr必须指向切片类型"}
+	}
+
+	elemType := sliceType.Elem()
+	if elemType.Kind() != reflect.Struct {
+		return nil, &ImportExportError{Message: "切片元素必须是结构体类型"}
+	}
+	return elemType, nil
+}
+
+type importProfiler struct {
+	config    *ImportConfig
+	elemType  reflect.Type
+	fields    []reflect.StructField // 与列按顺序对应的导出字段
+	columns   []*columnStats
+	totalRows int
+}
+
+type columnStats struct {
+	ColumnProfile
+	fieldType reflect.Type // 多出的列为 nil
+	distinct  map[string]struct{}
+
+	// 所有非空值是否都能解析为对应类型
+	maybeInt, maybeFloat, maybeBool, maybeTime bool
+}
+
+func newImportProfiler(elemType reflect.Type, config *ImportConfig) *importProfiler {
+	p := &importProfiler{config: config, elemType: elemType}
+	for i := 0; i < elemType.NumField(); i++ {
+		if field := elemType.Field(i); field.IsExported() {
+			p.fields = append(p.fields, field)
+		}
+	}
+	// 预先创建全部字段列，文件中缺失的列也会出现在结果中
+	for i := range p.fields {
+		p.column(i)
+	}
+	return p
+}
+
+// column 获取第 i 列的统计，不存在时创建
+func (p *importProfiler) column(i int) *columnStats {
+	for len(p.columns) <= i {
+		col := &columnStats{
+			ColumnProfile: ColumnProfile{Column: len(p.columns)},
+			distinct:      make(map[string]struct{}),
+			maybeInt:      true,
+			maybeFloat:    true,
+			maybeBool:     true,
+			maybeTime:     true,
+		}
+		if field, ok := p.columnField(col.Column); ok {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+
+			schema := FieldSchema{}
+			parseImportTag(field.Tag.Get("import"), &schema)
+			parseBindingTag(field.Tag.Get("binding"), &schema)
+
+			col.Field = field.Name
+			col.Kind = fieldKind(fieldType)
+			col.Required = schema.Required
+			col.fieldType = field.Type
+		}
+		p.columns = append(p.columns, col)
+	}
+	return p.columns[i]
+}
+
+// columnField 第 i 列对应的字段，配置了列映射时按表头解析的对应关系，否则按列顺序
+func (p *importProfiler) columnField(i int) (reflect.StructField, bool) {
+	if p.config.columnFields != nil {
+		if i < len(p.config.columnFields) && p.config.columnFields[i] >= 0 {
+			return p.elemType.Field(p.config.columnFields[i]), true
+		}
+		return reflect.StructField{}, false
+	}
+	if i < len(p.fields) {
+		return p.fields[i], true
+	}
+	return reflect.StructField{}, false
+}
+
+func (p *importProfiler) setHeader(record []string) error {
+	// 配置了列映射或字段有列名标签时按表头重新确定每列对应的字段，未被映射的字段不出现在结果中
+	if err := resolveColumnMapping(p.elemType, 0, record, p.config, nil); err != nil {
+		return err
+	}
+	if p.config.columnFields != nil {
+		p.columns = nil
+	}
+	for i, header := range record {
+		p.column(i).Header = strings.TrimSpace(header)
+	}
+	return nil
+}
+
+func (p *importProfiler) addRow(lineNum int, record []string) {
+	p.totalRows++
+	n := len(record)
+	if len(p.columns) > n {
+		n = len(p.columns)
+	}
+	for i := 0; i < n; i++ {
+		value := ""
+		if i < len(record) {
+			value = strings.TrimSpace(record[i])
+		}
+		p.column(i).observe(lineNum, value, p.config)
+	}
+}
+
+// scanCSV 按 importFromCSV 的规则遍历CSV数据行
+func (p *importProfiler) scanCSV(reader io.Reader) error {
+	reader, err := csvTextReader(reader, p.config.Encoding)
+	if err != nil {
+		return err
+	}
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	lineNum := 0
+	for {
+		lineNum++
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return &ImportExportError{Line: lineNum, Message: "读取CSV行失败: " + err.Error()}
+		}
+
+		if p.config.HasHeader && lineNum == 1 {
+			if err := p.setHeader(record); err != nil {
+				return err
+			}
+			continue
+		}
+		if lineNum < p.config.StartRow {
+			continue
+		}
+		p.addRow(lineNum, record)
+	}
+}
+
+// scanExcel 按 importFromExcel 的规则遍历Excel数据行
+func (p *importProfiler) scanExcel(reader io.Reader) error {
+	rows, headerRow, err := readExcelRows(reader, p.config)
+	if err != nil {
+		return err
+	}
+	if headerRow > 0 && headerRow <= len(rows) {
+		if err := p.setHeader(rows[headerRow-1]); err != nil {
+			return err
+		}
+	}
+
+	for i, row := range rows {
+		lineNum := i + 1
+		if lineNum <= headerRow || lineNum < p.config.StartRow || isBlankRow(row) {
+			continue
+		}
+		p.addRow(lineNum, row)
+	}
+	return nil
+}
+
+// observe 统计一个单元格的值
+func (c *columnStats) observe(lineNum int, value string, config *ImportConfig) {
+	if value == "" {
+		c.NullCount++
+		return
+	}
+	c.distinct[value] = struct{}{}
+	c.detect(value, config)
+
+	if c.fieldType == nil {
+		return
+	}
+	target := reflect.New(c.fieldType).Elem()
+	if err := setFieldValue(target, c.fieldType, value, config); err != nil {
+		c.InvalidCount++
+		if len(c.InvalidValues) < maxProfileInvalidSamples {
+			c.InvalidValues = append(c.InvalidValues, InvalidValue{Line: lineNum, Value: value, Message: err.Error()})
+		}
+	}
+}
+
+// detect 排除该值无法解析成的类型
+func (c *columnStats) detect(value string, config *ImportConfig) {
+	if c.maybeInt {
+		_, err := strconv.ParseInt(value, 10, 64)
+		c.maybeInt = err == nil
+	}
+	if c.maybeFloat {
+		// Excel 中的数字为原生数值，不受区域分隔符影响
+		locale := config.locale
+		if strings.EqualFold(config.FileType, "excel") {
+			locale = ExportLocale{}
+		}
+		_, err := parseLocaleFloat(value, 64, locale)
+		c.maybeFloat = err == nil
+	}
+	if c.maybeBool {
+		_, err := strconv.ParseBool(value)
+		c.maybeBool = err == nil
+	}
+	if c.maybeTime {
+		_, err := parseImportTime(value, config)
+		c.maybeTime = err == nil
+	}
+}
+
+// detectedType 按全部非空值推断的类型，优先取更具体的类型
+func (c *columnStats) detectedType() string {
+	switch {
+	case len(c.distinct) == 0:
+		return "empty"
+	case c.maybeInt:
+		return "int"
+	case c.maybeFloat:
+		return "float"
+	case c.maybeBool:
+		return "bool"
+	case c.maybeTime:
+		return "time"
+	default:
+		return "string"
+	}
+}
+
+// name 列在提示信息中的名称，优先使用表头
+func (c *columnStats) name() string {
+	if c.Header != "" {
+		return c.Header
+	}
+	if c.Field != "" {
+		return c.Field
+	}
+	return fmt.Sprintf("第%d列", c.Column+1)
+}
+
+func (p *importProfiler) profile() *ImportProfile {
+	result := &ImportProfile{
+		TotalRows: p.totalRows,
+		Columns:   make([]ColumnProfile, 0, len(p.columns)),
+	}
+	for _, c := range p.columns {
+		c.DetectedType = c.detectedType()
+		c.DistinctCount = len(c.distinct)
+		result.Columns = append(result.Columns, c.ColumnProfile)
+
+		switch {
+		case c.fieldType == nil:
+			if len(c.distinct) > 0 {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("列'%s'没有对应的字段，导入时将被忽略", c.name()))
+			}
+			continue
+		case c.InvalidCount > 0:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("列'%s'有%d个值无法转换为%s", c.name(), c.InvalidCount, c.Kind))
+		}
+		if c.Required && c.NullCount > 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("必填列'%s'有%d个空值", c.name(), c.NullCount))
+		}
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldSchema 导入导出字段描述，供前端动态生成导入映射界面
+// 字段约束来自结构体标签：import:"required,enum=a|b,max=50" 或 binding:"required,oneof=a b"，
+// 最大长度未在 import 标签中指定时取 gorm 标签的 size
+type FieldSchema struct {
+	Name     string   `json:"name"`           // 结构体字段名
+	Key      string   `json:"key"`            // JSON 字段名
+	Header   string   `json:"header"`         // 列名（charlotte 标签），有表头的导入按列名匹配
+	Column   int      `json:"column"`         // CSV/Excel 列序号（从0开始）
+	Type     string   `json:"type"`           // Go 类型
+	Kind     string   `json:"kind"`           // string/int/uint/float/bool/time/list/object
+	Required bool     `json:"required"`       // 是否必填
+	Enum     []string `json:"enum,omitempty"` // 可选值
+
+	MaxLength int `json:"max_length,omitempty"` // 字符串最大长度（字符数），0 表示不限制
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// DescribeFields 通过反射描述数据切片元素的字段
+// dataPtr 为指向切片的指针（与 ImportData 的参数一致），嵌入的结构体按导出展开顺序平铺
+func DescribeFields(dataPtr interface{}) ([]FieldSchema, error) {
+	t := reflect.TypeOf(dataPtr)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("数据必须是指向切片的指针")
+	}
+
+	elemType := t.Elem().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("切片元素必须是结构体")
+	}
+
+	var fields []FieldSchema
+	describeStructFields(elemType, &fields)
+	return fields, nil
+}
+
+// describeStructFields 递归收集结构体字段，嵌入结构体（非 time.Time）展开
+func describeStructFields(t reflect.Type, fields *[]FieldSchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct && fieldType != timeType {
+			describeStructFields(fieldType, fields)
+			continue
+		}
+
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+
+		schema := FieldSchema{
+			Name:   field.Name,
+			Key:    key,
+			Header: columnTagName(field),
+			Column: len(*fields),
+			Type:   field.Type.String(),
+			Kind:   fieldKind(fieldType),
+		}
+		parseImportTag(field.Tag.Get("import"), &schema)
+		parseBindingTag(field.Tag.Get("binding"), &schema)
+		if fieldType.Kind() == reflect.String {
+			schema.MaxLength = fieldMaxLength(field)
+		}
+		*fields = append(*fields, schema)
+	}
+}
+
+// fieldKind 字段类型分类
+func fieldKind(t reflect.Type) string {
+	if t == timeType {
+		return "time"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}
+
+// parseImportTag 解析 import 标签，如 import:"required,enum=user|vip|admin"
+func parseImportTag(tag string, schema *FieldSchema) {
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "required":
+			schema.Required = true
+		case strings.HasPrefix(opt, "enum="):
+			schema.Enum = strings.Split(strings.TrimPrefix(opt, "enum="), "|")
+		}
+	}
+}
+
+// fieldMaxLength 字段的最大长度（字符数）：import 标签的 max 优先，其次为 gorm 标签的 size，都没有时为 0
+func fieldMaxLength(field reflect.StructField) int {
+	for _, opt := range strings.Split(field.Tag.Get("import"), ",") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(opt), "max="); ok {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	for _, opt := range strings.Split(field.Tag.Get("gorm"), ";") {
+		key, value, _ := strings.Cut(opt, ":")
+		if strings.EqualFold(strings.TrimSpace(key), "size") {
+			if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// parseBindingTag 解析 validator 的 binding 标签中的 required 与 oneof 规则
+func parseBindingTag(tag string, schema *FieldSchema) {
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "required":
+			schema.Required = true
+		case strings.HasPrefix(opt, "oneof=") && len(schema.Enum) == 0:
+			schema.Enum = strings.Fields(strings.TrimPrefix(opt, "oneof="))
+		}
+	}
+}
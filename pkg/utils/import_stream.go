@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrStopImport ImportStream 的行回调返回该错误时停止读取，ImportStream 返回 nil
+var ErrStopImport = errors.New("停止导入")
+
+// ImportRowFunc 流式导入的行回调，rowIndex 为数据行在文件中的行号（从1开始），record 为该行各列的原始值
+type ImportRowFunc func(rowIndex int, record []string) error
+
+// ImportStream 流式导入CSV/Excel：逐行读取 reader 并对每个数据行调用 fn，不在内存中保留整个工作表或全部数据
+// 表头、开始行、行数上限等按 ImportData 的规则处理，Excel 总是以 StreamMode 读取（文件本身仍需读入内存解压）
+// fn 返回错误时该行计为失败并继续读取；返回 ErrStopImport（或包装了它的错误）时停止读取，该行不计入统计
+func ImportStream(reader io.Reader, config *ImportConfig, fn ImportRowFunc) (*ImportResult, error) {
+	locale, err := resolveImportLocale(config)
+	if err != nil {
+		return nil, err
+	}
+	config.locale = locale
+	config.StreamMode = true
+
+	result := &ImportResult{Errors: make([]*ImportExportError, 0)}
+	handle := func(lineNum int, record []string) error {
+		err := fn(lineNum, record)
+		if err == nil || errors.Is(err, ErrStopImport) {
+			return err
+		}
+
+		var rowErr *ImportExportError
+		if errors.As(err, &rowErr) {
+			if rowErr.Line == 0 {
+				rowErr.Line = lineNum
+			}
+		} else {
+			rowErr = &ImportExportError{Line: lineNum, Message: err.Error()}
+		}
+		result.Errors = append(result.Errors, rowErr)
+		return err
+	}
+	skipHeader := func(int, []string) error { return nil }
+
+	switch strings.ToLower(config.FileType) {
+	case "csv":
+		err = scanCSVRows(reader, config, result, skipHeader, handle)
+	case "excel":
+		err = scanExcelRows(reader, config, result, skipHeader, handle)
+	default:
+		err = &ImportExportError{Message: "流式导入仅支持CSV和Excel文件: " + config.FileType}
+	}
+	if errors.Is(err, ErrStopImport) {
+		err = nil
+	}
+	return result, err
+}
+
+// scanCSVRows 逐行读取CSV，跳过表头和开始行之前的行，对每个数据行调用 handle 并更新 result 的计数
+// 读到表头行时调用 onHeader；读取某行失败时记为失败行并继续
+func scanCSVRows(reader io.Reader, config *ImportConfig, result *ImportResult,
+	onHeader func(lineNum int, header []string) error, handle func(lineNum int, record []string) error) error {
+	reader, err := csvTextReader(reader, config.Encoding)
+	if err != nil {
+		return err
+	}
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1 // 允许字段数量不一致
+
+	lineNum := 0
+	for {
+		lineNum++
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, &ImportExportError{
+				Line:    lineNum,
+				Message: "读取CSV行失败: " + err.Error(),
+			})
+			result.FailedRows++
+			result.FailedLines = append(result.FailedLines, lineNum)
+			continue
+		}
+
+		// 跳过表头
+		if config.HasHeader && lineNum == 1 {
+			if err := onHeader(lineNum, record); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 跳过开始行之前的数据
+		if lineNum < config.StartRow {
+			continue
+		}
+
+		if err := handleImportRow(lineNum, record, config, result, handle); err != nil {
+			return err
+		}
+	}
+}
+
+// scanExcelRows 逐行读取Excel工作表，跳过表头及其之前的标题行、开始行之前的行和空行，
+// 对每个数据行调用 handle 并更新 result 的计数
+// StreamMode 时使用行迭代器读取，自动检测表头时只缓存用于检测的前几行
+func scanExcelRows(reader io.Reader, config *ImportConfig, result *ImportResult,
+	onHeader func(lineNum int, header []string) error, handle func(lineNum int, record []string) error) error {
+	file, err := excelize.OpenReader(reader)
+	if err != nil {
+		return &ImportExportError{Message: "打开Excel文件失败: " + err.Error()}
+	}
+	defer file.Close()
+
+	sheetName := config.SheetName
+	if sheetName == "" {
+		sheetName = file.GetSheetName(0)
+	}
+
+	var source excelRowSource
+	if config.StreamMode {
+		rows, err := file.Rows(sheetName)
+		if err != nil {
+			return &ImportExportError{Message: "读取Excel工作表失败: " + err.Error()}
+		}
+		defer rows.Close()
+		source = &excelRowStream{rows: rows}
+	} else {
+		// 读取原始值：数字不受单元格格式影响（不会被四舍五入或加千分位），日期为序列值，由 setFieldValue 转换
+		rows, err := file.GetRows(sheetName, excelize.Options{RawCellValue: true})
+		if err != nil {
+			return &ImportExportError{Message: "读取Excel工作表失败: " + err.Error()}
+		}
+		source = &excelRowSlice{rows: rows}
+	}
+
+	headerRow, buffered, err := locateExcelHeader(source, config)
+	if err != nil {
+		return err
+	}
+
+	for lineNum := 1; ; lineNum++ {
+		var row []string
+		if lineNum <= len(buffered) {
+			row = buffered[lineNum-1]
+		} else {
+			var ok bool
+			if row, ok, err = source.next(); err != nil {
+				return err
+			} else if !ok {
+				return nil
+			}
+		}
+
+		// 跳过表头及其之前的标题行
+		if lineNum == headerRow {
+			if err := onHeader(lineNum, row); err != nil {
+				return err
+			}
+			continue
+		}
+		if lineNum < headerRow {
+			continue
+		}
+
+		// 跳过开始行之前的数据和空行
+		if lineNum < config.StartRow || isBlankRow(row) {
+			continue
+		}
+
+		if err := handleImportRow(lineNum, row, config, result, handle); err != nil {
+			return err
+		}
+	}
+}
+
+// locateExcelHeader 确定Excel表头所在行（从1开始，无表头时为0）
+// 自动检测表头时需要先读取前若干行，这些行作为 buffered 返回，由调用方在后续遍历中先行处理
+func locateExcelHeader(source excelRowSource, config *ImportConfig) (int, [][]string, error) {
+	if !config.HasHeader {
+		return 0, nil, nil
+	}
+	if len(config.ExpectedHeaders) == 0 {
+		headerRow, err := resolveExcelHeaderRow(nil, config)
+		return headerRow, nil, err
+	}
+
+	searchRows := config.HeaderSearchRows
+	if searchRows <= 0 {
+		searchRows = defaultHeaderSearchRows
+	}
+	buffered := make([][]string, 0, searchRows)
+	for len(buffered) < searchRows {
+		row, ok, err := source.next()
+		if err != nil {
+			return 0, nil, err
+		}
+		if !ok {
+			break
+		}
+		buffered = append(buffered, row)
+	}
+
+	headerRow, err := resolveExcelHeaderRow(buffered, config)
+	return headerRow, buffered, err
+}
+
+// handleImportRow 处理一个数据行并更新计数
+// 已达到 MaxRows 时返回 ErrTooManyRows；handle 返回 ErrStopImport 时原样返回以停止读取，该行不计入统计
+func handleImportRow(lineNum int, record []string, config *ImportConfig, result *ImportResult,
+	handle func(lineNum int, record []string) error) error {
+	if err := checkImportRows(result, config, lineNum); err != nil {
+		return err
+	}
+
+	err := handle(lineNum, record)
+	if errors.Is(err, ErrStopImport) {
+		return err
+	}
+	result.TotalRows++
+	if err != nil {
+		result.FailedRows++
+		result.FailedLines = append(result.FailedLines, lineNum)
+	} else {
+		result.SuccessRows++
+	}
+	return nil
+}
+
+// excelRowSource 按顺序返回Excel工作表的行，读完时 ok 为 false
+type excelRowSource interface {
+	next() (row []string, ok bool, err error)
+}
+
+// excelRowSlice 已全部读入内存的行
+type excelRowSlice struct {
+	rows [][]string
+	pos  int
+}
+
+func (s *excelRowSlice) next() ([]string, bool, error) {
+	if s.pos >= len(s.rows) {
+		return nil, false, nil
+	}
+	row := s.rows[s.pos]
+	s.pos++
+	return row, true, nil
+}
+
+// excelRowStream 通过 excelize 行迭代器逐行读取，与 GetRows 一样读取原始值，中间的空行返回空切片
+type excelRowStream struct {
+	rows *excelize.Rows
+}
+
+func (s *excelRowStream) next() ([]string, bool, error) {
+	if !s.rows.Next() {
+		if err := s.rows.Error(); err != nil {
+			return nil, false, &ImportExportError{Message: "读取Excel行失败: " + err.Error()}
+		}
+		return nil, false, nil
+	}
+	row, err := s.rows.Columns(excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, false, &ImportExportError{Message: "读取Excel行失败: " + err.Error()}
+	}
+	return row, true, nil
+}
@@ -127,8 +127,8 @@ function Build-Project {
         $buildTime = Get-Date -Format "yyyy-MM-dd_HH:mm:ss"
         $ldflags = @(
             "-s", "-w",
-            "-X", "main.Version=$Version",
-            "-X", "main.BuildTime=$buildTime"
+            "-X", "github.com/VennLe/charlotte/cmd.Version=$Version",
+            "-X", "github.com/VennLe/charlotte/cmd.BuildTime=$buildTime"
         )
 
         # 直接调用命令，避免 Invoke-Expression
@@ -167,7 +167,7 @@ WORKDIR /build
 COPY go.mod go.sum ./
 RUN go mod download
 COPY . .
-RUN CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags "-s -w -X main.Version=$Ver" -o $AppName ./main.go
+RUN CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags "-s -w -X github.com/VennLe/charlotte/cmd.Version=$Ver" -o $AppName ./main.go
 
 FROM alpine:3.18
 RUN apk --no-cache add ca-certificates tzdata